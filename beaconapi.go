@@ -0,0 +1,182 @@
+package ethstore
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// beaconHTTPClient is the client of getBeaconJSON. Like the client of http.Service it keeps the connections to the
+// nodes open, the timeout of a request is set by its context.
+var beaconHTTPClient = &nethttp.Client{
+	Transport: &nethttp.Transport{
+		Proxy:               nethttp.ProxyFromEnvironment,
+		MaxIdleConns:        64,
+		MaxConnsPerHost:     64,
+		MaxIdleConnsPerHost: 64,
+		IdleConnTimeout:     600 * time.Second,
+	},
+}
+
+// beaconHeadersKey is the context key of the headers of withBeaconHeaders.
+type beaconHeadersKey struct{}
+
+// withBeaconHeaders returns ctx with the extra headers of the requests of the client created by newConsClient with the
+// options o, so that getBeaconJSON sends the same headers (like the request id of WithRequestID).
+func withBeaconHeaders(ctx context.Context, o *options) context.Context {
+	headers := beaconHeaders(o)
+	if headers == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, beaconHeadersKey{}, headers)
+}
+
+// getBeaconJSON decodes the response of the beacon-node-api endpoint path of the node of client into v, for endpoints
+// and formats the client does not support. The request has the timeout of the client (GetConsTimeout) and the headers
+// of withBeaconHeaders.
+func getBeaconJSON(ctx context.Context, client *http.Service, path string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, GetConsTimeout())
+	defer cancel()
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, client.Address()+path, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request for %v: %w", path, err)
+	}
+	if headers, ok := ctx.Value(beaconHeadersKey{}).(map[string]string); ok {
+		for k, v := range headers {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := beaconHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting %v: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != nethttp.StatusOK {
+		return fmt.Errorf("error requesting %v: status %v", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding %v: %w", path, err)
+	}
+	return nil
+}
+
+// getProposerRewards returns the consensus rewards the proposer of block blockID received for the block (for
+// including attestations, the sync aggregate and slashings) from the block rewards endpoint. They are part of the
+// balance delta of the proposer.
+func getProposerRewards(ctx context.Context, client *http.Service, blockID string) (phase0.Gwei, error) {
+	var res struct {
+		Data struct {
+			Total string `json:"total"`
+		} `json:"data"`
+	}
+	var err error
+	for j := 0; j < 10; j++ { // retry up to 10 times on failure
+		if err = waitForRequest(ctx); err != nil {
+			return 0, err
+		}
+		err = getBeaconJSON(ctx, client, fmt.Sprintf("/eth/v1/beacon/rewards/blocks/%s", blockID), &res)
+		if err == nil {
+			break
+		}
+		log.Printf("error retrieving block rewards %v: %v", blockID, err)
+		time.Sleep(time.Duration(j) * time.Second)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting block rewards %v: %w", blockID, err)
+	}
+	total, err := strconv.ParseUint(res.Data.Total, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid total of block rewards %v: %q", blockID, res.Data.Total)
+	}
+	return phase0.Gwei(total), nil
+}
+
+// withdrawalRequest is an EIP-7002 withdrawal request of the execution requests of a block, a request without
+// AmountGwei is a full exit.
+type withdrawalRequest struct {
+	Pubkey     phase0.BLSPubKey
+	AmountGwei phase0.Gwei
+}
+
+// getWithdrawalRequests returns the withdrawal requests of the block blockID. The client does not know the execution
+// requests of Electra blocks, so they are read from the raw block, blocks before Electra have none.
+func getWithdrawalRequests(ctx context.Context, client *http.Service, blockID string) ([]withdrawalRequest, error) {
+	var res struct {
+		Data struct {
+			Message struct {
+				Body struct {
+					ExecutionRequests *struct {
+						Withdrawals []struct {
+							ValidatorPubkey string `json:"validator_pubkey"`
+							Amount          string `json:"amount"`
+						} `json:"withdrawals"`
+					} `json:"execution_requests"`
+				} `json:"body"`
+			} `json:"message"`
+		} `json:"data"`
+	}
+	var err error
+	for j := 0; j < 10; j++ { // retry up to 10 times on failure
+		if err = waitForRequest(ctx); err != nil {
+			return nil, err
+		}
+		err = getBeaconJSON(ctx, client, fmt.Sprintf("/eth/v2/beacon/blocks/%s", blockID), &res)
+		if err == nil {
+			break
+		}
+		log.Printf("error retrieving execution requests of block %v: %v", blockID, err)
+		time.Sleep(time.Duration(j) * time.Second)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting execution requests of block %v: %w", blockID, err)
+	}
+	if res.Data.Message.Body.ExecutionRequests == nil {
+		return nil, nil
+	}
+	requests := make([]withdrawalRequest, 0, len(res.Data.Message.Body.ExecutionRequests.Withdrawals))
+	for _, w := range res.Data.Message.Body.ExecutionRequests.Withdrawals {
+		pubkey, err := hex.DecodeString(strings.TrimPrefix(w.ValidatorPubkey, "0x"))
+		if err != nil || len(pubkey) != len(phase0.BLSPubKey{}) {
+			return nil, fmt.Errorf("invalid validator_pubkey of withdrawal request of block %v: %q", blockID, w.ValidatorPubkey)
+		}
+		amount, err := strconv.ParseUint(w.Amount, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount of withdrawal request of block %v: %q", blockID, w.Amount)
+		}
+		r := withdrawalRequest{AmountGwei: phase0.Gwei(amount)}
+		copy(r.Pubkey[:], pubkey)
+		requests = append(requests, r)
+	}
+	return requests, nil
+}
+
+// getElectraForkEpoch returns ELECTRA_FORK_EPOCH of the spec of the node of client, FAR_FUTURE_EPOCH if the node does
+// not know the fork.
+func getElectraForkEpoch(ctx context.Context, client *http.Service) (phase0.Epoch, error) {
+	if err := waitForRequest(ctx); err != nil {
+		return 0, err
+	}
+	apiSpec, err := client.Spec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error getting spec: %w", err)
+	}
+	epochIf, exists := apiSpec["ELECTRA_FORK_EPOCH"]
+	if !exists {
+		return farFutureEpoch, nil
+	}
+	epoch, ok := epochIf.(uint64)
+	if !ok {
+		return 0, fmt.Errorf("invalid format of ELECTRA_FORK_EPOCH in spec")
+	}
+	return phase0.Epoch(epoch), nil
+}
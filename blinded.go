@@ -0,0 +1,105 @@
+package ethstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apiv1bellatrix "github.com/attestantio/go-eth2-client/api/v1/bellatrix"
+	apiv1capella "github.com/attestantio/go-eth2-client/api/v1/capella"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+)
+
+// getBlindedBlock gets the block blockID from the node of client and returns it if it is blinded, which is a post-merge
+// block with the execution payload header instead of the execution payload. The client fails to decode blinded blocks,
+// so they are detected in the raw block. The returned block has no execution payload, so GetBlockData sets Blinded and
+// IncompletePayload for it. If the block is not blinded nil is returned.
+func getBlindedBlock(ctx context.Context, client *http.Service, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	var res struct {
+		Version spec.DataVersion `json:"version"`
+		Data    json.RawMessage  `json:"data"`
+	}
+	if err := getBeaconJSON(ctx, client, fmt.Sprintf("/eth/v2/beacon/blocks/%s", blockID), &res); err != nil {
+		return nil, fmt.Errorf("error getting blinded block %v: %w", blockID, err)
+	}
+	var raw struct {
+		Message struct {
+			Body map[string]json.RawMessage `json:"body"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(res.Data, &raw); err != nil {
+		return nil, fmt.Errorf("error decoding blinded block %v: %w", blockID, err)
+	}
+	_, hasPayload := raw.Message.Body["execution_payload"]
+	_, hasPayloadHeader := raw.Message.Body["execution_payload_header"]
+	if hasPayload || !hasPayloadHeader {
+		return nil, nil
+	}
+
+	switch res.Version {
+	case spec.DataVersionBellatrix:
+		var blinded apiv1bellatrix.SignedBlindedBeaconBlock
+		if err := json.Unmarshal(res.Data, &blinded); err != nil {
+			return nil, fmt.Errorf("error decoding blinded block %v: %w", blockID, err)
+		}
+		m := blinded.Message
+		return &spec.VersionedSignedBeaconBlock{
+			Version: res.Version,
+			Bellatrix: &bellatrix.SignedBeaconBlock{
+				Message: &bellatrix.BeaconBlock{
+					Slot:          m.Slot,
+					ProposerIndex: m.ProposerIndex,
+					ParentRoot:    m.ParentRoot,
+					StateRoot:     m.StateRoot,
+					Body: &bellatrix.BeaconBlockBody{
+						RANDAOReveal:      m.Body.RANDAOReveal,
+						ETH1Data:          m.Body.ETH1Data,
+						Graffiti:          m.Body.Graffiti,
+						ProposerSlashings: m.Body.ProposerSlashings,
+						AttesterSlashings: m.Body.AttesterSlashings,
+						Attestations:      m.Body.Attestations,
+						Deposits:          m.Body.Deposits,
+						VoluntaryExits:    m.Body.VoluntaryExits,
+						SyncAggregate:     m.Body.SyncAggregate,
+					},
+				},
+				Signature: blinded.Signature,
+			},
+		}, nil
+	case spec.DataVersionCapella:
+		var blinded apiv1capella.SignedBlindedBeaconBlock
+		if err := json.Unmarshal(res.Data, &blinded); err != nil {
+			return nil, fmt.Errorf("error decoding blinded block %v: %w", blockID, err)
+		}
+		m := blinded.Message
+		return &spec.VersionedSignedBeaconBlock{
+			Version: res.Version,
+			Capella: &capella.SignedBeaconBlock{
+				Message: &capella.BeaconBlock{
+					Slot:          m.Slot,
+					ProposerIndex: m.ProposerIndex,
+					ParentRoot:    m.ParentRoot,
+					StateRoot:     m.StateRoot,
+					Body: &capella.BeaconBlockBody{
+						RANDAOReveal:          m.Body.RANDAOReveal,
+						ETH1Data:              m.Body.ETH1Data,
+						Graffiti:              m.Body.Graffiti,
+						ProposerSlashings:     m.Body.ProposerSlashings,
+						AttesterSlashings:     m.Body.AttesterSlashings,
+						Attestations:          m.Body.Attestations,
+						Deposits:              m.Body.Deposits,
+						VoluntaryExits:        m.Body.VoluntaryExits,
+						SyncAggregate:         m.Body.SyncAggregate,
+						BLSToExecutionChanges: m.Body.BLSToExecutionChanges,
+					},
+				},
+				Signature: blinded.Signature,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("error decoding blinded block %v: unexpected version %v", blockID, res.Version)
+	}
+}
@@ -0,0 +1,79 @@
+package ethstore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// blockArchiveVersions are the versions of the blocks that WithBlockArchive reads.
+var blockArchiveVersions = []spec.DataVersion{spec.DataVersionPhase0, spec.DataVersionAltair, spec.DataVersionBellatrix, spec.DataVersionCapella}
+
+// blockArchiveFile returns the file of the block of slot in the archive dir, see WithBlockArchive.
+func blockArchiveFile(dir string, slot uint64, version spec.DataVersion) string {
+	return filepath.Join(dir, fmt.Sprintf("%d.%s.ssz", slot, version))
+}
+
+// readArchivedBlock reads the block of slot from the archive dir, nil if the archive has no block for slot. If root
+// is not nil, a block with another root is not canonical and nil is returned as well, so that the block is requested
+// from the node.
+func readArchivedBlock(dir string, slot uint64, root *phase0.Root) (*spec.VersionedSignedBeaconBlock, error) {
+	for _, version := range blockArchiveVersions {
+		file := blockArchiveFile(dir, slot, version)
+		data, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading archived block %v: %w", file, err)
+		}
+		block := &spec.VersionedSignedBeaconBlock{Version: version}
+		switch version {
+		case spec.DataVersionPhase0:
+			block.Phase0 = &phase0.SignedBeaconBlock{}
+			err = block.Phase0.UnmarshalSSZ(data)
+		case spec.DataVersionAltair:
+			block.Altair = &altair.SignedBeaconBlock{}
+			err = block.Altair.UnmarshalSSZ(data)
+		case spec.DataVersionBellatrix:
+			block.Bellatrix = &bellatrix.SignedBeaconBlock{}
+			err = block.Bellatrix.UnmarshalSSZ(data)
+		case spec.DataVersionCapella:
+			block.Capella = &capella.SignedBeaconBlock{}
+			err = block.Capella.UnmarshalSSZ(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding archived block %v: %w", file, err)
+		}
+		blockSlot, err := block.Slot()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding archived block %v: %w", file, err)
+		}
+		if uint64(blockSlot) != slot {
+			return nil, fmt.Errorf("archived block %v is of slot %v", file, blockSlot)
+		}
+		if root != nil {
+			blockRoot, err := block.Root()
+			if err != nil {
+				return nil, fmt.Errorf("error getting root of archived block %v: %w", file, err)
+			}
+			if blockRoot != *root {
+				if GetDebugLevel() > 0 {
+					log.Printf("DEBUG eth.store: archived block %v is not canonical (root %#x instead of %#x), requesting it from the node", file, blockRoot, *root)
+				}
+				return nil, nil
+			}
+		}
+		return block, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,322 @@
+package ethstore
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/shopspring/decimal"
+)
+
+// checkpointSlots is the number of slots of the block loop between two checkpoints, see WithCheckpoint.
+const checkpointSlots = 1024
+
+// CheckpointStore persists the progress of the block loop of a day, see WithCheckpoint. The data is opaque to the
+// store.
+type CheckpointStore interface {
+	SaveCheckpoint(ctx context.Context, key string, data []byte) error
+	// LoadCheckpoint returns nil without an error if there is no checkpoint for key.
+	LoadCheckpoint(ctx context.Context, key string) ([]byte, error)
+}
+
+// checkpoint holds the accumulators of the block loop after all slots before NextSlot have been processed.
+type checkpoint struct {
+	Day       uint64 `json:"day"`
+	FirstSlot uint64 `json:"firstSlot"`
+	EndSlot   uint64 `json:"endSlot"`
+	NextSlot  uint64 `json:"nextSlot"`
+	// Flags are the options that change what the block loop accumulates, a checkpoint written with other flags is
+	// not resumed.
+	Flags string `json:"flags"`
+
+	ProposedBlocks            uint64                                         `json:"proposedBlocks"`
+	IncompletePayloadSlots    uint64                                         `json:"incompletePayloadSlots"`
+	PendingDepositsSumGwei    phase0.Gwei                                    `json:"pendingDepositsSumGwei"`
+	ExitedDepositsSumGwei     phase0.Gwei                                    `json:"exitedDepositsSumGwei"`
+	ActivationDepositsSumGwei phase0.Gwei                                    `json:"activationDepositsSumGwei"`
+	TopUpDepositsSumGwei      phase0.Gwei                                    `json:"topUpDepositsSumGwei"`
+	BlindedBlockSlots         uint64                                         `json:"blindedBlockSlots"`
+	SeenDeposits              []string                                       `json:"seenDeposits"`
+	BalanceEvents             []BalanceEvent                                 `json:"balanceEvents,omitempty"`
+	EpochSums                 map[uint64]*checkpointEpochSum                 `json:"epochSums,omitempty"`
+	IncludedAttestations      map[string]string                              `json:"includedAttestations,omitempty"`
+	InclusionSlots            map[string][]uint64                            `json:"inclusionSlots,omitempty"`
+	RewardsByClient           map[string]*big.Int                            `json:"rewardsByClient,omitempty"`
+	WithdrawalsByAddress      map[common.Address]phase0.Gwei                 `json:"withdrawalsByAddress,omitempty"`
+	BlockVersions             map[string]uint64                              `json:"blockVersions,omitempty"`
+	Validators                map[phase0.ValidatorIndex]*checkpointValidator `json:"validators"`
+}
+
+type checkpointEpochSum struct {
+	DepositsGwei        phase0.Gwei `json:"depositsGwei"`
+	WithdrawalsGwei     phase0.Gwei `json:"withdrawalsGwei"`
+	ExecutionRewardsWei *big.Int    `json:"executionRewardsWei"`
+}
+
+// checkpointValidator holds the fields of a Validator that are accumulated in the block loop, validators without
+// any are omitted.
+type checkpointValidator struct {
+	DepositsSumGwei              phase0.Gwei `json:"depositsSumGwei,omitempty"`
+	WithdrawalsSumGwei           phase0.Gwei `json:"withdrawalsSumGwei,omitempty"`
+	TxFeesSumWei                 *big.Int    `json:"txFeesSumWei,omitempty"`
+	MevRewardsWei                *big.Int    `json:"mevRewardsWei,omitempty"`
+	SyncCommitteeDuties          uint64      `json:"syncCommitteeDuties,omitempty"`
+	SyncCommitteeParticipations  uint64      `json:"syncCommitteeParticipations,omitempty"`
+	SkippedDeposits              uint64      `json:"skippedDeposits,omitempty"`
+	ProposedTxCount              uint64      `json:"proposedTxCount,omitempty"`
+	ProposerConsensusRewardsGwei phase0.Gwei `json:"proposerConsensusRewardsGwei,omitempty"`
+	// GasUtilizationSum is nil without PayloadBlocks
+	GasUtilizationSum *decimal.Decimal `json:"gasUtilizationSum,omitempty"`
+	PayloadBlocks     uint64           `json:"payloadBlocks,omitempty"`
+
+	WithdrawalRequestsSumGwei phase0.Gwei `json:"withdrawalRequestsSumGwei,omitempty"`
+}
+
+func checkpointKey(day, firstSlot, endSlot uint64) string {
+	return fmt.Sprintf("day-%d-slots-%d-%d", day, firstSlot, endSlot)
+}
+
+func checkpointFlags(o *options) string {
+	return fmt.Sprintf("mevLastTxAttribution=%v,balanceEvents=%v,epochBreakdown=%v,depositFilter=%v,missedAttestations=%v,clientClassifier=%v,proposerRewards=%v,feeStrategy=%T,inclusionDistance=%v,executionRewardsRate=%v,minActivationAge=%v,withdrawalRequests=%v", o.mevLastTxAttribution, o.balanceEvents, o.epochBreakdown, o.depositFilter != nil, o.missedAttestations, o.clientClassifier != nil, o.proposerRewards, o.feeStrategy, o.inclusionDistance, o.executionRewardsRate != nil, o.minActivationAge, o.withdrawalRequests)
+}
+
+// checkResumable returns an error if checkpoints can not be resumed with the options o. Functions can not be compared,
+// so a checkpoint written with another filter of WithDepositFilter (or classifier of WithClientClassifier) could not
+// be told apart from one written with the function of o.
+func checkResumable(o *options) error {
+	if o.depositFilter != nil {
+		return fmt.Errorf("checkpoints are not resumed with WithDepositFilter")
+	}
+	if o.clientClassifier != nil {
+		return fmt.Errorf("checkpoints are not resumed with WithClientClassifier")
+	}
+	return nil
+}
+
+func (c *checkpoint) setSeenDeposits(seenDeposits map[phase0.Root]bool) {
+	c.SeenDeposits = make([]string, 0, len(seenDeposits))
+	for root := range seenDeposits {
+		c.SeenDeposits = append(c.SeenDeposits, fmt.Sprintf("%#x", root))
+	}
+}
+
+func (c *checkpoint) getSeenDeposits() (map[phase0.Root]bool, error) {
+	seenDeposits := make(map[phase0.Root]bool, len(c.SeenDeposits))
+	for _, s := range c.SeenDeposits {
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil || len(b) != len(phase0.Root{}) {
+			return nil, fmt.Errorf("invalid deposit root %q", s)
+		}
+		var root phase0.Root
+		copy(root[:], b)
+		seenDeposits[root] = true
+	}
+	return seenDeposits, nil
+}
+
+func (c *checkpoint) setEpochSums(epochSums map[uint64]*epochSum) {
+	c.EpochSums = make(map[uint64]*checkpointEpochSum, len(epochSums))
+	for epoch, es := range epochSums {
+		c.EpochSums[epoch] = &checkpointEpochSum{DepositsGwei: es.depositsGwei, WithdrawalsGwei: es.withdrawalsGwei, ExecutionRewardsWei: new(big.Int).Set(es.executionRewardsWei)}
+	}
+}
+
+func (c *checkpoint) getEpochSums() map[uint64]*epochSum {
+	epochSums := make(map[uint64]*epochSum, len(c.EpochSums))
+	for epoch, es := range c.EpochSums {
+		executionRewardsWei := new(big.Int)
+		if es.ExecutionRewardsWei != nil {
+			executionRewardsWei.Set(es.ExecutionRewardsWei)
+		}
+		epochSums[epoch] = &epochSum{depositsGwei: es.DepositsGwei, withdrawalsGwei: es.WithdrawalsGwei, executionRewardsWei: executionRewardsWei}
+	}
+	return epochSums
+}
+
+// setIncludedAttestations stores the aggregation bits keyed by "slot/committee index".
+func (c *checkpoint) setIncludedAttestations(included map[attestationKey]bitfield.Bitlist) {
+	c.IncludedAttestations = make(map[string]string, len(included))
+	for key, bits := range included {
+		c.IncludedAttestations[fmt.Sprintf("%d/%d", key.Slot, key.Index)] = fmt.Sprintf("%#x", []byte(bits))
+	}
+}
+
+func (c *checkpoint) getIncludedAttestations() (map[attestationKey]bitfield.Bitlist, error) {
+	included := make(map[attestationKey]bitfield.Bitlist, len(c.IncludedAttestations))
+	for k, s := range c.IncludedAttestations {
+		var key attestationKey
+		if _, err := fmt.Sscanf(k, "%d/%d", &key.Slot, &key.Index); err != nil {
+			return nil, fmt.Errorf("invalid attestation key %q", k)
+		}
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid aggregation bits %q", s)
+		}
+		included[key] = bitfield.Bitlist(b)
+	}
+	return included, nil
+}
+
+// setInclusionSlots stores the inclusion slots keyed by "slot/committee index" like setIncludedAttestations.
+func (c *checkpoint) setInclusionSlots(inclusionSlots map[attestationKey][]uint64) {
+	c.InclusionSlots = make(map[string][]uint64, len(inclusionSlots))
+	for key, slots := range inclusionSlots {
+		c.InclusionSlots[fmt.Sprintf("%d/%d", key.Slot, key.Index)] = append([]uint64{}, slots...)
+	}
+}
+
+func (c *checkpoint) getInclusionSlots() (map[attestationKey][]uint64, error) {
+	inclusionSlots := make(map[attestationKey][]uint64, len(c.InclusionSlots))
+	for k, slots := range c.InclusionSlots {
+		var key attestationKey
+		if _, err := fmt.Sscanf(k, "%d/%d", &key.Slot, &key.Index); err != nil {
+			return nil, fmt.Errorf("invalid attestation key %q", k)
+		}
+		inclusionSlots[key] = append([]uint64{}, slots...)
+	}
+	return inclusionSlots, nil
+}
+
+func (c *checkpoint) setRewardsByClient(rewardsByClient map[string]*big.Int) {
+	c.RewardsByClient = make(map[string]*big.Int, len(rewardsByClient))
+	for clientName, rewardsWei := range rewardsByClient {
+		c.RewardsByClient[clientName] = new(big.Int).Set(rewardsWei)
+	}
+}
+
+func (c *checkpoint) getRewardsByClient() map[string]*big.Int {
+	rewardsByClient := make(map[string]*big.Int, len(c.RewardsByClient))
+	for clientName, rewardsWei := range c.RewardsByClient {
+		if rewardsWei != nil {
+			rewardsByClient[clientName] = new(big.Int).Set(rewardsWei)
+		}
+	}
+	return rewardsByClient
+}
+
+func (c *checkpoint) setWithdrawalsByAddress(withdrawalsByAddress map[common.Address]phase0.Gwei) {
+	c.WithdrawalsByAddress = make(map[common.Address]phase0.Gwei, len(withdrawalsByAddress))
+	for address, amount := range withdrawalsByAddress {
+		c.WithdrawalsByAddress[address] = amount
+	}
+}
+
+func (c *checkpoint) getWithdrawalsByAddress() map[common.Address]phase0.Gwei {
+	withdrawalsByAddress := make(map[common.Address]phase0.Gwei, len(c.WithdrawalsByAddress))
+	for address, amount := range c.WithdrawalsByAddress {
+		withdrawalsByAddress[address] = amount
+	}
+	return withdrawalsByAddress
+}
+
+func (c *checkpoint) setBlockVersions(blockVersions map[string]uint64) {
+	c.BlockVersions = make(map[string]uint64, len(blockVersions))
+	for version, n := range blockVersions {
+		c.BlockVersions[version] = n
+	}
+}
+
+func (c *checkpoint) getBlockVersions() map[string]uint64 {
+	blockVersions := make(map[string]uint64, len(c.BlockVersions))
+	for version, n := range c.BlockVersions {
+		blockVersions[version] = n
+	}
+	return blockVersions
+}
+
+func (c *checkpoint) setValidators(validators map[phase0.ValidatorIndex]*Validator) {
+	c.Validators = map[phase0.ValidatorIndex]*checkpointValidator{}
+	for index, v := range validators {
+		cv := &checkpointValidator{
+			DepositsSumGwei:              v.DepositsSumGwei,
+			WithdrawalsSumGwei:           v.WithdrawalsSumGwei,
+			SyncCommitteeDuties:          v.SyncCommitteeDuties,
+			SyncCommitteeParticipations:  v.SyncCommitteeParticipations,
+			SkippedDeposits:              v.SkippedDeposits,
+			ProposedTxCount:              v.ProposedTxCount,
+			ProposerConsensusRewardsGwei: v.ProposerConsensusRewardsGwei,
+			PayloadBlocks:                v.PayloadBlocks,
+			WithdrawalRequestsSumGwei:    v.WithdrawalRequestsSumGwei,
+		}
+		if v.PayloadBlocks != 0 {
+			gasUtilizationSum := v.GasUtilizationSum
+			cv.GasUtilizationSum = &gasUtilizationSum
+		}
+		if v.TxFeesSumWei.Sign() != 0 {
+			cv.TxFeesSumWei = new(big.Int).Set(v.TxFeesSumWei)
+		}
+		if v.MevRewardsWei != nil {
+			cv.MevRewardsWei = new(big.Int).Set(v.MevRewardsWei)
+		}
+		if *cv != (checkpointValidator{}) {
+			c.Validators[index] = cv
+		}
+	}
+}
+
+// restoreValidators sets the accumulated fields of validators, the validators of the checkpoint have to be a subset
+// of validators since both are derived from the same states.
+func (c *checkpoint) restoreValidators(validators map[phase0.ValidatorIndex]*Validator) error {
+	for index := range c.Validators {
+		if _, exists := validators[index]; !exists {
+			return fmt.Errorf("validator %v of the checkpoint is not accounted", index)
+		}
+	}
+	for index, cv := range c.Validators {
+		v := validators[index]
+		v.DepositsSumGwei = cv.DepositsSumGwei
+		v.WithdrawalsSumGwei = cv.WithdrawalsSumGwei
+		v.SyncCommitteeDuties = cv.SyncCommitteeDuties
+		v.SyncCommitteeParticipations = cv.SyncCommitteeParticipations
+		v.SkippedDeposits = cv.SkippedDeposits
+		v.ProposedTxCount = cv.ProposedTxCount
+		v.ProposerConsensusRewardsGwei = cv.ProposerConsensusRewardsGwei
+		v.PayloadBlocks = cv.PayloadBlocks
+		v.WithdrawalRequestsSumGwei = cv.WithdrawalRequestsSumGwei
+		if cv.GasUtilizationSum != nil {
+			v.GasUtilizationSum = *cv.GasUtilizationSum
+		}
+		if cv.TxFeesSumWei != nil {
+			v.TxFeesSumWei.Set(cv.TxFeesSumWei)
+		}
+		if cv.MevRewardsWei != nil {
+			v.MevRewardsWei = new(big.Int).Set(cv.MevRewardsWei)
+		}
+	}
+	return nil
+}
+
+func saveCheckpoint(ctx context.Context, store CheckpointStore, key string, c *checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("error marshaling checkpoint %v: %w", key, err)
+	}
+	err = store.SaveCheckpoint(ctx, key, data)
+	if err != nil {
+		return fmt.Errorf("error saving checkpoint %v: %w", key, err)
+	}
+	return nil
+}
+
+// loadCheckpoint returns the checkpoint stored for key, nil if there is none.
+func loadCheckpoint(ctx context.Context, store CheckpointStore, key string) (*checkpoint, error) {
+	data, err := store.LoadCheckpoint(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("error loading checkpoint %v: %w", key, err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	c := &checkpoint{}
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling checkpoint %v: %w", key, err)
+	}
+	return c, nil
+}
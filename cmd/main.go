@@ -24,6 +24,9 @@ var opts struct {
 	Validators  string
 	ConsAddress string
 	ConsTimeout time.Duration
+	ConsChunks  uint64
+	ConsRPS     float64
+	ConsIndices uint64
 	ExecAddress string
 	ExecTimeout time.Duration
 	Json        bool
@@ -36,6 +39,9 @@ func main() {
 	flag.StringVar(&opts.Days, "days", "", "days to calculate eth.store for, format: \"1-3\" or \"1,4,6\"")
 	flag.StringVar(&opts.ConsAddress, "cons.address", "http://localhost:4000", "address of the conensus-node-api")
 	flag.DurationVar(&opts.ConsTimeout, "cons.timeout", time.Second*120, "timeout duration for the consensus-node-api")
+	flag.Uint64Var(&opts.ConsChunks, "cons.chunksize", 0, "number of validators to request at once from the consensus-node-api (0 requests all validators at once)")
+	flag.Float64Var(&opts.ConsRPS, "cons.rps", 0, "maximum number of requests per second to the consensus-node-api (0 disables the limit)")
+	flag.Uint64Var(&opts.ConsIndices, "cons.maxindices", 0, "maximum number of validator indices in the url of a request to the consensus-node-api (0 uses the default of 1000)")
 	flag.StringVar(&opts.ExecAddress, "exec.address", "http://localhost:4000", "address of the execution-node-api")
 	flag.DurationVar(&opts.ExecTimeout, "exec.timeout", time.Second*120, "timeout duration for the execution-node-api")
 	flag.BoolVar(&opts.Json, "json", false, "format output as json")
@@ -51,6 +57,9 @@ func main() {
 
 	ethstore.SetConsTimeout(opts.ConsTimeout)
 	ethstore.SetExecTimeout(opts.ExecTimeout)
+	ethstore.SetValidatorsChunkSize(opts.ConsChunks)
+	ethstore.SetRequestsPerSecond(opts.ConsRPS)
+	ethstore.SetMaxIndicesPerRequest(opts.ConsIndices)
 	ethstore.SetDebugLevel(opts.DebugLevel)
 
 	days := []uint64{}
@@ -145,7 +154,7 @@ func main() {
 				logEthstoreDay(d)
 				continue
 			}
-			d, _, err := ethstore.Calculate(context.Background(), opts.ConsAddress, opts.ExecAddress, fmt.Sprintf("%d", dd), 10)
+			d, err := ethstore.CalculateNetwork(context.Background(), opts.ConsAddress, opts.ExecAddress, fmt.Sprintf("%d", dd))
 			if err != nil {
 				log.Fatalf("error calculating ethstore: %v", err)
 			}
@@ -168,7 +177,7 @@ func main() {
 	} else {
 		result := []*ethstore.Day{}
 		for _, dd := range days {
-			d, _, err := ethstore.Calculate(context.Background(), opts.ConsAddress, opts.ExecAddress, fmt.Sprintf("%d", dd), 10)
+			d, err := ethstore.CalculateNetwork(context.Background(), opts.ConsAddress, opts.ExecAddress, fmt.Sprintf("%d", dd))
 			if err != nil {
 				log.Fatalf("error calculating ethstore: %v", err)
 			}
@@ -0,0 +1,90 @@
+package ethstore
+
+import (
+	"log"
+	"sync"
+)
+
+// adaptiveLimiter bounds the requests of the block loop that are in flight, see WithAdaptiveConcurrency. The limit is
+// halved when a request fails and raised by one after limit requests in a row succeeded (AIMD), within [min, max].
+type adaptiveLimiter struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	min  int
+	max  int
+
+	limit     int
+	inFlight  int
+	successes int
+	// completed counts the finished requests, failures before holdUntil were started before the last decrease and
+	// do not decrease the limit again
+	completed uint64
+	holdUntil uint64
+}
+
+func newAdaptiveLimiter(minLimit, maxLimit, initial int) *adaptiveLimiter {
+	if initial < minLimit {
+		initial = minLimit
+	}
+	if initial > maxLimit {
+		initial = maxLimit
+	}
+	l := &adaptiveLimiter{min: minLimit, max: maxLimit, limit: initial}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire blocks until less than limit requests are in flight.
+func (l *adaptiveLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight >= l.limit {
+		l.cond.Wait()
+	}
+	l.inFlight++
+}
+
+func (l *adaptiveLimiter) release() {
+	l.mu.Lock()
+	l.inFlight--
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// observe adjusts the limit to the outcome of a single request (or attempt of a request).
+func (l *adaptiveLimiter) observe(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.completed++
+	if err != nil {
+		l.successes = 0
+		if l.completed <= l.holdUntil || l.limit == l.min {
+			return
+		}
+		l.limit /= 2
+		if l.limit < l.min {
+			l.limit = l.min
+		}
+		l.holdUntil = l.completed + uint64(l.inFlight)
+		if GetDebugLevel() > 0 {
+			log.Printf("DEBUG eth.store: decreasing concurrency to %v after error: %v", l.limit, err)
+		}
+		return
+	}
+	l.successes++
+	if l.successes < l.limit || l.limit == l.max {
+		return
+	}
+	l.successes = 0
+	l.limit++
+	l.cond.Broadcast()
+	if GetDebugLevel() > 0 {
+		log.Printf("DEBUG eth.store: increasing concurrency to %v", l.limit)
+	}
+}
+
+func (l *adaptiveLimiter) currentLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
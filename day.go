@@ -0,0 +1,527 @@
+package ethstore
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+)
+
+// FieldDiff describes a field that differs between two days.
+type FieldDiff struct {
+	Field string
+	A     string
+	B     string
+}
+
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Field, d.A, d.B)
+}
+
+// Equal reports whether d and other hold the same values. Numbers are compared by value, so
+// decimals with different exponents (e.g. 1.0 and 1.00) and times in different locations are equal. ComputedAt is not
+// compared.
+func (d *Day) Equal(other *Day) bool {
+	if d == nil || other == nil {
+		return d == other
+	}
+	return len(Diff(d, other)) == 0
+}
+
+// Diff lists all fields of a and b that differ, see Equal for how values are compared.
+func Diff(a, b *Day) []FieldDiff {
+	if a == nil || b == nil {
+		if a == b {
+			return nil
+		}
+		return []FieldDiff{{Field: "Day", A: fmt.Sprintf("%v", a), B: fmt.Sprintf("%v", b)}}
+	}
+	diffs := []FieldDiff{}
+	va := reflect.ValueOf(a).Elem()
+	vb := reflect.ValueOf(b).Elem()
+	for i := 0; i < va.NumField(); i++ {
+		// the time a day was calculated at differs between calculations of the same day
+		if !va.Type().Field(i).IsExported() || va.Type().Field(i).Name == "ComputedAt" {
+			continue
+		}
+		fa := va.Field(i)
+		fb := vb.Field(i)
+		if !valuesEqual(fa, fb) {
+			diffs = append(diffs, FieldDiff{
+				Field: va.Type().Field(i).Name,
+				A:     fmt.Sprintf("%v", fa.Interface()),
+				B:     fmt.Sprintf("%v", fb.Interface()),
+			})
+		}
+	}
+	return diffs
+}
+
+var (
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	timeType    = reflect.TypeOf(time.Time{})
+	bigIntType  = reflect.TypeOf(&big.Int{})
+	dayType     = reflect.TypeOf(&Day{})
+)
+
+func valuesEqual(a, b reflect.Value) bool {
+	switch a.Type() {
+	case decimalType:
+		return a.Interface().(decimal.Decimal).Equal(b.Interface().(decimal.Decimal))
+	case timeType:
+		return a.Interface().(time.Time).Equal(b.Interface().(time.Time))
+	case bigIntType:
+		ai, bi := a.Interface().(*big.Int), b.Interface().(*big.Int)
+		if ai == nil || bi == nil {
+			return ai == bi
+		}
+		return ai.Cmp(bi) == 0
+	case dayType:
+		// the days of the validator sets are compared like days, without the time they were calculated at
+		return a.Interface().(*Day).Equal(b.Interface().(*Day))
+	}
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return valuesEqual(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !a.Type().Field(i).IsExported() {
+				continue
+			}
+			if !valuesEqual(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !valuesEqual(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		iter := a.MapRange()
+		for iter.Next() {
+			bv := b.MapIndex(iter.Key())
+			if !bv.IsValid() || !valuesEqual(iter.Value(), bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// roundApr rounds the aprs to places. ExecutionApr is derived from the rounded Apr and ConsensusApr, so that the parts
+// still add up to Apr.
+func (d *Day) roundApr(places int32) {
+	d.Apr = d.Apr.Round(places)
+	d.Apy = d.Apy.Round(places)
+	d.ConsensusApr = d.ConsensusApr.Round(places)
+	d.ExecutionApr = d.Apr.Sub(d.ConsensusApr)
+	d.AprExMev = d.AprExMev.Round(places)
+}
+
+// clampNegativeApr sets a negative Apr to 0, together with Apy and both parts of Apr. AprExMev is not above Apr and
+// clamped on its own.
+func (d *Day) clampNegativeApr() {
+	if d.Apr.IsNegative() {
+		d.Apr = decimal.Zero
+		d.Apy = decimal.Zero
+		d.ConsensusApr = decimal.Zero
+		d.ExecutionApr = decimal.Zero
+	}
+	if d.AprExMev.IsNegative() {
+		d.AprExMev = decimal.Zero
+	}
+}
+
+// TxFeesEth returns TxFeesSumWei in ETH. The conversions shift the decimal point, so no precision is lost.
+func (d *Day) TxFeesEth() decimal.Decimal {
+	return d.TxFeesSumWei.Shift(-18)
+}
+
+// ConsensusRewardsEth returns ConsensusRewardsGwei in ETH.
+func (d *Day) ConsensusRewardsEth() decimal.Decimal {
+	return d.ConsensusRewardsGwei.Shift(-9)
+}
+
+// TotalRewardsEth returns TotalRewardsWei in ETH.
+func (d *Day) TotalRewardsEth() decimal.Decimal {
+	return d.TotalRewardsWei.Shift(-18)
+}
+
+// EffectiveBalanceEth returns EffectiveBalanceGwei in ETH.
+func (d *Day) EffectiveBalanceEth() decimal.Decimal {
+	return d.EffectiveBalanceGwei.Shift(-9)
+}
+
+// aprTolerance is the maximum difference between the apr of a day and the recomputed apr that Verify accepts,
+// both are rounded to decimal.DivisionPrecision digits in different orders.
+var aprTolerance = decimal.New(1, -12)
+
+// Verify recomputes the totals and the apr of day from the given validators and returns an error naming all
+// fields that do not match. The sums have to match exactly, the apr within aprTolerance. annualizationDays is the
+// number of days the daily rewards are annualized with (365 for days calculated by Calculate).
+func Verify(day *Day, validators []*Validator, annualizationDays decimal.Decimal) error {
+	var effectiveBalanceGwei, startBalanceGwei, endBalanceGwei, depositsSumGwei, withdrawalsSumGwei phase0.Gwei
+	txFeesSumWei := new(big.Int)
+	mevRewardsWei := new(big.Int)
+	for _, v := range validators {
+		effectiveBalanceGwei += v.EffectiveBalanceGwei
+		startBalanceGwei += v.StartBalanceGwei
+		endBalanceGwei += v.EndBalanceGwei
+		depositsSumGwei += v.DepositsSumGwei
+		withdrawalsSumGwei += v.WithdrawalsSumGwei
+		if v.TxFeesSumWei != nil {
+			txFeesSumWei.Add(txFeesSumWei, v.TxFeesSumWei)
+		}
+		if v.MevRewardsWei != nil {
+			mevRewardsWei.Add(mevRewardsWei, v.MevRewardsWei)
+		}
+	}
+	consensusRewards := consensusRewardsGwei(startBalanceGwei, endBalanceGwei, depositsSumGwei, withdrawalsSumGwei)
+	totalRewardsWei := decimal.NewFromBigInt(txFeesSumWei, 0).Add(decimal.NewFromBigInt(mevRewardsWei, 0)).Add(consensusRewards.Shift(9))
+
+	mismatches := []string{}
+	check := func(field string, got, want decimal.Decimal) {
+		if !got.Equal(want) {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %v != %v", field, got, want))
+		}
+	}
+	check("Validators", day.Validators, decimal.NewFromInt(int64(len(validators))))
+	check("EffectiveBalanceGwei", day.EffectiveBalanceGwei, gweiToDecimal(effectiveBalanceGwei))
+	check("StartBalanceGwei", day.StartBalanceGwei, gweiToDecimal(startBalanceGwei))
+	check("EndBalanceGwei", day.EndBalanceGwei, gweiToDecimal(endBalanceGwei))
+	check("DepositsSumGwei", day.DepositsSumGwei, gweiToDecimal(depositsSumGwei))
+	check("WithdrawalsSumGwei", day.WithdrawalsSumGwei, gweiToDecimal(withdrawalsSumGwei))
+	check("TxFeesSumWei", day.TxFeesSumWei, decimal.NewFromBigInt(txFeesSumWei, 0))
+	check("MevRewardsWei", day.MevRewardsWei, decimal.NewFromBigInt(mevRewardsWei, 0))
+	check("ConsensusRewardsGwei", day.ConsensusRewardsGwei, consensusRewards)
+	check("TotalRewardsWei", day.TotalRewardsWei, totalRewardsWei)
+	apr := ComputeApr(startBalanceGwei, endBalanceGwei, depositsSumGwei, withdrawalsSumGwei, new(big.Int).Add(txFeesSumWei, mevRewardsWei), effectiveBalanceGwei, annualizationDays)
+	if day.Apr.Sub(apr).Abs().GreaterThan(aprTolerance) {
+		mismatches = append(mismatches, fmt.Sprintf("Apr: %v != %v", day.Apr, apr))
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("day %v does not match its validators: %s", day.Day, strings.Join(mismatches, ", "))
+	}
+	return nil
+}
+
+// DayDelta holds the changes of a day compared to a previous day. The absolute changes are today - previous, the
+// relative changes are the absolute changes divided by the previous value and are zero if the previous value is zero.
+type DayDelta struct {
+	Apr                          decimal.Decimal `json:"apr"`
+	AprRelative                  decimal.Decimal `json:"aprRelative"`
+	Validators                   decimal.Decimal `json:"validators"`
+	ValidatorsRelative           decimal.Decimal `json:"validatorsRelative"`
+	EffectiveBalanceGwei         decimal.Decimal `json:"effectiveBalanceGwei"`
+	EffectiveBalanceGweiRelative decimal.Decimal `json:"effectiveBalanceGweiRelative"`
+}
+
+// Delta returns the changes of d compared to prev, which usually is the day before d.
+func (d *Day) Delta(prev *Day) DayDelta {
+	delta := DayDelta{}
+	delta.Apr, delta.AprRelative = change(d.Apr, prev.Apr)
+	delta.Validators, delta.ValidatorsRelative = change(d.Validators, prev.Validators)
+	delta.EffectiveBalanceGwei, delta.EffectiveBalanceGweiRelative = change(d.EffectiveBalanceGwei, prev.EffectiveBalanceGwei)
+	return delta
+}
+
+func change(current, previous decimal.Decimal) (absolute, relative decimal.Decimal) {
+	absolute = current.Sub(previous)
+	if previous.IsZero() {
+		return absolute, decimal.Zero
+	}
+	return absolute, absolute.Div(previous)
+}
+
+// AggregateDays combines consecutive days (sorted by day, without duplicates) into one day of the whole period. The
+// flows of the period (deposits, withdrawals, rewards, blocks and sync committee duties) are the sums of the days, the
+// start balance is the start balance of the first day and the end balance the end balance of the last day.
+//
+// Validators and EffectiveBalanceGwei are the means over the days, and Apr is weighted by the capital that was
+// actually at stake each day: apr = sum(apr_d * effectiveBalance_d) / sum(effectiveBalance_d). With the canonical
+// annualization of each day (365 * rewards_d / effectiveBalance_d) this is 365 * sum(rewards_d) / sum(effectiveBalance_d),
+// so a day with few validators, e.g. before a pool grew, weighs less than a day with many, other than with the mean of
+// the daily aprs or with the rewards of the period divided by the capital at its end. Apy compounds the weighted apr,
+// ConsensusApr and AprExMev are weighted the same way. The days of the validator sets that all days have are aggregated alike,
+// their ValidatorSetMeta is not.
+func AggregateDays(days []*Day) (*Day, error) {
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no days to aggregate")
+	}
+	for i := 1; i < len(days); i++ {
+		if !days[i].Day.Equal(days[i-1].Day.Add(decimal.NewFromInt(1))) {
+			return nil, fmt.Errorf("days are not consecutive: day %v follows day %v", days[i].Day, days[i-1].Day)
+		}
+	}
+	first, last := days[0], days[len(days)-1]
+	p := &Day{
+		Day:                first.Day,
+		DayTime:            first.DayTime,
+		StartEpoch:         first.StartEpoch,
+		StartBalanceGwei:   first.StartBalanceGwei,
+		EndBalanceGwei:     last.EndBalanceGwei,
+		ComputedAt:         first.ComputedAt,
+		MethodologyVersion: first.MethodologyVersion,
+	}
+	weightedAprSum := decimal.Zero
+	weightedConsensusAprSum := decimal.Zero
+	weightedAprExMevSum := decimal.Zero
+	syncParticipationsSum := decimal.Zero
+	gasUtilizationSum := decimal.Zero
+	inclusionDistanceSum := decimal.Zero
+	for _, d := range days {
+		p.Validators = p.Validators.Add(d.Validators)
+		p.EffectiveBalanceGwei = p.EffectiveBalanceGwei.Add(d.EffectiveBalanceGwei)
+		p.DepositsSumGwei = p.DepositsSumGwei.Add(d.DepositsSumGwei)
+		p.WithdrawalsSumGwei = p.WithdrawalsSumGwei.Add(d.WithdrawalsSumGwei)
+		p.WithdrawalRequestsSumGwei = p.WithdrawalRequestsSumGwei.Add(d.WithdrawalRequestsSumGwei)
+		p.ConsensusRewardsGwei = p.ConsensusRewardsGwei.Add(d.ConsensusRewardsGwei)
+		p.TxFeesSumWei = p.TxFeesSumWei.Add(d.TxFeesSumWei)
+		p.MevRewardsWei = p.MevRewardsWei.Add(d.MevRewardsWei)
+		p.TotalRewardsWei = p.TotalRewardsWei.Add(d.TotalRewardsWei)
+		p.TotalRewardsFiat = p.TotalRewardsFiat.Add(d.TotalRewardsFiat)
+		p.SyncCommitteeDuties = p.SyncCommitteeDuties.Add(d.SyncCommitteeDuties)
+		syncParticipationsSum = syncParticipationsSum.Add(d.SyncParticipationRate.Mul(d.SyncCommitteeDuties))
+		p.ProposedBlocks = p.ProposedBlocks.Add(d.ProposedBlocks)
+		p.MissedSlots = p.MissedSlots.Add(d.MissedSlots)
+		p.MissedAttestations = p.MissedAttestations.Add(d.MissedAttestations)
+		p.ActivatedValidators = p.ActivatedValidators.Add(d.ActivatedValidators)
+		p.ExitedValidators = p.ExitedValidators.Add(d.ExitedValidators)
+		p.YoungValidators = p.YoungValidators.Add(d.YoungValidators)
+		p.SkippedDeposits = p.SkippedDeposits.Add(d.SkippedDeposits)
+		p.IncompletePayloadSlots = p.IncompletePayloadSlots.Add(d.IncompletePayloadSlots)
+		p.BlindedBlockSlots = p.BlindedBlockSlots.Add(d.BlindedBlockSlots)
+		p.ProposerConsensusRewardsGwei = p.ProposerConsensusRewardsGwei.Add(d.ProposerConsensusRewardsGwei)
+		p.PayloadBlocks = p.PayloadBlocks.Add(d.PayloadBlocks)
+		gasUtilizationSum = gasUtilizationSum.Add(d.AvgGasUtilization.Mul(d.PayloadBlocks))
+		p.IncludedAttestations = p.IncludedAttestations.Add(d.IncludedAttestations)
+		inclusionDistanceSum = inclusionDistanceSum.Add(d.AvgInclusionDistance.Mul(d.IncludedAttestations))
+		for address, amount := range d.WithdrawalsByAddress {
+			if p.WithdrawalsByAddress == nil {
+				p.WithdrawalsByAddress = map[common.Address]phase0.Gwei{}
+			}
+			p.WithdrawalsByAddress[address] += amount
+		}
+		for version, n := range d.BlockVersions {
+			if p.BlockVersions == nil {
+				p.BlockVersions = map[string]uint64{}
+			}
+			p.BlockVersions[version] += n
+		}
+		p.PendingDepositsSumGwei = p.PendingDepositsSumGwei.Add(d.PendingDepositsSumGwei)
+		p.ExitedDepositsSumGwei = p.ExitedDepositsSumGwei.Add(d.ExitedDepositsSumGwei)
+		p.ActivationDepositsSumGwei = p.ActivationDepositsSumGwei.Add(d.ActivationDepositsSumGwei)
+		p.TopUpDepositsSumGwei = p.TopUpDepositsSumGwei.Add(d.TopUpDepositsSumGwei)
+		p.TotalTxCount = p.TotalTxCount.Add(d.TotalTxCount)
+		p.PeriodSeconds = p.PeriodSeconds.Add(d.PeriodSeconds)
+		p.InactivityLeakEpochs = p.InactivityLeakEpochs.Add(d.InactivityLeakEpochs)
+		p.PossiblyReorged = p.PossiblyReorged || d.PossiblyReorged
+		p.DuringNonFinality = p.DuringNonFinality || d.DuringNonFinality
+		p.WithoutBlocks = p.WithoutBlocks || d.WithoutBlocks
+		// the period is as recent as its oldest day and only of a methodology if all days are
+		if d.ComputedAt.Before(p.ComputedAt) {
+			p.ComputedAt = d.ComputedAt
+		}
+		if d.MethodologyVersion != p.MethodologyVersion {
+			p.MethodologyVersion = ""
+		}
+		// the period is as good as its worst day
+		if d.QualityScore != nil && (p.QualityScore == nil || d.QualityScore.LessThan(*p.QualityScore)) {
+			p.QualityScore = d.QualityScore
+		}
+		for _, w := range d.Warnings {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("day %v: %s", d.Day, w))
+		}
+		weightedAprSum = weightedAprSum.Add(d.Apr.Mul(d.EffectiveBalanceGwei))
+		weightedConsensusAprSum = weightedConsensusAprSum.Add(d.ConsensusApr.Mul(d.EffectiveBalanceGwei))
+		weightedAprExMevSum = weightedAprExMevSum.Add(d.AprExMev.Mul(d.EffectiveBalanceGwei))
+	}
+	if !p.EffectiveBalanceGwei.IsZero() {
+		p.Apr = weightedAprSum.Div(p.EffectiveBalanceGwei)
+		p.ConsensusApr = weightedConsensusAprSum.Div(p.EffectiveBalanceGwei)
+		p.ExecutionApr = p.Apr.Sub(p.ConsensusApr)
+		p.AprExMev = weightedAprExMevSum.Div(p.EffectiveBalanceGwei)
+	}
+	if !p.SyncCommitteeDuties.IsZero() {
+		p.SyncParticipationRate = syncParticipationsSum.Div(p.SyncCommitteeDuties)
+	}
+	if !p.PayloadBlocks.IsZero() {
+		p.AvgGasUtilization = gasUtilizationSum.Div(p.PayloadBlocks)
+	}
+	if !p.IncludedAttestations.IsZero() {
+		p.AvgInclusionDistance = inclusionDistanceSum.Div(p.IncludedAttestations)
+	}
+	n := decimal.NewFromInt(int64(len(days)))
+	p.Validators = p.Validators.Div(n)
+	p.EffectiveBalanceGwei = p.EffectiveBalanceGwei.Div(n)
+	p.Apy = apy(p.Apr)
+	if !p.Validators.IsZero() {
+		p.AvgRewardsPerValidatorEth = p.TotalRewardsEth().Div(p.Validators)
+	}
+	// a validator set is only aggregated if every day has it
+	for name := range first.ValidatorSets {
+		setDays := make([]*Day, 0, len(days))
+		for _, d := range days {
+			if setDay, exists := d.ValidatorSets[name]; exists {
+				setDays = append(setDays, setDay)
+			}
+		}
+		if len(setDays) < len(days) {
+			continue
+		}
+		setPeriod, err := AggregateDays(setDays)
+		if err != nil {
+			return nil, err
+		}
+		if p.ValidatorSets == nil {
+			p.ValidatorSets = make(map[string]*Day, len(first.ValidatorSets))
+		}
+		p.ValidatorSets[name] = setPeriod
+	}
+	return p, nil
+}
+
+// String returns a one-line summary of the day: day, date, apr in percent, validators and total rewards in ETH.
+func (d *Day) String() string {
+	if d == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("day %v (%s): apr %s%%, %v validators, %s ETH rewards", d.Day, d.DayTime.Format("2006-01-02"), d.Apr.Shift(2).StringFixed(4), d.Validators, d.TotalRewardsEth().StringFixed(6))
+}
+
+// Detailed returns the summary of String followed by a line per value of the day, balances and rewards in ETH.
+func (d *Day) Detailed() string {
+	if d == nil {
+		return "<nil>"
+	}
+	b := &strings.Builder{}
+	b.WriteString(d.String())
+	line := func(name string, value interface{}) {
+		fmt.Fprintf(b, "\n  %-24s %v", name+":", value)
+	}
+	line("dayTime", d.DayTime.Format(time.RFC3339))
+	if d.MethodologyVersion != "" {
+		line("computedAt", d.ComputedAt.Format(time.RFC3339)+" (methodology "+d.MethodologyVersion+")")
+	}
+	line("startEpoch", d.StartEpoch)
+	line("periodSeconds", d.PeriodSeconds)
+	line("apr", d.Apr)
+	line("apy", d.Apy)
+	line("consensusApr", d.ConsensusApr)
+	line("executionApr", d.ExecutionApr)
+	line("aprExMev", d.AprExMev)
+	line("aprStdDev", d.AprStdDev)
+	line("aprTrimmedMean", d.AprTrimmedMean)
+	line("effectiveBalance", d.EffectiveBalanceEth().String()+" ETH")
+	line("startBalance", d.StartBalanceGwei.Shift(-9).String()+" ETH")
+	line("endBalance", d.EndBalanceGwei.Shift(-9).String()+" ETH")
+	line("deposits", d.DepositsSumGwei.Shift(-9).String()+" ETH")
+	line("pendingDeposits", d.PendingDepositsSumGwei.Shift(-9).String()+" ETH")
+	line("exitedDeposits", d.ExitedDepositsSumGwei.Shift(-9).String()+" ETH")
+	line("activationDeposits", d.ActivationDepositsSumGwei.Shift(-9).String()+" ETH")
+	line("topUpDeposits", d.TopUpDepositsSumGwei.Shift(-9).String()+" ETH")
+	line("withdrawals", d.WithdrawalsSumGwei.Shift(-9).String()+" ETH")
+	line("withdrawalRequests", d.WithdrawalRequestsSumGwei.Shift(-9).String()+" ETH")
+	line("consensusRewards", d.ConsensusRewardsEth().String()+" ETH")
+	line("proposerConsensusRewards", d.ProposerConsensusRewardsGwei.Shift(-9).String()+" ETH")
+	line("txFees", d.TxFeesEth().String()+" ETH")
+	line("totalTxCount", d.TotalTxCount)
+	line("avgGasUtilization", d.AvgGasUtilization.String()+" ("+d.PayloadBlocks.String()+" blocks)")
+	line("mevRewards", d.MevRewardsWei.Shift(-18).String()+" ETH")
+	line("totalRewards", d.TotalRewardsEth().String()+" ETH")
+	line("avgRewardsPerValidator", d.AvgRewardsPerValidatorEth.String()+" ETH")
+	if !d.TotalRewardsFiat.IsZero() {
+		line("totalRewardsFiat", d.TotalRewardsFiat)
+	}
+	line("activatedValidators", d.ActivatedValidators)
+	line("exitedValidators", d.ExitedValidators)
+	if !d.YoungValidators.IsZero() {
+		line("youngValidators", d.YoungValidators)
+	}
+	line("syncCommitteeDuties", d.SyncCommitteeDuties)
+	line("syncParticipationRate", d.SyncParticipationRate)
+	line("proposedBlocks", d.ProposedBlocks)
+	line("missedSlots", d.MissedSlots)
+	line("missedAttestations", d.MissedAttestations)
+	line("avgInclusionDistance", d.AvgInclusionDistance.String()+" ("+d.IncludedAttestations.String()+" attestations)")
+	line("incompletePayloadSlots", d.IncompletePayloadSlots)
+	line("blindedBlockSlots", d.BlindedBlockSlots)
+	line("skippedDeposits", d.SkippedDeposits)
+	line("performanceScore", d.PerformanceScore)
+	line("possiblyReorged", d.PossiblyReorged)
+	line("duringNonFinality", d.DuringNonFinality)
+	if d.WithoutBlocks {
+		line("withoutBlocks", d.WithoutBlocks)
+	}
+	if d.QualityScore != nil {
+		line("qualityScore", d.QualityScore)
+	}
+	if d.ActiveFraction != nil {
+		line("activeFraction", d.ActiveFraction)
+	}
+	for _, w := range d.Warnings {
+		line("warning", w)
+	}
+	if d.WithdrawalAddress != nil {
+		line("withdrawalAddress", d.WithdrawalAddress.Hex())
+	}
+	if d.CredentialType != nil {
+		line("credentialType", fmt.Sprintf("%#02x", *d.CredentialType))
+	}
+	if len(d.BalanceEvents) > 0 {
+		line("balanceEvents", len(d.BalanceEvents))
+	}
+	for _, version := range []string{"phase0", "altair", "bellatrix", "capella"} {
+		if n, exists := d.BlockVersions[version]; exists {
+			line("blocks "+version, n)
+		}
+	}
+	for _, e := range d.EpochBreakdown {
+		line(fmt.Sprintf("apr of epoch %v", e.Epoch), e.Apr)
+	}
+	setNames := make([]string, 0, len(d.ValidatorSets))
+	for name := range d.ValidatorSets {
+		setNames = append(setNames, name)
+	}
+	sort.Strings(setNames)
+	for _, name := range setNames {
+		setDay := d.ValidatorSets[name]
+		value := fmt.Sprintf("apr %v, %v validators, %v ETH rewards", setDay.Apr, setDay.Validators, setDay.TotalRewardsEth())
+		if meta, exists := d.ValidatorSetMeta[name]; exists {
+			if len(meta.Missing) > 0 {
+				value += fmt.Sprintf(" (%v of %v requested validators not accounted)", len(meta.Missing), meta.Requested)
+			}
+			if meta.Duplicates > 0 {
+				value += fmt.Sprintf(" (%v duplicate indices)", meta.Duplicates)
+			}
+		}
+		line("set "+name, value)
+	}
+	for _, phase := range []string{"spec", "validators", "blocks", "attestations", "epochBreakdown", "reorgCheck", "finalityCheck", "aggregation", "total"} {
+		if t, exists := d.Timings[phase]; exists {
+			line("timing "+phase, t)
+		}
+	}
+	return b.String()
+}
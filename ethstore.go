@@ -2,10 +2,16 @@ package ethstore
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"math/big"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,6 +19,7 @@ import (
 	v1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
 	"github.com/attestantio/go-eth2-client/spec/capella"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -21,36 +28,190 @@ import (
 	gethTypes "github.com/ethereum/go-ethereum/core/types"
 	gethRPC "github.com/ethereum/go-ethereum/rpc"
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/prysmaticlabs/go-bitfield"
 	"github.com/prysmaticlabs/prysm/v3/beacon-chain/core/signing"
 	"github.com/prysmaticlabs/prysm/v3/contracts/deposit"
 	ethpb "github.com/prysmaticlabs/prysm/v3/proto/prysm/v1alpha1"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
 )
 
+// MethodologyVersion is the version of the methodology of Calculate, which is stored in Day.MethodologyVersion. It
+// changes whenever a change of the calculation changes the rewards, the fees or the aprs of days that were calculated
+// before, e.g. a fix of the fee accounting, so that stored days of an older methodology can be detected and
+// recalculated by CalculateRangeFunc when it loads them (Backfill does not, it only calculates days after the latest
+// stored one). Changes that only add fields to the days do not change it.
+const MethodologyVersion = "1"
+
 var debugLevel = uint64(0)
+var validatorsChunkSize = uint64(0)
+var maxIndicesPerRequest = uint64(0)
 var execTimeout = time.Second * 120
 var execTimeoutMu = sync.Mutex{}
 var consTimeout = time.Second * 120
 var consTimeoutMu = sync.Mutex{}
 var validatorsCache *lru.Cache
 var validatorsCacheMu = sync.Mutex{}
+var requestsLimiter *rate.Limiter
+var requestsLimiterMu = sync.Mutex{}
 
 type Day struct {
-	Day                  decimal.Decimal `json:"day"`
-	DayTime              time.Time       `json:"dayTime"`
-	Apr                  decimal.Decimal `json:"apr"`
-	Validators           decimal.Decimal `json:"validators"`
-	StartEpoch           decimal.Decimal `json:"startEpoch"`
-	EffectiveBalanceGwei decimal.Decimal `json:"effectiveBalanceGwei"`
-	StartBalanceGwei     decimal.Decimal `json:"startBalanceGwei"`
-	EndBalanceGwei       decimal.Decimal `json:"endBalanceGwei"`
-	DepositsSumGwei      decimal.Decimal `json:"depositsSumGwei"`
-	WithdrawalsSumGwei   decimal.Decimal `json:"withdrawalsSumGwei"`
-	ConsensusRewardsGwei decimal.Decimal `json:"consensusRewardsGwei"`
-	TxFeesSumWei         decimal.Decimal `json:"txFeesSumWei"`
-	TotalRewardsWei      decimal.Decimal `json:"totalRewardsWei"`
+	Day                    decimal.Decimal          `json:"day"`
+	DayTime                time.Time                `json:"dayTime"`
+	Apr                    decimal.Decimal          `json:"apr"`
+	Apy                    decimal.Decimal          `json:"apy"`
+	Validators             decimal.Decimal          `json:"validators"`
+	StartEpoch             decimal.Decimal          `json:"startEpoch"`
+	EffectiveBalanceGwei   decimal.Decimal          `json:"effectiveBalanceGwei"`
+	StartBalanceGwei       decimal.Decimal          `json:"startBalanceGwei"`
+	EndBalanceGwei         decimal.Decimal          `json:"endBalanceGwei"`
+	DepositsSumGwei        decimal.Decimal          `json:"depositsSumGwei"`
+	WithdrawalsSumGwei     decimal.Decimal          `json:"withdrawalsSumGwei"`
+	ConsensusRewardsGwei   decimal.Decimal          `json:"consensusRewardsGwei"`
+	TxFeesSumWei           decimal.Decimal          `json:"txFeesSumWei"`
+	MevRewardsWei          decimal.Decimal          `json:"mevRewardsWei"`
+	TotalRewardsWei        decimal.Decimal          `json:"totalRewardsWei"`
+	SyncCommitteeDuties    decimal.Decimal          `json:"syncCommitteeDuties"`
+	SyncParticipationRate  decimal.Decimal          `json:"syncParticipationRate"`
+	PossiblyReorged        bool                     `json:"possiblyReorged"`
+	ProposedBlocks         decimal.Decimal          `json:"proposedBlocks"`
+	MissedSlots            decimal.Decimal          `json:"missedSlots"`
+	PerformanceScore       decimal.Decimal          `json:"performanceScore"`
+	SkippedDeposits        decimal.Decimal          `json:"skippedDeposits"`
+	Timings                map[string]time.Duration `json:"timings,omitempty"`
+	IncompletePayloadSlots decimal.Decimal          `json:"incompletePayloadSlots"`
+	PendingDepositsSumGwei decimal.Decimal          `json:"pendingDepositsSumGwei"`
+	BalanceEvents          []BalanceEvent           `json:"balanceEvents,omitempty"`
+	WithdrawalAddress      *common.Address          `json:"withdrawalAddress,omitempty"`
+	DuringNonFinality      bool                     `json:"duringNonFinality"`
+	InactivityLeakEpochs   decimal.Decimal          `json:"inactivityLeakEpochs"`
+	EpochBreakdown         []EpochApr               `json:"epochBreakdown,omitempty"`
+	TotalRewardsFiat       decimal.Decimal          `json:"totalRewardsFiat"`
+	// AvgRewardsPerValidatorEth is TotalRewardsWei in ETH divided by Validators, for the days of the single
+	// validators it is the total rewards of the validator.
+	AvgRewardsPerValidatorEth decimal.Decimal `json:"avgRewardsPerValidatorEth"`
+	// ExitedDepositsSumGwei are the deposits to validators that were active at the start of the day and exited during
+	// it, which are not accounted (like their balances).
+	ExitedDepositsSumGwei decimal.Decimal `json:"exitedDepositsSumGwei"`
+	// AprStdDev is the population standard deviation of the aprs of the single validators, for the day of all
+	// validators it is only calculated with the validator days (see WithoutValidatorDays). The days of the validator
+	// sets have the deviation of the validators of the set, the validator days have none.
+	AprStdDev decimal.Decimal `json:"aprStdDev"`
+	// MissedAttestations are the attestation duties of the day whose attestation was not included in a block, it is
+	// only calculated with WithMissedAttestations.
+	MissedAttestations decimal.Decimal `json:"missedAttestations"`
+	// Ledger are the balances and rewards of every accounted validator sorted by validator index, only with
+	// WithLedger.
+	Ledger []LedgerEntry `json:"ledger,omitempty"`
+	// ActivatedValidators and ExitedValidators are the validators of the network that became active respectively
+	// stopped being active during the day, comparing the states at the start and the end of the day. Neither are
+	// accounted in Validators.
+	ActivatedValidators decimal.Decimal `json:"activatedValidators"`
+	ExitedValidators    decimal.Decimal `json:"exitedValidators"`
+	// RewardsByClient are the execution rewards (tx fees and mev rewards) of the blocks of the accounted validators in
+	// Wei by the client that WithClientClassifier inferred from the graffiti of the block.
+	RewardsByClient map[string]decimal.Decimal `json:"rewardsByClient,omitempty"`
+	// ActivationDepositsSumGwei are the deposits of the day to validators that did not exist at the start of the day,
+	// the initial stake of new validators. TopUpDepositsSumGwei are the deposits to validators that existed at the
+	// start of the day (accounted, pending or exited). Together they are DepositsSumGwei + PendingDepositsSumGwei +
+	// ExitedDepositsSumGwei. Only top-ups are accounted in the consensus rewards, since new validators are not active
+	// the whole day.
+	ActivationDepositsSumGwei decimal.Decimal `json:"activationDepositsSumGwei"`
+	TopUpDepositsSumGwei      decimal.Decimal `json:"topUpDepositsSumGwei"`
+	// TotalTxCount are the transactions in the execution payloads of the blocks of the accounted validators, of the
+	// validator in the validator days.
+	TotalTxCount decimal.Decimal `json:"totalTxCount"`
+	// PeriodSeconds is the duration of the slots of the day, (endSlot - firstSlot) * SECONDS_PER_SLOT. The apr is
+	// annualized with secondsPerYear / PeriodSeconds if WithExactAnnualization is set.
+	PeriodSeconds decimal.Decimal `json:"periodSeconds"`
+	// CredentialType is the prefix of the withdrawal credentials of the validator at the end of the day (0x00 BLS,
+	// 0x01 execution address, 0x02 compounding), only set in the validator days.
+	CredentialType *uint8 `json:"credentialType,omitempty"`
+	// WithoutBlocks is set if the day was calculated without its blocks, see WithoutBlocks.
+	WithoutBlocks bool `json:"withoutBlocks,omitempty"`
+	// AprTrimmedMean is the mean of the aprs of the single validators weighted by their apr basis, without the
+	// validators with the highest and lowest aprs (1% each, see WithAprTrimFraction). Like AprStdDev it is calculated
+	// for the day of all validators (only with the validator days) and for the validator sets, not for the validator
+	// days.
+	AprTrimmedMean decimal.Decimal `json:"aprTrimmedMean"`
+	// BlindedBlockSlots is the number of blocks of the day that the node served blinded. Their fees and withdrawals
+	// are not counted (the withdrawals are accounted as penalty), their deposits are. They are included in
+	// IncompletePayloadSlots.
+	BlindedBlockSlots decimal.Decimal `json:"blindedBlockSlots"`
+	// WithdrawalsByAddress is WithdrawalsSumGwei by the address the withdrawals were sent to, for reconciling payouts
+	// with the transfers on-chain. It is only set for the network day, validator days have their WithdrawalAddress.
+	WithdrawalsByAddress map[common.Address]phase0.Gwei `json:"withdrawalsByAddress,omitempty"`
+	// ConsensusApr is the part of Apr that is earned with consensus rewards (ConsensusRewardsGwei), ExecutionApr the
+	// part that is earned with execution rewards (TxFeesSumWei and MevRewardsWei). They add up to Apr.
+	ConsensusApr decimal.Decimal `json:"consensusApr"`
+	ExecutionApr decimal.Decimal `json:"executionApr"`
+	// ProposerConsensusRewardsGwei is the part of ConsensusRewardsGwei that was paid for proposing blocks, see
+	// WithProposerRewards.
+	ProposerConsensusRewardsGwei decimal.Decimal `json:"proposerConsensusRewardsGwei"`
+	// StartStateRoot and EndStateRoot are the roots of the states the start and end balances were read from, they are
+	// only set with WithStateRootVerification.
+	StartStateRoot *phase0.Root `json:"startStateRoot,omitempty"`
+	EndStateRoot   *phase0.Root `json:"endStateRoot,omitempty"`
+	// AvgGasUtilization is the mean of gasUsed / gasLimit of the execution payloads of the blocks of the accounted
+	// validators (of the validator in the validator days), PayloadBlocks is the number of these blocks. Blocks without
+	// (complete) execution payload are not included.
+	AvgGasUtilization decimal.Decimal `json:"avgGasUtilization"`
+	PayloadBlocks     decimal.Decimal `json:"payloadBlocks"`
+	// AvgInclusionDistance is the mean inclusion distance of the IncludedAttestations attestations of the accounted
+	// validators (of the validator in the validator days), they are only set with WithInclusionDistance.
+	AvgInclusionDistance decimal.Decimal `json:"avgInclusionDistance"`
+	IncludedAttestations decimal.Decimal `json:"includedAttestations"`
+	// BlockVersions is the number of blocks of the day by their fork version (phase0, altair, bellatrix or capella),
+	// which shows the mix of a day at a fork boundary. It is only set on the day of all validators and nil with
+	// WithoutBlocks.
+	BlockVersions map[string]uint64 `json:"blockVersions,omitempty"`
+	// YoungValidators are the validators that were active the whole day but are not accounted in Validators since
+	// they were activated less than WithMinActivationAge epochs before the day.
+	YoungValidators decimal.Decimal `json:"youngValidators"`
+	// ComputedAt is the time the day was calculated at and MethodologyVersion the MethodologyVersion it was calculated
+	// with, both are empty for days calculated before they were introduced.
+	ComputedAt         time.Time `json:"computedAt"`
+	MethodologyVersion string    `json:"methodologyVersion,omitempty"`
+	// QualityScore is 1 for a complete day and lower for days with gaps or approximations, which Warnings describe,
+	// only with WithQualityScore and only of the day of all validators.
+	QualityScore *decimal.Decimal `json:"qualityScore,omitempty"`
+	Warnings     []string         `json:"warnings,omitempty"`
+	// ValidatorSets are the days of the validator sets of WithValidatorSets by name and ValidatorSetMeta reports which
+	// of their validators are accounted, both are only set on the day of all validators.
+	ValidatorSets    map[string]*Day             `json:"validatorSets,omitempty"`
+	ValidatorSetMeta map[string]ValidatorSetMeta `json:"validatorSetMeta,omitempty"`
+	// AprExMev is Apr without MevRewardsWei, the apr of the consensus rewards and the tx fees of the blocks only. MEV
+	// are the builder payments that WithMevLastTxAttribution attributes, without it AprExMev is Apr.
+	AprExMev decimal.Decimal `json:"aprExMev"`
+	// WithdrawalRequestsSumGwei are the amounts of the EIP-7002 partial withdrawal requests for the accounted
+	// validators that were included in the blocks of the day, only with WithWithdrawalRequests.
+	WithdrawalRequestsSumGwei decimal.Decimal `json:"withdrawalRequestsSumGwei"`
+	// ActiveFraction is only set on the validator days of validators that were activated during the day, see
+	// WithActiveFractionNormalization.
+	ActiveFraction *decimal.Decimal `json:"activeFraction,omitempty"`
+}
+
+// EpochApr is the apr of the rewards of a single epoch of a day, see WithEpochBreakdown.
+type EpochApr struct {
+	Epoch uint64          `json:"epoch"`
+	Apr   decimal.Decimal `json:"apr"`
+}
+
+type BalanceEventType string
+
+const (
+	BalanceEventDeposit    BalanceEventType = "deposit"
+	BalanceEventWithdrawal BalanceEventType = "withdrawal"
+)
+
+// BalanceEvent is a deposit or withdrawal of a validator, see WithBalanceEvents.
+type BalanceEvent struct {
+	Slot           uint64                `json:"slot"`
+	ValidatorIndex phase0.ValidatorIndex `json:"validatorIndex"`
+	Type           BalanceEventType      `json:"type"`
+	AmountGwei     phase0.Gwei           `json:"amountGwei"`
 }
 
 type Validator struct {
@@ -62,6 +223,57 @@ type Validator struct {
 	DepositsSumGwei      phase0.Gwei
 	WithdrawalsSumGwei   phase0.Gwei
 	TxFeesSumWei         *big.Int
+	// MevRewardsWei are the builder payments attributed with WithMevLastTxAttribution, nil if there are none.
+	MevRewardsWei *big.Int
+	// SyncCommitteeDuties and SyncCommitteeParticipations are counted per committee position
+	// in every block of the day, a validator can hold multiple positions in one committee.
+	SyncCommitteeDuties         uint64
+	SyncCommitteeParticipations uint64
+	SyncParticipationRate       decimal.Decimal
+	// PerformanceScore is the reward per effective balance of the validator divided by the mean of all
+	// validators of the day, 1 is average and values below 1 flag underperforming validators.
+	PerformanceScore decimal.Decimal
+	SkippedDeposits  uint64
+	// WithdrawalAddress is the execution address of 0x01 and 0x02 withdrawal credentials at the end of the day,
+	// it is the zero address for 0x00 (BLS) credentials.
+	WithdrawalAddress common.Address
+	// MissedAttestations are the epochs of the day in which no attestation of the validator was included, see
+	// WithMissedAttestations.
+	MissedAttestations uint64
+	// ProposedTxCount are the transactions in the execution payloads of the blocks the validator proposed during the day.
+	ProposedTxCount uint64
+	// CredentialType is the prefix of the withdrawal credentials at the end of the day, IsCompounding is set for 0x02
+	// (compounding) credentials. The balances of compounding validators are not swept by partial withdrawals below
+	// the max effective balance of 2048 ETH, so their rewards stay in the balance and raise the effective balance. The
+	// consensus rewards (end - start - deposits + withdrawals) are reconstructed the same way, but without sweeps
+	// more of them show up as balance delta and, with a growing effective balance, compound in the apr of later days.
+	CredentialType uint8
+	IsCompounding  bool
+	// ProposerConsensusRewardsGwei are the consensus rewards of the blocks the validator proposed during the day, see
+	// WithProposerRewards. They are part of the consensus rewards of the balance delta.
+	ProposerConsensusRewardsGwei phase0.Gwei
+	// GasUtilizationSum is the sum of gasUsed / gasLimit of the execution payloads of the PayloadBlocks blocks the
+	// validator proposed during the day, AvgGasUtilization is their mean.
+	GasUtilizationSum decimal.Decimal
+	PayloadBlocks     uint64
+	AvgGasUtilization decimal.Decimal
+	// InclusionDistanceSum is the sum of the inclusion distances of the IncludedAttestations attestations of the
+	// validator that were included during the day, AvgInclusionDistance is their mean, see WithInclusionDistance.
+	InclusionDistanceSum uint64
+	IncludedAttestations uint64
+	AvgInclusionDistance decimal.Decimal
+	// WithdrawalRequestsSumGwei are the amounts of the EIP-7002 partial withdrawal requests for the validator that were
+	// included during the day, see WithWithdrawalRequests.
+	WithdrawalRequestsSumGwei phase0.Gwei
+}
+
+// Storage persists calculated days, see package sqlstorage for an implementation based on database/sql.
+type Storage interface {
+	SaveDay(ctx context.Context, day *Day) error
+	// LoadDay returns nil without an error if the day is not stored.
+	LoadDay(ctx context.Context, day uint64) (*Day, error)
+	// LatestDay returns the highest stored day, found is false if no day is stored.
+	LatestDay(ctx context.Context) (day uint64, found bool, err error)
 }
 
 func SetDebugLevel(lvl uint64) {
@@ -72,6 +284,57 @@ func GetDebugLevel() uint64 {
 	return atomic.LoadUint64(&debugLevel)
 }
 
+// SetValidatorsChunkSize sets the number of validators that are requested at once from the consensus-node-api.
+// Nodes that struggle with returning the whole validator-set in one response can be queried in chunks of
+// validator indices instead. A size of 0 (default) fetches all validators with a single request.
+func SetValidatorsChunkSize(size uint64) {
+	atomic.StoreUint64(&validatorsChunkSize, size)
+}
+
+func GetValidatorsChunkSize() uint64 {
+	return atomic.LoadUint64(&validatorsChunkSize)
+}
+
+// SetMaxIndicesPerRequest sets the number of validator indices that are sent in a single request to the
+// consensus-node-api, e.g. for the chunks of SetValidatorsChunkSize. The beacon-api queries validators by index with
+// GET and the indices in the url, so a large chunk is split into requests of at most n indices to stay below the url
+// limits of nodes and proxies ("414 URI Too Long"). A value of 0 (default) uses the limit of go-eth2-client, which
+// is 1000 indices. It applies to the clients created after the call.
+func SetMaxIndicesPerRequest(n uint64) {
+	atomic.StoreUint64(&maxIndicesPerRequest, n)
+}
+
+func GetMaxIndicesPerRequest() uint64 {
+	return atomic.LoadUint64(&maxIndicesPerRequest)
+}
+
+// SetRequestsPerSecond limits the requests to the consensus-node-api to n per second, to stay within the quota
+// of hosted providers. Requests wait for the limit respecting the deadline of their context.
+// A value of 0 (default) disables the limit.
+func SetRequestsPerSecond(n float64) {
+	requestsLimiterMu.Lock()
+	defer requestsLimiterMu.Unlock()
+	if n <= 0 {
+		requestsLimiter = nil
+		return
+	}
+	burst := int(n)
+	if burst < 1 {
+		burst = 1
+	}
+	requestsLimiter = rate.NewLimiter(rate.Limit(n), burst)
+}
+
+func waitForRequest(ctx context.Context) error {
+	requestsLimiterMu.Lock()
+	l := requestsLimiter
+	requestsLimiterMu.Unlock()
+	if l == nil {
+		return nil
+	}
+	return l.Wait(ctx)
+}
+
 func SetConsTimeout(dur time.Duration) {
 	consTimeoutMu.Lock()
 	defer consTimeoutMu.Unlock()
@@ -96,56 +359,165 @@ func GetExecTimeout() time.Duration {
 	return execTimeout
 }
 
-func GetFinalizedDay(ctx context.Context, address string) (uint64, error) {
-	service, err := http.New(ctx, http.WithAddress(address), http.WithTimeout(GetConsTimeout()), http.WithLogLevel(zerolog.WarnLevel))
+// ErrInvalidBeaconAddress is returned if the address of the consensus-node-api is not a http or https url.
+var ErrInvalidBeaconAddress = errors.New("invalid beacon node address")
+
+// SlotError is returned if the block of a slot can not be fetched or processed, errors.As extracts the slot.
+type SlotError struct {
+	Slot uint64
+	Err  error
+}
+
+func (e *SlotError) Error() string {
+	return fmt.Sprintf("error at slot %v: %v", e.Slot, e.Err)
+}
+
+func (e *SlotError) Unwrap() error {
+	return e.Err
+}
+
+// ErrNoValidators is returned if a state has no validators (e.g. for a wrong state id or a network without
+// validators) or no validator was active the whole day, the apr of such a day would be undefined.
+var ErrNoValidators = errors.New("no validators")
+
+// ErrDayInFuture is returned if the requested day is not complete at the finalized checkpoint, the message includes
+// the last day that can be calculated. It is returned right after the finalized header is requested.
+var ErrDayInFuture = errors.New("requested day is in the future")
+
+// normalizeBeaconAddress validates the address of the consensus-node-api and trims trailing slashes,
+// which would otherwise end up in the paths of the requests.
+func normalizeBeaconAddress(address string) (string, error) {
+	address = strings.TrimRight(strings.TrimSpace(address), "/")
+	if address == "" {
+		return "", fmt.Errorf("%w: address is empty", ErrInvalidBeaconAddress)
+	}
+	u, err := url.Parse(address)
 	if err != nil {
-		return 0, err
+		return "", fmt.Errorf("%w %q: %v", ErrInvalidBeaconAddress, address, err)
 	}
-	client := service.(*http.Service)
-	apiSpec, err := client.Spec(ctx)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w %q: scheme must be http or https", ErrInvalidBeaconAddress, address)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("%w %q: missing host", ErrInvalidBeaconAddress, address)
+	}
+	return address, nil
+}
+
+// requestIDHeader is the header of the request id of WithRequestID.
+const requestIDHeader = "X-Request-ID"
+
+// beaconHeaders returns the extra headers of the requests to the beacon nodes with the options o, or nil if there are
+// none. getBeaconJSON sends them as well, see withBeaconHeaders.
+func beaconHeaders(o *options) map[string]string {
+	if o == nil || o.requestID == "" {
+		return nil
+	}
+	return map[string]string{requestIDHeader: o.requestID}
+}
+
+// newConsClient returns the client of the consensus-node-api at address, o may be nil for the defaults.
+func newConsClient(ctx context.Context, address string, o *options) (*http.Service, error) {
+	address, err := normalizeBeaconAddress(address)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	params := []http.Parameter{http.WithAddress(address), http.WithTimeout(GetConsTimeout()), http.WithLogLevel(zerolog.WarnLevel)}
+	if headers := beaconHeaders(o); headers != nil {
+		params = append(params, http.WithExtraHeaders(headers))
 	}
+	if n := GetMaxIndicesPerRequest(); n != 0 {
+		params = append(params, http.WithIndexChunkSize(int(n)))
+	}
+	service, err := http.New(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	return service.(*http.Service), nil
+}
+
+// newStateClient returns the client for historical states: the archive node of WithArchiveNode, after checking that it
+// is on the same network as client, or client itself.
+func newStateClient(ctx context.Context, client *http.Service, o *options) (*http.Service, error) {
+	if o.archiveNode == "" {
+		return client, nil
+	}
+	archiveClient, err := newConsClient(ctx, o.archiveNode, o)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to archive node: %w", err)
+	}
+	if err := waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+	genesis, err := client.Genesis(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting genesis: %w", err)
+	}
+	if err := waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+	archiveGenesis, err := archiveClient.Genesis(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting genesis of archive node: %w", err)
+	}
+	if archiveGenesis.GenesisValidatorsRoot != genesis.GenesisValidatorsRoot {
+		return nil, fmt.Errorf("archive node is on another network: genesis validators root %#x != %#x", archiveGenesis.GenesisValidatorsRoot, genesis.GenesisValidatorsRoot)
+	}
+	return archiveClient, nil
+}
+
+// specSlotsPerDay returns the slots of a calendar day with SECONDS_PER_SLOT of apiSpec.
+func specSlotsPerDay(apiSpec map[string]interface{}) (uint64, error) {
 	secondsPerSlotIf, exists := apiSpec["SECONDS_PER_SLOT"]
 	if !exists {
 		return 0, fmt.Errorf("undefined SECONDS_PER_SLOT in spec")
 	}
-	secondsPerSlotDur, ok := secondsPerSlotIf.(time.Duration)
-	if !ok {
-		return 0, fmt.Errorf("invalid format of SECONDS_PER_SLOT in spec")
+	secondsPerSlot, err := parseSecondsPerSlot(secondsPerSlotIf)
+	if err != nil {
+		return 0, fmt.Errorf("invalid format of SECONDS_PER_SLOT in spec: %w", err)
+	}
+	return 3600 * 24 / secondsPerSlot, nil
+}
+
+func GetFinalizedDay(ctx context.Context, address string) (uint64, error) {
+	client, err := newConsClient(ctx, address, nil)
+	if err != nil {
+		return 0, err
+	}
+	apiSpec, err := client.Spec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	slotsPerDay, err := specSlotsPerDay(apiSpec)
+	if err != nil {
+		return 0, err
 	}
-	secondsPerSlot := uint64(secondsPerSlotDur.Seconds())
-	slotsPerDay := 3600 * 24 / secondsPerSlot
 
 	h, err := client.BeaconBlockHeader(ctx, "finalized")
 	if err != nil {
 		return 0, err
 	}
 
+	if uint64(h.Header.Message.Slot) < slotsPerDay {
+		return 0, fmt.Errorf("no complete day at finalized (slot %v)", h.Header.Message.Slot)
+	}
 	day := uint64(h.Header.Message.Slot)/slotsPerDay - 1
 	return day, nil
 }
 
 func GetHeadDay(ctx context.Context, address string) (uint64, error) {
-	service, err := http.New(ctx, http.WithAddress(address), http.WithTimeout(GetConsTimeout()), http.WithLogLevel(zerolog.WarnLevel))
+	client, err := newConsClient(ctx, address, nil)
 	if err != nil {
 		return 0, err
 	}
-	client := service.(*http.Service)
 	apiSpec, err := client.Spec(ctx)
 	if err != nil {
 		return 0, err
 	}
-	secondsPerSlotIf, exists := apiSpec["SECONDS_PER_SLOT"]
-	if !exists {
-		return 0, fmt.Errorf("undefined SECONDS_PER_SLOT in spec")
-	}
-	secondsPerSlotDur, ok := secondsPerSlotIf.(time.Duration)
-	if !ok {
-		return 0, fmt.Errorf("invalid format of SECONDS_PER_SLOT in spec")
+	slotsPerDay, err := specSlotsPerDay(apiSpec)
+	if err != nil {
+		return 0, err
 	}
-	secondsPerSlot := uint64(secondsPerSlotDur.Seconds())
-	slotsPerDay := 3600 * 24 / secondsPerSlot
 
 	h, err := client.BeaconBlockHeader(ctx, "finalized")
 	if err != nil {
@@ -156,24 +528,34 @@ func GetHeadDay(ctx context.Context, address string) (uint64, error) {
 	return day, nil
 }
 
+// IsBlockID reports whether id is a named block id or a 0x-prefixed block root as understood by the beacon-api.
+// Slot numbers are not considered block ids here, since a plain number always denotes a day.
+func IsBlockID(id string) bool {
+	switch id {
+	case "head", "finalized", "justified", "genesis":
+		return true
+	}
+	if !strings.HasPrefix(id, "0x") || len(id) != 66 {
+		return false
+	}
+	_, err := hex.DecodeString(id[2:])
+	return err == nil
+}
+
 func GetValidators(ctx context.Context, client *http.Service, stateID string) (map[phase0.ValidatorIndex]*v1.Validator, error) {
 	validatorsCacheMu.Lock()
 	defer validatorsCacheMu.Unlock()
 
-	if validatorsCache == nil {
-		c, err := lru.New(2)
-		if err != nil {
-			return nil, err
-		}
-		validatorsCache = c
+	if err := initValidatorsCache(); err != nil {
+		return nil, err
 	}
 
-	key := fmt.Sprintf("%s:%s", client.Address(), stateID)
+	key := validatorsCacheKey(client, stateID)
 	val, found := validatorsCache.Get(key)
 	if found {
 		return val.(map[phase0.ValidatorIndex]*v1.Validator), nil
 	}
-	vals, err := client.Validators(ctx, stateID, nil)
+	vals, err := fetchValidators(ctx, client, stateID)
 	if err != nil {
 		return nil, fmt.Errorf("error getting validators for slot %v: %w", stateID, err)
 	}
@@ -181,6 +563,122 @@ func GetValidators(ctx context.Context, client *http.Service, stateID string) (m
 	return vals, nil
 }
 
+// initValidatorsCache creates the cache of GetValidators on first use, validatorsCacheMu must be held.
+func initValidatorsCache() error {
+	if validatorsCache != nil {
+		return nil
+	}
+	c, err := lru.New(2)
+	if err != nil {
+		return err
+	}
+	validatorsCache = c
+	return nil
+}
+
+func validatorsCacheKey(client *http.Service, stateID string) string {
+	return fmt.Sprintf("%s:%s", client.Address(), stateID)
+}
+
+// cacheValidators adds the validators vals of the state stateID to the cache of GetValidators.
+func cacheValidators(client *http.Service, stateID string, vals map[phase0.ValidatorIndex]*v1.Validator) error {
+	validatorsCacheMu.Lock()
+	defer validatorsCacheMu.Unlock()
+
+	if err := initValidatorsCache(); err != nil {
+		return err
+	}
+	validatorsCache.Add(validatorsCacheKey(client, stateID), vals)
+	return nil
+}
+
+func fetchValidators(ctx context.Context, client *http.Service, stateID string) (map[phase0.ValidatorIndex]*v1.Validator, error) {
+	chunkSize := GetValidatorsChunkSize()
+	if chunkSize != 0 {
+		return fetchValidatorChunks(ctx, client, stateID, map[phase0.ValidatorIndex]*v1.Validator{}, 0, chunkSize)
+	}
+	if err := waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+	vals, err := client.Validators(ctx, stateID, nil)
+	if err != nil {
+		return nil, err
+	}
+	// the beacon-api does not paginate the validators, but some nodes and proxies truncate large responses to a page
+	// without indicating it. Validator indices are contiguous, so a complete response holds the indices 0 to len-1
+	// and there is no validator with the index len. That is checked with an additional request if the response has
+	// as many validators as a page could have.
+	for index := range vals {
+		if uint64(index) >= uint64(len(vals)) {
+			return nil, fmt.Errorf("incomplete validators response: %v validators with indices up to %v, the node dropped validators", len(vals), index)
+		}
+	}
+	if !isPageLimit(uint64(len(vals))) {
+		return vals, nil
+	}
+	next := phase0.ValidatorIndex(len(vals))
+	if err := waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+	probe, err := client.Validators(ctx, stateID, []phase0.ValidatorIndex{next})
+	if err != nil {
+		return nil, fmt.Errorf("error checking for validators after index %v: %w", next, err)
+	}
+	if _, exists := probe[next]; !exists {
+		return vals, nil
+	}
+	if GetDebugLevel() > 0 {
+		log.Printf("DEBUG eth.store: node returned only the first %v validators of state %v, fetching the remaining validators in pages of %v", len(vals), stateID, len(vals))
+	}
+	return fetchValidatorChunks(ctx, client, stateID, vals, uint64(len(vals)), uint64(len(vals)))
+}
+
+// isPageLimit reports whether n validators could be a page of a node or proxy that truncates responses: pages are
+// powers of ten or of two of at least 10 items.
+func isPageLimit(n uint64) bool {
+	if n < 10 {
+		return false
+	}
+	if n&(n-1) == 0 {
+		return true
+	}
+	for n%10 == 0 {
+		n /= 10
+	}
+	return n == 1
+}
+
+// fetchValidatorChunks adds the validators from the index start on to vals, requesting chunkSize indices at a time.
+func fetchValidatorChunks(ctx context.Context, client *http.Service, stateID string, vals map[phase0.ValidatorIndex]*v1.Validator, start, chunkSize uint64) (map[phase0.ValidatorIndex]*v1.Validator, error) {
+	// validator indices are contiguous, so the validators end before the first requested index that is missing. The
+	// last index of a chunk is requested again as the first of the next chunk, so the next chunk is only requested
+	// once a validator of it is known to exist and no request comes back empty when the number of validators is a
+	// multiple of the chunk size. Chunks of a single index can only end with an empty response.
+	step := chunkSize - 1
+	if step == 0 {
+		step = 1
+	}
+	for ; ; start += step {
+		indices := make([]phase0.ValidatorIndex, chunkSize)
+		for i := range indices {
+			indices[i] = phase0.ValidatorIndex(start + uint64(i))
+		}
+		if err := waitForRequest(ctx); err != nil {
+			return nil, err
+		}
+		chunk, err := client.Validators(ctx, stateID, indices)
+		if err != nil {
+			return nil, fmt.Errorf("error getting validators %v-%v: %w", start, start+chunkSize-1, err)
+		}
+		for index, val := range chunk {
+			vals[index] = val
+		}
+		if _, exists := chunk[indices[len(indices)-1]]; !exists {
+			return vals, nil
+		}
+	}
+}
+
 type BlockData struct {
 	ProposerIndex phase0.ValidatorIndex
 	Transactions  []bellatrix.Transaction
@@ -190,6 +688,18 @@ type BlockData struct {
 	GasLimit      uint64
 	Withdrawals   []*capella.Withdrawal
 	BlockNumber   uint64
+	BlockHash     phase0.Hash32
+	FeeRecipient  bellatrix.ExecutionAddress
+	SyncAggregate *altair.SyncAggregate
+	Attestations  []*phase0.Attestation
+	Graffiti      [32]byte
+	// IncompletePayload is set for post-merge blocks that are served without (complete) execution payload, which
+	// happens for slots before the checkpoint of checkpoint synced nodes and for blinded blocks. It is not set for
+	// missed slots.
+	IncompletePayload bool
+	// Blinded is set for post-merge blocks that are served blinded, with the execution payload header instead of the
+	// execution payload. The client rejects blocks without execution payload, so a block without one is blinded.
+	Blinded bool
 }
 
 func GetBlockData(block *spec.VersionedSignedBeaconBlock) (*BlockData, error) {
@@ -198,382 +708,2946 @@ func GetBlockData(block *spec.VersionedSignedBeaconBlock) (*BlockData, error) {
 	case spec.DataVersionPhase0:
 		d.Deposits = block.Phase0.Message.Body.Deposits
 		d.ProposerIndex = block.Phase0.Message.ProposerIndex
+		d.Attestations = block.Phase0.Message.Body.Attestations
+		d.Graffiti = block.Phase0.Message.Body.Graffiti
 	case spec.DataVersionAltair:
 		d.Deposits = block.Altair.Message.Body.Deposits
 		d.ProposerIndex = block.Altair.Message.ProposerIndex
+		d.Attestations = block.Altair.Message.Body.Attestations
+		d.Graffiti = block.Altair.Message.Body.Graffiti
+		d.SyncAggregate = block.Altair.Message.Body.SyncAggregate
 	case spec.DataVersionBellatrix:
 		d.Deposits = block.Bellatrix.Message.Body.Deposits
 		d.ProposerIndex = block.Bellatrix.Message.ProposerIndex
+		d.Attestations = block.Bellatrix.Message.Body.Attestations
+		d.Graffiti = block.Bellatrix.Message.Body.Graffiti
+		d.SyncAggregate = block.Bellatrix.Message.Body.SyncAggregate
+		if block.Bellatrix.Message.Body.ExecutionPayload == nil {
+			d.IncompletePayload = true
+			d.Blinded = true
+			break
+		}
 		d.GasUsed = block.Bellatrix.Message.Body.ExecutionPayload.GasUsed
 		d.GasLimit = block.Bellatrix.Message.Body.ExecutionPayload.GasLimit
 		d.BaseFeePerGas = block.Bellatrix.Message.Body.ExecutionPayload.BaseFeePerGas
 		d.BlockNumber = block.Bellatrix.Message.Body.ExecutionPayload.BlockNumber
+		d.BlockHash = block.Bellatrix.Message.Body.ExecutionPayload.BlockHash
+		d.FeeRecipient = block.Bellatrix.Message.Body.ExecutionPayload.FeeRecipient
 		d.Transactions = block.Bellatrix.Message.Body.ExecutionPayload.Transactions
 	case spec.DataVersionCapella:
 		d.Deposits = block.Capella.Message.Body.Deposits
 		d.ProposerIndex = block.Capella.Message.ProposerIndex
+		d.Attestations = block.Capella.Message.Body.Attestations
+		d.Graffiti = block.Capella.Message.Body.Graffiti
+		d.SyncAggregate = block.Capella.Message.Body.SyncAggregate
+		if block.Capella.Message.Body.ExecutionPayload == nil {
+			d.IncompletePayload = true
+			d.Blinded = true
+			break
+		}
 		d.GasUsed = block.Capella.Message.Body.ExecutionPayload.GasUsed
 		d.GasLimit = block.Capella.Message.Body.ExecutionPayload.GasLimit
 		d.BaseFeePerGas = block.Capella.Message.Body.ExecutionPayload.BaseFeePerGas
 		d.Withdrawals = block.Capella.Message.Body.ExecutionPayload.Withdrawals
 		d.BlockNumber = block.Capella.Message.Body.ExecutionPayload.BlockNumber
+		d.BlockHash = block.Capella.Message.Body.ExecutionPayload.BlockHash
+		d.FeeRecipient = block.Capella.Message.Body.ExecutionPayload.FeeRecipient
 		d.Transactions = block.Capella.Message.Body.ExecutionPayload.Transactions
 	default:
 		return nil, fmt.Errorf("unknown block version: %v", block.Version)
 	}
+	if d.GasUsed > 0 && len(d.Transactions) == 0 {
+		// gas can only be used by transactions, so they have been stripped from the payload
+		d.IncompletePayload = true
+	}
 	return d, nil
 }
 
-func Calculate(ctx context.Context, bnAddress, elAddress, dayStr string, concurrency int) (*Day, map[uint64]*Day, error) {
-	gethRpcClient, err := gethRPC.Dial(elAddress)
-	if err != nil {
-		return nil, nil, err
+// blockSize returns the ssz size of the block, which approximates the memory of the decoded block. The ssz size of a
+// block without execution payload sets an empty payload, so it must only be called after GetBlockData.
+func blockSize(block *spec.VersionedSignedBeaconBlock) uint64 {
+	switch block.Version {
+	case spec.DataVersionPhase0:
+		return uint64(block.Phase0.SizeSSZ())
+	case spec.DataVersionAltair:
+		return uint64(block.Altair.SizeSSZ())
+	case spec.DataVersionBellatrix:
+		return uint64(block.Bellatrix.SizeSSZ())
+	case spec.DataVersionCapella:
+		return uint64(block.Capella.SizeSSZ())
 	}
+	return 0
+}
 
-	service, err := http.New(ctx, http.WithAddress(bnAddress), http.WithTimeout(GetConsTimeout()), http.WithLogLevel(zerolog.WarnLevel))
-	if err != nil {
-		return nil, nil, err
+// chainSpec holds the constants of the spec that are needed for calculating eth.store.
+type chainSpec struct {
+	GenesisForkVersion           phase0.Version
+	DomainDeposit                phase0.DomainType
+	SlotsPerEpoch                uint64
+	SecondsPerSlot               uint64
+	EpochsPerSyncCommitteePeriod uint64
+}
+
+var mainnetDomainDeposit = phase0.DomainType{0x03, 0x00, 0x00, 0x00}
+
+const mainnetEpochsPerSyncCommitteePeriod = 256
+
+func getChainSpec(ctx context.Context, client *http.Service, o *options) (*chainSpec, error) {
+	if err := waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+	if o.slotsPerEpoch != 0 && o.secondsPerSlot != 0 {
+		genesis, err := client.Genesis(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting genesis: %w", err)
+		}
+		preset := MainnetPreset
+		if o.preset != nil {
+			preset = *o.preset
+		}
+		return &chainSpec{
+			GenesisForkVersion:           genesis.GenesisForkVersion,
+			DomainDeposit:                preset.DomainDeposit,
+			SlotsPerEpoch:                o.slotsPerEpoch,
+			SecondsPerSlot:               o.secondsPerSlot,
+			EpochsPerSyncCommitteePeriod: preset.EpochsPerSyncCommitteePeriod,
+		}, nil
 	}
-	client := service.(*http.Service)
 
 	apiSpec, err := client.Spec(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	genesisForkVersionIf, exists := apiSpec["GENESIS_FORK_VERSION"]
 	if !exists {
-		return nil, nil, fmt.Errorf("undefined GENESIS_FORK_VERSION in spec")
+		return nil, fmt.Errorf("undefined GENESIS_FORK_VERSION in spec")
 	}
 	genesisForkVersion, ok := genesisForkVersionIf.(phase0.Version)
 	if !ok {
-		return nil, nil, fmt.Errorf("invalid format of GENESIS_FORK_VERSION in spec")
+		return nil, fmt.Errorf("invalid format of GENESIS_FORK_VERSION in spec")
 	}
 
 	domainDepositIf, exists := apiSpec["DOMAIN_DEPOSIT"]
 	if !exists {
-		return nil, nil, fmt.Errorf("undefined DOMAIN_DEPOSIT in spec")
+		return nil, fmt.Errorf("undefined DOMAIN_DEPOSIT in spec")
 	}
 	domainDeposit, ok := domainDepositIf.(phase0.DomainType)
 	if !ok {
-		return nil, nil, fmt.Errorf("invalid format of DOMAIN_DEPOSIT in spec")
+		return nil, fmt.Errorf("invalid format of DOMAIN_DEPOSIT in spec")
 	}
 
-	genesisValidatorsRoot := [32]byte{}
-	depositDomainComputed, err := signing.ComputeDomain(domainDeposit, genesisForkVersion[:], genesisValidatorsRoot[:])
-	if err != nil {
-		return nil, nil, err
+	slotsPerEpoch := o.slotsPerEpoch
+	if slotsPerEpoch == 0 {
+		slotsPerEpochIf, exists := apiSpec["SLOTS_PER_EPOCH"]
+		if !exists {
+			return nil, fmt.Errorf("undefined SLOTS_PER_EPOCH in spec")
+		}
+		slotsPerEpoch, ok = slotsPerEpochIf.(uint64)
+		if !ok {
+			return nil, fmt.Errorf("invalid format of SLOTS_PER_EPOCH in spec")
+		}
 	}
 
-	slotsPerEpochIf, exists := apiSpec["SLOTS_PER_EPOCH"]
-	if !exists {
-		return nil, nil, fmt.Errorf("undefined SLOTS_PER_EPOCH in spec")
-	}
-	slotsPerEpoch, ok := slotsPerEpochIf.(uint64)
-	if !ok {
-		return nil, nil, fmt.Errorf("invalid format of SLOTS_PER_EPOCH in spec")
+	secondsPerSlot := o.secondsPerSlot
+	if secondsPerSlot == 0 {
+		secondsPerSlotIf, exists := apiSpec["SECONDS_PER_SLOT"]
+		if !exists {
+			return nil, fmt.Errorf("undefined SECONDS_PER_SLOT in spec")
+		}
+		secondsPerSlot, err = parseSecondsPerSlot(secondsPerSlotIf)
+		if err != nil {
+			return nil, fmt.Errorf("invalid format of SECONDS_PER_SLOT in spec: %w", err)
+		}
 	}
 
-	secondsPerSlotIf, exists := apiSpec["SECONDS_PER_SLOT"]
+	epochsPerSyncCommitteePeriodIf, exists := apiSpec["EPOCHS_PER_SYNC_COMMITTEE_PERIOD"]
 	if !exists {
-		return nil, nil, fmt.Errorf("undefined SECONDS_PER_SLOT in spec")
+		return nil, fmt.Errorf("undefined EPOCHS_PER_SYNC_COMMITTEE_PERIOD in spec")
 	}
-	secondsPerSlotDur, ok := secondsPerSlotIf.(time.Duration)
+	epochsPerSyncCommitteePeriod, ok := epochsPerSyncCommitteePeriodIf.(uint64)
 	if !ok {
-		return nil, nil, fmt.Errorf("invalid format of SECONDS_PER_SLOT in spec")
+		return nil, fmt.Errorf("invalid format of EPOCHS_PER_SYNC_COMMITTEE_PERIOD in spec")
 	}
-	secondsPerSlot := uint64(secondsPerSlotDur.Seconds())
 
-	slotsPerDay := 3600 * 24 / secondsPerSlot
-
-	finalizedHeader, err := client.BeaconBlockHeader(ctx, "finalized")
-	if err != nil {
-		return nil, nil, err
-	}
-	finalizedSlot := uint64(finalizedHeader.Header.Message.Slot)
-	finalizedDay := finalizedSlot/slotsPerDay - 1
+	return &chainSpec{
+		GenesisForkVersion:           genesisForkVersion,
+		DomainDeposit:                domainDeposit,
+		SlotsPerEpoch:                slotsPerEpoch,
+		SecondsPerSlot:               secondsPerSlot,
+		EpochsPerSyncCommitteePeriod: epochsPerSyncCommitteePeriod,
+	}, nil
+}
 
-	var day uint64
-	if dayStr == "finalized" {
-		day = finalizedDay
-	} else if dayStr == "head" {
-		day = finalizedSlot / slotsPerDay
-	} else {
-		day, err = strconv.ParseUint(dayStr, 10, 64)
+// parseSecondsPerSlot returns SECONDS_PER_SLOT of the spec in seconds. go-eth2-client parses it as a time.Duration, but
+// other versions of the client return it as a plain uint64 of seconds or leave it as the string of the node.
+func parseSecondsPerSlot(v interface{}) (uint64, error) {
+	var seconds uint64
+	switch v := v.(type) {
+	case time.Duration:
+		if v < 0 || v%time.Second != 0 {
+			return 0, fmt.Errorf("%v is not a positive whole number of seconds", v)
+		}
+		seconds = uint64(v / time.Second)
+	case uint64:
+		seconds = v
+	case string:
+		var err error
+		seconds, err = strconv.ParseUint(v, 10, 64)
 		if err != nil {
-			return nil, nil, err
+			return 0, err
 		}
+	default:
+		return 0, fmt.Errorf("unexpected type %T", v)
 	}
-
-	if day > finalizedDay {
-		return nil, nil, fmt.Errorf("requested to calculate eth.store for a future day (last finalized day: %v, requested day: %v)", finalizedDay, day)
+	if seconds == 0 {
+		return 0, fmt.Errorf("must be positive")
 	}
+	return seconds, nil
+}
 
-	firstSlot := day * slotsPerDay
-	endSlot := (day + 1) * slotsPerDay // first slot not included in this eth.store-day
+// dayRange is the slot range of an eth.store-day, resolved against an anchor block.
+type dayRange struct {
+	Day          uint64
+	AnchorID     string
+	AnchorHeader *v1.BeaconBlockHeader
+	AnchorSlot   uint64
+	FirstSlot    uint64
+	EndSlot      uint64 // first slot not included in the day
+	// StateIDs are the ids of the states at FirstSlot and EndSlot given with WithStateRange, by slot
+	StateIDs map[uint64]string
+}
 
-	if endSlot > finalizedSlot {
-		endSlot = finalizedSlot
+// resolveRange resolves the day dayStr or the states of WithStateRange.
+func resolveRange(ctx context.Context, client *http.Service, dayStr string, slotsPerDay uint64, o *options) (*dayRange, error) {
+	if o.startStateID == "" {
+		return resolveDay(ctx, client, dayStr, slotsPerDay)
 	}
-	lastSlot := endSlot - 1
+	if dayStr != "" {
+		return nil, fmt.Errorf("invalid day %q: must be empty with WithStateRange", dayStr)
+	}
+	return resolveStateRange(ctx, client, o.startStateID, o.endStateID, slotsPerDay)
+}
 
-	firstEpoch := firstSlot / slotsPerEpoch
-	lastEpoch := lastSlot / slotsPerEpoch
-	endEpoch := lastEpoch + 1
+func resolveDay(ctx context.Context, client *http.Service, dayStr string, slotsPerDay uint64) (*dayRange, error) {
+	// the day is either given as a number or derived from the slot of a block id, a numbered day
+	// has to be complete at the finalized checkpoint
+	anchorID := "finalized"
+	day, err := strconv.ParseUint(dayStr, 10, 64)
+	isDayNumber := err == nil
+	if !isDayNumber {
+		if !IsBlockID(dayStr) {
+			return nil, fmt.Errorf("invalid day %q: must be a day number, one of head, finalized, justified, genesis or a 0x-prefixed block root", dayStr)
+		}
+		anchorID = dayStr
+	}
 
-	genesis, err := client.GenesisTime(ctx)
+	if err := waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+	anchorHeader, err := client.BeaconBlockHeader(ctx, anchorID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error getting genesisTime: %w", err)
+		return nil, fmt.Errorf("error getting header for block id %v: %w", anchorID, err)
 	}
-	startTime := time.Unix(genesis.Unix()+int64(firstSlot)*int64(secondsPerSlot), 0)
-	endTime := time.Unix(genesis.Unix()+int64(lastSlot)*int64(secondsPerSlot), 0)
-
-	if GetDebugLevel() > 0 {
-		log.Printf("DEBUG eth.store: calculating day %v (%v - %v, epochs: %v-%v, slots: %v-%v, genesis: %v, finalizedSlot: %v)\n", day, startTime, endTime, firstEpoch, lastEpoch, firstSlot, lastSlot, genesis, finalizedSlot)
+	if anchorHeader == nil {
+		return nil, fmt.Errorf("no header found for block id %v", anchorID)
 	}
+	anchorSlot := uint64(anchorHeader.Header.Message.Slot)
 
-	validatorsByIndex := map[phase0.ValidatorIndex]*Validator{}
-	validatorsByPubkey := map[phase0.BLSPubKey]*Validator{}
+	// a day is complete at the anchor if the first slot of the next day is at or before the anchor, since the state
+	// at that slot holds the end balances of the day. These are the first anchorSlot/slotsPerDay days, the last one
+	// ends exactly at the anchor if the anchor is the first slot of a day.
+	completeDays := anchorSlot / slotsPerDay
 
-	startValidators, err := GetValidators(ctx, client, fmt.Sprintf("%d", firstSlot))
-	if err != nil {
-		return nil, nil, fmt.Errorf("error getting startValidators for firstSlot %d: %w", firstSlot, err)
+	if dayStr == "head" {
+		// the current day is calculated up to the head, even though it is not complete yet
+		day = anchorSlot / slotsPerDay
+	} else {
+		if completeDays == 0 {
+			if isDayNumber {
+				return nil, fmt.Errorf("%w: no day is complete at %v (slot %v), requested day: %v", ErrDayInFuture, anchorID, anchorSlot, day)
+			}
+			return nil, fmt.Errorf("no complete day at %v (slot %v)", anchorID, anchorSlot)
+		}
+		anchorDay := completeDays - 1
+		if !isDayNumber {
+			day = anchorDay
+		}
+		if isDayNumber && day > anchorDay {
+			return nil, fmt.Errorf("%w (last %v day: %v, requested day: %v)", ErrDayInFuture, anchorID, anchorDay, day)
+		}
 	}
 
-	for _, val := range startValidators {
-		if !val.Status.IsActive() {
-			continue
-		}
-		vv := &Validator{
-			Index:                val.Index,
-			Pubkey:               val.Validator.PublicKey,
-			EffectiveBalanceGwei: val.Validator.EffectiveBalance,
-			StartBalanceGwei:     val.Balance,
-			TxFeesSumWei:         new(big.Int),
+	firstSlot, endSlot := daySlots(day, slotsPerDay)
+	if endSlot > anchorSlot {
+		if dayStr != "head" {
+			return nil, fmt.Errorf("day %v is not complete at %v (slot %v)", day, anchorID, anchorSlot)
 		}
-		validatorsByIndex[val.Index] = vv
-		validatorsByPubkey[val.Validator.PublicKey] = vv
+		endSlot = anchorSlot
 	}
 
-	endValidators, err := GetValidators(ctx, client, fmt.Sprintf("%d", endSlot))
+	return &dayRange{
+		Day:          day,
+		AnchorID:     anchorID,
+		AnchorHeader: anchorHeader,
+		AnchorSlot:   anchorSlot,
+		FirstSlot:    firstSlot,
+		EndSlot:      endSlot,
+	}, nil
+}
+
+// resolveStateRange resolves the slots between the states startStateID and endStateID, see WithStateRange. The range is
+// anchored at the block of endStateID if it is a block id and at the finalized checkpoint otherwise.
+func resolveStateRange(ctx context.Context, client *http.Service, startStateID, endStateID string, slotsPerDay uint64) (*dayRange, error) {
+	firstSlot, startState, _, err := resolveStateID(ctx, client, startStateID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error getting endValidators for endSlot %d: %w", endSlot, err)
+		return nil, err
+	}
+	endSlot, endState, anchorHeader, err := resolveStateID(ctx, client, endStateID)
+	if err != nil {
+		return nil, err
+	}
+	if endSlot <= firstSlot {
+		return nil, fmt.Errorf("invalid state range: end state %v (slot %v) is not after start state %v (slot %v)", endStateID, endSlot, startStateID, firstSlot)
 	}
 
-	for _, val := range endValidators {
-		v, exists := validatorsByIndex[val.Index]
-		if !exists {
-			continue
+	anchorID := endStateID
+	if anchorHeader == nil {
+		// the end state is given by slot, which is only unambiguous up to the finalized checkpoint
+		anchorID = "finalized"
+		if err := waitForRequest(ctx); err != nil {
+			return nil, err
 		}
-		if uint64(val.Validator.ExitEpoch) < endEpoch {
-			// do not account validators that have not been active until the end of the day
-			delete(validatorsByIndex, val.Index)
-			delete(validatorsByPubkey, val.Validator.PublicKey)
-			continue
+		anchorHeader, err = client.BeaconBlockHeader(ctx, anchorID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting header for block id %v: %w", anchorID, err)
+		}
+		if anchorHeader == nil {
+			return nil, fmt.Errorf("no header found for block id %v", anchorID)
+		}
+		if finalizedSlot := uint64(anchorHeader.Header.Message.Slot); endSlot > finalizedSlot {
+			return nil, fmt.Errorf("end state %v is after the finalized slot %v", endStateID, finalizedSlot)
 		}
-		// set endBalance of validator to the balance of the first epoch of the next day
-		v.EndBalanceGwei = val.Balance
-	}
-	if GetDebugLevel() > 0 {
-		log.Printf("DEBUG eth.store: startValidators: %v, endValidators: %v, ethstoreValidators: %v", len(startValidators), len(endValidators), len(validatorsByIndex))
 	}
 
-	g := new(errgroup.Group)
-	g.SetLimit(concurrency)
-	validatorsMu := sync.Mutex{}
+	return &dayRange{
+		Day:          firstSlot / slotsPerDay,
+		AnchorID:     anchorID,
+		AnchorHeader: anchorHeader,
+		AnchorSlot:   uint64(anchorHeader.Header.Message.Slot),
+		FirstSlot:    firstSlot,
+		EndSlot:      endSlot,
+		StateIDs:     map[uint64]string{firstSlot: startState, endSlot: endState},
+	}, nil
+}
 
-	// get all deposits and txs of all active validators in the slot interval [startSlot,endSlot)
-	for i := firstSlot; i < endSlot; i++ {
-		i := i
-		if GetDebugLevel() > 0 && (endSlot-i)%1000 == 0 {
-			log.Printf("DEBUG eth.store: checking blocks for deposits and txs: %.0f%% (%v of %v-%v)\n", 100*float64(i-firstSlot)/float64(endSlot-firstSlot), i, firstSlot, endSlot)
-		}
+// resolveStateID returns the slot of the state id of WithStateRange and the id to request the state with. For a block
+// id this is the state root of the block, which is returned with its header.
+func resolveStateID(ctx context.Context, client *http.Service, id string) (uint64, string, *v1.BeaconBlockHeader, error) {
+	if slot, err := strconv.ParseUint(id, 10, 64); err == nil {
+		return slot, id, nil, nil
+	}
+	if err := waitForRequest(ctx); err != nil {
+		return 0, "", nil, err
+	}
+	header, err := client.BeaconBlockHeader(ctx, id)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("error getting header for block id %v: %w", id, err)
+	}
+	if header == nil {
+		return 0, "", nil, fmt.Errorf("no header found for block id %v", id)
+	}
+	return uint64(header.Header.Message.Slot), fmt.Sprintf("%#x", header.Header.Message.StateRoot), header, nil
+}
+
+// getStateRoot returns the root of the state stateID.
+func getStateRoot(ctx context.Context, client *http.Service, stateID string) (phase0.Root, error) {
+	if err := waitForRequest(ctx); err != nil {
+		return phase0.Root{}, err
+	}
+	root, err := client.BeaconStateRoot(ctx, stateID)
+	if err != nil {
+		return phase0.Root{}, fmt.Errorf("error getting root of state %v: %w", stateID, err)
+	}
+	if root == nil {
+		return phase0.Root{}, fmt.Errorf("no root found for state %v", stateID)
+	}
+	return *root, nil
+}
+
+// verifyStateRoot returns an error if the state stateID at slot does not have the root it had before its validators
+// were fetched (rootBefore), or if the block at slot, if there is one, has another post-state root, see
+// WithStateRootVerification.
+func verifyStateRoot(ctx context.Context, client *http.Service, stateID string, slot uint64, rootBefore phase0.Root) error {
+	rootAfter, err := getStateRoot(ctx, client, stateID)
+	if err != nil {
+		return err
+	}
+	if rootAfter != rootBefore {
+		return fmt.Errorf("state %v changed while its validators were fetched: root %#x before, %#x after", stateID, rootBefore, rootAfter)
+	}
+	if err := waitForRequest(ctx); err != nil {
+		return err
+	}
+	header, err := client.BeaconBlockHeader(ctx, fmt.Sprintf("%d", slot))
+	if err != nil {
+		return fmt.Errorf("error getting header of block at slot %v: %w", slot, err)
+	}
+	// the block of a slot is processed before its state is stored, so the state at the slot is its post-state
+	if header != nil && uint64(header.Header.Message.Slot) == slot && header.Header.Message.StateRoot != rootAfter {
+		return fmt.Errorf("state %v has root %#x, but the block at slot %v has state root %#x", stateID, rootAfter, slot, header.Header.Message.StateRoot)
+	}
+	return nil
+}
+
+// getFinalizedSlot returns the slot of the block of the finalized checkpoint.
+func getFinalizedSlot(ctx context.Context, client *http.Service) (uint64, error) {
+	if err := waitForRequest(ctx); err != nil {
+		return 0, err
+	}
+	finalizedHeader, err := client.BeaconBlockHeader(ctx, "finalized")
+	if err != nil {
+		return 0, fmt.Errorf("error getting header for block id finalized: %w", err)
+	}
+	if finalizedHeader == nil {
+		return 0, fmt.Errorf("no header found for block id finalized")
+	}
+	return uint64(finalizedHeader.Header.Message.Slot), nil
+}
+
+// checkFinalized returns an error if the state at endSlot (the end balances of the day) is after the finalized
+// checkpoint or if the anchor of the day is not the canonical block at its slot, see WithRequireFinalized.
+func checkFinalized(ctx context.Context, client *http.Service, anchorHeader *v1.BeaconBlockHeader, endSlot uint64) error {
+	finalizedSlot, err := getFinalizedSlot(ctx, client)
+	if err != nil {
+		return err
+	}
+	if endSlot > finalizedSlot {
+		return fmt.Errorf("day is not finalized: it ends at slot %v, the finalized slot is %v", endSlot, finalizedSlot)
+	}
+	// a block at or before the finalized slot is only finalized if it is part of the canonical chain
+	anchorSlot := uint64(anchorHeader.Header.Message.Slot)
+	if err := waitForRequest(ctx); err != nil {
+		return err
+	}
+	canonicalHeader, err := client.BeaconBlockHeader(ctx, fmt.Sprintf("%d", anchorSlot))
+	if err != nil {
+		return fmt.Errorf("error getting header for slot %v: %w", anchorSlot, err)
+	}
+	if canonicalHeader == nil || canonicalHeader.Root != anchorHeader.Root {
+		return fmt.Errorf("day is not finalized: anchor %#x at slot %v is not part of the finalized chain", anchorHeader.Root, anchorSlot)
+	}
+	return nil
+}
+
+// getBlock fetches the block at the given slot and retries up to 10 times on failure.
+// If the slot was missed the returned block is nil.
+func getBlock(ctx context.Context, client *http.Service, slot uint64) (*spec.VersionedSignedBeaconBlock, error) {
+	return getBlockByID(ctx, client, fmt.Sprintf("%d", slot))
+}
+
+func getBlockByID(ctx context.Context, client *http.Service, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	return getBlockByIDObserved(ctx, client, blockID, nil)
+}
+
+// getBlockByIDObserved is getBlockByID that reports the outcome of every attempt to observe, if it is not nil.
+func getBlockByIDObserved(ctx context.Context, client *http.Service, blockID string, observe func(error)) (*spec.VersionedSignedBeaconBlock, error) {
+	var block *spec.VersionedSignedBeaconBlock
+	var err error
+	for j := 0; j < 10; j++ { // retry up to 10 times on failure
+		if err := waitForRequest(ctx); err != nil {
+			return nil, err
+		}
+		block, err = client.SignedBeaconBlock(ctx, blockID)
+		if err != nil {
+			// the client fails to decode blinded blocks, the error is kept if the block is not blinded
+			if blinded, blindedErr := getBlindedBlock(ctx, client, blockID); blindedErr == nil && blinded != nil {
+				block, err = blinded, nil
+			}
+		}
+		if observe != nil {
+			observe(err)
+		}
+
+		if err == nil {
+			break
+		} else {
+			log.Printf("error retrieving beacon block %v: %v", blockID, err)
+			time.Sleep(time.Duration(j) * time.Second)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting block %v: %w", blockID, err)
+	}
+	return block, nil
+}
+
+// getCanonicalBlockRoots walks the parent roots from the anchor down to firstSlot and returns the roots of the
+// blocks in [firstSlot, endSlot) by slot and the state roots of the blocks in [firstSlot, endSlot]. Slots without a
+// root have been missed on the chain of the anchor.
+// The walk itself is sequential, so the headers of the slots below the anchor are prefetched by slot with at most
+// concurrency requests at a time. The walk follows the parent roots through the prefetched headers and only fetches a
+// header by its root where the chain of the node differs from the chain of the anchor.
+func getCanonicalBlockRoots(ctx context.Context, client *http.Service, anchorHeader *v1.BeaconBlockHeader, firstSlot, endSlot uint64, concurrency int) (map[uint64]phase0.Root, map[uint64]phase0.Root, error) {
+	prefetched := map[phase0.Root]*v1.BeaconBlockHeader{} // guarded by mu
+	mu := sync.Mutex{}
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+	for slot := firstSlot; slot < uint64(anchorHeader.Header.Message.Slot); slot++ {
+		slot := slot
+		g.Go(func() error {
+			if err := waitForRequest(ctx); err != nil {
+				return err
+			}
+			h, err := client.BeaconBlockHeader(ctx, fmt.Sprintf("%d", slot))
+			if err != nil {
+				return fmt.Errorf("error getting header for slot %v: %w", slot, err)
+			}
+			if h == nil {
+				// missed slot
+				return nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			prefetched[h.Root] = h
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	roots := map[uint64]phase0.Root{}
+	stateRoots := map[uint64]phase0.Root{}
+	header := anchorHeader
+	for {
+		slot := uint64(header.Header.Message.Slot)
+		if slot < firstSlot {
+			return roots, stateRoots, nil
+		}
+		if slot < endSlot {
+			roots[slot] = header.Root
+		}
+		if slot <= endSlot {
+			stateRoots[slot] = header.Header.Message.StateRoot
+		}
+		if slot == firstSlot {
+			return roots, stateRoots, nil
+		}
+		parentRoot := header.Header.Message.ParentRoot
+		if h, exists := prefetched[parentRoot]; exists {
+			header = h
+			continue
+		}
+		if err := waitForRequest(ctx); err != nil {
+			return nil, nil, err
+		}
+		h, err := client.BeaconBlockHeader(ctx, fmt.Sprintf("%#x", parentRoot))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting header for parent root %#x of slot %v: %w", parentRoot, slot, err)
+		}
+		if h == nil {
+			return nil, nil, fmt.Errorf("no header found for parent root %#x of slot %v", parentRoot, slot)
+		}
+		header = h
+	}
+}
+
+func verifyDepositSignature(d *phase0.Deposit, depositDomain []byte) error {
+	msg := &ethpb.Deposit_Data{
+		PublicKey:             d.Data.PublicKey[:],
+		WithdrawalCredentials: d.Data.WithdrawalCredentials,
+		Amount:                uint64(d.Data.Amount),
+		Signature:             d.Data.Signature[:],
+	}
+	return deposit.VerifyDepositSignature(msg, depositDomain)
+}
+
+// minDepositAmount is MIN_DEPOSIT_AMOUNT, the deposit contract rejects smaller deposits.
+const minDepositAmount = phase0.Gwei(1e9)
+
+// checkDeposit returns an error if the deposit must not be accounted: deposits that have already been seen in
+// the scanned slots (identical including the merkle proof, which commits to the deposit index), deposits below
+// MIN_DEPOSIT_AMOUNT and deposits with an invalid signature. The deposit is added to seen, which has to be
+// guarded by the caller.
+func checkDeposit(d *phase0.Deposit, depositDomain []byte, seen map[phase0.Root]bool) error {
+	root, err := d.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("error hashing deposit: %w", err)
+	}
+	if seen[root] {
+		return fmt.Errorf("duplicate deposit %#x", root)
+	}
+	seen[root] = true
+	if d.Data.Amount < minDepositAmount {
+		return fmt.Errorf("deposit amount %v is below the minimum of %v", d.Data.Amount, minDepositAmount)
+	}
+	err = verifyDepositSignature(d, depositDomain)
+	if err != nil {
+		return fmt.Errorf("invalid deposit signature: %w", err)
+	}
+	return nil
+}
+
+// DefaultConcurrency is the number of blocks CalculateNetwork fetches concurrently.
+const DefaultConcurrency = 10
+
+// CalculateNetwork calculates the network-wide eth.store of the given day and is the recommended entry point for
+// basic usage. It is Calculate with DefaultConcurrency that only returns the day of all validators.
+func CalculateNetwork(ctx context.Context, bnAddress, elAddress, dayStr string, opts ...Option) (*Day, error) {
+	d, _, err := Calculate(ctx, bnAddress, elAddress, dayStr, DefaultConcurrency, append(opts, WithoutValidatorDays())...)
+	return d, err
+}
+
+// Calculate calculates eth.store for the given day and returns the day and the days of all validators that
+// have been active the whole day (with WithStateRange the range between two states instead). It is safe to call
+// Calculate from multiple goroutines: all state of a call is local to it, per-call settings are given as options and
+// the package-level settings (Set* functions) are guarded and read when they are used, so changing them while calls
+// are running affects the remaining requests of those calls as well.
+func Calculate(ctx context.Context, bnAddress, elAddress, dayStr string, concurrency int, opts ...Option) (*Day, map[uint64]*Day, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// elapsed time per phase, only returned with WithTimings
+	calculationStart := time.Now()
+	phaseStart := calculationStart
+	timings := map[string]time.Duration{}
+	endPhase := func(phase string) {
+		now := time.Now()
+		timings[phase] = now.Sub(phaseStart)
+		phaseStart = now
+	}
+
+	gethRpcClient, err := gethRPC.Dial(elAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	blockReceipts := newBlockReceiptsState(o.blockReceipts)
+
+	ctx = withBeaconHeaders(ctx, o)
+	client, err := newConsClient(ctx, bnAddress, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chainSpec, err := getChainSpec(ctx, client, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	stateClient, err := newStateClient(ctx, client, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	slotsPerEpoch := chainSpec.SlotsPerEpoch
+	secondsPerSlot := chainSpec.SecondsPerSlot
+	epochsPerSyncCommitteePeriod := chainSpec.EpochsPerSyncCommitteePeriod
+
+	// the blocks from this slot on can have withdrawal requests, see WithWithdrawalRequests
+	electraForkSlot := uint64(math.MaxUint64)
+	if o.withdrawalRequests {
+		electraForkEpoch, err := getElectraForkEpoch(ctx, client)
+		if err != nil {
+			return nil, nil, err
+		}
+		if electraForkEpoch != farFutureEpoch {
+			electraForkSlot = uint64(electraForkEpoch) * slotsPerEpoch
+		}
+	}
+
+	genesisValidatorsRoot := [32]byte{}
+	depositDomainComputed, err := signing.ComputeDomain(chainSpec.DomainDeposit, chainSpec.GenesisForkVersion[:], genesisValidatorsRoot[:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slotsPerDay := 3600 * 24 / secondsPerSlot
+	annualizationDays := decimal.NewFromInt(365)
+	if o.periodSlots != 0 {
+		annualizationDays = periodAnnualizationDays(slotsPerDay, o.periodSlots)
+		slotsPerDay = o.periodSlots
+	}
+
+	dr, err := resolveRange(ctx, client, dayStr, slotsPerDay, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	day := dr.Day
+	anchorID := dr.AnchorID
+	anchorHeader := dr.AnchorHeader
+	anchorSlot := dr.AnchorSlot
+	firstSlot := dr.FirstSlot
+	endSlot := dr.EndSlot
+	if o.epochBoundaries {
+		firstSlot, endSlot = alignToEpochs(firstSlot, endSlot, slotsPerEpoch)
+		if endSlot <= firstSlot {
+			return nil, nil, fmt.Errorf("day %v has no complete epoch at %v (slot %v)", day, anchorID, anchorSlot)
+		}
+	}
+	// numbered days are anchored at the finalized checkpoint and complete at it
+	if o.requireFinalized && anchorID != "finalized" {
+		if err := checkFinalized(ctx, client, anchorHeader, endSlot); err != nil {
+			return nil, nil, fmt.Errorf("error calculating day %v (%v): %w", day, anchorID, err)
+		}
+	}
+	lastSlot := endSlot - 1
+
+	firstEpoch := firstSlot / slotsPerEpoch
+	lastEpoch := lastSlot / slotsPerEpoch
+
+	// the slots the annualization factor applies to, the epochs of WithEpochBreakdown are annualized relative to them
+	periodSeconds := (endSlot - firstSlot) * secondsPerSlot
+	annualizedSlots := slotsPerDay
+	if o.exactAnnualization || o.startStateID != "" {
+		annualizationDays = exactAnnualizationDays(periodSeconds)
+		annualizedSlots = endSlot - firstSlot
+	}
+
+	if err := waitForRequest(ctx); err != nil {
+		return nil, nil, err
+	}
+	genesis, err := client.GenesisTime(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting genesisTime: %w", err)
+	}
+	startTime := time.Unix(genesis.Unix()+int64(firstSlot)*int64(secondsPerSlot), 0)
+	endTime := time.Unix(genesis.Unix()+int64(lastSlot)*int64(secondsPerSlot), 0)
+	if o.reportTimezone != nil {
+		startTime = startTime.In(o.reportTimezone)
+		endTime = endTime.In(o.reportTimezone)
+	}
+
+	// with WithExecutionRewardsRate the execution rewards of the blocks are converted to the token of the balances
+	var executionRewardsRate decimal.Decimal
+	if o.executionRewardsRate != nil {
+		executionRewardsRate, err = o.executionRewardsRate(startTime)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting execution rewards rate for day %v (%v): %w", day, startTime, err)
+		}
+		if !executionRewardsRate.IsPositive() {
+			return nil, nil, fmt.Errorf("invalid execution rewards rate %v for day %v: must be positive", executionRewardsRate, day)
+		}
+	}
+
+	endPhase("spec")
+
+	if GetDebugLevel() > 0 {
+		log.Printf("DEBUG eth.store: calculating day %v (%v - %v, epochs: %v-%v, slots: %v-%v, genesis: %v, anchor: %v, anchorSlot: %v)\n", day, startTime, endTime, firstEpoch, lastEpoch, firstSlot, lastSlot, genesis, anchorID, anchorSlot)
+	}
+
+	// with WithCanonicalChain the blocks are fetched by their roots on the chain of the anchor and the start and end
+	// states by the state roots of the blocks at their slots, so that the day reflects a single chain even if the head
+	// of the node moves to another fork while the day is calculated. States at missed slots are fetched by slot.
+	var canonicalRoots, canonicalStateRoots map[uint64]phase0.Root
+	if o.canonicalChain && anchorID != "finalized" && !o.withoutBlocks {
+		canonicalRoots, canonicalStateRoots, err = getCanonicalBlockRoots(ctx, client, anchorHeader, firstSlot, endSlot, concurrency)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	stateIDAt := func(slot uint64) string {
+		if id, exists := dr.StateIDs[slot]; exists {
+			return id
+		}
+		if root, exists := canonicalStateRoots[slot]; exists {
+			return fmt.Sprintf("%#x", root)
+		}
+		return fmt.Sprintf("%d", slot)
+	}
+
+	// with WithStateRootVerification the validators are fetched without the cache, between two requests of the root
+	getValidators := GetValidators
+	if o.prefetched != nil && !o.stateRootVerification {
+		getValidators = func(ctx context.Context, client *http.Service, stateID string) (map[phase0.ValidatorIndex]*v1.Validator, error) {
+			if vals, ok := o.prefetched.get(ctx, stateID); ok {
+				// cached like fetched validators, the end state of the day is the start state of the next one
+				if err := cacheValidators(client, stateID, vals); err != nil {
+					return nil, err
+				}
+				return vals, nil
+			}
+			return GetValidators(ctx, client, stateID)
+		}
+	}
+	var startStateRoot, endStateRoot phase0.Root
+	if o.stateRootVerification {
+		getValidators = fetchValidators
+		startStateRoot, err = getStateRoot(ctx, stateClient, stateIDAt(firstSlot))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	startValidators, err := getValidators(ctx, stateClient, stateIDAt(firstSlot))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting startValidators for firstSlot %d: %w", firstSlot, err)
+	}
+	if o.stateRootVerification {
+		if err := verifyStateRoot(ctx, stateClient, stateIDAt(firstSlot), firstSlot, startStateRoot); err != nil {
+			return nil, nil, fmt.Errorf("error verifying the state at firstSlot %d: %w", firstSlot, err)
+		}
+	}
+	if len(startValidators) == 0 {
+		return nil, nil, fmt.Errorf("%w in state at firstSlot %d", ErrNoValidators, firstSlot)
+	}
+
+	// the accounted validators and their tx fees are allocated in one slice each instead of two allocations per
+	// validator, for the whole network these are millions of small allocations otherwise
+	activeValidators := 0
+	for _, val := range startValidators {
+		if val.Status.IsActive() {
+			activeValidators++
+		}
+	}
+	validators := make([]Validator, 0, activeValidators)
+	txFeesSumsWei := make([]big.Int, activeValidators)
+	validatorsByIndex := make(map[phase0.ValidatorIndex]*Validator, activeValidators)
+	validatorsByPubkey := make(map[phase0.BLSPubKey]*Validator, activeValidators)
+	for _, val := range startValidators {
+		if !val.Status.IsActive() {
+			continue
+		}
+		validators = append(validators, Validator{
+			Index:                val.Index,
+			Pubkey:               val.Validator.PublicKey,
+			EffectiveBalanceGwei: val.Validator.EffectiveBalance,
+			StartBalanceGwei:     val.Balance,
+			TxFeesSumWei:         &txFeesSumsWei[len(validators)],
+		})
+		vv := &validators[len(validators)-1]
+		validatorsByIndex[val.Index] = vv
+		validatorsByPubkey[val.Validator.PublicKey] = vv
+	}
+
+	if o.stateRootVerification {
+		endStateRoot, err = getStateRoot(ctx, stateClient, stateIDAt(endSlot))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	endValidators, err := getValidators(ctx, stateClient, stateIDAt(endSlot))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting endValidators for endSlot %d: %w", endSlot, err)
+	}
+	if o.stateRootVerification {
+		if err := verifyStateRoot(ctx, stateClient, stateIDAt(endSlot), endSlot, endStateRoot); err != nil {
+			return nil, nil, fmt.Errorf("error verifying the state at endSlot %d: %w", endSlot, err)
+		}
+	}
+	if len(endValidators) == 0 {
+		return nil, nil, fmt.Errorf("%w in state at endSlot %d", ErrNoValidators, endSlot)
+	}
+
+	// validators that were active at the start of the day and exited during it
+	exitedPubkeys := map[phase0.BLSPubKey]bool{}
+	activatedValidators, exitedValidators := validatorChurn(startValidators, endValidators)
+	// validators excluded by WithMinActivationAge
+	youngValidators := 0
+	// validators that were active at the start of the day and are missing in the state at its end (or not active in it)
+	unaccountedValidators := 0
+
+	// the accounted validators are the validators that were active in the state at the start of the day, so validators
+	// that only exist in the state at the end of the day (activated during it) are never accounted with a zero start
+	// balance. The inverse, an accounted validator without end balance, would be accounted with a zero end balance and
+	// is not accounted either.
+	for index, v := range validatorsByIndex {
+		if val, exists := endValidators[index]; !exists || val.Validator.PublicKey != v.Pubkey {
+			if GetDebugLevel() > 0 {
+				log.Printf("DEBUG eth.store: validator %v was active at the start of day %v but is missing in the state at the end, not accounting it", index, day)
+			}
+			unaccountedValidators++
+			delete(validatorsByIndex, index)
+			delete(validatorsByPubkey, v.Pubkey)
+		}
+	}
+	if unaccountedValidators > 0 {
+		log.Printf("WARNING eth.store: %v validators that were active at the start of day %v are missing in the state at the end, not accounting them", unaccountedValidators, day)
+	}
+	// validators with a status at the end of the day that is impossible after being active the whole day, they are
+	// part of unaccountedValidators
+	unexpectedStatusValidators := 0
+	for _, val := range endValidators {
+		v, exists := validatorsByIndex[val.Index]
+		if !exists {
+			continue
+		}
+		if !isActiveEndStatus(val.Status) {
+			if GetDebugLevel() > 0 {
+				log.Printf("DEBUG eth.store: validator %v was active at the start of day %v but has status %v at the end, not accounting it", val.Index, day, val.Status)
+			}
+			unaccountedValidators++
+			unexpectedStatusValidators++
+			delete(validatorsByIndex, val.Index)
+			delete(validatorsByPubkey, val.Validator.PublicKey)
+			continue
+		}
+		if !isActiveDuring(val.Validator, phase0.Epoch(firstEpoch), phase0.Epoch(lastEpoch)) {
+			// do not account validators that have not been active until the end of the day. Exits are not prorated, so
+			// a validator that exits during the day contributes neither its balance nor deposits to it, the deposits
+			// are returned in Day.ExitedDepositsSumGwei instead.
+			delete(validatorsByIndex, val.Index)
+			delete(validatorsByPubkey, val.Validator.PublicKey)
+			exitedPubkeys[val.Validator.PublicKey] = true
+			continue
+		}
+		if o.minActivationAge != 0 && uint64(val.Validator.ActivationEpoch)+o.minActivationAge > firstEpoch {
+			// not accounted like a pending validator, so deposits to it are pending deposits
+			delete(validatorsByIndex, val.Index)
+			delete(validatorsByPubkey, val.Validator.PublicKey)
+			youngValidators++
+			continue
+		}
+		// set endBalance of validator to the balance of the first epoch of the next day
+		v.EndBalanceGwei = val.Balance
+		v.WithdrawalAddress = withdrawalAddress(val.Validator.WithdrawalCredentials)
+		v.CredentialType = credentialType(val.Validator.WithdrawalCredentials)
+		v.IsCompounding = v.CredentialType == compoundingWithdrawalPrefix
+	}
+	if unexpectedStatusValidators > 0 {
+		log.Printf("WARNING eth.store: %v validators that were active at the start of day %v have an unexpected status at the end, not accounting them", unexpectedStatusValidators, day)
+	}
+	if len(validatorsByIndex) == 0 {
+		return nil, nil, fmt.Errorf("%w: no validator was active the whole day %v", ErrNoValidators, day)
+	}
+
+	if o.effectiveBalanceSlotOffset != 0 {
+		effectiveBalanceSlot := firstSlot + o.effectiveBalanceSlotOffset
+		if effectiveBalanceSlot > endSlot {
+			return nil, nil, fmt.Errorf("effective balance slot %v (offset %v) is after the end of day %v (slot %v)", effectiveBalanceSlot, o.effectiveBalanceSlotOffset, day, endSlot)
+		}
+		effectiveBalanceValidators := endValidators
+		if effectiveBalanceSlot != endSlot {
+			// without the cache of GetValidators, so that it does not evict the start and end states of the day
+			effectiveBalanceValidators, err = fetchValidators(ctx, stateClient, stateIDAt(effectiveBalanceSlot))
+			if err != nil {
+				return nil, nil, fmt.Errorf("error getting validators for effective balance slot %d: %w", effectiveBalanceSlot, err)
+			}
+		}
+		for _, val := range effectiveBalanceValidators {
+			if v, exists := validatorsByIndex[val.Index]; exists {
+				v.EffectiveBalanceGwei = val.Validator.EffectiveBalance
+			}
+		}
+	}
+
+	if o.effectiveBalanceEpochs != 0 {
+		// the first sample is the state at the start of the day, which the effective balances were read from. The other
+		// samples are fetched without the cache of GetValidators, so that they do not evict the start and end states
+		// that CalculateRange reuses for the adjacent days, and are added up as soon as they are fetched.
+		weightedSumGwei := make(map[phase0.ValidatorIndex]uint64, len(validatorsByIndex))
+		sampleSlots := o.effectiveBalanceEpochs * slotsPerEpoch
+		g := new(errgroup.Group)
+		g.SetLimit(concurrency)
+		mu := sync.Mutex{}
+		for slot := firstSlot; slot < endSlot; slot += sampleSlots {
+			slot := slot
+			weight := sampleSlots
+			if slot+weight > endSlot {
+				weight = endSlot - slot
+			}
+			g.Go(func() error {
+				sampleValidators := startValidators
+				if slot != firstSlot {
+					var err error
+					sampleValidators, err = fetchValidators(ctx, stateClient, stateIDAt(slot))
+					if err != nil {
+						return fmt.Errorf("error getting validators for effective balance sample at slot %d: %w", slot, err)
+					}
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for index := range validatorsByIndex {
+					val, exists := sampleValidators[index]
+					if !exists {
+						return fmt.Errorf("validator %v is missing in the effective balance sample at slot %d", index, slot)
+					}
+					weightedSumGwei[index] += uint64(val.Validator.EffectiveBalance) * weight
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return nil, nil, err
+		}
+		for index, v := range validatorsByIndex {
+			v.EffectiveBalanceGwei = phase0.Gwei(weightedSumGwei[index] / (endSlot - firstSlot))
+		}
+	}
+
+	// validators in the registry that are not active the whole day (and did not exit during it), deposits to them are
+	// pending capital
+	pendingPubkeys := map[phase0.BLSPubKey]bool{}
+	// pending validators whose index held no validator (or another one) at the start of the day, deposits to them are
+	// activation deposits
+	newPubkeys := map[phase0.BLSPubKey]bool{}
+	for _, val := range endValidators {
+		if _, exists := validatorsByIndex[val.Index]; !exists && !exitedPubkeys[val.Validator.PublicKey] {
+			pendingPubkeys[val.Validator.PublicKey] = true
+			if start, existed := startValidators[val.Index]; !existed || start.Validator.PublicKey != val.Validator.PublicKey {
+				newPubkeys[val.Validator.PublicKey] = true
+			}
+		}
+	}
+	// the validators that were activated during the day by index, see WithActiveFractionNormalization
+	var activatedByIndex map[phase0.ValidatorIndex]*Validator
+	var activatedByPubkey map[phase0.BLSPubKey]*Validator
+	activeFractions := map[phase0.ValidatorIndex]decimal.Decimal{}
+	if o.activeFractionNormalization {
+		activatedByIndex = map[phase0.ValidatorIndex]*Validator{}
+		activatedByPubkey = map[phase0.BLSPubKey]*Validator{}
+		for _, val := range endValidators {
+			start, existed := startValidators[val.Index]
+			if !pendingPubkeys[val.Validator.PublicKey] || !existed || start.Validator.PublicKey != val.Validator.PublicKey || start.Status.IsActive() {
+				continue
+			}
+			activationEpoch := uint64(val.Validator.ActivationEpoch)
+			if activationEpoch <= firstEpoch || activationEpoch > lastEpoch || !isActiveEndStatus(val.Status) || !isActiveDuring(val.Validator, val.Validator.ActivationEpoch, phase0.Epoch(lastEpoch)) {
+				continue
+			}
+			v := &Validator{
+				Index:                val.Index,
+				Pubkey:               val.Validator.PublicKey,
+				EffectiveBalanceGwei: val.Validator.EffectiveBalance,
+				StartBalanceGwei:     start.Balance,
+				EndBalanceGwei:       val.Balance,
+				TxFeesSumWei:         new(big.Int),
+				WithdrawalAddress:    withdrawalAddress(val.Validator.WithdrawalCredentials),
+				CredentialType:       credentialType(val.Validator.WithdrawalCredentials),
+			}
+			activatedByIndex[val.Index] = v
+			activatedByPubkey[val.Validator.PublicKey] = v
+			activeFractions[val.Index] = decimal.NewFromInt(int64(lastEpoch + 1 - activationEpoch)).Div(decimal.NewFromInt(int64(lastEpoch + 1 - firstEpoch)))
+		}
+	}
+	endPhase("validators")
+	if o.nextPrefetch != nil {
+		// the state at the end of the next day, if the next day is complete at the anchor of this one
+		_, nextEndSlot := daySlots(day+1, slotsPerDay)
+		if o.epochBoundaries {
+			nextEndSlot -= nextEndSlot % slotsPerEpoch
+		}
+		if o.startStateID == "" && anchorID == "finalized" && nextEndSlot <= anchorSlot && !o.stateRootVerification {
+			o.nextPrefetch.start(ctx, stateClient, fmt.Sprintf("%d", nextEndSlot))
+		} else {
+			o.nextPrefetch.skip()
+		}
+	}
+	if GetDebugLevel() > 0 {
+		log.Printf("DEBUG eth.store: startValidators: %v, endValidators: %v, ethstoreValidators: %v", len(startValidators), len(endValidators), len(validatorsByIndex))
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+	// the requests in flight of the block loop, only with WithAdaptiveConcurrency
+	var limiter *adaptiveLimiter
+	var observe func(error)
+	if o.adaptiveConcurrencyMax != 0 {
+		g.SetLimit(o.adaptiveConcurrencyMax)
+		limiter = newAdaptiveLimiter(o.adaptiveConcurrencyMin, o.adaptiveConcurrencyMax, concurrency)
+		observe = limiter.observe
+	}
+	validatorsMu := sync.Mutex{}
+	proposedBlocks := uint64(0)
+	incompletePayloadSlots := uint64(0)
+	blindedBlockSlots := uint64(0)
+	var pendingDepositsSumGwei phase0.Gwei    // guarded by validatorsMu
+	var exitedDepositsSumGwei phase0.Gwei     // guarded by validatorsMu
+	var activationDepositsSumGwei phase0.Gwei // guarded by validatorsMu
+	var topUpDepositsSumGwei phase0.Gwei      // guarded by validatorsMu
+	seenDeposits := map[phase0.Root]bool{}    // guarded by validatorsMu
+	balanceEvents := []BalanceEvent{}         // guarded by validatorsMu
+	epochSums := map[uint64]*epochSum{}       // guarded by validatorsMu, only with WithEpochBreakdown
+	// the execution rewards by client, guarded by validatorsMu, only with WithClientClassifier
+	rewardsByClient := map[string]*big.Int{}
+	withdrawalsByAddress := map[common.Address]phase0.Gwei{}
+	blockVersions := map[string]uint64{} // guarded by validatorsMu
+	// the aggregation bits of the included attestations of the epochs in [attestationsFirstSlot,attestationsEndSlot)
+	// or-ed per committee, guarded by validatorsMu, only with WithMissedAttestations
+	includedAttestations := map[attestationKey]bitfield.Bitlist{}
+	// the slot of the first block that includes each aggregation bit of the attestations of the same epochs, 0 if the
+	// bit is not included, guarded by validatorsMu, only with WithInclusionDistance
+	inclusionSlots := map[attestationKey][]uint64{}
+	attestationsFirstSlot, attestationsEndSlot := attestationSlots(firstSlot, endSlot, slotsPerEpoch)
+	// the size of the blocks that are in flight, only with WithMaxInFlightBytes
+	inFlightBytes := uint64(0)
+	inFlightMu := sync.Mutex{}
+	inFlightCond := sync.NewCond(&inFlightMu)
+	epochSumAt := func(slot uint64) *epochSum {
+		epoch := slot / slotsPerEpoch
+		if _, exists := epochSums[epoch]; !exists {
+			epochSums[epoch] = &epochSum{executionRewardsWei: new(big.Int)}
+		}
+		return epochSums[epoch]
+	}
+
+	// sync committees are fetched lazily (only for altair+ blocks) and cached per sync committee period
+	syncCommittees := map[uint64]*v1.SyncCommittee{}
+	syncCommitteesMu := sync.Mutex{}
+	getSyncCommittee := func(slot uint64) (*v1.SyncCommittee, error) {
+		syncCommitteesMu.Lock()
+		defer syncCommitteesMu.Unlock()
+		period := slot / slotsPerEpoch / epochsPerSyncCommitteePeriod
+		if sc, exists := syncCommittees[period]; exists {
+			return sc, nil
+		}
+		// the state at the first slot of the day knows the current and the next sync committee,
+		// which covers all periods a day can span
+		if err := waitForRequest(ctx); err != nil {
+			return nil, err
+		}
+		sc, err := stateClient.SyncCommitteeAtEpoch(ctx, stateIDAt(firstSlot), phase0.Epoch(period*epochsPerSyncCommitteePeriod))
+		if err != nil {
+			return nil, fmt.Errorf("error getting sync committee for period %v: %w", period, err)
+		}
+		syncCommittees[period] = sc
+		return sc, nil
+	}
+
+	// the genesis block is part of the genesis state and not proposed, so it is neither a proposed nor a missed slot
+	blocksFirstSlot := firstSlot
+	if blocksFirstSlot == 0 {
+		blocksFirstSlot = 1
+	}
+
+	// with WithCheckpoint the block loop resumes after the slots of a stored checkpoint of the day and stores a
+	// checkpoint every checkpointSlots slots and after the last slot
+	loopFirstSlot := blocksFirstSlot
+	var cpKey string
+	resumeCheckpoint := func(c *checkpoint) error {
+		if c.Day != day || c.FirstSlot != firstSlot || c.EndSlot != endSlot || c.Flags != checkpointFlags(o) {
+			return fmt.Errorf("checkpoint is for day %v, slots %v-%v with %v", c.Day, c.FirstSlot, c.EndSlot, c.Flags)
+		}
+		if err := checkResumable(o); err != nil {
+			return err
+		}
+		if c.NextSlot < blocksFirstSlot || c.NextSlot > endSlot {
+			return fmt.Errorf("invalid next slot %v", c.NextSlot)
+		}
+		seen, err := c.getSeenDeposits()
+		if err != nil {
+			return err
+		}
+		if err := c.restoreValidators(validatorsByIndex); err != nil {
+			return err
+		}
+		proposedBlocks = c.ProposedBlocks
+		incompletePayloadSlots = c.IncompletePayloadSlots
+		blindedBlockSlots = c.BlindedBlockSlots
+		pendingDepositsSumGwei = c.PendingDepositsSumGwei
+		exitedDepositsSumGwei = c.ExitedDepositsSumGwei
+		activationDepositsSumGwei = c.ActivationDepositsSumGwei
+		topUpDepositsSumGwei = c.TopUpDepositsSumGwei
+		seenDeposits = seen
+		if o.balanceEvents {
+			balanceEvents = append(balanceEvents, c.BalanceEvents...)
+		}
+		epochSums = c.getEpochSums()
+		included, err := c.getIncludedAttestations()
+		if err != nil {
+			return err
+		}
+		includedAttestations = included
+		inclusionSlots, err = c.getInclusionSlots()
+		if err != nil {
+			return err
+		}
+		rewardsByClient = c.getRewardsByClient()
+		withdrawalsByAddress = c.getWithdrawalsByAddress()
+		blockVersions = c.getBlockVersions()
+		loopFirstSlot = c.NextSlot
+		return nil
+	}
+	// storeCheckpoint must only be called while no block of the loop is in flight
+	storeCheckpoint := func(nextSlot uint64) {
+		c := &checkpoint{
+			Day:                    day,
+			FirstSlot:              firstSlot,
+			EndSlot:                endSlot,
+			NextSlot:               nextSlot,
+			Flags:                  checkpointFlags(o),
+			ProposedBlocks:         proposedBlocks,
+			IncompletePayloadSlots: incompletePayloadSlots,
+			PendingDepositsSumGwei: pendingDepositsSumGwei,
+			ExitedDepositsSumGwei:  exitedDepositsSumGwei,
+			BalanceEvents:          balanceEvents,
+
+			ActivationDepositsSumGwei: activationDepositsSumGwei,
+			BlindedBlockSlots:         blindedBlockSlots,
+			TopUpDepositsSumGwei:      topUpDepositsSumGwei,
+		}
+		c.setSeenDeposits(seenDeposits)
+		c.setEpochSums(epochSums)
+		c.setIncludedAttestations(includedAttestations)
+		c.setInclusionSlots(inclusionSlots)
+		c.setRewardsByClient(rewardsByClient)
+		c.setWithdrawalsByAddress(withdrawalsByAddress)
+		c.setBlockVersions(blockVersions)
+		c.setValidators(validatorsByIndex)
+		// a failing store only costs the progress of a re-run, so the calculation goes on
+		if err := saveCheckpoint(ctx, o.checkpointStore, cpKey, c); err != nil {
+			log.Printf("WARNING eth.store: %v", err)
+		}
+	}
+	if o.checkpointStore != nil && !o.withoutBlocks {
+		cpKey = checkpointKey(day, firstSlot, endSlot)
+		c, err := loadCheckpoint(ctx, o.checkpointStore, cpKey)
+		if err != nil {
+			log.Printf("WARNING eth.store: %v, processing all slots of the day", err)
+		} else if c != nil {
+			if err := resumeCheckpoint(c); err != nil {
+				log.Printf("WARNING eth.store: ignoring checkpoint %v: %v, processing all slots of the day", cpKey, err)
+			} else if GetDebugLevel() > 0 {
+				log.Printf("DEBUG eth.store: resuming day %v at slot %v from checkpoint", day, loopFirstSlot)
+			}
+		}
+	}
+	if o.withoutBlocks {
+		loopFirstSlot = endSlot
+	}
+
+	// get all deposits, withdrawals and txs of all active validators in the slot interval [blocksFirstSlot,endSlot).
+	// endSlot is the first slot of the next day, so the last slot of the day is scanned and every slot is scanned by
+	// exactly one day: a withdrawal in the last block of the day is part of the day, like its effect on the end balance
+	// (the state at endSlot is after the last block of the day).
+	// g.Go blocks while concurrency slots are in flight, so the slots are requested in ascending order. A slot starts as
+	// soon as any slot in flight is done though, so the slots in flight are only consecutive with WithOrderedBlocks.
+	// Its window is a ring of the slots in flight, a slot waits for the slot a window before it at its position.
+	var window []chan struct{}
+	if o.orderedBlocks {
+		window = make([]chan struct{}, concurrency)
+		if o.adaptiveConcurrencyMax != 0 {
+			window = make([]chan struct{}, o.adaptiveConcurrencyMax)
+		}
+	}
+	for i := loopFirstSlot; i < endSlot; i++ {
+		i := i
+		if o.checkpointStore != nil && i > loopFirstSlot && (i-loopFirstSlot)%checkpointSlots == 0 {
+			if err := g.Wait(); err != nil {
+				return nil, nil, err
+			}
+			storeCheckpoint(i)
+		}
+		if GetDebugLevel() > 0 && (endSlot-i)%1000 == 0 {
+			log.Printf("DEBUG eth.store: checking blocks for deposits and txs: %.0f%% (%v of %v-%v)\n", 100*float64(i-firstSlot)/float64(endSlot-firstSlot), i, firstSlot, endSlot)
+		}
+		var slotDone chan struct{}
+		if window != nil {
+			k := (i - loopFirstSlot) % uint64(len(window))
+			if window[k] != nil {
+				<-window[k]
+			}
+			slotDone = make(chan struct{})
+			window[k] = slotDone
+		}
+		if o.maxInFlightBytes != 0 {
+			inFlightMu.Lock()
+			for inFlightBytes >= o.maxInFlightBytes {
+				inFlightCond.Wait()
+			}
+			inFlightMu.Unlock()
+		}
+		if limiter != nil {
+			limiter.acquire()
+		}
+		g.Go(func() error {
+			if slotDone != nil {
+				defer close(slotDone)
+			}
+			if limiter != nil {
+				defer limiter.release()
+			}
+			var block *spec.VersionedSignedBeaconBlock
+			var err error
+			blockID := fmt.Sprintf("%d", i)
+			var canonicalRoot *phase0.Root
+			if root, exists := canonicalRoots[i]; exists {
+				blockID = fmt.Sprintf("%#x", root)
+				canonicalRoot = &root
+			}
+			// there is no block at slots without a canonical root
+			if canonicalRoots == nil || canonicalRoot != nil {
+				if o.blockArchive != "" {
+					block, err = readArchivedBlock(o.blockArchive, i, canonicalRoot)
+				}
+				if err == nil && block == nil {
+					block, err = getBlockByIDObserved(ctx, client, blockID, observe)
+				}
+			}
+			if err != nil {
+				return &SlotError{Slot: i, Err: err}
+			}
+			if block == nil {
+				return nil
+			}
+			atomic.AddUint64(&proposedBlocks, 1)
+			blockData, err := GetBlockData(block)
+			if err != nil {
+				return &SlotError{Slot: i, Err: fmt.Errorf("error getting blockData: %w", err)}
+			}
+			validatorsMu.Lock()
+			blockVersions[block.Version.String()]++
+			validatorsMu.Unlock()
+			if o.maxInFlightBytes != 0 {
+				size := blockSize(block)
+				inFlightMu.Lock()
+				inFlightBytes += size
+				inFlightMu.Unlock()
+				defer func() {
+					inFlightMu.Lock()
+					inFlightBytes -= size
+					inFlightMu.Unlock()
+					inFlightCond.Broadcast()
+				}()
+			}
+			if blockData.Blinded {
+				// the header has neither the transactions nor the withdrawals, the deposits are in the body
+				atomic.AddUint64(&blindedBlockSlots, 1)
+				atomic.AddUint64(&incompletePayloadSlots, 1)
+				if GetDebugLevel() > 0 {
+					log.Printf("DEBUG eth.store: block at slot %v is blinded, skipping its fees and withdrawals", i)
+				}
+			} else if blockData.IncompletePayload {
+				// the fees (and withdrawals) of the slot are unknown, use an archive node to get complete results
+				atomic.AddUint64(&incompletePayloadSlots, 1)
+				if GetDebugLevel() > 0 {
+					log.Printf("DEBUG eth.store: block at slot %v has no complete execution payload, skipping its fees and withdrawals", i)
+				}
+			}
+
+			var syncCommittee *v1.SyncCommittee
+			if blockData.SyncAggregate != nil {
+				syncCommittee, err = getSyncCommittee(i)
+				if err != nil {
+					return err
+				}
+			}
+
+			v, exists := validatorsByIndex[blockData.ProposerIndex]
+			// only calculate for validators that have been active the whole day,
+			// pre-merge blocks have no execution payload and therefore no transactions. Empty post-merge blocks have
+			// none either, so they pay no fees and no mev payment in a last tx, but they are proposed blocks like any other.
+			if exists && len(blockData.Transactions) > 0 {
+				totalTxFee, mevPayment, err := getTxFees(gethRpcClient, blockData, i, o.mevLastTxAttribution, blockReceipts, o.feeStrategy, o.txCache)
+				if err != nil {
+					return &SlotError{Slot: i, Err: err}
+				}
+				if o.executionRewardsRate != nil {
+					totalTxFee = convertWei(totalTxFee, executionRewardsRate)
+					mevPayment = convertWei(mevPayment, executionRewardsRate)
+				}
+
+				validatorsMu.Lock()
+				v.TxFeesSumWei.Add(v.TxFeesSumWei, totalTxFee)
+				v.ProposedTxCount += uint64(len(blockData.Transactions))
+				if mevPayment.Sign() != 0 {
+					if v.MevRewardsWei == nil {
+						v.MevRewardsWei = new(big.Int)
+					}
+					v.MevRewardsWei.Add(v.MevRewardsWei, mevPayment)
+				}
+				if o.epochBreakdown {
+					es := epochSumAt(i)
+					es.executionRewardsWei.Add(es.executionRewardsWei, totalTxFee)
+					es.executionRewardsWei.Add(es.executionRewardsWei, mevPayment)
+				}
+				if o.clientClassifier != nil {
+					clientName := o.clientClassifier(blockData.Graffiti)
+					if _, exists := rewardsByClient[clientName]; !exists {
+						rewardsByClient[clientName] = new(big.Int)
+					}
+					rewardsByClient[clientName].Add(rewardsByClient[clientName], totalTxFee)
+					rewardsByClient[clientName].Add(rewardsByClient[clientName], mevPayment)
+				}
+				validatorsMu.Unlock()
+			}
+			if a, activated := activatedByIndex[blockData.ProposerIndex]; activated && len(blockData.Transactions) > 0 {
+				totalTxFee, mevPayment, err := getTxFees(gethRpcClient, blockData, i, o.mevLastTxAttribution, blockReceipts, o.feeStrategy, o.txCache)
+				if err != nil {
+					return &SlotError{Slot: i, Err: err}
+				}
+				if o.executionRewardsRate != nil {
+					totalTxFee = convertWei(totalTxFee, executionRewardsRate)
+					mevPayment = convertWei(mevPayment, executionRewardsRate)
+				}
+				validatorsMu.Lock()
+				a.TxFeesSumWei.Add(a.TxFeesSumWei, totalTxFee)
+				a.ProposedTxCount += uint64(len(blockData.Transactions))
+				if mevPayment.Sign() != 0 {
+					if a.MevRewardsWei == nil {
+						a.MevRewardsWei = new(big.Int)
+					}
+					a.MevRewardsWei.Add(a.MevRewardsWei, mevPayment)
+				}
+				validatorsMu.Unlock()
+			}
+			if exists && blockData.GasLimit > 0 {
+				gasUtilization := decimal.NewFromInt(int64(blockData.GasUsed)).Div(decimal.NewFromInt(int64(blockData.GasLimit)))
+				validatorsMu.Lock()
+				v.GasUtilizationSum = v.GasUtilizationSum.Add(gasUtilization)
+				v.PayloadBlocks++
+				validatorsMu.Unlock()
+			}
+			if exists && o.proposerRewards {
+				proposerRewardsGwei, err := getProposerRewards(ctx, client, blockID)
+				if err != nil {
+					return &SlotError{Slot: i, Err: err}
+				}
+				validatorsMu.Lock()
+				v.ProposerConsensusRewardsGwei += proposerRewardsGwei
+				validatorsMu.Unlock()
+			}
+			// the requests of a block are for any validator, not only for its proposer
+			if o.withdrawalRequests && i >= electraForkSlot {
+				requests, err := getWithdrawalRequests(ctx, client, blockID)
+				if err != nil {
+					return &SlotError{Slot: i, Err: err}
+				}
+				validatorsMu.Lock()
+				for _, r := range requests {
+					if v, exists := validatorsByPubkey[r.Pubkey]; exists {
+						v.WithdrawalRequestsSumGwei += r.AmountGwei
+					}
+				}
+				validatorsMu.Unlock()
+			}
+
+			validatorsMu.Lock()
+			defer validatorsMu.Unlock()
+			for _, d := range blockData.Deposits {
+				if o.depositFilter != nil && !o.depositFilter(d) {
+					continue
+				}
+				v, exists := validatorsByPubkey[d.Data.PublicKey]
+				if !exists {
+					// only calculate for validators that have been active the whole day
+					if pendingPubkeys[d.Data.PublicKey] && checkDeposit(d, depositDomainComputed, seenDeposits) == nil {
+						pendingDepositsSumGwei += d.Data.Amount
+						if a, activated := activatedByPubkey[d.Data.PublicKey]; activated {
+							a.DepositsSumGwei += d.Data.Amount
+						}
+						if newPubkeys[d.Data.PublicKey] {
+							activationDepositsSumGwei += d.Data.Amount
+						} else {
+							topUpDepositsSumGwei += d.Data.Amount
+						}
+					}
+					if exitedPubkeys[d.Data.PublicKey] && checkDeposit(d, depositDomainComputed, seenDeposits) == nil {
+						if GetDebugLevel() > 0 {
+							log.Printf("DEBUG eth.store: deposit at block %d to %#x, which exited during the day: %v\n", i, d.Data.PublicKey, d.Data.Amount)
+						}
+						exitedDepositsSumGwei += d.Data.Amount
+						topUpDepositsSumGwei += d.Data.Amount
+					}
+					continue
+				}
+				err := checkDeposit(d, depositDomainComputed, seenDeposits)
+				if err != nil {
+					if GetDebugLevel() > 0 {
+						log.Printf("DEBUG eth.store: skipping deposit in block %d: %v", i, err)
+					}
+					v.SkippedDeposits++
+					continue
+				}
+				if GetDebugLevel() > 0 {
+					log.Printf("DEBUG eth.store: extra deposit at block %d from %v: %#x: %v\n", i, v.Index, d.Data.PublicKey, d.Data.Amount)
+				}
+				v.DepositsSumGwei += d.Data.Amount
+				topUpDepositsSumGwei += d.Data.Amount
+				if o.epochBreakdown {
+					epochSumAt(i).depositsGwei += d.Data.Amount
+				}
+				if o.balanceEvents {
+					balanceEvents = append(balanceEvents, BalanceEvent{Slot: i, ValidatorIndex: v.Index, Type: BalanceEventDeposit, AmountGwei: d.Data.Amount})
+				}
+			}
+			for _, d := range blockData.Withdrawals {
+				v, exists := validatorsByIndex[d.ValidatorIndex]
+				if !exists {
+					// only calculate for validators that have been active the whole day
+					if a, activated := activatedByIndex[d.ValidatorIndex]; activated {
+						a.WithdrawalsSumGwei += d.Amount
+					}
+					continue
+				}
+				v.WithdrawalsSumGwei += d.Amount
+				withdrawalsByAddress[common.Address(d.Address)] += d.Amount
+				if o.epochBreakdown {
+					epochSumAt(i).withdrawalsGwei += d.Amount
+				}
+				if o.balanceEvents {
+					balanceEvents = append(balanceEvents, BalanceEvent{Slot: i, ValidatorIndex: v.Index, Type: BalanceEventWithdrawal, AmountGwei: d.Amount})
+				}
+			}
+			if syncCommittee != nil {
+				for j, idx := range syncCommittee.Validators {
+					v, exists := validatorsByIndex[idx]
+					if !exists {
+						// only calculate for validators that have been active the whole day
+						continue
+					}
+					v.SyncCommitteeDuties++
+					if blockData.SyncAggregate.SyncCommitteeBits.BitAt(uint64(j)) {
+						v.SyncCommitteeParticipations++
+					}
+				}
+			}
+			if o.missedAttestations {
+				for _, a := range blockData.Attestations {
+					if uint64(a.Data.Slot) < attestationsFirstSlot || uint64(a.Data.Slot) >= attestationsEndSlot {
+						continue
+					}
+					key := attestationKey{Slot: uint64(a.Data.Slot), Index: uint64(a.Data.Index)}
+					bits, exists := includedAttestations[key]
+					if !exists {
+						includedAttestations[key] = append(bitfield.Bitlist{}, a.AggregationBits...)
+						continue
+					}
+					bits, err = bits.Or(a.AggregationBits)
+					if err != nil {
+						return fmt.Errorf("error aggregating attestation of slot %v, committee %v in block at slot %v: %w", key.Slot, key.Index, i, err)
+					}
+					includedAttestations[key] = bits
+				}
+			}
+			if o.inclusionDistance {
+				for _, a := range blockData.Attestations {
+					if uint64(a.Data.Slot) < attestationsFirstSlot || uint64(a.Data.Slot) >= attestationsEndSlot {
+						continue
+					}
+					key := attestationKey{Slot: uint64(a.Data.Slot), Index: uint64(a.Data.Index)}
+					slots := inclusionSlots[key]
+					for j := uint64(0); j < a.AggregationBits.Len(); j++ {
+						if !a.AggregationBits.BitAt(j) {
+							continue
+						}
+						for uint64(len(slots)) <= j {
+							slots = append(slots, 0)
+						}
+						// the blocks are processed concurrently, so a later block can be processed first
+						if slots[j] == 0 || i < slots[j] {
+							slots[j] = i
+						}
+					}
+					inclusionSlots[key] = slots
+				}
+			}
+
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	if o.checkpointStore != nil && loopFirstSlot < endSlot {
+		storeCheckpoint(endSlot)
+	}
+	endPhase("blocks")
+
+	if o.missedAttestations || o.inclusionDistance {
+		if !o.missedAttestations {
+			includedAttestations = nil
+		}
+		if !o.inclusionDistance {
+			inclusionSlots = nil
+		}
+		err = countAttestationDuties(ctx, stateClient, validatorsByIndex, includedAttestations, inclusionSlots, attestationsFirstSlot, attestationsEndSlot, slotsPerEpoch, concurrency)
+		if err != nil {
+			return nil, nil, err
+		}
+		endPhase("attestations")
+	}
+
+	// the balances of the accounted validators at the start of the day, at the start of each epoch during the day and
+	// at the end of the day
+	var epochBoundaries []uint64
+	var epochBalancesGwei []phase0.Gwei
+	if o.epochBreakdown {
+		epochBoundaries = []uint64{firstSlot}
+		for slot := (firstSlot/slotsPerEpoch + 1) * slotsPerEpoch; slot < endSlot; slot += slotsPerEpoch {
+			epochBoundaries = append(epochBoundaries, slot)
+		}
+		epochBoundaries = append(epochBoundaries, endSlot)
+		epochBalancesGwei = make([]phase0.Gwei, len(epochBoundaries))
+		for _, v := range validatorsByIndex {
+			epochBalancesGwei[0] += v.StartBalanceGwei
+			epochBalancesGwei[len(epochBoundaries)-1] += v.EndBalanceGwei
+		}
+		g := new(errgroup.Group)
+		g.SetLimit(concurrency)
+		for k := 1; k < len(epochBoundaries)-1; k++ {
+			k := k
+			g.Go(func() error {
+				// without the cache of GetValidators, see the samples of WithEffectiveBalanceEpochs
+				validators, err := fetchValidators(ctx, stateClient, stateIDAt(epochBoundaries[k]))
+				if err != nil {
+					return fmt.Errorf("error getting validators for epoch breakdown at slot %d: %w", epochBoundaries[k], err)
+				}
+				for index := range validatorsByIndex {
+					if val, exists := validators[index]; exists {
+						epochBalancesGwei[k] += val.Balance
+					}
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return nil, nil, err
+		}
+		endPhase("epochBreakdown")
+	}
+
+	missedSlots := endSlot - blocksFirstSlot - proposedBlocks
+	if o.withoutBlocks {
+		missedSlots = 0
+	}
+	if blindedBlockSlots > 0 {
+		log.Printf("WARNING eth.store: %v blocks of day %v are blinded, the node serves execution payload headers only (use a node that serves full blocks), skipping their fees and withdrawals", blindedBlockSlots, day)
+	}
+	if n := incompletePayloadSlots - blindedBlockSlots; n > 0 {
+		log.Printf("WARNING eth.store: %v blocks of day %v have no complete execution payload, the node lacks execution payload data (use an archive node), skipping their fees and withdrawals", n, day)
+	}
+
+	// a day that is not anchored at the finalized checkpoint can be affected by a reorg while it is calculated,
+	// in that case the anchor is not canonical anymore and the fetched blocks and states might not belong
+	// to the same chain. Numbered days are always anchored at the finalized checkpoint which is the safe default.
+	possiblyReorged := false
+	if anchorID != "finalized" {
+		if err := waitForRequest(ctx); err != nil {
+			return nil, nil, err
+		}
+		h, err := client.BeaconBlockHeader(ctx, fmt.Sprintf("%#x", anchorHeader.Root))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error checking anchor %v for reorgs: %w", anchorID, err)
+		}
+		if h == nil || !h.Canonical {
+			possiblyReorged = true
+			log.Printf("WARNING eth.store: anchor %v (slot %v, root %#x) is not canonical anymore, day %v is possibly affected by a reorg", anchorID, anchorSlot, anchorHeader.Root, day)
+		}
+	}
+
+	endPhase("reorgCheck")
+
+	inactivityLeakEpochs := uint64(0)
+	if o.finalityCheck {
+		inactivityLeakEpochs, err = getInactivityLeakEpochs(ctx, stateClient, firstEpoch, endSlot, slotsPerEpoch, concurrency)
+		if err != nil {
+			return nil, nil, err
+		}
+		if inactivityLeakEpochs > 0 {
+			log.Printf("WARNING eth.store: day %v was in an inactivity leak for %v epochs, the apr is depressed by inactivity penalties", day, inactivityLeakEpochs)
+		}
+		endPhase("finalityCheck")
+	}
+
+	var totalEffectiveBalanceGwei phase0.Gwei
+	var totalStartBalanceGwei phase0.Gwei
+	var totalEndBalanceGwei phase0.Gwei
+	var totalDepositsSumGwei phase0.Gwei
+	var totalWithdrawalsSumGwei phase0.Gwei
+	var totalSyncCommitteeDuties uint64
+	var totalSyncCommitteeParticipations uint64
+	var totalSkippedDeposits uint64
+	var totalMissedAttestations uint64
+	var totalTxCount uint64
+	var totalProposerConsensusRewardsGwei phase0.Gwei
+	var totalPayloadBlocks uint64
+	totalGasUtilizationSum := decimal.Zero
+	var totalInclusionDistanceSum uint64
+	var totalIncludedAttestations uint64
+	var totalWithdrawalRequestsSumGwei phase0.Gwei
+	totalTxFeesSumWei := new(big.Int)
+	totalMevRewardsWei := new(big.Int)
+
+	// the time the days are stamped with
+	computedAt := time.Now().UTC()
+
+	var ethstorePerValidator map[uint64]*Day
+	if !o.withoutValidatorDays {
+		ethstorePerValidator = make(map[uint64]*Day, len(validatorsByIndex))
+	}
+
+	for index, v := range validatorsByIndex {
+		totalEffectiveBalanceGwei += v.EffectiveBalanceGwei
+		totalStartBalanceGwei += v.StartBalanceGwei
+		totalEndBalanceGwei += v.EndBalanceGwei
+		totalDepositsSumGwei += v.DepositsSumGwei
+		totalWithdrawalsSumGwei += v.WithdrawalsSumGwei
+		totalTxFeesSumWei.Add(totalTxFeesSumWei, v.TxFeesSumWei)
+		totalTxCount += v.ProposedTxCount
+		totalProposerConsensusRewardsGwei += v.ProposerConsensusRewardsGwei
+		totalPayloadBlocks += v.PayloadBlocks
+		totalGasUtilizationSum = totalGasUtilizationSum.Add(v.GasUtilizationSum)
+		v.AvgGasUtilization = avgGasUtilization(v.GasUtilizationSum, v.PayloadBlocks)
+		totalInclusionDistanceSum += v.InclusionDistanceSum
+		totalIncludedAttestations += v.IncludedAttestations
+		totalWithdrawalRequestsSumGwei += v.WithdrawalRequestsSumGwei
+		v.AvgInclusionDistance = avgInclusionDistance(v.InclusionDistanceSum, v.IncludedAttestations)
+		validatorMevRewardsWei := decimal.Zero
+		if v.MevRewardsWei != nil {
+			totalMevRewardsWei.Add(totalMevRewardsWei, v.MevRewardsWei)
+			validatorMevRewardsWei = decimal.NewFromBigInt(v.MevRewardsWei, 0)
+		}
+		totalSyncCommitteeDuties += v.SyncCommitteeDuties
+		totalSyncCommitteeParticipations += v.SyncCommitteeParticipations
+		totalSkippedDeposits += v.SkippedDeposits
+		totalMissedAttestations += v.MissedAttestations
+		v.SyncParticipationRate = syncParticipationRate(v.SyncCommitteeParticipations, v.SyncCommitteeDuties)
+
+		if o.withoutValidatorDays {
+			continue
+		}
+
+		validatorConsensusRewardsGwei := consensusRewardsGwei(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei)
+		validatorRewardsWei := decimal.NewFromBigInt(v.TxFeesSumWei, 0).Add(validatorMevRewardsWei).Add(validatorConsensusRewardsGwei.Mul(decimal.NewFromInt(1e9)))
+
+		validatorAprBasisGwei := v.EffectiveBalanceGwei
+		if o.nominalBalanceGwei != 0 {
+			validatorAprBasisGwei = o.nominalBalanceGwei
+		}
+		validatorExecutionRewardsWei := new(big.Int).Set(v.TxFeesSumWei)
+		if v.MevRewardsWei != nil {
+			validatorExecutionRewardsWei.Add(validatorExecutionRewardsWei, v.MevRewardsWei)
+		}
+		validatorApr := ComputeApr(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei, validatorExecutionRewardsWei, validatorAprBasisGwei, annualizationDays)
+		validatorConsensusApr := ComputeApr(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei, nil, validatorAprBasisGwei, annualizationDays)
+		validatorAprExMev := ComputeApr(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei, v.TxFeesSumWei, validatorAprBasisGwei, annualizationDays)
+
+		ethstorePerValidator[uint64(index)] = &Day{
+			Day:                    decimal.NewFromInt(int64(day)),
+			DayTime:                startTime,
+			StartEpoch:             decimal.NewFromInt(int64(firstEpoch)),
+			Apr:                    validatorApr,
+			Apy:                    apy(validatorApr),
+			Validators:             decimal.NewFromInt(int64(len(validatorsByIndex))),
+			EffectiveBalanceGwei:   gweiToDecimal(v.EffectiveBalanceGwei),
+			StartBalanceGwei:       gweiToDecimal(v.StartBalanceGwei),
+			EndBalanceGwei:         gweiToDecimal(v.EndBalanceGwei),
+			DepositsSumGwei:        gweiToDecimal(v.DepositsSumGwei),
+			TxFeesSumWei:           decimal.NewFromBigInt(v.TxFeesSumWei, 0),
+			MevRewardsWei:          validatorMevRewardsWei,
+			ConsensusRewardsGwei:   validatorConsensusRewardsGwei,
+			TotalRewardsWei:        validatorRewardsWei,
+			WithdrawalsSumGwei:     gweiToDecimal(v.WithdrawalsSumGwei),
+			SyncCommitteeDuties:    decimal.NewFromInt(int64(v.SyncCommitteeDuties)),
+			SyncParticipationRate:  v.SyncParticipationRate,
+			PossiblyReorged:        possiblyReorged,
+			ProposedBlocks:         decimal.NewFromInt(int64(proposedBlocks)),
+			MissedSlots:            decimal.NewFromInt(int64(missedSlots)),
+			SkippedDeposits:        decimal.NewFromInt(int64(v.SkippedDeposits)),
+			IncompletePayloadSlots: decimal.NewFromInt(int64(incompletePayloadSlots)),
+			BlindedBlockSlots:      decimal.NewFromInt(int64(blindedBlockSlots)),
+			DuringNonFinality:      inactivityLeakEpochs > 0,
+			InactivityLeakEpochs:   decimal.NewFromInt(int64(inactivityLeakEpochs)),
+			MissedAttestations:     decimal.NewFromInt(int64(v.MissedAttestations)),
+			ActivatedValidators:    decimal.NewFromInt(int64(activatedValidators)),
+			ExitedValidators:       decimal.NewFromInt(int64(exitedValidators)),
+			YoungValidators:        decimal.NewFromInt(int64(youngValidators)),
+			ComputedAt:             computedAt,
+			MethodologyVersion:     MethodologyVersion,
+			// the deposits of an accounted validator are top-ups
+			TopUpDepositsSumGwei: gweiToDecimal(v.DepositsSumGwei),
+			TotalTxCount:         decimal.NewFromInt(int64(v.ProposedTxCount)),
+			PeriodSeconds:        decimal.NewFromInt(int64(periodSeconds)),
+			WithoutBlocks:        o.withoutBlocks,
+			ConsensusApr:         validatorConsensusApr,
+			ExecutionApr:         validatorApr.Sub(validatorConsensusApr),
+			AprExMev:             validatorAprExMev,
+
+			ProposerConsensusRewardsGwei: gweiToDecimal(v.ProposerConsensusRewardsGwei),
+			AvgGasUtilization:            v.AvgGasUtilization,
+			PayloadBlocks:                decimal.NewFromInt(int64(v.PayloadBlocks)),
+			AvgInclusionDistance:         v.AvgInclusionDistance,
+			IncludedAttestations:         decimal.NewFromInt(int64(v.IncludedAttestations)),
+			WithdrawalRequestsSumGwei:    gweiToDecimal(v.WithdrawalRequestsSumGwei),
+		}
+		ethstorePerValidator[uint64(index)].AvgRewardsPerValidatorEth = avgRewardsPerValidatorEth(validatorRewardsWei, 1)
+		if v.WithdrawalAddress != (common.Address{}) {
+			withdrawalAddress := v.WithdrawalAddress
+			ethstorePerValidator[uint64(index)].WithdrawalAddress = &withdrawalAddress
+		}
+		credentialType := v.CredentialType
+		ethstorePerValidator[uint64(index)].CredentialType = &credentialType
+	}
+
+	// the apr is proportional to the reward per effective balance, so the score can be derived from it
+	if len(ethstorePerValidator) > 0 {
+		aprSum := decimal.Zero
+		for _, d := range ethstorePerValidator {
+			aprSum = aprSum.Add(d.Apr)
+		}
+		aprMean := aprSum.Div(decimal.NewFromInt(int64(len(ethstorePerValidator))))
+		for index, v := range validatorsByIndex {
+			if !aprMean.IsZero() {
+				v.PerformanceScore = ethstorePerValidator[uint64(index)].Apr.Div(aprMean)
+			}
+			ethstorePerValidator[uint64(index)].PerformanceScore = v.PerformanceScore
+		}
+	}
+	trimFraction := defaultAprTrimFraction
+	if o.aprTrimFraction != nil {
+		trimFraction = *o.aprTrimFraction
+	}
+	aprStdDev, aprTrimmedMean := decimal.Zero, decimal.Zero
+	if len(ethstorePerValidator) > 0 {
+		aprs := make([]weightedApr, 0, len(ethstorePerValidator))
+		for _, d := range ethstorePerValidator {
+			weight := d.EffectiveBalanceGwei
+			if o.nominalBalanceGwei != 0 {
+				weight = decimal.NewFromInt(1)
+			}
+			aprs = append(aprs, weightedApr{apr: d.Apr, weight: weight})
+		}
+		aprStdDev = stdDevApr(aprs)
+		aprTrimmedMean = trimmedMeanApr(aprs, trimFraction)
+	}
+	// after the statistics, which are over the accounted validators only
+	for index, v := range activatedByIndex {
+		activeFraction := activeFractions[index]
+		validatorConsensusRewardsGwei := consensusRewardsGwei(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei)
+		validatorExecutionRewardsWei := new(big.Int).Set(v.TxFeesSumWei)
+		if v.MevRewardsWei != nil {
+			validatorExecutionRewardsWei.Add(validatorExecutionRewardsWei, v.MevRewardsWei)
+		}
+		validatorRewardsWei := decimal.NewFromBigInt(validatorExecutionRewardsWei, 0).Add(validatorConsensusRewardsGwei.Mul(decimal.NewFromInt(1e9)))
+		validatorAprBasisGwei := v.EffectiveBalanceGwei
+		if o.nominalBalanceGwei != 0 {
+			validatorAprBasisGwei = o.nominalBalanceGwei
+		}
+		// the execution rewards of the whole day at the rate of the slots the validator was active in
+		normalizedExecutionRewardsWei := decimal.NewFromBigInt(validatorExecutionRewardsWei, 0).Div(activeFraction).BigInt()
+		normalizedTxFeesSumWei := decimal.NewFromBigInt(v.TxFeesSumWei, 0).Div(activeFraction).BigInt()
+		validatorApr := ComputeApr(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei, normalizedExecutionRewardsWei, validatorAprBasisGwei, annualizationDays)
+		validatorConsensusApr := ComputeApr(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei, nil, validatorAprBasisGwei, annualizationDays)
+		validatorMevRewardsWei := decimal.Zero
+		if v.MevRewardsWei != nil {
+			validatorMevRewardsWei = decimal.NewFromBigInt(v.MevRewardsWei, 0)
+		}
+		credentialType := v.CredentialType
+		ethstorePerValidator[uint64(index)] = &Day{
+			Day:                  decimal.NewFromInt(int64(day)),
+			DayTime:              startTime,
+			StartEpoch:           decimal.NewFromInt(int64(firstEpoch)),
+			Apr:                  validatorApr,
+			Apy:                  apy(validatorApr),
+			Validators:           decimal.NewFromInt(int64(len(validatorsByIndex))),
+			EffectiveBalanceGwei: gweiToDecimal(v.EffectiveBalanceGwei),
+			StartBalanceGwei:     gweiToDecimal(v.StartBalanceGwei),
+			EndBalanceGwei:       gweiToDecimal(v.EndBalanceGwei),
+			DepositsSumGwei:      gweiToDecimal(v.DepositsSumGwei),
+			WithdrawalsSumGwei:   gweiToDecimal(v.WithdrawalsSumGwei),
+			TxFeesSumWei:         decimal.NewFromBigInt(v.TxFeesSumWei, 0),
+			MevRewardsWei:        validatorMevRewardsWei,
+			ConsensusRewardsGwei: validatorConsensusRewardsGwei,
+			TotalRewardsWei:      validatorRewardsWei,
+			PossiblyReorged:      possiblyReorged,
+			ProposedBlocks:       decimal.NewFromInt(int64(proposedBlocks)),
+			MissedSlots:          decimal.NewFromInt(int64(missedSlots)),
+			DuringNonFinality:    inactivityLeakEpochs > 0,
+			InactivityLeakEpochs: decimal.NewFromInt(int64(inactivityLeakEpochs)),
+			ComputedAt:           computedAt,
+			MethodologyVersion:   MethodologyVersion,
+			TotalTxCount:         decimal.NewFromInt(int64(v.ProposedTxCount)),
+			PeriodSeconds:        decimal.NewFromInt(int64(periodSeconds)),
+			CredentialType:       &credentialType,
+			ConsensusApr:         validatorConsensusApr,
+			ExecutionApr:         validatorApr.Sub(validatorConsensusApr),
+			AprExMev:             ComputeApr(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei, normalizedTxFeesSumWei, validatorAprBasisGwei, annualizationDays),
+			ActiveFraction:       &activeFraction,
+			TopUpDepositsSumGwei: gweiToDecimal(v.DepositsSumGwei),
+		}
+		ethstorePerValidator[uint64(index)].AvgRewardsPerValidatorEth = avgRewardsPerValidatorEth(validatorRewardsWei, 1)
+		if v.WithdrawalAddress != (common.Address{}) {
+			withdrawalAddress := v.WithdrawalAddress
+			ethstorePerValidator[uint64(index)].WithdrawalAddress = &withdrawalAddress
+		}
+	}
+
+	totalConsensusRewardsGwei := consensusRewardsGwei(totalStartBalanceGwei, totalEndBalanceGwei, totalDepositsSumGwei, totalWithdrawalsSumGwei)
+	totalRewardsWei := decimal.NewFromBigInt(totalTxFeesSumWei, 0).Add(decimal.NewFromBigInt(totalMevRewardsWei, 0)).Add(totalConsensusRewardsGwei.Mul(decimal.NewFromInt(1e9)))
+	aprBasisGwei := totalEffectiveBalanceGwei
+	if o.nominalBalanceGwei != 0 {
+		aprBasisGwei = o.nominalBalanceGwei * phase0.Gwei(len(validatorsByIndex))
+	}
+	totalExecutionRewardsWei := new(big.Int).Add(totalTxFeesSumWei, totalMevRewardsWei)
+	totalApr := ComputeApr(totalStartBalanceGwei, totalEndBalanceGwei, totalDepositsSumGwei, totalWithdrawalsSumGwei, totalExecutionRewardsWei, aprBasisGwei, annualizationDays)
+	// the execution apr is the difference, so that the rounding of the divisions does not show in the sum
+	totalConsensusApr := ComputeApr(totalStartBalanceGwei, totalEndBalanceGwei, totalDepositsSumGwei, totalWithdrawalsSumGwei, nil, aprBasisGwei, annualizationDays)
+	totalAprExMev := ComputeApr(totalStartBalanceGwei, totalEndBalanceGwei, totalDepositsSumGwei, totalWithdrawalsSumGwei, totalTxFeesSumWei, aprBasisGwei, annualizationDays)
+
+	var epochBreakdown []EpochApr
+	for k := 0; k+1 < len(epochBoundaries); k++ {
+		epoch := epochBoundaries[k] / slotsPerEpoch
+		es, exists := epochSums[epoch]
+		if !exists {
+			es = &epochSum{executionRewardsWei: new(big.Int)}
+		}
+		// annualized with the number of such intervals in a year
+		epochAnnualizationDays := annualizationDays.Mul(decimal.NewFromInt(int64(annualizedSlots))).Div(decimal.NewFromInt(int64(epochBoundaries[k+1] - epochBoundaries[k])))
+		epochBreakdown = append(epochBreakdown, EpochApr{
+			Epoch: epoch,
+			Apr:   ComputeApr(epochBalancesGwei[k], epochBalancesGwei[k+1], es.depositsGwei, es.withdrawalsGwei, es.executionRewardsWei, aprBasisGwei, epochAnnualizationDays),
+		})
+	}
+
+	ethstoreDay := &Day{
+		Day:                    decimal.NewFromInt(int64(day)),
+		DayTime:                startTime,
+		StartEpoch:             decimal.NewFromInt(int64(firstEpoch)),
+		Apr:                    totalApr,
+		Apy:                    apy(totalApr),
+		Validators:             decimal.NewFromInt(int64(len(validatorsByIndex))),
+		EffectiveBalanceGwei:   gweiToDecimal(totalEffectiveBalanceGwei),
+		StartBalanceGwei:       gweiToDecimal(totalStartBalanceGwei),
+		EndBalanceGwei:         gweiToDecimal(totalEndBalanceGwei),
+		DepositsSumGwei:        gweiToDecimal(totalDepositsSumGwei),
+		TxFeesSumWei:           decimal.NewFromBigInt(totalTxFeesSumWei, 0),
+		MevRewardsWei:          decimal.NewFromBigInt(totalMevRewardsWei, 0),
+		ConsensusRewardsGwei:   totalConsensusRewardsGwei,
+		WithdrawalsSumGwei:     gweiToDecimal(totalWithdrawalsSumGwei),
+		TotalRewardsWei:        totalRewardsWei,
+		SyncCommitteeDuties:    decimal.NewFromInt(int64(totalSyncCommitteeDuties)),
+		SyncParticipationRate:  syncParticipationRate(totalSyncCommitteeParticipations, totalSyncCommitteeDuties),
+		PossiblyReorged:        possiblyReorged,
+		ProposedBlocks:         decimal.NewFromInt(int64(proposedBlocks)),
+		MissedSlots:            decimal.NewFromInt(int64(missedSlots)),
+		SkippedDeposits:        decimal.NewFromInt(int64(totalSkippedDeposits)),
+		IncompletePayloadSlots: decimal.NewFromInt(int64(incompletePayloadSlots)),
+		BlindedBlockSlots:      decimal.NewFromInt(int64(blindedBlockSlots)),
+		DuringNonFinality:      inactivityLeakEpochs > 0,
+		InactivityLeakEpochs:   decimal.NewFromInt(int64(inactivityLeakEpochs)),
+		PendingDepositsSumGwei: gweiToDecimal(pendingDepositsSumGwei),
+		ExitedDepositsSumGwei:  gweiToDecimal(exitedDepositsSumGwei),
+		EpochBreakdown:         epochBreakdown,
+		AprStdDev:              aprStdDev,
+		AprTrimmedMean:         aprTrimmedMean,
+		MissedAttestations:     decimal.NewFromInt(int64(totalMissedAttestations)),
+		ActivatedValidators:    decimal.NewFromInt(int64(activatedValidators)),
+		ExitedValidators:       decimal.NewFromInt(int64(exitedValidators)),
+
+		ActivationDepositsSumGwei: gweiToDecimal(activationDepositsSumGwei),
+		TopUpDepositsSumGwei:      gweiToDecimal(topUpDepositsSumGwei),
+		TotalTxCount:              decimal.NewFromInt(int64(totalTxCount)),
+		PeriodSeconds:             decimal.NewFromInt(int64(periodSeconds)),
+		WithoutBlocks:             o.withoutBlocks,
+		WithdrawalsByAddress:      withdrawalsByAddress,
+		BlockVersions:             blockVersions,
+		ConsensusApr:              totalConsensusApr,
+		ExecutionApr:              totalApr.Sub(totalConsensusApr),
+		AprExMev:                  totalAprExMev,
+
+		ProposerConsensusRewardsGwei: gweiToDecimal(totalProposerConsensusRewardsGwei),
+		AvgGasUtilization:            avgGasUtilization(totalGasUtilizationSum, totalPayloadBlocks),
+		PayloadBlocks:                decimal.NewFromInt(int64(totalPayloadBlocks)),
+		AvgInclusionDistance:         avgInclusionDistance(totalInclusionDistanceSum, totalIncludedAttestations),
+		IncludedAttestations:         decimal.NewFromInt(int64(totalIncludedAttestations)),
+		YoungValidators:              decimal.NewFromInt(int64(youngValidators)),
+		WithdrawalRequestsSumGwei:    gweiToDecimal(totalWithdrawalRequestsSumGwei),
+		ComputedAt:                   computedAt,
+		MethodologyVersion:           MethodologyVersion,
+	}
+	ethstoreDay.AvgRewardsPerValidatorEth = avgRewardsPerValidatorEth(totalRewardsWei, len(validatorsByIndex))
+	if o.stateRootVerification {
+		ethstoreDay.StartStateRoot = &startStateRoot
+		ethstoreDay.EndStateRoot = &endStateRoot
+	}
+	// without sets (as with WithValidatorSets(nil)) the days keep nil maps and the loops over them below are free
+	if len(o.validatorSets) > 0 {
+		ethstoreDay.ValidatorSets, ethstoreDay.ValidatorSetMeta = validatorSetDays(ethstoreDay, o.validatorSets, o.validatorSetDuplicates, validatorsByIndex, uint64(len(endValidators)), o.nominalBalanceGwei, annualizationDays, trimFraction)
+		for name, meta := range ethstoreDay.ValidatorSetMeta {
+			if len(meta.OutOfRange) > 0 {
+				log.Printf("WARNING eth.store: %v indices of validator set %v are out of range, there are only %v validators at the end of day %v (stale input or of a different network?)", len(meta.OutOfRange), name, len(endValidators), day)
+			}
+		}
+	}
+
+	if o.priceProvider != nil {
+		price, err := o.priceProvider(startTime)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting price for day %v (%v): %w", day, startTime, err)
+		}
+		ethstoreDay.TotalRewardsFiat = ethstoreDay.TotalRewardsEth().Mul(price)
+		for _, d := range ethstorePerValidator {
+			d.TotalRewardsFiat = d.TotalRewardsEth().Mul(price)
+		}
+		for _, d := range ethstoreDay.ValidatorSets {
+			d.TotalRewardsFiat = d.TotalRewardsEth().Mul(price)
+		}
+	}
+
+	switch o.negativeAprPolicy {
+	case NegativeAprClampZero:
+		ethstoreDay.clampNegativeApr()
+		for _, d := range ethstorePerValidator {
+			d.clampNegativeApr()
+		}
+		for _, d := range ethstoreDay.ValidatorSets {
+			d.clampNegativeApr()
+		}
+	case NegativeAprExclude:
+		for index, d := range ethstorePerValidator {
+			if d.Apr.IsNegative() {
+				delete(ethstorePerValidator, index)
+			}
+		}
+	}
+
+	if o.aprPrecision != nil {
+		ethstoreDay.roundApr(*o.aprPrecision)
+		for _, d := range ethstorePerValidator {
+			d.roundApr(*o.aprPrecision)
+		}
+		for _, d := range ethstoreDay.ValidatorSets {
+			d.roundApr(*o.aprPrecision)
+		}
+	}
+
+	if o.clientClassifier != nil {
+		ethstoreDay.RewardsByClient = make(map[string]decimal.Decimal, len(rewardsByClient))
+		for clientName, rewardsWei := range rewardsByClient {
+			ethstoreDay.RewardsByClient[clientName] = decimal.NewFromBigInt(rewardsWei, 0)
+		}
+	}
+
+	if o.ledger {
+		ethstoreDay.Ledger = make([]LedgerEntry, 0, len(validatorsByIndex))
+		for _, v := range validatorsByIndex {
+			ethstoreDay.Ledger = append(ethstoreDay.Ledger, newLedgerEntry(v))
+		}
+		sort.Slice(ethstoreDay.Ledger, func(i, j int) bool {
+			return ethstoreDay.Ledger[i].ValidatorIndex < ethstoreDay.Ledger[j].ValidatorIndex
+		})
+	}
+
+	if o.balanceEvents {
+		// events of a block are appended in block order, so a stable sort keeps them in the order they were applied
+		sort.SliceStable(balanceEvents, func(i, j int) bool { return balanceEvents[i].Slot < balanceEvents[j].Slot })
+		ethstoreDay.BalanceEvents = balanceEvents
+	}
+
+	if o.qualityScore {
+		// a day anchored at another block than the finalized checkpoint is final as well once its anchor is finalized
+		finalizedSlot := anchorSlot
+		if anchorID != "finalized" {
+			finalizedSlot, err = getFinalizedSlot(ctx, client)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		score, warnings := assessQuality(ethstoreDay, unaccountedValidators, anchorID, anchorSlot, finalizedSlot)
+		ethstoreDay.QualityScore = &score
+		ethstoreDay.Warnings = warnings
+	}
+
+	if o.timings {
+		endPhase("aggregation")
+		timings["total"] = time.Since(calculationStart)
+		ethstoreDay.Timings = timings
+	}
+
+	if GetDebugLevel() > 0 {
+		log.Printf("DEBUG eth.store: %s\n", ethstoreDay.Detailed())
+	}
+
+	return ethstoreDay, ethstorePerValidator, nil
+}
+
+// minEpochsToInactivityPenalty is MIN_EPOCHS_TO_INACTIVITY_PENALTY, which is the same in all presets.
+const minEpochsToInactivityPenalty = 4
+
+// isInInactivityLeak reports whether the rewards of the given epoch are processed in an inactivity leak, given the
+// finalized epoch after its epoch processing. As in the spec the leak starts when the finality delay (the distance
+// from the previous epoch to the finalized epoch) exceeds MIN_EPOCHS_TO_INACTIVITY_PENALTY.
+func isInInactivityLeak(epoch, finalizedEpoch uint64) bool {
+	return epoch > 0 && epoch-1 > finalizedEpoch+minEpochsToInactivityPenalty
+}
+
+// getInactivityLeakEpochs counts the epochs of the day whose rewards were processed in an inactivity leak. The
+// finalized checkpoint of each epoch is read from the state at the first slot of the following epoch, so epochs
+// whose processing is after endSlot (the last partial epoch of days that do not end at an epoch boundary) are not
+// checked.
+func getInactivityLeakEpochs(ctx context.Context, client *http.Service, firstEpoch, endSlot, slotsPerEpoch uint64, concurrency int) (uint64, error) {
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+	leakEpochs := uint64(0)
+	for epoch := firstEpoch; (epoch+1)*slotsPerEpoch <= endSlot; epoch++ {
+		epoch := epoch
 		g.Go(func() error {
-			var block *spec.VersionedSignedBeaconBlock
-			var err error
-			for j := 0; j < 10; j++ { // retry up to 10 times on failure
-				block, err = client.SignedBeaconBlock(ctx, fmt.Sprintf("%d", i))
+			if err := waitForRequest(ctx); err != nil {
+				return err
+			}
+			stateID := fmt.Sprintf("%d", (epoch+1)*slotsPerEpoch)
+			finality, err := client.Finality(ctx, stateID)
+			if err != nil {
+				return fmt.Errorf("error getting finality checkpoints of state %v: %w", stateID, err)
+			}
+			if finality == nil || finality.Finalized == nil {
+				return fmt.Errorf("no finality checkpoints for state %v", stateID)
+			}
+			if isInInactivityLeak(epoch, uint64(finality.Finalized.Epoch)) {
+				atomic.AddUint64(&leakEpochs, 1)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return 0, err
+	}
+	return leakEpochs, nil
+}
+
+// attestationKey identifies a committee by the slot and the committee index of its attestations.
+type attestationKey struct {
+	Slot  uint64
+	Index uint64
+}
+
+// attestationSlots returns the slots [first,end) of the whole epochs of the day whose attestations can only be
+// included in the slots of the day: an attestation can be included up to SLOTS_PER_EPOCH slots after its slot, so the
+// attestations of the last epoch of the day are left to the next day.
+func attestationSlots(firstSlot, endSlot, slotsPerEpoch uint64) (uint64, uint64) {
+	first := (firstSlot + slotsPerEpoch - 1) / slotsPerEpoch * slotsPerEpoch
+	end := endSlot / slotsPerEpoch * slotsPerEpoch
+	if end < first+slotsPerEpoch {
+		return first, first
+	}
+	return first, end - slotsPerEpoch
+}
+
+// countAttestationDuties adds the committee positions of the epochs in [firstSlot,endSlot) that are not set in the
+// included attestations to the MissedAttestations of the validators and the inclusion distances of the positions with
+// an inclusion slot to their InclusionDistanceSum. A validator has one position per epoch. A nil map skips its count.
+func countAttestationDuties(ctx context.Context, client *http.Service, validatorsByIndex map[phase0.ValidatorIndex]*Validator, included map[attestationKey]bitfield.Bitlist, inclusionSlots map[attestationKey][]uint64, firstSlot, endSlot, slotsPerEpoch uint64, concurrency int) error {
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+	mu := sync.Mutex{}
+	for slot := firstSlot; slot < endSlot; slot += slotsPerEpoch {
+		epoch := slot / slotsPerEpoch
+		stateID := fmt.Sprintf("%d", slot)
+		g.Go(func() error {
+			if err := waitForRequest(ctx); err != nil {
+				return err
+			}
+			committees, err := client.BeaconCommitteesAtEpoch(ctx, stateID, phase0.Epoch(epoch))
+			if err != nil {
+				return fmt.Errorf("error getting committees of epoch %v: %w", epoch, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, c := range committees {
+				key := attestationKey{Slot: uint64(c.Slot), Index: uint64(c.Index)}
+				bits := included[key]
+				slots := inclusionSlots[key]
+				for j, index := range c.Validators {
+					v, exists := validatorsByIndex[index]
+					if !exists {
+						continue
+					}
+					if included != nil && (uint64(j) >= bits.Len() || !bits.BitAt(uint64(j))) {
+						v.MissedAttestations++
+					}
+					if j < len(slots) && slots[j] != 0 {
+						v.InclusionDistanceSum += slots[j] - uint64(c.Slot)
+						v.IncludedAttestations++
+					}
+				}
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// epochSum holds the sums of an epoch that are needed for its apr, see WithEpochBreakdown.
+type epochSum struct {
+	depositsGwei        phase0.Gwei
+	withdrawalsGwei     phase0.Gwei
+	executionRewardsWei *big.Int
+}
+
+// secondsPerYear are the seconds of the year of 365 days the aprs are annualized to.
+const secondsPerYear = 365 * 24 * 3600
+
+// exactAnnualizationDays returns the number of periods of periodSeconds in a year, see WithExactAnnualization.
+func exactAnnualizationDays(periodSeconds uint64) decimal.Decimal {
+	return decimal.NewFromInt(secondsPerYear).Div(decimal.NewFromInt(int64(periodSeconds)))
+}
+
+// periodAnnualizationDays returns the number of periods of periodSlots in a year of 365 days of slotsPerDay, the
+// factor the rewards of a period are annualized with.
+func periodAnnualizationDays(slotsPerDay, periodSlots uint64) decimal.Decimal {
+	return decimal.NewFromInt(365).Mul(decimal.NewFromInt(int64(slotsPerDay))).Div(decimal.NewFromInt(int64(periodSlots)))
+}
+
+// ComputeApr returns the apr of rewards earned on the given effective balance in a day, annualized with
+// annualizationDays (365 in Calculate): annualizationDays * (consensusRewards + txFees) / effectiveBalance, where the
+// consensus rewards are end - start - deposits + withdrawals. txFeesWei are all execution layer rewards, which
+// includes MEV rewards if they are attributed. The apr of an effective balance of 0 is 0.
+func ComputeApr(startBalanceGwei, endBalanceGwei, depositsGwei, withdrawalsGwei phase0.Gwei, txFeesWei *big.Int, effectiveBalanceGwei phase0.Gwei, annualizationDays decimal.Decimal) decimal.Decimal {
+	if effectiveBalanceGwei == 0 {
+		return decimal.Zero
+	}
+	rewardsWei := consensusRewardsGwei(startBalanceGwei, endBalanceGwei, depositsGwei, withdrawalsGwei).Mul(decimal.NewFromInt(1e9))
+	if txFeesWei != nil {
+		rewardsWei = rewardsWei.Add(decimal.NewFromBigInt(txFeesWei, 0))
+	}
+	return annualizationDays.Mul(rewardsWei).Div(gweiToDecimal(effectiveBalanceGwei).Mul(decimal.NewFromInt(1e9)))
+}
+
+// consensusRewardsGwei returns end - start - deposits + withdrawals. The calculation is done with big.Int
+// since casting the summed balances of a large set of validators to int64 would wrap around.
+func consensusRewardsGwei(start, end, deposits, withdrawals phase0.Gwei) decimal.Decimal {
+	r := new(big.Int).SetUint64(uint64(end))
+	r.Sub(r, new(big.Int).SetUint64(uint64(start)))
+	r.Sub(r, new(big.Int).SetUint64(uint64(deposits)))
+	r.Add(r, new(big.Int).SetUint64(uint64(withdrawals)))
+	return decimal.NewFromBigInt(r, 0)
+}
+
+func gweiToDecimal(g phase0.Gwei) decimal.Decimal {
+	return decimal.NewFromBigInt(new(big.Int).SetUint64(uint64(g)), 0)
+}
+
+func syncParticipationRate(participations, duties uint64) decimal.Decimal {
+	if duties == 0 {
+		return decimal.Zero
+	}
+	return decimal.NewFromInt(int64(participations)).Div(decimal.NewFromInt(int64(duties)))
+}
+
+// avgGasUtilization returns the mean gasUsed / gasLimit of blocks blocks whose utilizations add up to sum.
+func avgGasUtilization(sum decimal.Decimal, blocks uint64) decimal.Decimal {
+	if blocks == 0 {
+		return decimal.Zero
+	}
+	return sum.Div(decimal.NewFromInt(int64(blocks)))
+}
+
+// avgInclusionDistance returns the mean inclusion distance of attestations with the sum of their distances, zero if
+// there are none.
+func avgInclusionDistance(sum, attestations uint64) decimal.Decimal {
+	if attestations == 0 {
+		return decimal.Zero
+	}
+	return decimal.NewFromInt(int64(sum)).Div(decimal.NewFromInt(int64(attestations)))
+}
+
+// defaultAprTrimFraction is the fraction of the validators with the highest and the lowest aprs each that
+// Day.AprTrimmedMean discards by default.
+const defaultAprTrimFraction = 0.01
+
+type weightedApr struct {
+	apr    decimal.Decimal
+	weight decimal.Decimal
+}
+
+// trimmedMeanApr returns the weighted mean of aprs without the floor(len(aprs) * trimFraction) highest and lowest
+// aprs, 0 if no apr or no weight is left.
+func trimmedMeanApr(aprs []weightedApr, trimFraction float64) decimal.Decimal {
+	sort.Slice(aprs, func(i, j int) bool { return aprs[i].apr.LessThan(aprs[j].apr) })
+	trim := int(float64(len(aprs)) * trimFraction)
+	weightedSum, weightSum := decimal.Zero, decimal.Zero
+	for _, a := range aprs[trim : len(aprs)-trim] {
+		weightedSum = weightedSum.Add(a.apr.Mul(a.weight))
+		weightSum = weightSum.Add(a.weight)
+	}
+	if weightSum.IsZero() {
+		return decimal.Zero
+	}
+	return weightedSum.Div(weightSum)
+}
+
+// stdDevApr returns the population standard deviation of the (unweighted) aprs, 0 if there are none.
+func stdDevApr(aprs []weightedApr) decimal.Decimal {
+	if len(aprs) == 0 {
+		return decimal.Zero
+	}
+	aprSum := decimal.Zero
+	for _, a := range aprs {
+		aprSum = aprSum.Add(a.apr)
+	}
+	aprMean := aprSum.Div(decimal.NewFromInt(int64(len(aprs))))
+	squaredDeviationsSum := decimal.Zero
+	for _, a := range aprs {
+		deviation := a.apr.Sub(aprMean)
+		squaredDeviationsSum = squaredDeviationsSum.Add(deviation.Mul(deviation))
+	}
+	return sqrt(squaredDeviationsSum.Div(decimal.NewFromInt(int64(len(aprs)))))
+}
+
+// sqrt returns the square root of d with float64 precision, which is plenty for a dispersion. d must not be negative.
+func sqrt(d decimal.Decimal) decimal.Decimal {
+	f, _ := d.Float64()
+	return decimal.NewFromFloat(math.Sqrt(f))
+}
+
+// avgRewardsPerValidatorEth returns the rewards in ETH per validator, zero if there are no validators.
+func avgRewardsPerValidatorEth(totalRewardsWei decimal.Decimal, validators int) decimal.Decimal {
+	if validators == 0 {
+		return decimal.Zero
+	}
+	return totalRewardsWei.Shift(-18).Div(decimal.NewFromInt(int64(validators)))
+}
+
+// apy compounds the daily rate of the given apr: apy = (1 + apr/365)^365 - 1. The apr is the simple annualization
+// of the rewards of the day (365 * rewards / effectiveBalance), it assumes that rewards are not restaked. The apy
+// assumes that the rewards of every day are added to the balance that earns rewards on the next day, so it is
+// always greater than or equal to the apr for positive rates.
+//
+// The daily rate is rounded to decimal.DivisionPrecision digits, the power itself is calculated exactly (by
+// repeated squaring) and the result is rounded to 18 digits. For a daily rate of r the rounding of the daily rate
+// shifts the apy by at most about 365*(1+r)^364 * 10^-DivisionPrecision, which is negligible for realistic rates but
+// grows quickly for very high daily rates. A daily loss of the whole balance or more has no meaningful compounded
+// rate, -1 is returned in that case.
+func apy(apr decimal.Decimal) decimal.Decimal {
+	dailyRate := apr.Div(decimal.NewFromInt(365))
+	if dailyRate.LessThanOrEqual(decimal.NewFromInt(-1)) {
+		return decimal.NewFromInt(-1)
+	}
+	return decimal.NewFromInt(1).Add(dailyRate).Pow(decimal.NewFromInt(365)).Sub(decimal.NewFromInt(1)).Round(18)
+}
+
+// The prefixes of the withdrawal credentials of the spec.
+const (
+	blsWithdrawalPrefix         = uint8(0x00)
+	eth1WithdrawalPrefix        = uint8(0x01)
+	compoundingWithdrawalPrefix = uint8(0x02)
+)
+
+// credentialType returns the prefix of withdrawal credentials, 0x00 (BLS) for malformed credentials.
+func credentialType(credentials []byte) uint8 {
+	if len(credentials) != 32 {
+		return blsWithdrawalPrefix
+	}
+	return credentials[0]
+}
+
+// withdrawalAddress returns the execution address of 0x01 and 0x02 withdrawal credentials (prefix + 11 zero bytes +
+// address) and the zero address for all other credentials.
+func withdrawalAddress(credentials []byte) common.Address {
+	if t := credentialType(credentials); t != eth1WithdrawalPrefix && t != compoundingWithdrawalPrefix {
+		return common.Address{}
+	}
+	return common.BytesToAddress(credentials[12:])
+}
+
+// farFutureEpoch is FAR_FUTURE_EPOCH, the exit epoch of validators that have not initiated an exit.
+const farFutureEpoch = phase0.Epoch(math.MaxUint64)
+
+// isActiveDuring reports whether val is active in every epoch from firstEpoch to lastEpoch (both inclusive).
+// As in the spec a validator is active in epoch e if ActivationEpoch <= e < ExitEpoch, so a validator activated
+// in firstEpoch is included and a validator that exits in lastEpoch is not (it is not active in its exit epoch).
+// The far future sentinel is checked explicitly so that no arithmetic is ever done with it.
+func isActiveDuring(val *phase0.Validator, firstEpoch, lastEpoch phase0.Epoch) bool {
+	if val.ActivationEpoch == farFutureEpoch || val.ActivationEpoch > firstEpoch {
+		return false
+	}
+	return val.ExitEpoch == farFutureEpoch || val.ExitEpoch > lastEpoch
+}
+
+// isActiveEndStatus reports whether status is a possible status at the first slot of the next day of a validator that
+// was active the whole day: it is still active, or it exited in the first epoch of the next day. A validator can
+// only become withdrawable MIN_VALIDATOR_WITHDRAWABILITY_DELAY epochs after its exit and never becomes pending again,
+// so the other statuses mean that the state of the node is inconsistent with the epochs of the validator.
+func isActiveEndStatus(status v1.ValidatorState) bool {
+	switch status {
+	case v1.ValidatorStateActiveOngoing, v1.ValidatorStateActiveExiting, v1.ValidatorStateActiveSlashed, v1.ValidatorStateExitedUnslashed, v1.ValidatorStateExitedSlashed:
+		return true
+	}
+	return false
+}
+
+// validatorChurn returns the number of validators that are active in the end state but not in the start state and
+// the number of validators that are active in the start state but not in the end state.
+func validatorChurn(startValidators, endValidators map[phase0.ValidatorIndex]*v1.Validator) (activated, exited uint64) {
+	for index, val := range endValidators {
+		start, exists := startValidators[index]
+		startActive := exists && start.Status.IsActive()
+		if val.Status.IsActive() && !startActive {
+			activated++
+		}
+		if !val.Status.IsActive() && startActive {
+			exited++
+		}
+	}
+	return activated, exited
+}
+
+// daySlots returns the first slot of day and the first slot not included in it.
+func daySlots(day, slotsPerDay uint64) (firstSlot, endSlot uint64) {
+	return day * slotsPerDay, (day + 1) * slotsPerDay
+}
+
+// DayBounds returns the slots and epochs that day spans with the default boundaries of Calculate (without
+// WithEpochBoundaries and WithPeriodSlots), all bounds are included in the day. It does no request, so it can be used to
+// show the range of a day before or without calculating it. slotsPerEpoch and secondsPerSlot must be positive.
+func DayBounds(day, slotsPerEpoch, secondsPerSlot uint64) (firstSlot, lastSlot, firstEpoch, lastEpoch uint64) {
+	firstSlot, endSlot := daySlots(day, 3600*24/secondsPerSlot)
+	lastSlot = endSlot - 1
+	return firstSlot, lastSlot, firstSlot / slotsPerEpoch, lastSlot / slotsPerEpoch
+}
+
+// DayEpochs returns the epochs from the first to the last epoch of DayBounds, both included, e.g. to request the
+// rewards of every epoch of the day. The first and the last epoch are only partially in the day if the day does not
+// start at an epoch boundary (see DayBounds). slotsPerEpoch and secondsPerSlot must be positive.
+func DayEpochs(day, slotsPerEpoch, secondsPerSlot uint64) []phase0.Epoch {
+	_, _, firstEpoch, lastEpoch := DayBounds(day, slotsPerEpoch, secondsPerSlot)
+	epochs := make([]phase0.Epoch, 0, lastEpoch-firstEpoch+1)
+	for epoch := firstEpoch; epoch <= lastEpoch; epoch++ {
+		epochs = append(epochs, phase0.Epoch(epoch))
+	}
+	return epochs
+}
+
+// alignToEpochs moves the slots of a day to the first slot of the epochs they fall into, so that the day consists of
+// the epochs that start during it.
+func alignToEpochs(firstSlot, endSlot, slotsPerEpoch uint64) (uint64, uint64) {
+	return firstSlot - firstSlot%slotsPerEpoch, endSlot - endSlot%slotsPerEpoch
+}
+
+// CalculateDeposits only scans the blocks of the day for deposits and returns the sum of the valid deposits per
+// validator. Unlike Calculate the result is not restricted to validators that have been active the whole day,
+// it contains deposits to all validators that exist at the end of the day (including newly created ones).
+// Since no balances are compared and no transactions are decoded this is much cheaper than Calculate.
+func CalculateDeposits(ctx context.Context, bnAddress, dayStr string, concurrency int, opts ...Option) (map[phase0.ValidatorIndex]phase0.Gwei, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
 
-				if err == nil {
-					break
-				} else {
-					log.Printf("error retrieving beacon block at slot %v: %v", i, err)
-					time.Sleep(time.Duration(j) * time.Second)
-				}
-			}
+	ctx = withBeaconHeaders(ctx, o)
+	client, err := newConsClient(ctx, bnAddress, o)
+	if err != nil {
+		return nil, err
+	}
+
+	chainSpec, err := getChainSpec(ctx, client, o)
+	if err != nil {
+		return nil, err
+	}
+
+	genesisValidatorsRoot := [32]byte{}
+	depositDomainComputed, err := signing.ComputeDomain(chainSpec.DomainDeposit, chainSpec.GenesisForkVersion[:], genesisValidatorsRoot[:])
+	if err != nil {
+		return nil, err
+	}
+
+	dr, err := resolveRange(ctx, client, dayStr, 3600*24/chainSpec.SecondsPerSlot, o)
+	if err != nil {
+		return nil, err
+	}
+
+	stateClient, err := newStateClient(ctx, client, o)
+	if err != nil {
+		return nil, err
+	}
+	endStateID, exists := dr.StateIDs[dr.EndSlot]
+	if !exists {
+		endStateID = fmt.Sprintf("%d", dr.EndSlot)
+	}
+	endValidators, err := GetValidators(ctx, stateClient, endStateID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting endValidators for endSlot %d: %w", dr.EndSlot, err)
+	}
+	if len(endValidators) == 0 {
+		return nil, fmt.Errorf("%w in state at endSlot %d", ErrNoValidators, dr.EndSlot)
+	}
+	indexByPubkey := make(map[phase0.BLSPubKey]phase0.ValidatorIndex, len(endValidators))
+	for _, val := range endValidators {
+		indexByPubkey[val.Validator.PublicKey] = val.Index
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+	deposits := map[phase0.ValidatorIndex]phase0.Gwei{}
+	depositsMu := sync.Mutex{}
+	seenDeposits := map[phase0.Root]bool{} // guarded by depositsMu
+
+	for i := dr.FirstSlot; i < dr.EndSlot; i++ {
+		i := i
+		g.Go(func() error {
+			block, err := getBlock(ctx, client, i)
 			if err != nil {
-				return fmt.Errorf("error getting block %v: %w", i, err)
+				return &SlotError{Slot: i, Err: err}
 			}
 			if block == nil {
 				return nil
 			}
 			blockData, err := GetBlockData(block)
 			if err != nil {
-				return fmt.Errorf("error getting blockData for block at slot %v: %w", i, err)
+				return &SlotError{Slot: i, Err: fmt.Errorf("error getting blockData: %w", err)}
 			}
 
-			v, exists := validatorsByIndex[blockData.ProposerIndex]
-			// only calculate for validators that have been active the whole day
-			if exists && len(blockData.Transactions) > 0 {
-				txHashes := []common.Hash{}
-				for _, tx := range blockData.Transactions {
-					var decTx gethTypes.Transaction
-					err := decTx.UnmarshalBinary([]byte(tx))
-					if err != nil {
-						return err
-					}
-					txHashes = append(txHashes, decTx.Hash())
-				}
-
-				var txReceipts []*TxReceipt
-				for j := 0; j < 10; j++ { // retry up to 10 times
-					ctx, cancel := context.WithTimeout(context.Background(), GetExecTimeout())
-					txReceipts, err = batchRequestReceipts(ctx, gethRpcClient, txHashes)
-					if err == nil {
-						cancel()
-						break
-					} else {
-						log.Printf("error doing batchRequestReceipts for slot %v: %v", i, err)
-						time.Sleep(time.Duration(j) * time.Second)
-					}
-					cancel()
+			depositsMu.Lock()
+			defer depositsMu.Unlock()
+			for _, d := range blockData.Deposits {
+				if o.depositFilter != nil && !o.depositFilter(d) {
+					continue
 				}
-				if err != nil {
-					return fmt.Errorf("error doing batchRequestReceipts for slot %v: %w", i, err)
+				index, exists := indexByPubkey[d.Data.PublicKey]
+				if !exists {
+					continue
 				}
-
-				totalTxFee := big.NewInt(0)
-				for _, r := range txReceipts {
-					if r.EffectiveGasPrice == nil {
-						return fmt.Errorf("no EffectiveGasPrice for slot %v: %v", i, txHashes)
+				err := checkDeposit(d, depositDomainComputed, seenDeposits)
+				if err != nil {
+					if GetDebugLevel() > 0 {
+						log.Printf("DEBUG eth.store: skipping deposit in block %d: %v", i, err)
 					}
-					txFee := new(big.Int).Mul(r.EffectiveGasPrice.ToInt(), new(big.Int).SetUint64(uint64(r.GasUsed)))
-					totalTxFee.Add(totalTxFee, txFee)
+					continue
 				}
+				deposits[index] += d.Data.Amount
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 
-				// base fee per gas is stored little-endian but we need it
-				// big-endian for big.Int.
-				var baseFeePerGasBEBytes [32]byte
-				for i := 0; i < 32; i++ {
-					baseFeePerGasBEBytes[i] = blockData.BaseFeePerGas[32-1-i]
-				}
-				baseFeePerGas := new(big.Int).SetBytes(baseFeePerGasBEBytes[:])
-				burntFee := new(big.Int).Mul(baseFeePerGas, new(big.Int).SetUint64(blockData.GasUsed))
+	return deposits, nil
+}
+
+// CalculateSlots only fetches the given slots and sums the tx fees paid to their proposers and the valid deposits
+// included in them, which is useful to spot-check the fee contributions of specific blocks. Balance deltas do not
+// apply to a sparse set of slots, so only TxFeesSumWei, TotalRewardsWei (equal to TxFeesSumWei), TotalTxCount,
+// DepositsSumGwei, ProposedBlocks and MissedSlots of the returned day are set, as well as Day and DayTime of the day
+// (or period of WithPeriodSlots) of the first of the slots. Unlike Calculate the fees and deposits are not restricted
+// to validators that have been active the whole day. Duplicate slots are only accounted once.
+func CalculateSlots(ctx context.Context, bnAddress, elAddress string, slots []uint64, concurrency int, opts ...Option) (*Day, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
 
-				totalTxFee.Sub(totalTxFee, burntFee)
+	gethRpcClient, err := gethRPC.Dial(elAddress)
+	if err != nil {
+		return nil, err
+	}
+	blockReceipts := newBlockReceiptsState(o.blockReceipts)
 
-				validatorsMu.Lock()
-				v.TxFeesSumWei.Add(v.TxFeesSumWei, totalTxFee)
-				validatorsMu.Unlock()
+	ctx = withBeaconHeaders(ctx, o)
+	client, err := newConsClient(ctx, bnAddress, o)
+	if err != nil {
+		return nil, err
+	}
+
+	chainSpec, err := getChainSpec(ctx, client, o)
+	if err != nil {
+		return nil, err
+	}
+
+	genesisValidatorsRoot := [32]byte{}
+	depositDomainComputed, err := signing.ComputeDomain(chainSpec.DomainDeposit, chainSpec.GenesisForkVersion[:], genesisValidatorsRoot[:])
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueSlots := make(map[uint64]bool, len(slots))
+	firstSlot := uint64(math.MaxUint64)
+	for _, slot := range slots {
+		uniqueSlots[slot] = true
+		if slot < firstSlot {
+			firstSlot = slot
+		}
+	}
+	if len(slots) == 0 {
+		firstSlot = 0
+	}
+	slotsPerDay := 3600 * 24 / chainSpec.SecondsPerSlot
+	if o.periodSlots != 0 {
+		slotsPerDay = o.periodSlots
+	}
+	day := firstSlot / slotsPerDay
+
+	if err := waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+	genesis, err := client.GenesisTime(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting genesisTime: %w", err)
+	}
+	dayTime := time.Unix(genesis.Unix()+int64(day*slotsPerDay*chainSpec.SecondsPerSlot), 0)
+	if o.reportTimezone != nil {
+		dayTime = dayTime.In(o.reportTimezone)
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+	mu := sync.Mutex{}
+	proposedBlocks := uint64(0)
+	totalTxCount := uint64(0)
+	totalTxFeesSumWei := new(big.Int)
+	var totalDepositsSumGwei phase0.Gwei
+	skippedDeposits := uint64(0)
+	seenDeposits := map[phase0.Root]bool{} // guarded by mu
+
+	for slot := range uniqueSlots {
+		slot := slot
+		g.Go(func() error {
+			block, err := getBlock(ctx, client, slot)
+			if err != nil {
+				return &SlotError{Slot: slot, Err: err}
+			}
+			if block == nil {
+				return nil
+			}
+			blockData, err := GetBlockData(block)
+			if err != nil {
+				return &SlotError{Slot: slot, Err: fmt.Errorf("error getting blockData: %w", err)}
+			}
 
-				if GetDebugLevel() > 1 {
-					log.Printf("DEBUG eth.store: slot: %v, block: %v, baseFee: %v, txFees: %v, burnt: %v\n", i, blockData.BlockNumber, baseFeePerGas, totalTxFee, burntFee)
+			txFee := new(big.Int)
+			if len(blockData.Transactions) > 0 {
+				txFee, _, err = getTxFees(gethRpcClient, blockData, slot, false, blockReceipts, o.feeStrategy, o.txCache)
+				if err != nil {
+					return &SlotError{Slot: slot, Err: err}
 				}
 			}
 
-			validatorsMu.Lock()
-			defer validatorsMu.Unlock()
+			mu.Lock()
+			defer mu.Unlock()
+			proposedBlocks++
+			totalTxFeesSumWei.Add(totalTxFeesSumWei, txFee)
+			totalTxCount += uint64(len(blockData.Transactions))
 			for _, d := range blockData.Deposits {
-				v, exists := validatorsByPubkey[d.Data.PublicKey]
-				if !exists {
-					// only calculate for validators that have been active the whole day
+				if o.depositFilter != nil && !o.depositFilter(d) {
 					continue
 				}
-				msg := &ethpb.Deposit_Data{
-					PublicKey:             d.Data.PublicKey[:],
-					WithdrawalCredentials: d.Data.WithdrawalCredentials,
-					Amount:                uint64(d.Data.Amount),
-					Signature:             d.Data.Signature[:],
-				}
-				err := deposit.VerifyDepositSignature(msg, depositDomainComputed)
+				err := checkDeposit(d, depositDomainComputed, seenDeposits)
 				if err != nil {
 					if GetDebugLevel() > 0 {
-						log.Printf("DEBUG eth.store: invalid deposit signature in block %d: %v", i, err)
+						log.Printf("DEBUG eth.store: skipping deposit in block %d: %v", slot, err)
 					}
+					skippedDeposits++
 					continue
 				}
-				if GetDebugLevel() > 0 {
-					log.Printf("DEBUG eth.store: extra deposit at block %d from %v: %#x: %v\n", i, v.Index, d.Data.PublicKey, d.Data.Amount)
+				totalDepositsSumGwei += d.Data.Amount
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &Day{
+		Day:             decimal.NewFromInt(int64(day)),
+		DayTime:         dayTime,
+		DepositsSumGwei: gweiToDecimal(totalDepositsSumGwei),
+		TxFeesSumWei:    decimal.NewFromBigInt(totalTxFeesSumWei, 0),
+		TotalRewardsWei: decimal.NewFromBigInt(totalTxFeesSumWei, 0),
+		TotalTxCount:    decimal.NewFromInt(int64(totalTxCount)),
+		ProposedBlocks:  decimal.NewFromInt(int64(proposedBlocks)),
+		MissedSlots:     decimal.NewFromInt(int64(uint64(len(uniqueSlots)) - proposedBlocks)),
+		SkippedDeposits: decimal.NewFromInt(int64(skippedDeposits)),
+	}, nil
+}
+
+// CalculateRange calculates eth.store for all days from fromDay to toDay (inclusive). If storage is not nil
+// days that are already stored are loaded instead of being recalculated and calculated days are saved. Storage can not
+// be used with WithPeriodSlots, since it is keyed by calendar day, nor with the options that change the apr of a day
+// (WithNominalBalanceBasis, WithFeeStrategy, WithEpochBoundaries, WithExactAnnualization and WithNegativeAprPolicy),
+// since it is not keyed by them.
+// Since numbered days are only calculated once they are finalized, stored days only have to be recalculated if they
+// were calculated with another MethodologyVersion, which they are (and saved again).
+// Only the days of all validators are returned, pass WithoutValidatorDays to skip building the unused validator days.
+func CalculateRange(ctx context.Context, bnAddress, elAddress string, fromDay, toDay uint64, concurrency int, storage Storage, opts ...Option) ([]*Day, error) {
+	if toDay < fromDay {
+		return nil, fmt.Errorf("invalid range: toDay (%v) < fromDay (%v)", toDay, fromDay)
+	}
+	days := make([]*Day, 0, toDay-fromDay+1)
+	err := CalculateRangeFunc(ctx, bnAddress, elAddress, fromDay, toDay, concurrency, storage, func(d *Day) error {
+		days = append(days, d)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// CalculateRangeFunc is CalculateRange that passes each day to fn as soon as it is loaded or calculated instead of
+// returning all days, so that long ranges can be processed without holding them in memory. The days are passed in
+// order, if fn returns an error no further days are calculated and the error is returned.
+func CalculateRangeFunc(ctx context.Context, bnAddress, elAddress string, fromDay, toDay uint64, concurrency int, storage Storage, fn func(*Day) error, opts ...Option) error {
+	if toDay < fromDay {
+		return fmt.Errorf("invalid range: toDay (%v) < fromDay (%v)", toDay, fromDay)
+	}
+	o, err := newOptions(opts)
+	if err != nil {
+		return err
+	}
+	if storage != nil && o.periodSlots != 0 {
+		return fmt.Errorf("storage is not supported with WithPeriodSlots, stored days are keyed by calendar day")
+	}
+	if storage != nil {
+		if err := checkStorable(o); err != nil {
+			return err
+		}
+	}
+	// with WithRangePrefetch the prefetch started by the calculation of the previous day, a prefetch still running
+	// when the range ends is canceled
+	var prefetched *validatorsPrefetch
+	if o.rangePrefetch {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+	}
+	for dd := fromDay; dd <= toDay; dd++ {
+		var d *Day
+		if storage != nil {
+			var err error
+			d, err = storage.LoadDay(ctx, dd)
+			if err != nil {
+				return fmt.Errorf("error loading day %v from storage: %w", dd, err)
+			}
+			if d != nil && d.MethodologyVersion != MethodologyVersion {
+				log.Printf("WARNING eth.store: recalculating stored day %v of methodology version %q (current: %q)", dd, d.MethodologyVersion, MethodologyVersion)
+				d = nil
+			}
+		}
+		if d == nil {
+			dayOpts := opts[:len(opts):len(opts)]
+			if o.rangePrefetch {
+				next, err := nextDayPrefetch(ctx, storage, dd, toDay)
+				if err != nil {
+					return err
 				}
-				v.DepositsSumGwei += d.Data.Amount
+				dayOpts = append(dayOpts, withValidatorsPrefetch(prefetched, next))
+				prefetched = next
 			}
-			for _, d := range blockData.Withdrawals {
-				v, exists := validatorsByIndex[d.ValidatorIndex]
-				if !exists {
-					// only calculate for validators that have been active the whole day
-					continue
+			var err error
+			d, _, err = Calculate(ctx, bnAddress, elAddress, fmt.Sprintf("%d", dd), concurrency, dayOpts...)
+			if err != nil {
+				return fmt.Errorf("error calculating day %v: %w", dd, err)
+			}
+			if storage != nil {
+				err = storage.SaveDay(ctx, d)
+				if err != nil {
+					return fmt.Errorf("error saving day %v to storage: %w", dd, err)
 				}
-				v.WithdrawalsSumGwei += d.Amount
 			}
+		}
+		if err := fn(d); err != nil {
+			return fmt.Errorf("error processing day %v: %w", dd, err)
+		}
+	}
+	return nil
+}
 
-			return nil
-		})
+// checkStorable returns an error if the days calculated with the options o can not be stored. Stored days are keyed
+// by the day and MethodologyVersion only, so a day calculated with an option that changes its apr would later be
+// loaded for a calculation without the option (or with another value of it).
+func checkStorable(o *options) error {
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"WithNominalBalanceBasis", o.nominalBalanceGwei != 0},
+		{"WithFeeStrategy", o.feeStrategy != nil},
+		{"WithEpochBoundaries", o.epochBoundaries},
+		{"WithExactAnnualization", o.exactAnnualization},
+		{"WithNegativeAprPolicy", o.negativeAprPolicy != NegativeAprAsIs},
+	} {
+		if option.set {
+			return fmt.Errorf("storage is not supported with %v, stored days are not keyed by the options they were calculated with", option.name)
+		}
 	}
-	if err := g.Wait(); err != nil {
-		return nil, nil, err
+	return nil
+}
+
+// nextDayPrefetch returns the prefetch for the day after dd of the range up to toDay, see WithRangePrefetch, or nil if
+// dd is the last day of the range or the next day is loaded from storage (stored with the current MethodologyVersion).
+func nextDayPrefetch(ctx context.Context, storage Storage, dd, toDay uint64) (*validatorsPrefetch, error) {
+	if dd == toDay {
+		return nil, nil
+	}
+	if storage != nil {
+		d, err := storage.LoadDay(ctx, dd+1)
+		if err != nil {
+			return nil, fmt.Errorf("error loading day %v from storage: %w", dd+1, err)
+		}
+		if d != nil && d.MethodologyVersion == MethodologyVersion {
+			return nil, nil
+		}
 	}
+	return newValidatorsPrefetch(), nil
+}
 
-	var totalEffectiveBalanceGwei phase0.Gwei
-	var totalStartBalanceGwei phase0.Gwei
-	var totalEndBalanceGwei phase0.Gwei
-	var totalDepositsSumGwei phase0.Gwei
-	var totalWithdrawalsSumGwei phase0.Gwei
-	totalTxFeesSumWei := new(big.Int)
+// getTxFees returns the fees of the transactions of the block that are paid to the proposer, which are the
+// total fees of the transactions minus the burnt base fee.
+//
+// The block rewards endpoint of the beacon-node-api (/eth/v1/beacon/rewards/blocks/{block_id}) can not replace
+// this: it only reports the consensus layer rewards of the proposer (attestation and sync aggregate inclusion,
+// slashings), which are already part of the balance deltas (see WithProposerRewards), and knows nothing about the
+// execution payload.
+//
+// With attributeMev the value of the last transaction of the block is returned as mevPayment if it is sent to the
+// fee recipient of the block by another address and succeeded, which is how builders commonly pay the proposer.
+// The tip of that transaction is part of the fees as for every other transaction, so nothing is counted twice.
+// Not every such transfer is a builder payment, which is why the attribution is optional.
+//
+// With a feeStrategy (see WithFeeStrategy) the fees are the sum of the fees it attributes to the proposer for the
+// single transactions instead. With a txCache (see WithTxCache) the transactions and receipts of cached blocks are not
+// decoded and requested again. blockReceipts is the state of WithBlockReceipts of the calculation, see fetchTxs.
+func getTxFees(gethRpcClient *gethRPC.Client, blockData *BlockData, slot uint64, attributeMev bool, blockReceipts *blockReceiptsState, feeStrategy FeeStrategy, txCache *TxCache) (fees *big.Int, mevPayment *big.Int, err error) {
+	var txs []*gethTypes.Transaction
+	var txReceipts []*TxReceipt
+	// blocks without block hash (not decoded from a block) are not cached
+	useCache := txCache != nil && blockData.BlockHash != (phase0.Hash32{})
+	var cached *txCacheEntry
+	if useCache {
+		cached, _ = txCache.get(blockData.BlockHash)
+	}
+	if cached != nil {
+		txs, txReceipts = cached.txs, cached.receipts
+	} else {
+		txs, txReceipts, err = fetchTxs(gethRpcClient, blockData, slot, blockReceipts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if useCache {
+			txCache.add(blockData.BlockHash, &txCacheEntry{txs: txs, receipts: txReceipts})
+		}
+	}
+	var lastTx *gethTypes.Transaction
+	if len(txs) > 0 {
+		lastTx = txs[len(txs)-1]
+	}
 
-	ethstorePerValidator := make(map[uint64]*Day, len(validatorsByIndex))
+	// base fee per gas is stored little-endian but we need it
+	// big-endian for big.Int.
+	var baseFeePerGasBEBytes [32]byte
+	for i := 0; i < 32; i++ {
+		baseFeePerGasBEBytes[i] = blockData.BaseFeePerGas[32-1-i]
+	}
+	baseFeePerGas := new(big.Int).SetBytes(baseFeePerGasBEBytes[:])
 
-	for index, v := range validatorsByIndex {
-		totalEffectiveBalanceGwei += v.EffectiveBalanceGwei
-		totalStartBalanceGwei += v.StartBalanceGwei
-		totalEndBalanceGwei += v.EndBalanceGwei
-		totalDepositsSumGwei += v.DepositsSumGwei
-		totalWithdrawalsSumGwei += v.WithdrawalsSumGwei
-		totalTxFeesSumWei.Add(totalTxFeesSumWei, v.TxFeesSumWei)
+	if len(txReceipts) != len(txs) {
+		return nil, nil, fmt.Errorf("got %v receipts for %v transactions for slot %v", len(txReceipts), len(txs), slot)
+	}
+	totalTxFee := big.NewInt(0)
+	if feeStrategy != nil {
+		for i, r := range txReceipts {
+			txFee, err := feeStrategy.ProposerFeeWei(txs[i], baseFeePerGas, r)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error attributing the fee of tx %v for slot %v: %w", txs[i].Hash(), slot, err)
+			}
+			totalTxFee.Add(totalTxFee, txFee)
+		}
+		if GetDebugLevel() > 1 {
+			log.Printf("DEBUG eth.store: slot: %v, block: %v, baseFee: %v, txFees: %v (%T)\n", slot, blockData.BlockNumber, baseFeePerGas, totalTxFee, feeStrategy)
+		}
+	} else {
+		for i, r := range txReceipts {
+			gasPrice := effectiveGasPrice(txs[i], r, baseFeePerGas)
+			if gasPrice == nil {
+				return nil, nil, fmt.Errorf("no EffectiveGasPrice for tx %v of type %v for slot %v", txs[i].Hash(), txs[i].Type(), slot)
+			}
+			txFee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(uint64(r.GasUsed)))
+			totalTxFee.Add(totalTxFee, txFee)
+		}
 
-		validatorConsensusRewardsGwei := decimal.NewFromInt(int64(v.EndBalanceGwei) - int64(v.StartBalanceGwei) - int64(v.DepositsSumGwei) + int64(v.WithdrawalsSumGwei))
-		validatorRewardsWei := decimal.NewFromBigInt(v.TxFeesSumWei, 0).Add(validatorConsensusRewardsGwei.Mul(decimal.NewFromInt(1e9)))
+		burntFee := new(big.Int).Mul(baseFeePerGas, new(big.Int).SetUint64(blockData.GasUsed))
 
-		ethstorePerValidator[uint64(index)] = &Day{
-			Day:                  decimal.NewFromInt(int64(day)),
-			DayTime:              startTime,
-			StartEpoch:           decimal.NewFromInt(int64(firstEpoch)),
-			Apr:                  decimal.NewFromInt(365).Mul(validatorRewardsWei).Div(decimal.NewFromInt(int64(v.EffectiveBalanceGwei)).Mul(decimal.NewFromInt(1e9))),
-			Validators:           decimal.NewFromInt(int64(len(validatorsByIndex))),
-			EffectiveBalanceGwei: decimal.NewFromInt(int64(v.EffectiveBalanceGwei)),
-			StartBalanceGwei:     decimal.NewFromInt(int64(v.StartBalanceGwei)),
-			EndBalanceGwei:       decimal.NewFromInt(int64(v.EndBalanceGwei)),
-			DepositsSumGwei:      decimal.NewFromInt(int64(v.DepositsSumGwei)),
-			TxFeesSumWei:         decimal.NewFromBigInt(v.TxFeesSumWei, 0),
-			ConsensusRewardsGwei: validatorConsensusRewardsGwei,
-			TotalRewardsWei:      validatorRewardsWei,
-			WithdrawalsSumGwei:   decimal.NewFromInt(int64(v.WithdrawalsSumGwei)),
+		totalTxFee.Sub(totalTxFee, burntFee)
+
+		if GetDebugLevel() > 1 {
+			log.Printf("DEBUG eth.store: slot: %v, block: %v, baseFee: %v, txFees: %v, burnt: %v\n", slot, blockData.BlockNumber, baseFeePerGas, totalTxFee, burntFee)
 		}
 	}
 
-	totalConsensusRewardsGwei := decimal.NewFromInt(int64(totalEndBalanceGwei) - int64(totalStartBalanceGwei) - int64(totalDepositsSumGwei) + int64(totalWithdrawalsSumGwei))
-	totalRewardsWei := decimal.NewFromBigInt(totalTxFeesSumWei, 0).Add(totalConsensusRewardsGwei.Mul(decimal.NewFromInt(1e9)))
+	mevPayment = new(big.Int)
+	if attributeMev && lastTx != nil && lastTx.To() != nil && *lastTx.To() == common.Address(blockData.FeeRecipient) {
+		r := txReceipts[len(txReceipts)-1]
+		if r.Status == 1 && (r.From == nil || *r.From != common.Address(blockData.FeeRecipient)) {
+			mevPayment.Set(lastTx.Value())
+			if GetDebugLevel() > 1 {
+				log.Printf("DEBUG eth.store: slot: %v, block: %v, mevPayment: %v by %v\n", slot, blockData.BlockNumber, mevPayment, r.From)
+			}
+		}
+	}
+	return totalTxFee, mevPayment, nil
+}
 
-	ethstoreDay := &Day{
-		Day:                  decimal.NewFromInt(int64(day)),
-		DayTime:              startTime,
-		StartEpoch:           decimal.NewFromInt(int64(firstEpoch)),
-		Apr:                  decimal.NewFromInt(365).Mul(totalRewardsWei).Div(decimal.NewFromInt(int64(totalEffectiveBalanceGwei)).Mul(decimal.NewFromInt(1e9))),
-		Validators:           decimal.NewFromInt(int64(len(validatorsByIndex))),
-		EffectiveBalanceGwei: decimal.NewFromInt(int64(totalEffectiveBalanceGwei)),
-		StartBalanceGwei:     decimal.NewFromInt(int64(totalStartBalanceGwei)),
-		EndBalanceGwei:       decimal.NewFromInt(int64(totalEndBalanceGwei)),
-		DepositsSumGwei:      decimal.NewFromInt(int64(totalDepositsSumGwei)),
-		TxFeesSumWei:         decimal.NewFromBigInt(totalTxFeesSumWei, 0),
-		ConsensusRewardsGwei: totalConsensusRewardsGwei,
-		WithdrawalsSumGwei:   decimal.NewFromInt(int64(totalWithdrawalsSumGwei)),
-		TotalRewardsWei:      totalRewardsWei,
+// fetchTxs decodes the transactions of the execution payload of the block and requests their receipts.
+func fetchTxs(gethRpcClient *gethRPC.Client, blockData *BlockData, slot uint64, blockReceipts *blockReceiptsState) ([]*gethTypes.Transaction, []*TxReceipt, error) {
+	txHashes := []common.Hash{}
+	txs := []*gethTypes.Transaction{}
+	for _, tx := range blockData.Transactions {
+		decTx, err := safeDecodeTx(tx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error decoding tx %v of slot %v: %w", len(txs), slot, err)
+		}
+		txHashes = append(txHashes, decTx.Hash())
+		txs = append(txs, decTx)
 	}
 
-	if GetDebugLevel() > 0 {
-		log.Printf("DEBUG eth.store: %+v\n", ethstoreDay)
+	var txReceipts []*TxReceipt
+	var err error
+	for j := 0; j < 10; j++ { // retry up to 10 times
+		ctx, cancel := context.WithTimeout(context.Background(), GetExecTimeout())
+		useBlockReceipts := blockReceipts.enabled()
+		if useBlockReceipts {
+			txReceipts, err = requestBlockReceipts(ctx, gethRpcClient, blockData.BlockNumber, txHashes)
+			var rpcErr gethRPC.Error
+			if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcMethodNotFound {
+				blockReceipts.setUnsupported()
+				useBlockReceipts = false
+			}
+		}
+		if !useBlockReceipts {
+			txReceipts, err = batchRequestReceipts(ctx, gethRpcClient, txHashes)
+		}
+		if err == nil {
+			cancel()
+			break
+		} else {
+			log.Printf("error doing batchRequestReceipts for slot %v: %v", slot, err)
+			time.Sleep(time.Duration(j) * time.Second)
+		}
+		cancel()
 	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error doing batchRequestReceipts for slot %v: %w", slot, err)
+	}
+	return txs, txReceipts, nil
+}
 
-	return ethstoreDay, ethstorePerValidator, nil
+// Backfill calculates the finalized days that are missing in storage, in order: from the day after the latest stored
+// day (or from fromDay if storage is empty) up to the last finalized day. A service that stores every day can call
+// it on startup to catch up with the days it missed while it was down. Gaps before the latest stored day are not
+// detected, CalculateRange fills those since it skips days that are already stored. Neither are stored days of an
+// older MethodologyVersion, CalculateRange over the stored days recalculates them.
+func Backfill(ctx context.Context, bnAddress, elAddress string, storage Storage, fromDay uint64, concurrency int, opts ...Option) error {
+	o, err := newOptions(opts)
+	if err != nil {
+		return err
+	}
+	if o.periodSlots != 0 {
+		return fmt.Errorf("backfill is not supported with WithPeriodSlots, the finalized day is a calendar day")
+	}
+	if err := checkStorable(o); err != nil {
+		return err
+	}
+	latestDay, found, err := storage.LatestDay(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting latest stored day: %w", err)
+	}
+	if found && latestDay+1 > fromDay {
+		fromDay = latestDay + 1
+	}
+	finalizedDay, err := GetFinalizedDay(ctx, bnAddress)
+	if err != nil {
+		return fmt.Errorf("error getting finalized day: %w", err)
+	}
+	if fromDay > finalizedDay {
+		return nil
+	}
+	if GetDebugLevel() > 0 {
+		log.Printf("DEBUG eth.store: backfilling days %v-%v", fromDay, finalizedDay)
+	}
+	_, err = CalculateRange(ctx, bnAddress, elAddress, fromDay, finalizedDay, concurrency, storage, opts...)
+	return err
 }
 
 func batchRequestReceipts(ctx context.Context, elClient *gethRPC.Client, txHashes []common.Hash) ([]*TxReceipt, error) {
@@ -604,6 +3678,93 @@ func batchRequestReceipts(ctx context.Context, elClient *gethRPC.Client, txHashe
 	return txReceipts, nil
 }
 
+// safeDecodeTx decodes a transaction of an execution payload. The bytes are given by the proposer, so a panic of the
+// decoder on malformed input is recovered and returned as error instead of aborting the calculation.
+func safeDecodeTx(b []byte) (tx *gethTypes.Transaction, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tx = nil
+			err = fmt.Errorf("panic decoding tx: %v", r)
+		}
+	}()
+	tx = &gethTypes.Transaction{}
+	if err := tx.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// effectiveGasPrice returns the price per gas the sender of tx paid. It is taken from the receipt if the execution
+// client returns it (all clients since london do), otherwise it is derived from the transaction by its type: legacy
+// and access list transactions pay their gas price, dynamic fee transactions the base fee plus their tip, capped at
+// their fee cap. Tx.GasPrice can not be used for dynamic fee transactions since it returns the fee cap. It returns
+// nil for unknown transaction types.
+func effectiveGasPrice(tx *gethTypes.Transaction, r *TxReceipt, baseFee *big.Int) *big.Int {
+	if r != nil && r.EffectiveGasPrice != nil {
+		return r.EffectiveGasPrice.ToInt()
+	}
+	switch tx.Type() {
+	case gethTypes.LegacyTxType, gethTypes.AccessListTxType:
+		return tx.GasPrice()
+	case gethTypes.DynamicFeeTxType:
+		price := new(big.Int).Add(baseFee, tx.GasTipCap())
+		if price.Cmp(tx.GasFeeCap()) > 0 {
+			price.Set(tx.GasFeeCap())
+		}
+		return price
+	}
+	return nil
+}
+
+// blockReceiptsState is whether the receipts of the blocks of a calculation are requested with eth_getBlockReceipts,
+// see WithBlockReceipts. Once the execution client does not support the method, the receipts of all further blocks of
+// the calculation are requested with eth_getTransactionReceipt. A nil state always uses eth_getTransactionReceipt.
+type blockReceiptsState struct {
+	unsupported uint32 // accessed atomically
+}
+
+// newBlockReceiptsState returns the state of a calculation, which is nil if WithBlockReceipts is not set.
+func newBlockReceiptsState(blockReceipts bool) *blockReceiptsState {
+	if !blockReceipts {
+		return nil
+	}
+	return &blockReceiptsState{}
+}
+
+func (s *blockReceiptsState) enabled() bool {
+	return s != nil && atomic.LoadUint32(&s.unsupported) == 0
+}
+
+// setUnsupported records that the execution client does not support eth_getBlockReceipts, which is logged once.
+func (s *blockReceiptsState) setUnsupported() {
+	if atomic.CompareAndSwapUint32(&s.unsupported, 0, 1) {
+		log.Printf("WARNING eth.store: eth_getBlockReceipts is not supported by the execution client, falling back to eth_getTransactionReceipt")
+	}
+}
+
+// rpcMethodNotFound is the json-rpc error code of calls to methods that the server does not provide.
+const rpcMethodNotFound = -32601
+
+// requestBlockReceipts fetches all receipts of the block with the given number in a single eth_getBlockReceipts call.
+// The receipts are checked against the hashes of the transactions of the payload, so that receipts of another block
+// at the same height (e.g. if the execution client is on a different fork) are not used.
+func requestBlockReceipts(ctx context.Context, elClient *gethRPC.Client, blockNumber uint64, txHashes []common.Hash) ([]*TxReceipt, error) {
+	txReceipts := []*TxReceipt{}
+	err := elClient.CallContext(ctx, &txReceipts, "eth_getBlockReceipts", hexutil.EncodeUint64(blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("error when fetching block-receipts of block %v: %w", blockNumber, err)
+	}
+	if len(txReceipts) != len(txHashes) {
+		return nil, fmt.Errorf("error when fetching block-receipts of block %v: got %v receipts for %v transactions", blockNumber, len(txReceipts), len(txHashes))
+	}
+	for i, r := range txReceipts {
+		if r.TransactionHash == nil || *r.TransactionHash != txHashes[i] {
+			return nil, fmt.Errorf("error when fetching block-receipts of block %v: receipt %v is for transaction %v instead of %v", blockNumber, i, r.TransactionHash, txHashes[i])
+		}
+	}
+	return txReceipts, nil
+}
+
 type TxReceipt struct {
 	BlockHash         *common.Hash    `json:"blockHash"`
 	BlockNumber       *hexutil.Big    `json:"blockNumber"`
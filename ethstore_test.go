@@ -1,19 +1,37 @@
 package ethstore
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	gethRPC "github.com/ethereum/go-ethereum/rpc"
+	"github.com/prysmaticlabs/go-bitfield"
 	"github.com/shopspring/decimal"
 )
 
@@ -39,6 +57,282 @@ func TestEthstore(t *testing.T) {
 	// - 32e18*29 = sumOfEffectiveBalances = 29 validators have each an effective balance of 32 eth at the start of the eth.store-day
 	// - 0.0621640625 = eth.store-apr = according to the eth.store-calculation validators will earn 6.22% interest in a year
 
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// SetDebugLevel(1)
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 1)
+	if err != nil {
+		t.Error(err)
+	}
+
+	t.Logf("%+v", *day)
+
+	extraDepositsWei := decimal.NewFromInt(32e9).Mul(decimal.NewFromInt(1e9))
+	endWei := decimal.NewFromInt(29 * 320032e5).Mul(decimal.NewFromInt(1e9)).Add(extraDepositsWei)
+	startWei := decimal.NewFromInt(29 * 32e9).Mul(decimal.NewFromInt(1e9))
+	consWei := endWei.Sub(startWei).Sub(extraDepositsWei)
+	execWei := decimal.NewFromInt(29 * 10000 * 225).Mul(decimal.NewFromInt(1e9))
+	eff := decimal.NewFromInt(29 * 32e9).Mul(decimal.NewFromInt(1e9))
+	apr := decimal.NewFromInt(365).Mul(consWei.Add(execWei)).Div(eff)
+
+	if day.Day.String() != "10" {
+		t.Errorf("wrong Day: %v != %v", day.Day.String(), 10)
+	}
+	if !day.Apr.Equal(apr) {
+		t.Errorf("wrong Apr: %v != %v", day.Apr, apr)
+	}
+	if day.Validators.IntPart() != 29 {
+		t.Errorf("wrong Validators: %v != %v", day.Validators, 29)
+	}
+	if day.StartEpoch.IntPart() != 2250 {
+		t.Errorf("wrong StartEpoch: %v != %v", day.StartEpoch, 2250)
+	}
+	if !day.StartBalanceGwei.Equal(startWei.Div(decimal.NewFromInt(1e9))) {
+		t.Errorf("wrong StartBalanceGwei: %v != %v", day.StartBalanceGwei, startWei.Div(decimal.NewFromInt(1e9)))
+	}
+	if !day.EndBalanceGwei.Equal(endWei.Div(decimal.NewFromInt(1e9))) {
+		t.Errorf("wrong EndBalanceGwei: %v != %v", day.EndBalanceGwei, endWei.Div(decimal.NewFromInt(1e9)))
+	}
+	if !day.DepositsSumGwei.Equal(extraDepositsWei.Div(decimal.NewFromInt(1e9))) {
+		t.Errorf("wrong DepositsSumGwei: %v != %v", day.DepositsSumGwei, extraDepositsWei.Div(decimal.NewFromInt(1e9)))
+	}
+	if !day.ConsensusRewardsGwei.Equal(consWei.Div(decimal.NewFromInt(1e9))) {
+		t.Errorf("wrong ConsensusRewardsGwei: %v != %v", day.ConsensusRewardsGwei, 92800000)
+	}
+	if !day.TxFeesSumWei.Equal(execWei) {
+		t.Errorf("wrong TxFeesSumWei: %v != %v", day.TxFeesSumWei, execWei)
+	}
+	if !day.TotalRewardsWei.Equal(consWei.Add(execWei)) {
+		t.Errorf("wrong TotalRewardsWei: %v != %v", day.TotalRewardsWei, consWei.Add(execWei))
+	}
+
+	// every block of the day has the same sync_committee_bits with 415 of 512 bits set
+	syncDuties := decimal.NewFromInt(225 * 32 * 512)
+	syncRate := decimal.NewFromInt(415).Div(decimal.NewFromInt(512))
+	if !day.SyncCommitteeDuties.Equal(syncDuties) {
+		t.Errorf("wrong SyncCommitteeDuties: %v != %v", day.SyncCommitteeDuties, syncDuties)
+	}
+	if !day.SyncParticipationRate.Equal(syncRate) {
+		t.Errorf("wrong SyncParticipationRate: %v != %v", day.SyncParticipationRate, syncRate)
+	}
+	if !validatorDays[5].SyncParticipationRate.Equal(syncRate) {
+		t.Errorf("wrong SyncParticipationRate of validator 5: %v != %v", validatorDays[5].SyncParticipationRate, syncRate)
+	}
+	if !validatorDays[6].SyncCommitteeDuties.IsZero() {
+		t.Errorf("wrong SyncCommitteeDuties of validator 6: %v != %v", validatorDays[6].SyncCommitteeDuties, 0)
+	}
+	scoreSum := decimal.Zero
+	for _, d := range validatorDays {
+		scoreSum = scoreSum.Add(d.PerformanceScore)
+	}
+	if scoreMean := scoreSum.Div(decimal.NewFromInt(int64(len(validatorDays)))); scoreMean.Sub(decimal.NewFromInt(1)).Abs().GreaterThan(decimal.New(1, -12)) {
+		t.Errorf("wrong mean PerformanceScore: %v != %v", scoreMean, 1)
+	}
+	if day.ProposedBlocks.IntPart() != 7200 {
+		t.Errorf("wrong ProposedBlocks: %v != %v", day.ProposedBlocks, 7200)
+	}
+	if !day.MissedSlots.IsZero() {
+		t.Errorf("wrong MissedSlots: %v != %v", day.MissedSlots, 0)
+	}
+}
+
+func TestComputedAt(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.MethodologyVersion != MethodologyVersion || day.ComputedAt.IsZero() || !validatorDays[5].ComputedAt.Equal(day.ComputedAt) {
+		t.Errorf("wrong MethodologyVersion and ComputedAt: %q, %v", day.MethodologyVersion, day.ComputedAt)
+	}
+}
+
+func TestBlockVersions(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(day.BlockVersions) != 1 || day.BlockVersions["bellatrix"] != 7200 || validatorDays[5].BlockVersions != nil {
+		t.Errorf("wrong BlockVersions: %v, %v != map[bellatrix:7200], nil", day.BlockVersions, validatorDays[5].BlockVersions)
+	}
+}
+
+func TestAvgGasUtilization(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every block uses 230800 of 30000000 gas, 29 of the 32 proposers are accounted
+	gasUtilization := decimal.NewFromInt(230800).Div(decimal.NewFromInt(30000000))
+	if day.PayloadBlocks.IntPart() != 7200*29/32 || day.AvgGasUtilization.Sub(gasUtilization).Abs().GreaterThan(decimal.New(1, -12)) {
+		t.Errorf("wrong AvgGasUtilization, PayloadBlocks: %v, %v != %v, %v", day.AvgGasUtilization, day.PayloadBlocks, gasUtilization, 7200*29/32)
+	}
+	if validatorDays[5].PayloadBlocks.IntPart() != 225 || validatorDays[5].AvgGasUtilization.Sub(gasUtilization).Abs().GreaterThan(decimal.New(1, -12)) {
+		t.Errorf("wrong AvgGasUtilization, PayloadBlocks of validator 5: %v, %v != %v, %v", validatorDays[5].AvgGasUtilization, validatorDays[5].PayloadBlocks, gasUtilization, 225)
+	}
+}
+
+func TestConsensusAndExecutionApr(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the consensus rewards of the 29 accounted validators are 0.0032 Eth each, see TestEthstore
+	consensusApr := decimal.NewFromInt(365).Mul(decimal.NewFromInt(29 * 32e5)).Div(decimal.NewFromInt(29 * 32e9))
+	if !day.ConsensusApr.Equal(consensusApr) || !day.ConsensusApr.Add(day.ExecutionApr).Equal(day.Apr) {
+		t.Errorf("wrong ConsensusApr, ExecutionApr: %v, %v != %v, %v", day.ConsensusApr, day.ExecutionApr, consensusApr, day.Apr.Sub(consensusApr))
+	}
+	if !validatorDays[5].ExecutionApr.IsPositive() || !validatorDays[5].ConsensusApr.Add(validatorDays[5].ExecutionApr).Equal(validatorDays[5].Apr) {
+		t.Errorf("wrong ConsensusApr, ExecutionApr of validator 5: %v, %v (apr %v)", validatorDays[5].ConsensusApr, validatorDays[5].ExecutionApr, validatorDays[5].Apr)
+	}
+}
+
+func TestTotalTxCount(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every block has a single transaction
+	if day.TotalTxCount.IntPart() != 29*225 || validatorDays[5].TotalTxCount.IntPart() != 225 {
+		t.Errorf("wrong TotalTxCount: %v, %v of validator 5 != %v, %v", day.TotalTxCount, validatorDays[5].TotalTxCount, 29*225, 225)
+	}
+}
+
+func TestActivatedAndExitedValidators(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// validators 2 and 3 activated and validator 1 exited during the day
+	if day.ActivatedValidators.IntPart() != 2 || day.ExitedValidators.IntPart() != 1 || !validatorDays[5].ActivatedValidators.Equal(day.ActivatedValidators) {
+		t.Errorf("wrong ActivatedValidators, ExitedValidators: %v, %v != %v, %v", day.ActivatedValidators, day.ExitedValidators, 2, 1)
+	}
+}
+
+func TestAvgRewardsPerValidatorEth(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the total rewards of the 29 accounted validators, see TestEthstore
+	if avg := day.TotalRewardsWei.Shift(-18).Div(decimal.NewFromInt(29)); !day.AvgRewardsPerValidatorEth.Equal(avg) {
+		t.Errorf("wrong AvgRewardsPerValidatorEth: %v != %v", day.AvgRewardsPerValidatorEth, avg)
+	}
+	if !validatorDays[5].AvgRewardsPerValidatorEth.Equal(validatorDays[5].TotalRewardsEth()) {
+		t.Errorf("wrong AvgRewardsPerValidatorEth of validator 5: %v != %v", validatorDays[5].AvgRewardsPerValidatorEth, validatorDays[5].TotalRewardsEth())
+	}
+}
+
+func TestBalanceEvents(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the only deposit of an accounted validator is the 32 Eth of validator 4
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithBalanceEvents())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(day.BalanceEvents) != 1 || day.BalanceEvents[0].ValidatorIndex != 4 || day.BalanceEvents[0].Type != BalanceEventDeposit || day.BalanceEvents[0].AmountGwei != 32e9 {
+		t.Errorf("wrong BalanceEvents: %+v", day.BalanceEvents)
+	}
+	// without the option there are none
+	day, _, err = Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.BalanceEvents != nil {
+		t.Errorf("wrong BalanceEvents without WithBalanceEvents: %+v", day.BalanceEvents)
+	}
+}
+
+func TestEthstorePreMerge(t *testing.T) {
+	// same scenario as TestEthstore, but all blocks of the day are pre-merge blocks,
+	// so the apr only consists of consensus rewards: 365 * 29*0.0032e18 / (29*32e18) = 0.0365
+	bnServer, elServer := newTestServers(t, true)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !day.TxFeesSumWei.IsZero() {
+		t.Errorf("wrong TxFeesSumWei: %v != %v", day.TxFeesSumWei, 0)
+	}
+	if !day.TotalRewardsWei.Equal(day.ConsensusRewardsGwei.Mul(decimal.NewFromInt(1e9))) {
+		t.Errorf("wrong TotalRewardsWei: %v != %v", day.TotalRewardsWei, day.ConsensusRewardsGwei.Mul(decimal.NewFromInt(1e9)))
+	}
+	if !day.Apr.Equal(decimal.RequireFromString("0.0365")) {
+		t.Errorf("wrong Apr: %v != %v", day.Apr, "0.0365")
+	}
+
+	// 29 * 0.0032 Eth at 1000.5 per Eth
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 1, WithPriceProvider(func(dayTime time.Time) (decimal.Decimal, error) {
+		if !dayTime.Equal(time.Unix(1606824023+72000*12, 0)) {
+			t.Errorf("wrong time for the price: %v", dayTime)
+		}
+		return decimal.RequireFromString("1000.5"), nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.TotalRewardsFiat.Equal(decimal.RequireFromString("92.8464")) {
+		t.Errorf("wrong TotalRewardsFiat: %v != %v", day.TotalRewardsFiat, "92.8464")
+	}
+	if !validatorDays[6].TotalRewardsFiat.Equal(decimal.RequireFromString("3.2016")) {
+		t.Errorf("wrong TotalRewardsFiat of validator 6: %v != %v", validatorDays[6].TotalRewardsFiat, "3.2016")
+	}
+
+	// all validators have an effective balance of 32 Eth, with a basis of 16 Eth the apr doubles
+	day, validatorDays, err = Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 1, WithNominalBalanceBasis(16e9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Apr.Equal(decimal.RequireFromString("0.073")) {
+		t.Errorf("wrong Apr with nominal balance basis: %v != %v", day.Apr, "0.073")
+	}
+	if !validatorDays[6].Apr.Equal(decimal.RequireFromString("0.073")) {
+		t.Errorf("wrong Apr of validator 6 with nominal balance basis: %v != %v", validatorDays[6].Apr, "0.073")
+	}
+	if !day.EffectiveBalanceGwei.Equal(decimal.NewFromInt(29 * 32e9)) {
+		t.Errorf("wrong EffectiveBalanceGwei with nominal balance basis: %v != %v", day.EffectiveBalanceGwei, 29*32e9)
+	}
+}
+
+// newTestServers returns the mocked beacon-node-api and execution-node-api of the scenario described in TestEthstore.
+// With preMerge all blocks of the day are altair blocks without execution payload.
+func newTestServers(t testing.TB, preMerge bool) (*httptest.Server, *httptest.Server) {
 	mocks := map[string]string{
 		"/eth/v1/beacon/genesis":           `{"data":{"genesis_time":"1606824023","genesis_validators_root":"0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95","genesis_fork_version":"0x00000000"}}`,
 		"/eth/v1/beacon/headers/finalized": `{"data":{"root":"0x3aee29bcfa7a9fdf01394a3dce74ae063c89023df71867ad1555f1e494d138ee","canonical":true,"header":{"message":{"slot":"4485760","proposer_index":"44643","parent_root":"0x4a451b6a4962bcbd619ee1f0b6a7d85dded49f049877de325122e21350e5d6f2","state_root":"0xf12219d8bcdb7ed125da01e4f7aa30754bff2c9fc0bf57dd728c0b02bb847a92","body_root":"0x31f4433e6e260a0fac6e80ad3f9df1998fbbab269408601a6da7a5d32ccbb258"},"signature":"0x8ccb90ff41ec1f82975fb12384f3d44194b27403f1454e878e9c07c9951df33968556e2ce0dfb8ce42e2e0bbac8c80e211d35d01617712292805bc8d9ac2e3429f821953cfc1dbb9d9ea359cd37b39850f4e29c81fc3d67e150985c609d4e826"}}}`,
@@ -46,9 +340,16 @@ func TestEthstore(t *testing.T) {
 		"/eth/v1/config/deposit_contract":  `{"data":{"chain_id":"1","address":"0x00000000219ab540356cbb839cbe05303d7705fa"}}`,
 		"/eth/v1/config/fork_schedule":     `{"data":[{"previous_version":"0x00000000","current_version":"0x00000000","epoch":"0"},{"previous_version":"0x00000000","current_version":"0x01000000","epoch":"74240"}]}`,
 		"/eth/v1/node/version":             `{"data":{"version":"Lighthouse/v2.3.1-564d7da/x86_64-linux"}}`,
+		"/eth/v1/node/syncing":             `{"data":{"head_slot":"4485800","sync_distance":"0","is_syncing":false,"is_optimistic":false}}`,
 		"/eth/v2/beacon/blocks/0":          `{"version":"phase0","data":{"message":{"slot":"0","proposer_index":"0","parent_root":"0x0000000000000000000000000000000000000000000000000000000000000000","state_root":"0x7e76880eb67bbdc86250aa578958e9d0675e64e714337855204fb5abaaf82c2b","body":{"randao_reveal":"0x000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000","eth1_data":{"deposit_root":"0x0000000000000000000000000000000000000000000000000000000000000000","deposit_count":"0","block_hash":"0x0000000000000000000000000000000000000000000000000000000000000000"},"graffiti":"0x0000000000000000000000000000000000000000000000000000000000000000","proposer_slashings":[],"attester_slashings":[],"attestations":[],"deposits":[],"voluntary_exits":[]}},"signature":"0x000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"}}`,
 	}
 
+	// the chain finalized normally during day 10
+	for slot := 72000; slot <= 79200; slot += 32 {
+		epoch := slot / 32
+		mocks[fmt.Sprintf("/eth/v1/beacon/states/%d/finality_checkpoints", slot)] = fmt.Sprintf(`{"data":{"previous_justified":{"epoch":"%d","root":"0x0000000000000000000000000000000000000000000000000000000000000000"},"current_justified":{"epoch":"%d","root":"0x0000000000000000000000000000000000000000000000000000000000000000"},"finalized":{"epoch":"%d","root":"0x0000000000000000000000000000000000000000000000000000000000000000"}}}`, epoch-2, epoch-1, epoch-2)
+	}
+
 	type MockValidator struct {
 		Index     string `json:"index"`
 		Balance   string `json:"balance"`
@@ -142,6 +443,17 @@ func TestEthstore(t *testing.T) {
 		t.Error(err)
 	}
 
+	// all positions of the sync committee are held by validator 5
+	mockSyncCommitteeValidators := make([]string, 512)
+	for i := range mockSyncCommitteeValidators {
+		mockSyncCommitteeValidators[i] = "5"
+	}
+	mockSyncCommitteeJson, err := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"validators": mockSyncCommitteeValidators, "validator_aggregates": [][]string{mockSyncCommitteeValidators[:128], mockSyncCommitteeValidators[128:256], mockSyncCommitteeValidators[256:384], mockSyncCommitteeValidators[384:]}}})
+	if err != nil {
+		t.Error(err)
+	}
+	mocks["/eth/v1/beacon/states/72000/sync_committees"] = string(mockSyncCommitteeJson)
+
 	mocks["/eth/v1/beacon/states/72000/validators"] = string(mockStartValidatorsJson)
 	mocks["/eth/v1/beacon/states/79200/validators"] = string(mockEndValidatorsJson)
 
@@ -194,7 +506,14 @@ func TestEthstore(t *testing.T) {
 				"signature": "0xa70b7440dd48d5b0d11e530c63ba307dfa07a011b695e8f0621555e6af85e365da6f7de39f61ad5f13ee9f8b9d5c10990d52cb993eb5ad2e7f0cf7f96a33bc596444972ca5d99e134bbb166fc720a8ca04f3ee9027756f91afacf8d6603cd392"
 			} }]`
 		}
-		mocks[fmt.Sprintf("/eth/v2/beacon/blocks/%d", i)] = fmt.Sprintf(`{"version":"bellatrix","data":{"message":{"slot":"%d","proposer_index":"%d","parent_root":"0xae77f6e0db57769b5ec6c16c4ef7489ddd47728d98297833b5a1692afc5072cb","state_root":"0x3c900df8e277bade69a1c29a93f9442940fc5e43a96c60dfc33d0f0a54a73af6","body":{"randao_reveal":"0x886b31ed2d6caead1e6632dcaec7edb113789f81dbc101160f903ad72c01429203c15ae75e00bd6987ca5ec79750f9c6040a7805284b24f5b3fa8131579c743e592033de069345ccb4b9a99fd73712d8b2276791847282dbfb7634fcb050ae80","eth1_data":{"deposit_root":"0x9df92d765b5aa041fd4bbe8d5878eb89290efa78e444c1a603eecfae2ea05fa4","deposit_count":"403","block_hash":"0x4d0d1732d9a72d2127ab2ad120e66da738cab3369239ec9debd7aea3b89f9812"},"graffiti":"0x0000000000000000000000000000000000000000000000000000000000000000","proposer_slashings":[],"attester_slashings":[],"attestations":[{"aggregation_bits":"0xf7fa6fffbcbbbf6f","data":{"slot":"357843","index":"0","beacon_block_root":"0xae77f6e0db57769b5ec6c16c4ef7489ddd47728d98297833b5a1692afc5072cb","source":{"epoch":"11181","root":"0xa0d0f93cc58e7e0a6b08c600d2a8054dc41fbadd8aba116e6e8cb1a1870321d0"},"target":{"epoch":"11182","root":"0x82cf146d63ea46194fb6ea4e2c99b244aea76cf8c6546ae09a749a0406d78823"}},"signature":"0xad7d675b775c89fb5c1605f1c91bb595e4feb0a2a0440b23aacfbc6d95daa02e761e8ad48a6cf0dd041d65250a97bf1200e879212f389173cdb2c5792d977411aa44f62eb79e71447f00f2eb02c3aacb4fdc4e939a5d7d01a2198ccdb758b641"}],"deposits":%s,"voluntary_exits":[],"sync_aggregate":{"sync_committee_bits":"0xf74edf53ffdb7f7f7db76efef7fcfb6eff7ffeffbff7f7fddf3f57f7d7fff1b7b7fb3e7bffffff5afe7fffff7fcb437fdffee3efd6dff76df766ffffd7fffff1","sync_committee_signature":"0x98fef94f6488bcb1d1c47517e28683d280c36cfd3caa37403e40a72b0500de7ce84f234760edc17a2bd1031db194570d17af1eb253d4d117f88b39e30ee0ab7c00db268db8369188600a9665708ddd34701840ca1bc1b3c646641b60eda2019d"},"execution_payload":{"parent_hash":"0xca7e7e7fcf3ef35a569c1647d56b11873664e3972d17c5dc339af901230166d5","fee_recipient":"0x8b0c2c4c8eb078bc6c01f48523764c8942c0c6c4","state_root":"0x65ff6f9be55e066f1ed9f5f899752e174c31793034260389316c0ae897483512","receipts_root":"0x1544df33845496bdab8cb97867ec0c6e060ed6690e54c85ae4cb9cc58ddc00dd","logs_bloom":"0x08000000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000200000000000000000000000000004000000000000001002000000000000001000000000000000000000000000000020000000100000000000800000000000000000000000000000000080000000000000000000000000000000000000480000000008000000000000000000000001040000000000000000000000000000000000000000000000000000000000000000000000400000000000000004000000001000000000000000020000000000000000000000000000000000000000000000000000000000000000010","prev_randao":"0x3c3397f7c670538c30a11f6c5733e66af09f9a34ab0ef31b0ffa63314b79099f","block_number":"1663387","gas_limit":"30000000","gas_used":"230800","timestamp":"1660027728","extra_data":"0x","base_fee_per_gas":"10","block_hash":"0x8145108c4ba0bd6507019ee9ef1eaa225daa0fd220bfea44f5e1d3b58c313875","transactions":["%#x"]}}},"signature":"0x8b0c109f0148cd7979bc8101f35e909c8b24e08fbfb0a36491270f2d3889c08b71ab83f59f005eff75272627e569f2d91769524dd5790f918955315534e245ad65423fe45f6fb749d9d4cc593c6f56388eef6c5b123b0f7cb526cbdf7fa053c8"}}`, i, proposer, deposits, createTx(txFeeGweiPerBlock))
+		version := "bellatrix"
+		executionPayload := fmt.Sprintf(`,"execution_payload":{"parent_hash":"0xca7e7e7fcf3ef35a569c1647d56b11873664e3972d17c5dc339af901230166d5","fee_recipient":"0x8b0c2c4c8eb078bc6c01f48523764c8942c0c6c4","state_root":"0x65ff6f9be55e066f1ed9f5f899752e174c31793034260389316c0ae897483512","receipts_root":"0x1544df33845496bdab8cb97867ec0c6e060ed6690e54c85ae4cb9cc58ddc00dd","logs_bloom":"0x08000000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000200000000000000000000000000004000000000000001002000000000000001000000000000000000000000000000020000000100000000000800000000000000000000000000000000080000000000000000000000000000000000000480000000008000000000000000000000001040000000000000000000000000000000000000000000000000000000000000000000000400000000000000004000000001000000000000000020000000000000000000000000000000000000000000000000000000000000000010","prev_randao":"0x3c3397f7c670538c30a11f6c5733e66af09f9a34ab0ef31b0ffa63314b79099f","block_number":"1663387","gas_limit":"30000000","gas_used":"230800","timestamp":"1660027728","extra_data":"0x","base_fee_per_gas":"10","block_hash":"0x8145108c4ba0bd6507019ee9ef1eaa225daa0fd220bfea44f5e1d3b58c313875","transactions":["%#x"]}`, createTx(txFeeGweiPerBlock))
+		if preMerge {
+			// pre-merge blocks have no execution payload
+			version = "altair"
+			executionPayload = ""
+		}
+		mocks[fmt.Sprintf("/eth/v2/beacon/blocks/%d", i)] = fmt.Sprintf(`{"version":"%s","data":{"message":{"slot":"%d","proposer_index":"%d","parent_root":"0xae77f6e0db57769b5ec6c16c4ef7489ddd47728d98297833b5a1692afc5072cb","state_root":"0x3c900df8e277bade69a1c29a93f9442940fc5e43a96c60dfc33d0f0a54a73af6","body":{"randao_reveal":"0x886b31ed2d6caead1e6632dcaec7edb113789f81dbc101160f903ad72c01429203c15ae75e00bd6987ca5ec79750f9c6040a7805284b24f5b3fa8131579c743e592033de069345ccb4b9a99fd73712d8b2276791847282dbfb7634fcb050ae80","eth1_data":{"deposit_root":"0x9df92d765b5aa041fd4bbe8d5878eb89290efa78e444c1a603eecfae2ea05fa4","deposit_count":"403","block_hash":"0x4d0d1732d9a72d2127ab2ad120e66da738cab3369239ec9debd7aea3b89f9812"},"graffiti":"0x0000000000000000000000000000000000000000000000000000000000000000","proposer_slashings":[],"attester_slashings":[],"attestations":[{"aggregation_bits":"0xf7fa6fffbcbbbf6f","data":{"slot":"357843","index":"0","beacon_block_root":"0xae77f6e0db57769b5ec6c16c4ef7489ddd47728d98297833b5a1692afc5072cb","source":{"epoch":"11181","root":"0xa0d0f93cc58e7e0a6b08c600d2a8054dc41fbadd8aba116e6e8cb1a1870321d0"},"target":{"epoch":"11182","root":"0x82cf146d63ea46194fb6ea4e2c99b244aea76cf8c6546ae09a749a0406d78823"}},"signature":"0xad7d675b775c89fb5c1605f1c91bb595e4feb0a2a0440b23aacfbc6d95daa02e761e8ad48a6cf0dd041d65250a97bf1200e879212f389173cdb2c5792d977411aa44f62eb79e71447f00f2eb02c3aacb4fdc4e939a5d7d01a2198ccdb758b641"}],"deposits":%s,"voluntary_exits":[],"sync_aggregate":{"sync_committee_bits":"0xf74edf53ffdb7f7f7db76efef7fcfb6eff7ffeffbff7f7fddf3f57f7d7fff1b7b7fb3e7bffffff5afe7fffff7fcb437fdffee3efd6dff76df766ffffd7fffff1","sync_committee_signature":"0x98fef94f6488bcb1d1c47517e28683d280c36cfd3caa37403e40a72b0500de7ce84f234760edc17a2bd1031db194570d17af1eb253d4d117f88b39e30ee0ab7c00db268db8369188600a9665708ddd34701840ca1bc1b3c646641b60eda2019d"}%s}},"signature":"0x8b0c109f0148cd7979bc8101f35e909c8b24e08fbfb0a36491270f2d3889c08b71ab83f59f005eff75272627e569f2d91769524dd5790f918955315534e245ad65423fe45f6fb749d9d4cc593c6f56388eef6c5b123b0f7cb526cbdf7fa053c8"}}`, version, i, proposer, deposits, executionPayload)
 	}
 
 	bnServer := httptest.NewServer(
@@ -206,7 +525,6 @@ func TestEthstore(t *testing.T) {
 			w.Write([]byte(mock))
 		}),
 	)
-	defer bnServer.Close()
 
 	elServer := httptest.NewServer(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -216,74 +534,4472 @@ func TestEthstore(t *testing.T) {
 			w.Write(d)
 		}),
 	)
-	defer elServer.Close()
 
-	// SetDebugLevel(1)
-	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 1)
-	if err != nil {
-		t.Error(err)
-	}
+	return bnServer, elServer
+}
 
-	t.Logf("%+v", *day)
+func TestCalculateConcurrently(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
 
-	extraDepositsWei := decimal.NewFromInt(32e9).Mul(decimal.NewFromInt(1e9))
-	endWei := decimal.NewFromInt(29 * 320032e5).Mul(decimal.NewFromInt(1e9)).Add(extraDepositsWei)
-	startWei := decimal.NewFromInt(29 * 32e9).Mul(decimal.NewFromInt(1e9))
-	consWei := endWei.Sub(startWei).Sub(extraDepositsWei)
-	execWei := decimal.NewFromInt(29 * 10000 * 225).Mul(decimal.NewFromInt(1e9))
-	eff := decimal.NewFromInt(29 * 32e9).Mul(decimal.NewFromInt(1e9))
-	apr := decimal.NewFromInt(365).Mul(consWei.Add(execWei)).Div(eff)
+	// two calls run at the same time while the package-level settings are changed to values that do not change the
+	// result, run with -race to check the claim of the Calculate doc
+	defer SetRequestsPerSecond(0)
+	defer SetValidatorsChunkSize(0)
+	defer SetConsTimeout(GetConsTimeout())
+	defer SetExecTimeout(GetExecTimeout())
+	done := make(chan struct{})
+	settingsDone := make(chan struct{})
+	go func() {
+		defer close(settingsDone)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+			}
+			SetRequestsPerSecond(float64(1e6 * (i % 2)))
+			SetValidatorsChunkSize(uint64(100 * (i % 2)))
+			SetMaxIndicesPerRequest(0)
+			SetConsTimeout(GetConsTimeout())
+			SetExecTimeout(GetExecTimeout())
+			SetDebugLevel(0)
+		}
+	}()
 
-	if day.Day.String() != "10" {
-		t.Errorf("wrong Day: %v != %v", day.Day.String(), 10)
-	}
-	if !day.Apr.Equal(apr) {
-		t.Errorf("wrong Apr: %v != %v", day.Apr, apr)
+	days := make([]*Day, 2)
+	errs := make([]error, 2)
+	wg := sync.WaitGroup{}
+	for i := range days {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			days[i], _, errs[i] = Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+		}()
 	}
-	if day.Validators.IntPart() != 29 {
-		t.Errorf("wrong Validators: %v != %v", day.Validators, 29)
+	wg.Wait()
+	close(done)
+	<-settingsDone
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
 	}
-	if day.StartEpoch.IntPart() != 2250 {
-		t.Errorf("wrong StartEpoch: %v != %v", day.StartEpoch, 2250)
+	if !days[0].Equal(days[1]) {
+		t.Errorf("concurrent calls returned different days: %v", Diff(days[0], days[1]))
 	}
-	if !day.StartBalanceGwei.Equal(startWei.Div(decimal.NewFromInt(1e9))) {
-		t.Errorf("wrong StartBalanceGwei: %v != %v", day.StartBalanceGwei, startWei.Div(decimal.NewFromInt(1e9)))
+}
+
+func BenchmarkCalculate(b *testing.B) {
+	bnServer, elServer := newTestServers(b, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{"validatorDays", nil},
+		{"withoutValidatorDays", []Option{WithoutValidatorDays()}},
+		{"validatorSets", []Option{WithValidatorSets(map[string][]uint64{"a": {4, 5, 6, 7}, "b": {8, 9, 10, 11}})}},
+		{"orderedBlocks", []Option{WithOrderedBlocks()}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, bm.opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
 	}
-	if !day.EndBalanceGwei.Equal(endWei.Div(decimal.NewFromInt(1e9))) {
-		t.Errorf("wrong EndBalanceGwei: %v != %v", day.EndBalanceGwei, endWei.Div(decimal.NewFromInt(1e9)))
+}
+
+func TestOrderedBlocks(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a node that is slow to serve the block of slot 72010, spread is the largest distance of two slots in flight
+	var mu sync.Mutex
+	inFlight := map[uint64]bool{}
+	spread := uint64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var slot uint64
+		if _, err := fmt.Sscanf(r.URL.Path, "/eth/v2/beacon/blocks/%d", &slot); err != nil {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		mu.Lock()
+		inFlight[slot] = true
+		for other := range inFlight {
+			if slot > other && slot-other > spread {
+				spread = slot - other
+			}
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			delete(inFlight, slot)
+			mu.Unlock()
+		}()
+		if slot == 72010 {
+			time.Sleep(300 * time.Millisecond)
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.RequestURI())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
 	}
-	if !day.DepositsSumGwei.Equal(extraDepositsWei.Div(decimal.NewFromInt(1e9))) {
-		t.Errorf("wrong DepositsSumGwei: %v != %v", day.DepositsSumGwei, extraDepositsWei.Div(decimal.NewFromInt(1e9)))
+	// the other slots overtake the slow one
+	if spread < 4 {
+		t.Errorf("slots in flight should spread behind the slow slot: %v", spread)
 	}
-	if !day.ConsensusRewardsGwei.Equal(consWei.Div(decimal.NewFromInt(1e9))) {
-		t.Errorf("wrong ConsensusRewardsGwei: %v != %v", day.ConsensusRewardsGwei, 92800000)
+
+	mu.Lock()
+	spread = 0
+	mu.Unlock()
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithOrderedBlocks())
+	if err != nil {
+		t.Fatal(err)
 	}
-	if !day.TxFeesSumWei.Equal(execWei) {
-		t.Errorf("wrong TxFeesSumWei: %v != %v", day.TxFeesSumWei, execWei)
+	if spread >= 4 {
+		t.Errorf("slots in flight should be within a window of 4: %v", spread)
 	}
-	if !day.TotalRewardsWei.Equal(consWei.Add(execWei)) {
-		t.Errorf("wrong TotalRewardsWei: %v != %v", day.TotalRewardsWei, consWei.Add(execWei))
+	if !day.Equal(want) {
+		t.Errorf("the order of the blocks changed the day: %v", Diff(day, want))
 	}
 }
 
-func createTx(feeGwei uint64) []byte {
-	privateKey, err := crypto.HexToECDSA("fad9c8855b740a0b7ed4c221dbad0f33a83a49cad6b3fe8d5817ac83d38b6a19")
+func TestMaxInFlightBytes(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
 	if err != nil {
-		log.Fatal(err)
+		t.Fatal(err)
 	}
-	value := big.NewInt(1e18)
-	gasLimit := uint64(feeGwei)
-	toAddress := common.HexToAddress("0x4592d8f8d7b001e72cb26a73e4fa1806a51ac79d")
-	var data []byte
-	tx := types.NewTransaction(1, toAddress, value, gasLimit, new(big.Int).SetInt64(1e9), data)
-	chainID := new(big.Int).SetInt64(11155111)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+	// every block exceeds the limit, so the blocks are throttled as much as possible
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithMaxInFlightBytes(1))
 	if err != nil {
-		log.Fatal(err)
+		t.Fatal(err)
 	}
-	b, err := signedTx.MarshalBinary()
-	if err != nil {
-		log.Fatal(err)
+	if !day.Apr.Equal(want.Apr) || !day.ProposedBlocks.Equal(want.ProposedBlocks) || !day.TxFeesSumWei.Equal(want.TxFeesSumWei) {
+		t.Errorf("wrong day with maxInFlightBytes: %v != %v", day, want)
+	}
+}
+
+func TestCalculateRangeFunc(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	errStop := errors.New("stop")
+	calls := 0
+	err := CalculateRangeFunc(context.Background(), bnServer.URL, elServer.URL, 10, 11, 4, nil, func(d *Day) error {
+		calls++
+		if d.Day.IntPart() != 10 {
+			t.Errorf("wrong Day: %v != %v", d.Day, 10)
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("expected error of the callback, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("no day should be calculated after the callback returned an error, got %v calls", calls)
+	}
+}
+
+// mapStorage is a Storage in memory.
+type mapStorage map[uint64]*Day
+
+func (s mapStorage) SaveDay(ctx context.Context, d *Day) error {
+	s[uint64(d.Day.IntPart())] = d
+	return nil
+}
+
+func (s mapStorage) LoadDay(ctx context.Context, day uint64) (*Day, error) {
+	return s[day], nil
+}
+
+func (s mapStorage) LatestDay(ctx context.Context) (uint64, bool, error) {
+	latest, found := uint64(0), false
+	for day := range s {
+		if !found || day > latest {
+			latest, found = day, true
+		}
+	}
+	return latest, found, nil
+}
+
+func TestStorageOptions(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a stored day is not keyed by the options that change its apr, so they can not be used with storage
+	stored := &Day{Day: decimal.NewFromInt(10), Apr: decimal.RequireFromString("0.5"), MethodologyVersion: MethodologyVersion}
+	for _, opt := range []Option{
+		WithNominalBalanceBasis(32e9),
+		WithFeeStrategy(FullGasFeeStrategy{}),
+		WithEpochBoundaries(),
+		WithExactAnnualization(),
+		WithNegativeAprPolicy(NegativeAprClampZero),
+	} {
+		if _, err := CalculateRange(context.Background(), bnServer.URL, elServer.URL, 10, 10, 4, mapStorage{10: stored}, opt); err == nil {
+			t.Errorf("expected error for storage with an option that changes the apr")
+		}
+		if err := Backfill(context.Background(), bnServer.URL, elServer.URL, mapStorage{}, 10, 4, opt); err == nil {
+			t.Errorf("expected error for backfill with an option that changes the apr")
+		}
+	}
+
+	// without storage the days are calculated with the options
+	days, err := CalculateRange(context.Background(), bnServer.URL, elServer.URL, 10, 10, 4, nil, WithExactAnnualization(), WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 || days[0].Apr.Equal(stored.Apr) {
+		t.Errorf("wrong days: %+v", days)
+	}
+}
+
+func TestStoredMethodologyVersion(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a day of an older methodology is recalculated and saved again, a day of the current one is loaded
+	stale := &Day{Day: decimal.NewFromInt(10), Apr: decimal.RequireFromString("0.5")}
+	storage := mapStorage{10: stale}
+	days, err := CalculateRange(context.Background(), bnServer.URL, elServer.URL, 10, 10, 4, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days[0] == stale || days[0].MethodologyVersion != MethodologyVersion || days[0].ComputedAt.IsZero() || storage[10] != days[0] {
+		t.Errorf("the stale day should have been recalculated: %+v", days[0])
+	}
+
+	current := &Day{Day: decimal.NewFromInt(10), Apr: decimal.RequireFromString("0.5"), MethodologyVersion: MethodologyVersion}
+	storage[10] = current
+	days, err = CalculateRange(context.Background(), bnServer.URL, elServer.URL, 10, 10, 4, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days[0] != current {
+		t.Errorf("the stored day should have been loaded: %+v", days[0])
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a node whose finalized checkpoint is on day 11, so that day 10 is the last finalized day
+	var blockRequests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/eth/v2/beacon/blocks/") {
+			atomic.AddInt64(&blockRequests, 1)
+		}
+		if r.URL.Path != "/eth/v1/beacon/headers/finalized" {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		w.Write([]byte(`{"data":{"root":"0x3aee29bcfa7a9fdf01394a3dce74ae063c89023df71867ad1555f1e494d138ee","canonical":true,"header":{"message":{"slot":"80000","proposer_index":"1","parent_root":"0x4a451b6a4962bcbd619ee1f0b6a7d85dded49f049877de325122e21350e5d6f2","state_root":"0xf12219d8bcdb7ed125da01e4f7aa30754bff2c9fc0bf57dd728c0b02bb847a92","body_root":"0x31f4433e6e260a0fac6e80ad3f9df1998fbbab269408601a6da7a5d32ccbb258"},"signature":"0x8ccb90ff41ec1f82975fb12384f3d44194b27403f1454e878e9c07c9951df33968556e2ce0dfb8ce42e2e0bbac8c80e211d35d01617712292805bc8d9ac2e3429f821953cfc1dbb9d9ea359cd37b39850f4e29c81fc3d67e150985c609d4e826"}}}`))
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the empty storage is filled from fromDay on, a storage with day 9 from the day after it
+	for _, storage := range []mapStorage{{}, {9: &Day{Day: decimal.NewFromInt(9)}}} {
+		if err := Backfill(context.Background(), server.URL, elServer.URL, storage, 10, 4, WithoutValidatorDays()); err != nil {
+			t.Fatal(err)
+		}
+		if storage[10] == nil || !storage[10].Apr.Equal(want.Apr) || !storage[10].TotalRewardsWei.Equal(want.TotalRewardsWei) {
+			t.Errorf("wrong backfilled day 10: %v != %v", storage[10], want)
+		}
+		if storage[11] != nil {
+			t.Errorf("day 11 is not finalized and should not have been calculated")
+		}
+	}
+
+	// nothing is calculated if the latest stored day or fromDay is after the last finalized day
+	stored := &Day{Day: decimal.NewFromInt(10)}
+	for _, test := range []struct {
+		storage mapStorage
+		fromDay uint64
+	}{
+		{storage: mapStorage{10: stored}, fromDay: 0},
+		{storage: mapStorage{}, fromDay: 11},
+	} {
+		atomic.StoreInt64(&blockRequests, 0)
+		if err := Backfill(context.Background(), server.URL, elServer.URL, test.storage, test.fromDay, 4); err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt64(&blockRequests); n != 0 || len(test.storage) > 1 {
+			t.Errorf("from day %v: got %v block requests and stored days %v, want nothing calculated", test.fromDay, n, test.storage)
+		}
+	}
+
+	if err := Backfill(context.Background(), server.URL, elServer.URL, mapStorage{}, 10, 4, WithPeriodSlots(32)); err == nil {
+		t.Errorf("expected error for backfill with WithPeriodSlots")
+	}
+}
+
+func TestRangePrefetch(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// counts the requests of the validators of the end state of day 10, the mock has no state at the end of day 11
+	var endStateRequests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/beacon/states/79200/validators":
+			atomic.AddInt64(&endStateRequests, 1)
+		case "/eth/v1/beacon/states/86400/validators":
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+	client, err := newConsClient(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefetched := newValidatorsPrefetch()
+	prefetched.start(context.Background(), client, "79200")
+	if _, ok := prefetched.get(context.Background(), "79200"); !ok {
+		t.Fatalf("prefetch failed: %v", prefetched.err)
+	}
+	if _, ok := prefetched.get(context.Background(), "72000"); ok {
+		t.Errorf("the prefetch of another state should not be used")
+	}
+	prefetchRequests := atomic.LoadInt64(&endStateRequests)
+
+	next := newValidatorsPrefetch()
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, withValidatorsPrefetch(prefetched, next))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Apr.Equal(want.Apr) || !day.EndBalanceGwei.Equal(want.EndBalanceGwei) {
+		t.Errorf("wrong day with prefetched validators: %v != %v", day, want)
+	}
+	if n := atomic.LoadInt64(&endStateRequests); n != prefetchRequests {
+		t.Errorf("the end state should not be requested again: %v requests after the prefetch", n-prefetchRequests)
+	}
+	// the calculation of day 11 would fetch the state again
+	<-next.done
+	if next.stateID != "86400" || next.err == nil {
+		t.Errorf("wrong prefetch of the next day: %v, %v", next.stateID, next.err)
+	}
+
+	days, err := CalculateRange(context.Background(), bnServer.URL, elServer.URL, 10, 10, 4, nil, WithRangePrefetch())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 || !days[0].Apr.Equal(want.Apr) {
+		t.Errorf("wrong days with WithRangePrefetch: %v", days)
+	}
+}
+
+func TestEffectiveBalanceSlot(t *testing.T) {
+	bnServer, elServer := newTestServers(t, true)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the effective balances at the first slot of day 11 are the same as at the first slot of day 10
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithEffectiveBalanceSlot(7200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.EffectiveBalanceGwei.Equal(decimal.NewFromInt(29*32e9)) || !day.Apr.Equal(decimal.RequireFromString("0.0365")) {
+		t.Errorf("wrong EffectiveBalanceGwei and Apr: %v, %v != %v, %v", day.EffectiveBalanceGwei, day.Apr, 29*32e9, "0.0365")
+	}
+
+	if _, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithEffectiveBalanceSlot(7201)); err == nil {
+		t.Errorf("expected error for effective balance slot after the end of the day")
+	}
+}
+
+func TestEffectiveBalanceSlotValidatorsCache(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+	server := newGenesisServer(t, bnServer, map[uint64]string{0: "32000000000", 32: "32000014000", 64: "32000030000"})
+	defer server.Close()
+
+	if _, _, err := Calculate(context.Background(), server.URL, elServer.URL, "0", 4, WithPeriodSlots(64), WithEffectiveBalanceSlot(32)); err != nil {
+		t.Fatal(err)
+	}
+	// the state of the effective balances does not evict the start and end states of the day from the cache
+	for _, stateID := range []string{"0", "64"} {
+		if !validatorsCacheContains(server.URL + ":" + stateID) {
+			t.Errorf("the validators of state %v are not cached", stateID)
+		}
+	}
+}
+
+func TestTimeWeightedEffectiveBalance(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// with samples every 75 epochs the effective balances are read at the slots 72000, 74400 and 76800. The mock has
+	// no states in between, so they are the start state, with an effective balance of 31 Eth of validator 5 at 74400.
+	var mu sync.Mutex
+	sampledStates := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v1/beacon/states/74400/validators" && r.URL.Path != "/eth/v1/beacon/states/76800/validators" {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		mu.Lock()
+		sampledStates[r.URL.Path] = true
+		mu.Unlock()
+		resp, err := http.Get(bnServer.URL + "/eth/v1/beacon/states/72000/validators")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		vals := struct {
+			Data []map[string]interface{} `json:"data"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&vals); err != nil {
+			t.Error(err)
+			return
+		}
+		if strings.Contains(r.URL.Path, "74400") {
+			vals.Data[5]["validator"].(map[string]interface{})["effective_balance"] = "31000000000"
+		}
+		json.NewEncoder(w).Encode(vals)
+	}))
+	defer server.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithTimeWeightedEffectiveBalance(75))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sampledStates) != 2 {
+		t.Errorf("wrong sampled states: %v", sampledStates)
+	}
+	// (32 + 31 + 32) / 3 Eth
+	if !validatorDays[5].EffectiveBalanceGwei.Equal(decimal.NewFromInt(31666666666)) {
+		t.Errorf("wrong EffectiveBalanceGwei of validator 5: %v != %v", validatorDays[5].EffectiveBalanceGwei, 31666666666)
+	}
+	if !day.EffectiveBalanceGwei.Equal(decimal.NewFromInt(28*32e9 + 31666666666)) {
+		t.Errorf("wrong EffectiveBalanceGwei: %v != %v", day.EffectiveBalanceGwei, 28*32e9+31666666666)
+	}
+
+	// the default is the snapshot at the start of the day, which the proxy does not change
+	day, _, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.EffectiveBalanceGwei.Equal(decimal.NewFromInt(29 * 32e9)) {
+		t.Errorf("wrong EffectiveBalanceGwei without the option: %v != %v", day.EffectiveBalanceGwei, 29*32e9)
+	}
+}
+
+func TestEpochBreakdownValidatorsCache(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+	server := newGenesisServer(t, bnServer, map[uint64]string{0: "32000000000", 32: "32000014000", 64: "32000030000"})
+	defer server.Close()
+
+	if _, _, err := Calculate(context.Background(), server.URL, elServer.URL, "0", 4, WithPeriodSlots(64), WithEpochBreakdown()); err != nil {
+		t.Fatal(err)
+	}
+	// the sample at the epoch boundary does not evict the start and end states of the day from the cache
+	for _, stateID := range []string{"0", "64"} {
+		if !validatorsCacheContains(server.URL + ":" + stateID) {
+			t.Errorf("the validators of state %v are not cached", stateID)
+		}
+	}
+}
+
+// validatorsCacheContains reports whether the cache of GetValidators holds the validators of key, see
+// validatorsCacheKey.
+func validatorsCacheContains(key string) bool {
+	validatorsCacheMu.Lock()
+	defer validatorsCacheMu.Unlock()
+	return validatorsCache != nil && validatorsCache.Contains(key)
+}
+
+func TestEpochBreakdown(t *testing.T) {
+	// the first period of two epochs, see newGenesisServer: every validator earned 0.000014 Eth consensus rewards in
+	// epoch 0 and 0.000016 Eth in epoch 1, the blocks of epoch 0 (without the genesis block) paid 31, the blocks of
+	// epoch 1 32 times 10000 Gwei tx fees
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+	server := newGenesisServer(t, bnServer, map[uint64]string{0: "32000000000", 32: "32000014000", 64: "32000030000"})
+	defer server.Close()
+
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "0", 4, WithPeriodSlots(64), WithEpochBreakdown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each epoch is annualized with 365 * 7200 / 32 epochs per year
+	annualizationDays := decimal.NewFromInt(365 * 7200 / 32)
+	want := []EpochApr{
+		{Epoch: 0, Apr: ComputeApr(33*32e9, 33*32.000014e9, 0, 0, big.NewInt(31*1e13), 33*32e9, annualizationDays)},
+		{Epoch: 1, Apr: ComputeApr(33*32.000014e9, 33*32.00003e9, 0, 0, big.NewInt(32*1e13), 33*32e9, annualizationDays)},
+	}
+	if len(day.EpochBreakdown) != len(want) {
+		t.Fatalf("wrong EpochBreakdown: %+v != %+v", day.EpochBreakdown, want)
+	}
+	aprSum := decimal.Zero
+	for i := range want {
+		if day.EpochBreakdown[i].Epoch != want[i].Epoch || !day.EpochBreakdown[i].Apr.Equal(want[i].Apr) {
+			t.Errorf("wrong apr of epoch %v: %+v != %+v", i, day.EpochBreakdown[i], want[i])
+		}
+		aprSum = aprSum.Add(day.EpochBreakdown[i].Apr)
+	}
+	// the apr of the day is the mean of the aprs of its epochs
+	if aprMean := aprSum.Div(decimal.NewFromInt(2)); aprMean.Sub(day.Apr).Abs().GreaterThan(decimal.New(1, -12)) {
+		t.Errorf("mean apr of the epochs does not match the apr of the day: %v != %v", aprMean, day.Apr)
+	}
+}
+
+func TestArchiveNode(t *testing.T) {
+	bnServer, elServer := newTestServers(t, true)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a pruned node that does not serve any state, and an archive node of another network
+	prunedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/states/") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"state not found"}`))
+			return
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer prunedServer.Close()
+	otherNetworkServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/eth/v1/beacon/genesis" {
+			w.Write([]byte(`{"data":{"genesis_time":"1606824023","genesis_validators_root":"0x0000000000000000000000000000000000000000000000000000000000000001","genesis_fork_version":"0x00000000"}}`))
+			return
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer otherNetworkServer.Close()
+
+	day, _, err := Calculate(context.Background(), prunedServer.URL, elServer.URL, "10", 4, WithArchiveNode(bnServer.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Apr.Equal(decimal.RequireFromString("0.0365")) {
+		t.Errorf("wrong Apr with archive node: %v != %v", day.Apr, "0.0365")
+	}
+
+	if _, _, err := Calculate(context.Background(), prunedServer.URL, elServer.URL, "10", 4, WithArchiveNode(otherNetworkServer.URL)); err == nil {
+		t.Errorf("expected error for archive node of another network")
+	}
+}
+
+// newModifiedValidatorsServer returns a proxy to bnServer that applies modify to the validators of the state at the given
+// slot.
+func newModifiedValidatorsServer(t *testing.T, bnServer *httptest.Server, slot string, modify func(vals []map[string]interface{})) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v1/beacon/states/"+slot+"/validators" {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		vals := struct {
+			Data []map[string]interface{} `json:"data"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&vals); err != nil {
+			t.Error(err)
+			return
+		}
+		modify(vals.Data)
+		json.NewEncoder(w).Encode(vals)
+	}))
+}
+
+func TestAprStdDev(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// all validators earned the same
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.AprStdDev.IsZero() {
+		t.Errorf("wrong AprStdDev: %v != %v", day.AprStdDev, 0)
+	}
+
+	// validator 5 earned 0.0032 Eth more than the other 28 validators, so its apr is 365 * 0.0032 / 32 = 0.0365 higher
+	// and the standard deviation of the 29 aprs is 0.0365 * sqrt(28) / 29
+	server := newModifiedValidatorsServer(t, bnServer, "79200", func(vals []map[string]interface{}) {
+		vals[5]["balance"] = "32006400000"
+	})
+	defer server.Close()
+
+	day, validatorDays, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithValidatorSets(map[string][]uint64{"a": {5, 6, 7}, "b": {8, 9}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := 0.0365 * math.Sqrt(28) / 29
+	if stdDev, _ := day.AprStdDev.Float64(); math.Abs(stdDev-want) > 1e-12 {
+		t.Errorf("wrong AprStdDev: %v != %v", stdDev, want)
+	}
+	// the deviations of the sets are within the set, 0.0365 * sqrt(2) / 3 for validator 5 and two of the others
+	want = 0.0365 * math.Sqrt(2) / 3
+	if stdDev, _ := day.ValidatorSets["a"].AprStdDev.Float64(); math.Abs(stdDev-want) > 1e-12 {
+		t.Errorf("wrong AprStdDev of set a: %v != %v", stdDev, want)
+	}
+	if !day.ValidatorSets["b"].AprStdDev.IsZero() {
+		t.Errorf("wrong AprStdDev of set b: %v != %v", day.ValidatorSets["b"].AprStdDev, 0)
+	}
+	// a single validator has no deviation
+	if !validatorDays[5].AprStdDev.IsZero() {
+		t.Errorf("wrong AprStdDev of validator 5: %v != %v", validatorDays[5].AprStdDev, 0)
+	}
+	// the aprs of the single validators are not calculated without the validator days
+	day, _, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.AprStdDev.IsZero() {
+		t.Errorf("wrong AprStdDev without validator days: %v != %v", day.AprStdDev, 0)
+	}
+}
+
+func TestAprTrimmedMean(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// validator 5 earned more than the other 28 validators, which all have the same apr
+	server := newModifiedValidatorsServer(t, bnServer, "79200", func(vals []map[string]interface{}) {
+		vals[5]["balance"] = "32006400000"
+	})
+	defer server.Close()
+
+	// 1% of 29 validators trims none, so the mean weighted by the effective balances is the apr of the day
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.AprTrimmedMean.Equal(day.Apr) || !validatorDays[6].AprTrimmedMean.IsZero() {
+		t.Errorf("wrong AprTrimmedMean: %v != %v (validator 6: %v)", day.AprTrimmedMean, day.Apr, validatorDays[6].AprTrimmedMean)
+	}
+	// 4% trims the highest (validator 5) and one of the lowest aprs
+	day, validatorDays, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithAprTrimFraction(0.04))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.AprTrimmedMean.Equal(validatorDays[6].Apr) {
+		t.Errorf("wrong AprTrimmedMean without validator 5: %v != %v", day.AprTrimmedMean, validatorDays[6].Apr)
+	}
+	// the sets are trimmed by themselves: 1% of 3 validators trims none, a third trims validator 5 and one of the others
+	sets := WithValidatorSets(map[string][]uint64{"a": {5, 6, 7}})
+	day, validatorDays, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := validatorDays[5].Apr.Add(validatorDays[6].Apr.Mul(decimal.NewFromInt(2))).Div(decimal.NewFromInt(3)); !day.ValidatorSets["a"].AprTrimmedMean.Equal(want) {
+		t.Errorf("wrong AprTrimmedMean of set a: %v != %v", day.ValidatorSets["a"].AprTrimmedMean, want)
+	}
+	day, validatorDays, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, sets, WithAprTrimFraction(0.34))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.ValidatorSets["a"].AprTrimmedMean.Equal(validatorDays[6].Apr) {
+		t.Errorf("wrong trimmed AprTrimmedMean of set a: %v != %v", day.ValidatorSets["a"].AprTrimmedMean, validatorDays[6].Apr)
+	}
+
+	aprs := []weightedApr{
+		{apr: decimal.RequireFromString("0.5"), weight: decimal.NewFromInt(1)},
+		{apr: decimal.RequireFromString("0.04"), weight: decimal.NewFromInt(3)},
+		{apr: decimal.RequireFromString("-1"), weight: decimal.NewFromInt(1)},
+		{apr: decimal.RequireFromString("0.02"), weight: decimal.NewFromInt(1)},
+	}
+	if got := trimmedMeanApr(aprs, 0.25); !got.Equal(decimal.RequireFromString("0.035")) {
+		t.Errorf("wrong trimmedMeanApr: %v != %v", got, 0.035)
+	}
+	if got := trimmedMeanApr(nil, 0.25); !got.IsZero() {
+		t.Errorf("wrong trimmedMeanApr of no aprs: %v != %v", got, 0)
+	}
+}
+
+func TestNegativeAprPolicy(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// validator 5 lost 1 ETH, which its execution rewards do not make up for
+	server := newModifiedValidatorsServer(t, bnServer, "79200", func(vals []map[string]interface{}) {
+		vals[5]["balance"] = "31000000000"
+	})
+	defer server.Close()
+
+	_, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validatorDays[5].Apr.IsNegative() || !validatorDays[6].Apr.IsPositive() {
+		t.Fatalf("expected a negative apr of validator 5 only: %v, %v", validatorDays[5].Apr, validatorDays[6].Apr)
+	}
+	rawConsensusRewards := validatorDays[5].ConsensusRewardsGwei
+	rawApr := validatorDays[6].Apr
+
+	_, validatorDays, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithNegativeAprPolicy(NegativeAprClampZero))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validatorDays[5].Apr.IsZero() || !validatorDays[5].Apy.IsZero() || !validatorDays[5].ConsensusRewardsGwei.Equal(rawConsensusRewards) {
+		t.Errorf("wrong clamped day of validator 5: apr %v, apy %v, consensus rewards %v", validatorDays[5].Apr, validatorDays[5].Apy, validatorDays[5].ConsensusRewardsGwei)
+	}
+	if !validatorDays[5].ConsensusApr.IsZero() || !validatorDays[5].ExecutionApr.IsZero() {
+		t.Errorf("the parts of the clamped apr of validator 5 should be 0: %v, %v", validatorDays[5].ConsensusApr, validatorDays[5].ExecutionApr)
+	}
+	if !validatorDays[6].Apr.Equal(rawApr) {
+		t.Errorf("wrong apr of validator 6: %v != %v", validatorDays[6].Apr, rawApr)
+	}
+
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithNegativeAprPolicy(NegativeAprExclude))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := validatorDays[5]; exists || len(validatorDays) != 28 {
+		t.Errorf("expected the 28 validator days without validator 5, got %v", len(validatorDays))
+	}
+	if !day.Validators.Equal(decimal.NewFromInt(29)) {
+		t.Errorf("wrong validators of the day: %v != %v", day.Validators, 29)
+	}
+}
+
+func TestExitedValidatorWithDeposit(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// validator 4 deposited 32 Eth during day 10 and exits in the last epoch of day 10
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v1/beacon/states/79200/validators" {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		vals := struct {
+			Data []map[string]interface{} `json:"data"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&vals); err != nil {
+			t.Error(err)
+			return
+		}
+		vals.Data[4]["status"] = "active_exiting"
+		vals.Data[4]["validator"].(map[string]interface{})["exit_epoch"] = fmt.Sprintf("%d", 11*225-1)
+		json.NewEncoder(w).Encode(vals)
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the validator is not accounted, neither with its balances nor with its deposit
+	if _, exists := validatorDays[4]; exists || day.Validators.IntPart() != 28 {
+		t.Errorf("validator 4 should not be accounted: %v validators", day.Validators)
+	}
+	if !day.DepositsSumGwei.IsZero() {
+		t.Errorf("wrong DepositsSumGwei: %v != %v", day.DepositsSumGwei, 0)
+	}
+	if !day.ExitedDepositsSumGwei.Equal(decimal.NewFromInt(32e9)) {
+		t.Errorf("wrong ExitedDepositsSumGwei: %v != %v", day.ExitedDepositsSumGwei, 32e9)
+	}
+	if !day.PendingDepositsSumGwei.Equal(want.PendingDepositsSumGwei) {
+		t.Errorf("deposit of an exited validator should not be pending: %v != %v", day.PendingDepositsSumGwei, want.PendingDepositsSumGwei)
+	}
+	if !want.ExitedDepositsSumGwei.IsZero() {
+		t.Errorf("wrong ExitedDepositsSumGwei without exit: %v != %v", want.ExitedDepositsSumGwei, 0)
+	}
+}
+
+func TestActivationDeposits(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// validator 4 existed at the start of the day, so its deposit is a top-up
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.ActivationDepositsSumGwei.IsZero() {
+		t.Errorf("wrong ActivationDepositsSumGwei: %v != %v", day.ActivationDepositsSumGwei, 0)
+	}
+	if !day.TopUpDepositsSumGwei.Equal(decimal.NewFromInt(32e9)) {
+		t.Errorf("wrong TopUpDepositsSumGwei: %v != %v", day.TopUpDepositsSumGwei, 32e9)
+	}
+	if !validatorDays[4].TopUpDepositsSumGwei.Equal(decimal.NewFromInt(32e9)) {
+		t.Errorf("wrong TopUpDepositsSumGwei of validator 4: %v != %v", validatorDays[4].TopUpDepositsSumGwei, 32e9)
+	}
+
+	// validator 4 is created by its deposit during the day, at the start of the day another validator has its index
+	server := newModifiedValidatorsServer(t, bnServer, "72000", func(vals []map[string]interface{}) {
+		vals[4]["status"] = "pending_queued"
+		vals[4]["validator"].(map[string]interface{})["pubkey"] = fmt.Sprintf("%#x", make([]byte, 48))
+		vals[4]["validator"].(map[string]interface{})["activation_epoch"] = fmt.Sprintf("%d", 11*225)
+	})
+	defer server.Close()
+	day, _, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.ActivationDepositsSumGwei.Equal(decimal.NewFromInt(32e9)) || !day.PendingDepositsSumGwei.Equal(decimal.NewFromInt(32e9)) {
+		t.Errorf("wrong ActivationDepositsSumGwei: %v != %v", day.ActivationDepositsSumGwei, 32e9)
+	}
+	if !day.TopUpDepositsSumGwei.IsZero() {
+		t.Errorf("wrong TopUpDepositsSumGwei: %v != %v", day.TopUpDepositsSumGwei, 0)
+	}
+}
+
+func TestPendingDepositsSumGwei(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, wantValidatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !want.PendingDepositsSumGwei.IsZero() || !want.DepositsSumGwei.Equal(decimal.NewFromInt(32e9)) {
+		t.Errorf("the deposit to the active validator 4 should be accounted: %v pending, %v deposits", want.PendingDepositsSumGwei, want.DepositsSumGwei)
+	}
+
+	// validator 4 is in the activation queue for the whole day, so its deposit is pending capital and it is not
+	// accounted
+	server := newModifiedValidatorsServer(t, bnServer, "72000", func(vals []map[string]interface{}) {
+		vals[4]["status"] = "pending_queued"
+		vals[4]["validator"].(map[string]interface{})["activation_epoch"] = fmt.Sprintf("%d", 11*225)
+	})
+	defer server.Close()
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.PendingDepositsSumGwei.Equal(decimal.NewFromInt(32e9)) || !day.DepositsSumGwei.IsZero() {
+		t.Errorf("the deposit to validator 4 should be pending: %v pending, %v deposits", day.PendingDepositsSumGwei, day.DepositsSumGwei)
+	}
+	if _, exists := validatorDays[4]; exists || day.Validators.IntPart() != 28 {
+		t.Errorf("the pending validator 4 should not be accounted: %v validators", day.Validators)
+	}
+	wantRewards := want.ConsensusRewardsGwei.Sub(wantValidatorDays[4].ConsensusRewardsGwei)
+	if !day.ConsensusRewardsGwei.Equal(wantRewards) {
+		t.Errorf("the pending deposit should not change the consensus rewards: %v != %v", day.ConsensusRewardsGwei, wantRewards)
+	}
+}
+
+func TestMinActivationAge(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, wantValidatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// validator 4 (which gets a deposit during the day) was activated 10 epochs before the first epoch of the day
+	server := newModifiedValidatorsServer(t, bnServer, "79200", func(vals []map[string]interface{}) {
+		vals[4]["validator"].(map[string]interface{})["activation_epoch"] = fmt.Sprintf("%d", 10*225-10)
+	})
+	defer server.Close()
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithMinActivationAge(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.Validators.IntPart() != 29 || !day.YoungValidators.IsZero() {
+		t.Errorf("validator 4 is old enough: %v validators, %v young", day.Validators, day.YoungValidators)
+	}
+
+	day, validatorDays, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithMinActivationAge(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := validatorDays[4]; exists || day.Validators.IntPart() != 28 || day.YoungValidators.IntPart() != 1 {
+		t.Errorf("validator 4 should not be accounted: %v validators, %v young", day.Validators, day.YoungValidators)
+	}
+	wantEffectiveBalance := want.EffectiveBalanceGwei.Sub(wantValidatorDays[4].EffectiveBalanceGwei)
+	if !day.EffectiveBalanceGwei.Equal(wantEffectiveBalance) {
+		t.Errorf("wrong EffectiveBalanceGwei: %v != %v", day.EffectiveBalanceGwei, wantEffectiveBalance)
+	}
+	wantRewards := want.ConsensusRewardsGwei.Sub(wantValidatorDays[4].ConsensusRewardsGwei)
+	if !day.ConsensusRewardsGwei.Equal(wantRewards) {
+		t.Errorf("wrong ConsensusRewardsGwei: %v != %v", day.ConsensusRewardsGwei, wantRewards)
+	}
+	if !day.DepositsSumGwei.IsZero() || !day.PendingDepositsSumGwei.Equal(decimal.NewFromInt(32e9)) {
+		t.Errorf("the deposit to validator 4 should be pending: %v, %v", day.DepositsSumGwei, day.PendingDepositsSumGwei)
+	}
+}
+
+func TestValidatorsMissingFromState(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, wantValidatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// at the start of the day index 5 holds another validator: validator 5 only exists at the end of the day, the other
+	// validator only at the start
+	otherPubkey := fmt.Sprintf("%#x", make([]byte, 48))
+	server := newModifiedValidatorsServer(t, bnServer, "72000", func(vals []map[string]interface{}) {
+		vals[5]["validator"].(map[string]interface{})["pubkey"] = otherPubkey
+	})
+	defer server.Close()
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := validatorDays[5]; exists || day.Validators.IntPart() != 28 {
+		t.Errorf("validator 5 should not be accounted: %v validators", day.Validators)
+	}
+	wantRewards := want.ConsensusRewardsGwei.Sub(wantValidatorDays[5].ConsensusRewardsGwei)
+	if !day.ConsensusRewardsGwei.Equal(wantRewards) {
+		t.Errorf("wrong ConsensusRewardsGwei: %v != %v", day.ConsensusRewardsGwei, wantRewards)
+	}
+}
+
+func TestValidatorsPagination(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a node that returns at most 10 validators per response, and one that drops a validator within the response
+	paginatingServer := func(dropIndex string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasSuffix(r.URL.Path, "/validators") {
+				http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+				return
+			}
+			resp, err := http.Get(bnServer.URL + r.URL.Path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			all := struct {
+				Data []map[string]interface{} `json:"data"`
+			}{}
+			if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
+				t.Error(err)
+				return
+			}
+			ids := map[string]bool{}
+			for _, id := range strings.Split(r.URL.Query().Get("id"), ",") {
+				if id != "" {
+					ids[id] = true
+				}
+			}
+			page := []map[string]interface{}{}
+			for _, v := range all.Data {
+				if (len(ids) == 0 || ids[v["index"].(string)]) && v["index"] != dropIndex && len(page) < 10 {
+					page = append(page, v)
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": page})
+		}))
+	}
+	pagesServer := paginatingServer("")
+	defer pagesServer.Close()
+	gapServer := paginatingServer("5")
+	defer gapServer.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, _, err := Calculate(context.Background(), pagesServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Validators.Equal(want.Validators) || !day.Apr.Equal(want.Apr) {
+		t.Errorf("wrong day with paginated validators: %v != %v", day, want)
+	}
+
+	if _, _, err := Calculate(context.Background(), gapServer.URL, elServer.URL, "10", 4); err == nil {
+		t.Errorf("expected error for a validators response with a gap")
+	}
+}
+
+func TestIsPageLimit(t *testing.T) {
+	for n, want := range map[uint64]bool{0: false, 8: false, 10: true, 16: true, 29: false, 100: true, 1024: true, 1000000: true, 1000001: false, 1200000: false} {
+		if got := isPageLimit(n); got != want {
+			t.Errorf("isPageLimit(%v) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestFetchValidatorChunks(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a node that filters the validators by the indices of the url and records the number of validators of every
+	// response
+	var mu sync.Mutex
+	responses := []int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/validators") {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		ids := map[string]bool{}
+		for _, id := range strings.Split(r.URL.Query().Get("id"), ",") {
+			if id != "" {
+				ids[id] = true
+			}
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		all := struct {
+			Data []map[string]interface{} `json:"data"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
+			t.Error(err)
+			return
+		}
+		filtered := []map[string]interface{}{}
+		for _, v := range all.Data {
+			if len(ids) == 0 || ids[v["index"].(string)] {
+				filtered = append(filtered, v)
+			}
+		}
+		mu.Lock()
+		responses = append(responses, len(filtered))
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": filtered})
+	}))
+	defer server.Close()
+
+	client, err := newConsClient(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the chunks of the 33 validators 0-32 overlap by one index and the last one holds at least one validator, also
+	// when 33 is a multiple of the chunk size
+	for _, test := range []struct {
+		chunkSize uint64
+		responses []int
+	}{
+		{chunkSize: 11, responses: []int{11, 11, 11, 3}},
+		{chunkSize: 12, responses: []int{12, 12, 11}},
+		{chunkSize: 17, responses: []int{17, 17, 1}},
+		{chunkSize: 33, responses: []int{33, 1}},
+		{chunkSize: 40, responses: []int{33}},
+	} {
+		responses = []int{}
+		vals, err := fetchValidatorChunks(context.Background(), client, "79200", map[phase0.ValidatorIndex]*v1.Validator{}, 0, test.chunkSize)
+		if err != nil {
+			t.Fatalf("chunk size %v: %v", test.chunkSize, err)
+		}
+		if len(vals) != 33 {
+			t.Errorf("chunk size %v: got %v validators, want 33", test.chunkSize, len(vals))
+		}
+		for i := 0; i < 33; i++ {
+			if vals[phase0.ValidatorIndex(i)] == nil {
+				t.Errorf("chunk size %v: missing validator %v", test.chunkSize, i)
+			}
+		}
+		if fmt.Sprint(responses) != fmt.Sprint(test.responses) {
+			t.Errorf("chunk size %v: got responses with %v validators, want %v", test.chunkSize, responses, test.responses)
+		}
+	}
+}
+
+func TestMaxIndicesPerRequest(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a node that filters the validators by the indices of the url and rejects urls with more than 8 indices
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/validators") {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		ids := map[string]bool{}
+		for _, id := range strings.Split(r.URL.Query().Get("id"), ",") {
+			if id != "" {
+				ids[id] = true
+			}
+		}
+		if len(ids) > 8 {
+			http.Error(w, "URI Too Long", http.StatusRequestURITooLong)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		all := struct {
+			Data []map[string]interface{} `json:"data"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
+			t.Error(err)
+			return
+		}
+		filtered := []map[string]interface{}{}
+		for _, v := range all.Data {
+			if len(ids) == 0 || ids[v["index"].(string)] {
+				filtered = append(filtered, v)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": filtered})
+	}))
+	defer server.Close()
+
+	SetValidatorsChunkSize(20)
+	SetMaxIndicesPerRequest(8)
+	defer SetValidatorsChunkSize(0)
+	defer SetMaxIndicesPerRequest(0)
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Validators.Equal(want.Validators) || !day.Apr.Equal(want.Apr) {
+		t.Errorf("wrong day with at most 8 indices per request: %v != %v", day, want)
+	}
+}
+
+// memoryCheckpointStore keeps the checkpoints in memory and records every saved checkpoint.
+type memoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string][]byte
+	saved       [][]byte
+}
+
+func (s *memoryCheckpointStore) SaveCheckpoint(ctx context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[key] = data
+	s.saved = append(s.saved, data)
+	return nil
+}
+
+func (s *memoryCheckpointStore) LoadCheckpoint(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoints[key], nil
+}
+
+func TestCheckpoint(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	var blockRequests uint64
+	countingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/eth/v2/beacon/blocks/") {
+			atomic.AddUint64(&blockRequests, 1)
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer countingServer.Close()
+
+	store := &memoryCheckpointStore{checkpoints: map[string][]byte{}}
+	day, validatorDays, err := Calculate(context.Background(), countingServer.URL, elServer.URL, "10", 4, WithCheckpoint(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a checkpoint every 1024 slots and one after the last slot
+	if len(store.saved) != 7200/1024+1 {
+		t.Fatalf("wrong number of saved checkpoints: %v != %v", len(store.saved), 7200/1024+1)
+	}
+
+	// resume from the first checkpoint as if the calculation had failed after it
+	for key := range store.checkpoints {
+		store.checkpoints[key] = store.saved[0]
+	}
+	atomic.StoreUint64(&blockRequests, 0)
+	resumedDay, resumedValidatorDays, err := Calculate(context.Background(), countingServer.URL, elServer.URL, "10", 4, WithCheckpoint(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blockRequests != 7200-1024 {
+		t.Errorf("wrong number of block requests when resuming: %v != %v", blockRequests, 7200-1024)
+	}
+	if resumedDay.String() != day.String() || !resumedDay.TxFeesSumWei.Equal(day.TxFeesSumWei) || !resumedDay.DepositsSumGwei.Equal(day.DepositsSumGwei) {
+		t.Errorf("resumed day differs: %v != %v", resumedDay, day)
+	}
+	for index, vd := range validatorDays {
+		if !resumedValidatorDays[index].TotalRewardsWei.Equal(vd.TotalRewardsWei) {
+			t.Errorf("wrong TotalRewardsWei of validator %v when resuming: %v != %v", index, resumedValidatorDays[index].TotalRewardsWei, vd.TotalRewardsWei)
+		}
+	}
+
+	// the last checkpoint skips the whole block loop, checkpoints of other options are ignored
+	atomic.StoreUint64(&blockRequests, 0)
+	if _, _, err := Calculate(context.Background(), countingServer.URL, elServer.URL, "10", 4, WithCheckpoint(store)); err != nil {
+		t.Fatal(err)
+	}
+	if blockRequests != 0 {
+		t.Errorf("wrong number of block requests after the last checkpoint: %v != %v", blockRequests, 0)
+	}
+	atomic.StoreUint64(&blockRequests, 0)
+	if _, _, err := Calculate(context.Background(), countingServer.URL, elServer.URL, "10", 4, WithCheckpoint(store), WithBalanceEvents()); err != nil {
+		t.Fatal(err)
+	}
+	if blockRequests != 7200 {
+		t.Errorf("wrong number of block requests with other options: %v != %v", blockRequests, 7200)
+	}
+}
+
+func TestCheckResumable(t *testing.T) {
+	// checkpoints written with one deposit filter or client classifier must not be resumed with another one
+	if err := checkResumable(&options{depositFilter: func(*phase0.Deposit) bool { return true }}); err == nil {
+		t.Errorf("expected error for checkpoints with a deposit filter")
+	}
+	if err := checkResumable(&options{clientClassifier: func(graffiti [32]byte) string { return "" }}); err == nil {
+		t.Errorf("expected error for checkpoints with a client classifier")
+	}
+	if err := checkResumable(&options{}); err != nil {
+		t.Errorf("checkpoints without functions should be resumable: %v", err)
+	}
+}
+
+// newGenesisServer serves the first day of the chain on top of bnServer: all 33 validators are active from genesis
+// with the balances of the states by slot, and the blocks of day 10 are served as the blocks of day 0 (the block of
+// slot 72000+i at slot i). The genesis block at slot 0 is part of the genesis state and not proposed.
+func newGenesisServer(t *testing.T, bnServer *httptest.Server, balances map[uint64]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/eth/v2/beacon/blocks/"):
+			slot, err := strconv.ParseUint(parts[len(parts)-1], 10, 64)
+			if err != nil || slot == 0 || slot >= 7200 {
+				t.Errorf("unexpected block request: %v", r.URL.Path)
+				http.NotFound(w, r)
+				return
+			}
+			resp, err := http.Get(fmt.Sprintf("%s/eth/v2/beacon/blocks/%d", bnServer.URL, 72000+slot))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			w.Write(bytes.Replace(body, []byte(fmt.Sprintf(`"slot":"%d"`, 72000+slot)), []byte(fmt.Sprintf(`"slot":"%d"`, slot)), 1))
+		case r.URL.Path == "/eth/v1/beacon/states/0/sync_committees":
+			http.Redirect(w, r, bnServer.URL+"/eth/v1/beacon/states/72000/sync_committees", http.StatusTemporaryRedirect)
+		case strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/states/") && strings.HasSuffix(r.URL.Path, "/validators"):
+			slot, err := strconv.ParseUint(parts[5], 10, 64)
+			balance, exists := balances[slot]
+			if err != nil || !exists {
+				t.Errorf("unexpected state request: %v", r.URL.Path)
+				http.NotFound(w, r)
+				return
+			}
+			resp, err := http.Get(bnServer.URL + "/eth/v1/beacon/states/79200/validators")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			vals := struct {
+				Data []map[string]interface{} `json:"data"`
+			}{}
+			if err := json.NewDecoder(resp.Body).Decode(&vals); err != nil {
+				t.Error(err)
+				return
+			}
+			// all validators are copies of validator 5, which is active the whole day 10
+			template, err := json.Marshal(vals.Data[5])
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for i := range vals.Data {
+				v := map[string]interface{}{}
+				if err := json.Unmarshal(template, &v); err != nil {
+					t.Error(err)
+					return
+				}
+				v["index"] = fmt.Sprintf("%d", i)
+				v["balance"] = balance
+				v["validator"].(map[string]interface{})["pubkey"] = fmt.Sprintf("%#096x", i)
+				v["validator"].(map[string]interface{})["activation_eligibility_epoch"] = "0"
+				vals.Data[i] = v
+			}
+			json.NewEncoder(w).Encode(vals)
+		default:
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+		}
+	}))
+}
+
+func TestGenesisDay(t *testing.T) {
+	// day 0: all 33 validators are active from genesis with 32 Eth and earned 0.002 Eth consensus rewards each, the
+	// blocks at the slots 1-7199 are proposed and the genesis block is neither proposed nor missed
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+	server := newGenesisServer(t, bnServer, map[uint64]string{0: "32000000000", 7200: "32002000000"})
+	defer server.Close()
+
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "0", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Day.IsZero() || !day.DayTime.Equal(time.Unix(1606824023, 0)) {
+		t.Errorf("wrong Day and DayTime: %v, %v != 0, %v", day.Day, day.DayTime, time.Unix(1606824023, 0))
+	}
+	if day.Validators.IntPart() != 33 {
+		t.Errorf("wrong Validators: %v != %v", day.Validators, 33)
+	}
+	if !day.StartBalanceGwei.Equal(decimal.NewFromInt(33 * 32e9)) {
+		t.Errorf("wrong StartBalanceGwei: %v != %v", day.StartBalanceGwei, 33*32e9)
+	}
+	if !day.ConsensusRewardsGwei.Equal(decimal.NewFromInt(33 * 2e6)) {
+		t.Errorf("wrong ConsensusRewardsGwei: %v != %v", day.ConsensusRewardsGwei, 33*2e6)
+	}
+	if day.ProposedBlocks.IntPart() != 7199 || !day.MissedSlots.IsZero() {
+		t.Errorf("wrong ProposedBlocks and MissedSlots: %v, %v != %v, %v", day.ProposedBlocks, day.MissedSlots, 7199, 0)
+	}
+	if !day.TxFeesSumWei.Equal(decimal.NewFromInt(7199 * 10000 * 1e9)) {
+		t.Errorf("wrong TxFeesSumWei: %v != %v", day.TxFeesSumWei, 7199*10000*1e9)
+	}
+}
+
+func TestDayInFuture(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the finalized slot 4485760 is in day 623, so day 622 is the last complete day
+	_, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "623", 1)
+	if !errors.Is(err, ErrDayInFuture) || !strings.Contains(err.Error(), "last finalized day: 622") {
+		t.Errorf("expected ErrDayInFuture with the last finalized day 622, got %v", err)
+	}
+}
+
+func TestStateRange(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// day 10 is the range of the states at its first slot and at the first slot of day 11
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "", 1, WithStateRange("72000", "79200"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Day.Equal(want.Day) || !day.Apr.Equal(want.Apr) || !day.TotalRewardsWei.Equal(want.TotalRewardsWei) || !day.Validators.Equal(want.Validators) {
+		t.Errorf("wrong day for the state range: %v, %v, %v, %v != %v, %v, %v, %v", day.Day, day.Apr, day.TotalRewardsWei, day.Validators, want.Day, want.Apr, want.TotalRewardsWei, want.Validators)
+	}
+
+	_, _, err = Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 1, WithStateRange("72000", "79200"))
+	if err == nil {
+		t.Errorf("expected error for a day with WithStateRange")
+	}
+	_, _, err = Calculate(context.Background(), bnServer.URL, elServer.URL, "", 1, WithStateRange("79200", "72000"))
+	if err == nil || !strings.Contains(err.Error(), "is not after start state") {
+		t.Errorf("expected error for an end state before the start state, got %v", err)
+	}
+	_, _, err = Calculate(context.Background(), bnServer.URL, elServer.URL, "", 1, WithStateRange("72000", "4485761"))
+	if err == nil || !strings.Contains(err.Error(), "after the finalized slot") {
+		t.Errorf("expected error for an end state after the finalized checkpoint, got %v", err)
+	}
+}
+
+func TestResolveDayAtDayBoundary(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// proxy to the mocked beacon-node-api that replaces the slot of the finalized header
+	finalizedSlot := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if r.URL.Path == "/eth/v1/beacon/headers/finalized" {
+			body = bytes.Replace(body, []byte(`"slot":"4485760"`), []byte(fmt.Sprintf(`"slot":"%s"`, finalizedSlot)), 1)
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client, err := newConsClient(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the finalized slot is the first slot of day 11, so day 10 is complete
+	finalizedSlot = "79200"
+	dr, err := resolveDay(context.Background(), client, "10", 7200)
+	if err != nil {
+		t.Errorf("day 10 should be complete at slot 79200: %v", err)
+	} else if dr.EndSlot != 79200 {
+		t.Errorf("wrong EndSlot: %v != %v", dr.EndSlot, 79200)
+	}
+	if _, err := resolveDay(context.Background(), client, "11", 7200); err == nil {
+		t.Errorf("day 11 should not be complete at slot 79200")
+	}
+
+	// the finalized slot is the last slot of day 10, the state at the first slot of day 11 is not finalized yet
+	finalizedSlot = "79199"
+	if _, err := resolveDay(context.Background(), client, "10", 7200); err == nil {
+		t.Errorf("day 10 should not be complete at slot 79199")
+	}
+	dr, err = resolveDay(context.Background(), client, "finalized", 7200)
+	if err != nil {
+		t.Error(err)
+	} else if dr.Day != 9 {
+		t.Errorf("wrong day for finalized: %v != %v", dr.Day, 9)
+	}
+
+	// no day is complete before the first slot of day 1
+	finalizedSlot = "7199"
+	if _, err := resolveDay(context.Background(), client, "finalized", 7200); err == nil {
+		t.Errorf("expected error if no day is complete")
+	}
+}
+
+func TestPossiblyReorged(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.PossiblyReorged {
+		t.Errorf("a day anchored at the finalized checkpoint can not be reorged")
+	}
+
+	// the justified block at slot 79232 anchors day 10, when the day has been calculated the node reports the block
+	// as canonical, as orphaned or not at all
+	anchorRoot := "0x" + strings.Repeat("aa", 32)
+	header := func(canonical bool) string {
+		return fmt.Sprintf(`{"data":{"root":"%s","canonical":%v,"header":{"message":{"slot":"79232","proposer_index":"1","parent_root":"0x%s","state_root":"0x%s","body_root":"0x%s"},"signature":"0x%s"}}}`, anchorRoot, canonical, strings.Repeat("bb", 32), strings.Repeat("cc", 32), strings.Repeat("dd", 32), strings.Repeat("ee", 96))
+	}
+	for _, test := range []struct {
+		status string
+		want   bool
+	}{
+		{status: "canonical", want: false},
+		{status: "orphaned", want: true},
+		{status: "unknown", want: true},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/eth/v1/beacon/headers/justified":
+				w.Write([]byte(header(true)))
+			case "/eth/v1/beacon/headers/" + anchorRoot:
+				switch test.status {
+				case "canonical":
+					w.Write([]byte(header(true)))
+				case "orphaned":
+					w.Write([]byte(header(false)))
+				default:
+					http.NotFound(w, r)
+				}
+			default:
+				http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			}
+		}))
+		day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "justified", 4, WithoutValidatorDays())
+		server.Close()
+		if err != nil {
+			t.Fatalf("%v anchor: %v", test.status, err)
+		}
+		if day.PossiblyReorged != test.want {
+			t.Errorf("%v anchor: got PossiblyReorged %v, want %v", test.status, day.PossiblyReorged, test.want)
+		}
+		if !day.Day.Equal(want.Day) || !day.TotalRewardsWei.Equal(want.TotalRewardsWei) {
+			t.Errorf("%v anchor: wrong day %v != %v", test.status, day, want)
+		}
+	}
+}
+
+func TestCanonicalChainPinsStates(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a chain with a block at every slot, the block roots start with 0xaa and the state roots with 0xbb followed by
+	// the slot. The justified block is at slot 79232 when the day is resolved, then the head of the node moves to
+	// another fork, on which the states by slot differ, which the calculation must not query anymore.
+	root := func(prefix string, slot uint64) string { return fmt.Sprintf("0x%s%062x", prefix, slot) }
+	slotOf := func(id, prefix string) (uint64, bool) {
+		if len(id) != 66 || !strings.HasPrefix(id, "0x"+prefix) {
+			return 0, false
+		}
+		slot, err := strconv.ParseUint(id[4:], 16, 64)
+		return slot, err == nil
+	}
+	header := func(slot uint64) string {
+		return fmt.Sprintf(`{"data":{"root":"%s","canonical":true,"header":{"message":{"slot":"%d","proposer_index":"1","parent_root":"%s","state_root":"%s","body_root":"%#064x"},"signature":"%#0192x"}}}`, root("aa", slot), slot, root("aa", slot-1), root("bb", slot), 0, 0)
+	}
+	var justifiedRequests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		switch {
+		case r.URL.Path == "/eth/v1/beacon/headers/justified":
+			if atomic.AddInt64(&justifiedRequests, 1) > 1 {
+				t.Errorf("the anchor was resolved again")
+			}
+			w.Write([]byte(header(79232)))
+		case strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/headers/"):
+			if slot, err := strconv.ParseUint(parts[len(parts)-1], 10, 64); err == nil {
+				// the headers by slot are of the other fork, their roots start with 0xcc
+				w.Write([]byte(strings.ReplaceAll(header(slot), "0xaa", "0xcc")))
+				return
+			}
+			slot, ok := slotOf(parts[len(parts)-1], "aa")
+			if !ok {
+				t.Errorf("unexpected header request: %v", r.URL.Path)
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(header(slot)))
+		case strings.HasPrefix(r.URL.Path, "/eth/v2/beacon/blocks/0x"):
+			slot, ok := slotOf(parts[len(parts)-1], "aa")
+			if !ok {
+				t.Errorf("unexpected block request: %v", r.URL.Path)
+				http.NotFound(w, r)
+				return
+			}
+			http.Redirect(w, r, fmt.Sprintf("%s/eth/v2/beacon/blocks/%d", bnServer.URL, slot), http.StatusTemporaryRedirect)
+		case strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/states/"):
+			slot, ok := slotOf(parts[5], "bb")
+			if !ok {
+				t.Errorf("state requested by slot after the head moved: %v", r.URL.Path)
+				http.Error(w, "state of another fork", http.StatusInternalServerError)
+				return
+			}
+			parts[5] = fmt.Sprintf("%d", slot)
+			http.Redirect(w, r, bnServer.URL+strings.Join(parts, "/"), http.StatusTemporaryRedirect)
+		default:
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+		}
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "justified", 4, WithCanonicalChain(), WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Day.Equal(want.Day) || !day.Apr.Equal(want.Apr) || !day.TotalRewardsWei.Equal(want.TotalRewardsWei) || day.PossiblyReorged {
+		t.Errorf("wrong day on the pinned chain: %v != %v", day, want)
+	}
+}
+
+func TestCheckFinalized(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	header := func(slot uint64, root byte) string {
+		return fmt.Sprintf(`{"data":{"root":"%#064x","canonical":true,"header":{"message":{"slot":"%d","proposer_index":"1","parent_root":"%#064x","state_root":"%#064x","body_root":"%#064x"},"signature":"%#0192x"}}}`, root, slot, 0, 0, 0, 0)
+	}
+	// the chain is finalized up to slot 79264, the canonical block at slot 79232 has the root 0x..01
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/beacon/headers/finalized":
+			w.Write([]byte(header(79264, 2)))
+		case "/eth/v1/beacon/headers/79232":
+			w.Write([]byte(header(79232, 1)))
+		default:
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+		}
+	}))
+	defer server.Close()
+	client, err := newConsClient(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anchor := func(root byte) *v1.BeaconBlockHeader {
+		return &v1.BeaconBlockHeader{Root: phase0.Root{31: root}, Header: &phase0.SignedBeaconBlockHeader{Message: &phase0.BeaconBlockHeader{Slot: 79232}}}
+	}
+	if err := checkFinalized(context.Background(), client, anchor(1), 79232); err != nil {
+		t.Errorf("day ending at the canonical anchor before the finalized slot should be finalized: %v", err)
+	}
+	if err := checkFinalized(context.Background(), client, anchor(1), 79265); err == nil {
+		t.Errorf("expected error for a day that ends after the finalized slot")
+	}
+	if err := checkFinalized(context.Background(), client, anchor(3), 79232); err == nil {
+		t.Errorf("expected error for an anchor that is not canonical")
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	report, err := Preflight(context.Background(), bnServer.URL, "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || !report.Synced || !report.StatesAvailable || report.FirstSlot != 72000 || report.EndSlot != 79200 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	if _, err := Preflight(context.Background(), bnServer.URL, "yesterday"); err == nil {
+		t.Errorf("expected error for invalid day")
+	}
+
+	report, err = Preflight(context.Background(), "http://localhost:0", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || report.Reachable {
+		t.Errorf("unreachable node should be reported: %+v", report)
+	}
+}
+
+func TestAvailableDays(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a node that serves the states from the first slot of day 10 on
+	syncing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var slot uint64
+		if _, err := fmt.Sscanf(r.URL.Path, "/eth/v1/beacon/states/%d/finality_checkpoints", &slot); err == nil {
+			if slot < 72000 {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, `{"data":{"previous_justified":{"epoch":"0","root":"0x0000000000000000000000000000000000000000000000000000000000000000"},"current_justified":{"epoch":"0","root":"0x0000000000000000000000000000000000000000000000000000000000000000"},"finalized":{"epoch":"0","root":"0x0000000000000000000000000000000000000000000000000000000000000000"}}}`)
+			return
+		}
+		if r.URL.Path == "/eth/v1/node/syncing" && syncing {
+			fmt.Fprint(w, `{"data":{"head_slot":"4485800","sync_distance":"64","is_syncing":true,"is_optimistic":false}}`)
+			return
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	// the finalized checkpoint of the mock is at slot 4485760, in day 623
+	firstDay, lastDay, err := AvailableDays(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firstDay != 10 || lastDay != 622 {
+		t.Errorf("wrong available days: %v-%v != %v-%v", firstDay, lastDay, 10, 622)
+	}
+
+	syncing = true
+	if _, _, err := AvailableDays(context.Background(), server.URL); err == nil || !strings.Contains(err.Error(), "syncing") {
+		t.Errorf("expected error for a syncing node, got %v", err)
+	}
+}
+
+func TestMevLastTxAttribution(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	elClient, err := gethRPC.Dial(elServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last (and only) transaction sends 1 Eth to the fee recipient
+	blockData := &BlockData{
+		Transactions: []bellatrix.Transaction{createTx(10000)},
+		FeeRecipient: bellatrix.ExecutionAddress(common.HexToAddress("0x4592d8f8d7b001e72cb26a73e4fa1806a51ac79d")),
+	}
+	_, mevPayment, err := getTxFees(elClient, blockData, 1, true, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mevPayment.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("wrong mevPayment: %v != %v", mevPayment, big.NewInt(1e18))
+	}
+
+	_, mevPayment, err = getTxFees(elClient, blockData, 1, false, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mevPayment.Sign() != 0 {
+		t.Errorf("wrong mevPayment without attribution: %v != %v", mevPayment, 0)
+	}
+
+	blockData.FeeRecipient = bellatrix.ExecutionAddress{}
+	_, mevPayment, err = getTxFees(elClient, blockData, 1, true, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mevPayment.Sign() != 0 {
+		t.Errorf("wrong mevPayment for transfer to another address: %v != %v", mevPayment, 0)
+	}
+}
+
+func TestSafeDecodeTx(t *testing.T) {
+	tx := createTx(10000)
+	if decTx, err := safeDecodeTx(tx); err != nil || decTx == nil {
+		t.Fatalf("valid tx should be decoded: %v", err)
+	}
+	for name, b := range map[string][]byte{
+		"empty":        {},
+		"truncated":    tx[:len(tx)/2],
+		"unknown type": {0x7f, 0xc0},
+		"garbage":      []byte("not a transaction"),
+	} {
+		if decTx, err := safeDecodeTx(b); err == nil || decTx != nil {
+			t.Errorf("%s: expected error, got tx %v", name, decTx)
+		}
+	}
+}
+
+func FuzzSafeDecodeTx(f *testing.F) {
+	tx := createTx(10000)
+	f.Add(tx)
+	f.Add(tx[:len(tx)-1])
+	f.Add([]byte{})
+	f.Add([]byte{0x02})
+	f.Add([]byte{0x02, 0xc0})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		decTx, err := safeDecodeTx(b)
+		if (err == nil) == (decTx == nil) {
+			t.Errorf("either a tx or an error must be returned: %v, %v", decTx, err)
+		}
+	})
+}
+
+func TestEffectiveGasPrice(t *testing.T) {
+	baseFee := big.NewInt(10)
+	to := common.HexToAddress("0x4592d8f8d7b001e72cb26a73e4fa1806a51ac79d")
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		receipt *TxReceipt
+		want    *big.Int
+	}{
+		{name: "legacy", tx: types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(100), To: &to}), want: big.NewInt(100)},
+		{name: "access list", tx: types.NewTx(&types.AccessListTx{GasPrice: big.NewInt(100), To: &to}), want: big.NewInt(100)},
+		{name: "dynamic fee", tx: types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(5), GasFeeCap: big.NewInt(100), To: &to}), want: big.NewInt(15)},
+		{name: "dynamic fee at fee cap", tx: types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(5), GasFeeCap: big.NewInt(12), To: &to}), want: big.NewInt(12)},
+		{name: "dynamic fee without fields", tx: types.NewTx(&types.DynamicFeeTx{To: &to}), want: big.NewInt(0)},
+		{name: "receipt", tx: types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(5), GasFeeCap: big.NewInt(100), To: &to}), receipt: &TxReceipt{EffectiveGasPrice: (*hexutil.Big)(big.NewInt(42))}, want: big.NewInt(42)},
+	}
+	for _, tt := range tests {
+		if got := effectiveGasPrice(tt.tx, tt.receipt, baseFee); got == nil || got.Cmp(tt.want) != 0 {
+			t.Errorf("%s: wrong effective gas price: %v != %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBlockReceipts(t *testing.T) {
+	tx := createTx(10000)
+	decTx := &types.Transaction{}
+	if err := decTx.UnmarshalBinary(tx); err != nil {
+		t.Fatal(err)
+	}
+	receipt := fmt.Sprintf(`{"effectiveGasPrice":"0x64","gasUsed":"0x2710","status":"0x1","transactionHash":"%s"}`, decTx.Hash().Hex())
+
+	supported := true
+	blockReceiptsCalls := 0
+	elServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			blockReceiptsCalls++
+			if !supported {
+				w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method eth_getBlockReceipts does not exist/is not available"}}`))
+				return
+			}
+			w.Write([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":[%s]}`, receipt)))
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(`[{"jsonrpc":"2.0","id":1,"result":%s}]`, receipt)))
+	}))
+	defer elServer.Close()
+
+	elClient, err := gethRPC.Dial(elServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockData := &BlockData{Transactions: []bellatrix.Transaction{tx}, BaseFeePerGas: [32]byte{10}, GasUsed: 10000}
+	want := big.NewInt((100 - 10) * 10000)
+
+	blockReceipts := newBlockReceiptsState(true)
+	fees, _, err := getTxFees(elClient, blockData, 1, false, blockReceipts, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Cmp(want) != 0 || blockReceiptsCalls != 1 {
+		t.Errorf("wrong fees with eth_getBlockReceipts: %v != %v (%v calls)", fees, want, blockReceiptsCalls)
+	}
+
+	supported = false
+	fees, _, err = getTxFees(elClient, blockData, 1, false, blockReceipts, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Cmp(want) != 0 {
+		t.Errorf("wrong fees after falling back to eth_getTransactionReceipt: %v != %v", fees, want)
+	}
+	// the unsupported method is not tried again for the next block of the calculation
+	fees, _, err = getTxFees(elClient, blockData, 2, false, blockReceipts, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Cmp(want) != 0 || blockReceiptsCalls != 2 {
+		t.Errorf("wrong fees after the fallback: %v != %v (%v eth_getBlockReceipts calls, want 2)", fees, want, blockReceiptsCalls)
+	}
+
+	// receipts of another block are rejected
+	supported = true
+	receipt = `{"effectiveGasPrice":"0x64","gasUsed":"0x2710","status":"0x1","transactionHash":"0x0000000000000000000000000000000000000000000000000000000000000001"}`
+	if _, err := requestBlockReceipts(context.Background(), elClient, 1, []common.Hash{decTx.Hash()}); err == nil {
+		t.Errorf("expected error for receipts that do not match the transactions")
+	}
+}
+
+func TestFeeStrategy(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the receipts of the mock report a gas used of 1e11 + 23080 at an effective gas price of 100 Wei, the blocks a gas
+	// used of 230800 at a base fee of 10 Wei, which the default subtracts: 1e13 Wei per block of an accounted validator
+	accountedBlocks := want.TxFeesSumWei.Div(decimal.NewFromInt(1e13))
+	if !accountedBlocks.IsInteger() || accountedBlocks.IsZero() {
+		t.Fatalf("wrong TxFeesSumWei without fee strategy: %v", want.TxFeesSumWei)
+	}
+	for _, tt := range []struct {
+		strategy    FeeStrategy
+		feePerBlock int64
+	}{
+		{TipFeeStrategy{}, (100 - 10) * (1e11 + 23080)},
+		{FullGasFeeStrategy{}, 100 * (1e11 + 23080)},
+	} {
+		day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithFeeStrategy(tt.strategy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantFees := accountedBlocks.Mul(decimal.NewFromInt(tt.feePerBlock))
+		if !day.TxFeesSumWei.Equal(wantFees) {
+			t.Errorf("wrong TxFeesSumWei with %T: %v != %v", tt.strategy, day.TxFeesSumWei, wantFees)
+		}
+	}
+}
+
+func TestTxCache(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the blocks of the mock share one block hash, the proxy gives every block its own
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var slot int
+		if _, err := fmt.Sscanf(r.URL.Path, "/eth/v2/beacon/blocks/%d", &slot); err != nil {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write(bytes.Replace(body, []byte(`"block_hash":"0x8145108c4ba0bd6507019ee9ef1eaa225daa0fd220bfea44f5e1d3b58c313875"`), []byte(fmt.Sprintf(`"block_hash":"%#064x"`, slot)), 1))
+	}))
+	defer server.Close()
+	receiptRequests := int64(0)
+	elProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&receiptRequests, 1)
+		http.Redirect(w, r, elServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer elProxy.Close()
+
+	cache, err := NewTxCache(7200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _, err := Calculate(context.Background(), server.URL, elProxy.URL, "10", 4, WithTxCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the blocks of the accounted validators are cached, one receipt request each
+	if accountedBlocks := want.TxFeesSumWei.Div(decimal.NewFromInt(1e13)).IntPart(); int64(cache.Len()) != accountedBlocks || atomic.LoadInt64(&receiptRequests) != accountedBlocks {
+		t.Errorf("wrong cached blocks, receipt requests: %v, %v != %v", cache.Len(), atomic.LoadInt64(&receiptRequests), accountedBlocks)
+	}
+
+	// a re-run only attributes the fees of the cached blocks
+	atomic.StoreInt64(&receiptRequests, 0)
+	day, _, err := Calculate(context.Background(), server.URL, elProxy.URL, "10", 4, WithTxCache(cache), WithFeeStrategy(TipFeeStrategy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(&receiptRequests); n != 0 {
+		t.Errorf("wrong receipt requests of the re-run: %v != %v", n, 0)
+	}
+	if wantFees := want.TxFeesSumWei.Div(decimal.NewFromInt(1e13)).Mul(decimal.NewFromInt((100 - 10) * (1e11 + 23080))); !day.TxFeesSumWei.Equal(wantFees) {
+		t.Errorf("wrong TxFeesSumWei of the re-run: %v != %v", day.TxFeesSumWei, wantFees)
+	}
+
+	if _, err := NewTxCache(0); err == nil {
+		t.Errorf("expected error for a tx cache of 0 blocks")
+	}
+}
+
+func TestPreset(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	client, err := newConsClient(context.Background(), bnServer.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the spec of the node (mainnet) is not queried
+	o, err := newOptions([]Option{WithPreset(GnosisPreset)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := getChainSpec(context.Background(), client, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.SlotsPerEpoch != 16 || spec.SecondsPerSlot != 5 || spec.EpochsPerSyncCommitteePeriod != 512 || spec.DomainDeposit != mainnetDomainDeposit {
+		t.Errorf("wrong spec for GnosisPreset: %+v", spec)
+	}
+	// a day of Gnosis Chain has 17280 slots of 5 seconds, 1080 epochs of 16 slots
+	if firstSlot, lastSlot, firstEpoch, lastEpoch := DayBounds(1, spec.SlotsPerEpoch, spec.SecondsPerSlot); firstSlot != 17280 || lastSlot != 34559 || firstEpoch != 1080 || lastEpoch != 2159 {
+		t.Errorf("wrong bounds of day 1 on Gnosis Chain: %v, %v, %v, %v", firstSlot, lastSlot, firstEpoch, lastEpoch)
+	}
+
+	if _, err := newOptions([]Option{WithPreset(Preset{SlotsPerEpoch: 16})}); err == nil {
+		t.Errorf("expected error for a preset without SecondsPerSlot")
+	}
+}
+
+func TestExecutionRewardsRate(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithExecutionRewardsRate(func(day time.Time) (decimal.Decimal, error) {
+		return decimal.RequireFromString("0.5"), nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the consensus rewards are not converted
+	if !day.TxFeesSumWei.Equal(want.TxFeesSumWei.Div(decimal.NewFromInt(2))) || !day.ConsensusRewardsGwei.Equal(want.ConsensusRewardsGwei) {
+		t.Errorf("wrong TxFeesSumWei, ConsensusRewardsGwei: %v, %v != %v, %v", day.TxFeesSumWei, day.ConsensusRewardsGwei, want.TxFeesSumWei.Div(decimal.NewFromInt(2)), want.ConsensusRewardsGwei)
+	}
+	if !day.TotalRewardsWei.Equal(want.TotalRewardsWei.Sub(want.TxFeesSumWei.Div(decimal.NewFromInt(2)))) {
+		t.Errorf("wrong TotalRewardsWei: %v != %v", day.TotalRewardsWei, want.TotalRewardsWei.Sub(want.TxFeesSumWei.Div(decimal.NewFromInt(2))))
+	}
+
+	_, _, err = Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithExecutionRewardsRate(func(day time.Time) (decimal.Decimal, error) {
+		return decimal.Zero, nil
+	}))
+	if err == nil {
+		t.Errorf("expected error for a zero execution rewards rate")
+	}
+}
+
+func TestDepositFilter(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the extra deposit of validator 4 is filtered out, its balance then grows by the 32 Eth as if they were rewards
+	filtered := 0
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 1, WithDepositFilter(func(d *phase0.Deposit) bool {
+		if d.Data.Amount == 32e9 {
+			filtered++
+			return false
+		}
+		return true
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filtered == 0 {
+		t.Fatalf("the filter was not called with the deposit of validator 4")
+	}
+	if !day.DepositsSumGwei.IsZero() || !validatorDays[4].DepositsSumGwei.IsZero() {
+		t.Errorf("wrong DepositsSumGwei: %v, %v != %v", day.DepositsSumGwei, validatorDays[4].DepositsSumGwei, 0)
+	}
+	if !day.SkippedDeposits.IsZero() {
+		t.Errorf("filtered deposits should not be skipped: %v", day.SkippedDeposits)
+	}
+	if !validatorDays[4].ConsensusRewardsGwei.Equal(decimal.NewFromInt(32e9 + 32e5)) {
+		t.Errorf("wrong ConsensusRewardsGwei of validator 4: %v != %v", validatorDays[4].ConsensusRewardsGwei, 32e9+32e5)
+	}
+}
+
+func TestMissedAttestations(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// every slot has one committee of the validators whose index modulo 32 is the slot modulo 32, every block
+	// includes the attestation of the previous slot in which all validators but validator 7 attested
+	committeeOf := func(slot int) []string {
+		validators := []string{}
+		for v := slot % 32; v < 33; v += 32 {
+			validators = append(validators, fmt.Sprintf("%d", v))
+		}
+		return validators
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var slot int
+		if _, err := fmt.Sscanf(r.URL.Path, "/eth/v1/beacon/states/%d/committees", &slot); err == nil && strings.HasSuffix(r.URL.Path, "/committees") {
+			committees := []map[string]interface{}{}
+			for s := slot; s < slot+32; s++ {
+				committees = append(committees, map[string]interface{}{"index": "0", "slot": fmt.Sprintf("%d", s), "validators": committeeOf(s)})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": committees})
+			return
+		}
+		if _, err := fmt.Sscanf(r.URL.Path, "/eth/v2/beacon/blocks/%d", &slot); err != nil || slot == 0 {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		members := committeeOf(slot - 1)
+		bits := bitfield.NewBitlist(uint64(len(members)))
+		for j, v := range members {
+			bits.SetBitAt(uint64(j), v != "7")
+		}
+		w.Write(bytes.Replace(body, []byte(`"aggregation_bits":"0xf7fa6fffbcbbbf6f","data":{"slot":"357843"`), []byte(fmt.Sprintf(`"aggregation_bits":"%#x","data":{"slot":"%d"`, []byte(bits), slot-1)), 1))
+	}))
+	defer server.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithMissedAttestations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the 224 epochs before the last epoch of the day are counted
+	if !validatorDays[7].MissedAttestations.Equal(decimal.NewFromInt(224)) || !day.MissedAttestations.Equal(decimal.NewFromInt(224)) {
+		t.Errorf("wrong MissedAttestations: %v, %v != %v", validatorDays[7].MissedAttestations, day.MissedAttestations, 224)
+	}
+	if !validatorDays[8].MissedAttestations.IsZero() || !validatorDays[32].MissedAttestations.IsZero() {
+		t.Errorf("wrong MissedAttestations of validators 8 and 32: %v, %v != %v", validatorDays[8].MissedAttestations, validatorDays[32].MissedAttestations, 0)
+	}
+
+	// every included attestation is included in the block of the next slot, the 28 accounted validators but validator
+	// 7 are included in all 224 epochs
+	day, validatorDays, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithMissedAttestations(), WithInclusionDistance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validatorDays[8].AvgInclusionDistance.Equal(decimal.NewFromInt(1)) || !validatorDays[8].IncludedAttestations.Equal(decimal.NewFromInt(224)) {
+		t.Errorf("wrong AvgInclusionDistance, IncludedAttestations of validator 8: %v, %v != %v, %v", validatorDays[8].AvgInclusionDistance, validatorDays[8].IncludedAttestations, 1, 224)
+	}
+	if !validatorDays[7].AvgInclusionDistance.IsZero() || !validatorDays[7].IncludedAttestations.IsZero() {
+		t.Errorf("wrong AvgInclusionDistance, IncludedAttestations of validator 7: %v, %v != %v, %v", validatorDays[7].AvgInclusionDistance, validatorDays[7].IncludedAttestations, 0, 0)
+	}
+	if !day.AvgInclusionDistance.Equal(decimal.NewFromInt(1)) || !day.IncludedAttestations.Equal(decimal.NewFromInt(28*224)) || !day.MissedAttestations.Equal(decimal.NewFromInt(224)) {
+		t.Errorf("wrong AvgInclusionDistance, IncludedAttestations, MissedAttestations: %v, %v, %v != %v, %v, %v", day.AvgInclusionDistance, day.IncludedAttestations, day.MissedAttestations, 1, 28*224, 224)
+	}
+
+	// the included attestations survive a checkpoint
+	included := map[attestationKey]bitfield.Bitlist{{Slot: 72001, Index: 3}: bitfield.Bitlist{0x05}}
+	c := &checkpoint{}
+	c.setIncludedAttestations(included)
+	restored, err := c.getIncludedAttestations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored, included) {
+		t.Errorf("wrong included attestations of the checkpoint: %v != %v", restored, included)
+	}
+	inclusionSlots := map[attestationKey][]uint64{{Slot: 72001, Index: 3}: {72002, 0, 72004}}
+	c.setInclusionSlots(inclusionSlots)
+	restoredSlots, err := c.getInclusionSlots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restoredSlots, inclusionSlots) {
+		t.Errorf("wrong inclusion slots of the checkpoint: %v != %v", restoredSlots, inclusionSlots)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	requests, withoutID := int64(0), int64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		if r.Header.Get("X-Request-ID") != "day-10-run-1" {
+			atomic.AddInt64(&withoutID, 1)
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	_, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithRequestID("day-10-run-1"), WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests == 0 || withoutID != 0 {
+		t.Errorf("%v of %v requests without request id", withoutID, requests)
+	}
+}
+
+func TestBeaconJSONHeaders(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the endpoints the client does not support are requested with the headers of the client as well
+	requestID := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v1/beacon/rewards/blocks/72003" {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		requestID = r.Header.Get("X-Request-ID")
+		w.Write([]byte(`{"data":{"total":"1000"}}`))
+	}))
+	defer server.Close()
+
+	o, err := newOptions([]Option{WithRequestID("day-10-run-1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := withBeaconHeaders(context.Background(), o)
+	client, err := newConsClient(ctx, server.URL, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewards, err := getProposerRewards(ctx, client, "72003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewards != 1000 || requestID != "day-10-run-1" {
+		t.Errorf("wrong rewards or request id: %v, %q", rewards, requestID)
+	}
+}
+
+func TestNoValidators(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a state without validators, e.g. of a wrong state id
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/eth/v1/beacon/states/72000/validators" {
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+	_, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if !errors.Is(err, ErrNoValidators) {
+		t.Errorf("expected ErrNoValidators for an empty validator response, got %v", err)
+	}
+
+	// all validators exited during the day
+	exitedServer := newModifiedValidatorsServer(t, bnServer, "79200", func(vals []map[string]interface{}) {
+		for _, v := range vals {
+			v["status"] = "exited_unslashed"
+			v["validator"].(map[string]interface{})["exit_epoch"] = fmt.Sprintf("%d", 10*225+1)
+		}
+	})
+	defer exitedServer.Close()
+	_, _, err = Calculate(context.Background(), exitedServer.URL, elServer.URL, "10", 4)
+	if !errors.Is(err, ErrNoValidators) {
+		t.Errorf("expected ErrNoValidators without validators active the whole day, got %v", err)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithLedger(), WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(day.Ledger) != 29 || day.Ledger[0].ValidatorIndex != 4 || day.Ledger[28].ValidatorIndex != 32 {
+		t.Fatalf("wrong ledger: %+v", day.Ledger)
+	}
+	// the ledger reconciles with the totals of the day
+	var startBalanceGwei, depositsSumGwei phase0.Gwei
+	totalRewardsWei := decimal.Zero
+	for _, e := range day.Ledger {
+		startBalanceGwei += e.StartBalanceGwei
+		depositsSumGwei += e.DepositsSumGwei
+		totalRewardsWei = totalRewardsWei.Add(e.TotalRewardsWei)
+	}
+	if !gweiToDecimal(startBalanceGwei).Equal(day.StartBalanceGwei) || !gweiToDecimal(depositsSumGwei).Equal(day.DepositsSumGwei) || !totalRewardsWei.Equal(day.TotalRewardsWei) {
+		t.Errorf("ledger does not reconcile: %v, %v, %v != %v, %v, %v", startBalanceGwei, depositsSumGwei, totalRewardsWei, day.StartBalanceGwei, day.DepositsSumGwei, day.TotalRewardsWei)
+	}
+	if day.Ledger[0].DepositsSumGwei != 32e9 || !day.Ledger[0].ConsensusRewardsGwei.Equal(decimal.NewFromInt(32e5)) {
+		t.Errorf("wrong ledger entry of validator 4: %+v", day.Ledger[0])
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteLedgerCSV(buf, day.Ledger); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 30 || !strings.HasPrefix(lines[0], "validator_index,pubkey,") || !strings.HasPrefix(lines[1], "4,0xb07210c8") {
+		t.Errorf("wrong csv: %v", lines[:2])
+	}
+}
+
+func TestClientClassifier(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the blocks at even slots have the graffiti "teku", all others are empty
+	zeroGraffiti := fmt.Sprintf(`"graffiti":"%#064x"`, 0)
+	tekuGraffiti := fmt.Sprintf(`"graffiti":"0x%x"`, append([]byte("teku"), make([]byte, 28)...))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var slot int
+		if _, err := fmt.Sscanf(r.URL.Path, "/eth/v2/beacon/blocks/%d", &slot); err != nil || slot%2 != 0 {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write(bytes.Replace(body, []byte(zeroGraffiti), []byte(tekuGraffiti), 1))
+	}))
+	defer server.Close()
+
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithoutValidatorDays(), WithClientClassifier(func(graffiti [32]byte) string {
+		if bytes.HasPrefix(graffiti[:], []byte("teku")) {
+			return "teku"
+		}
+		return "unknown"
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	teku, unknown := day.RewardsByClient["teku"], day.RewardsByClient["unknown"]
+	if len(day.RewardsByClient) != 2 || teku.IsZero() || unknown.IsZero() || !teku.Add(unknown).Equal(day.TxFeesSumWei.Add(day.MevRewardsWei)) {
+		t.Errorf("wrong RewardsByClient: %v, the execution rewards are %v", day.RewardsByClient, day.TxFeesSumWei.Add(day.MevRewardsWei))
+	}
+}
+
+func TestSlotError(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the block at slot 72100 has a transaction that can not be decoded
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v2/beacon/blocks/72100" {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write(regexp.MustCompile(`"transactions":\["0x[0-9a-f]*"\]`).ReplaceAll(body, []byte(`"transactions":["0xdeadbeef"]`)))
+	}))
+	defer server.Close()
+
+	_, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	var slotErr *SlotError
+	if !errors.As(err, &slotErr) || slotErr.Slot != 72100 {
+		t.Fatalf("expected a SlotError of slot 72100, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "error decoding tx") {
+		t.Errorf("the SlotError should wrap the cause: %v", err)
+	}
+}
+
+func TestWithoutBlocks(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// no block is requested
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/eth/v2/beacon/blocks/") {
+			t.Errorf("unexpected block request: %v", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithoutValidatorDays(), WithoutBlocks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the deposit of validator 4 is not known and accounted as reward
+	consGwei := decimal.NewFromInt(29*32e5 + 32e9)
+	if !day.ConsensusRewardsGwei.Equal(consGwei) || !day.DepositsSumGwei.IsZero() {
+		t.Errorf("wrong ConsensusRewardsGwei, DepositsSumGwei: %v, %v != %v, %v", day.ConsensusRewardsGwei, day.DepositsSumGwei, consGwei, 0)
+	}
+	if !day.TxFeesSumWei.IsZero() || !day.ProposedBlocks.IsZero() || !day.MissedSlots.IsZero() || !day.WithoutBlocks {
+		t.Errorf("wrong day without blocks: txFees %v, proposedBlocks %v, missedSlots %v, withoutBlocks %v", day.TxFeesSumWei, day.ProposedBlocks, day.MissedSlots, day.WithoutBlocks)
+	}
+}
+
+func TestCredentialType(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// validator 5 switched to compounding credentials during the day
+	server := newModifiedValidatorsServer(t, bnServer, "79200", func(vals []map[string]interface{}) {
+		vals[5]["validator"].(map[string]interface{})["withdrawal_credentials"] = "0x020000000000000000000000b9d7934878b5fb9610b3fe8a5e441e8fad7e293f"
+	})
+	defer server.Close()
+	_, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := validatorDays[5].CredentialType; ct == nil || *ct != compoundingWithdrawalPrefix {
+		t.Errorf("wrong CredentialType of validator 5: %v != %#02x", ct, compoundingWithdrawalPrefix)
+	}
+	if a := validatorDays[5].WithdrawalAddress; a == nil || *a != common.HexToAddress("0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f") {
+		t.Errorf("wrong WithdrawalAddress of validator 5: %v", a)
+	}
+	if ct := validatorDays[6].CredentialType; ct == nil || *ct == compoundingWithdrawalPrefix {
+		t.Errorf("wrong CredentialType of validator 6: %v", ct)
+	}
+}
+
+func TestAdaptiveConcurrency(t *testing.T) {
+	l := newAdaptiveLimiter(2, 8, 16)
+	if l.currentLimit() != 8 {
+		t.Errorf("wrong initial limit: %v != %v", l.currentLimit(), 8)
+	}
+	l.observe(errors.New("timeout"))
+	if l.currentLimit() != 4 {
+		t.Errorf("wrong limit after an error: %v != %v", l.currentLimit(), 4)
+	}
+	l.observe(errors.New("timeout"))
+	l.observe(errors.New("timeout"))
+	if l.currentLimit() != 2 {
+		t.Errorf("wrong limit at the lower bound: %v != %v", l.currentLimit(), 2)
+	}
+	for j := 0; j < 2; j++ {
+		l.observe(nil)
+	}
+	if l.currentLimit() != 3 {
+		t.Errorf("wrong limit after limit successes: %v != %v", l.currentLimit(), 3)
+	}
+
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the first request of every 100th block fails as if the node was overloaded
+	failed := sync.Map{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slot, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/eth/v2/beacon/blocks/"), 10, 64); err == nil && slot%100 == 0 {
+			if _, loaded := failed.LoadOrStore(slot, true); !loaded {
+				http.Error(w, "overloaded", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithoutValidatorDays(), WithAdaptiveConcurrency(1, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Apr.Equal(want.Apr) || !day.ProposedBlocks.Equal(want.ProposedBlocks) {
+		t.Errorf("wrong day with adaptive concurrency: apr %v != %v, proposedBlocks %v != %v", day.Apr, want.Apr, day.ProposedBlocks, want.ProposedBlocks)
+	}
+}
+
+func TestEmptyBlock(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the block at slot 72100 (proposed by validator 5) has an execution payload without transactions
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v2/beacon/blocks/72100" {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		body = regexp.MustCompile(`"transactions":\["0x[0-9a-f]*"\]`).ReplaceAll(body, []byte(`"transactions":[]`))
+		w.Write(regexp.MustCompile(`"gas_used":"[0-9]*"`).ReplaceAll(body, []byte(`"gas_used":"0"`)))
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the empty block is neither a missed slot nor a block with an incomplete payload
+	if !day.ProposedBlocks.Equal(want.ProposedBlocks) || !day.MissedSlots.IsZero() || !day.IncompletePayloadSlots.IsZero() {
+		t.Errorf("wrong ProposedBlocks, MissedSlots, IncompletePayloadSlots: %v, %v, %v != %v, 0, 0", day.ProposedBlocks, day.MissedSlots, day.IncompletePayloadSlots, want.ProposedBlocks)
+	}
+	if txFees := want.TxFeesSumWei.Sub(decimal.NewFromInt(10000e9)); !day.TxFeesSumWei.Equal(txFees) {
+		t.Errorf("wrong TxFeesSumWei: %v != %v", day.TxFeesSumWei, txFees)
+	}
+	if validatorDays[5].TotalTxCount.IntPart() != 224 {
+		t.Errorf("wrong TotalTxCount of validator 5: %v != %v", validatorDays[5].TotalTxCount, 224)
+	}
+}
+
+func TestIncompletePayload(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the block at slot 72100 (proposed by validator 5) is served without the transactions of its execution payload,
+	// as by a checkpoint synced node, but still has the gas they used
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v2/beacon/blocks/72100" {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write(regexp.MustCompile(`"transactions":\["0x[0-9a-f]*"\]`).ReplaceAll(body, []byte(`"transactions":[]`)))
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the block is proposed, but its fees are unknown
+	if !day.ProposedBlocks.Equal(want.ProposedBlocks) || !day.IncompletePayloadSlots.Equal(decimal.NewFromInt(1)) || !day.BlindedBlockSlots.IsZero() {
+		t.Errorf("wrong ProposedBlocks, IncompletePayloadSlots, BlindedBlockSlots: %v, %v, %v != %v, 1, 0", day.ProposedBlocks, day.IncompletePayloadSlots, day.BlindedBlockSlots, want.ProposedBlocks)
+	}
+	if txFees := want.TxFeesSumWei.Sub(decimal.NewFromInt(10000e9)); !day.TxFeesSumWei.Equal(txFees) {
+		t.Errorf("wrong TxFeesSumWei: %v != %v", day.TxFeesSumWei, txFees)
+	}
+}
+
+func TestBlindedBlock(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the block at slot 72003 (proposed by validator 4, with its deposit) is served blinded
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v2/beacon/blocks/72003" {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		body = bytes.Replace(body, []byte(`"execution_payload":`), []byte(`"execution_payload_header":`), 1)
+		w.Write(regexp.MustCompile(`"transactions":\["0x[0-9a-f]*"\]`).ReplaceAll(body, []byte(`"transactions_root":"0x`+strings.Repeat("00", 32)+`"`)))
+	}))
+	defer server.Close()
+
+	want, wantValidatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.ProposedBlocks.Equal(want.ProposedBlocks) || !day.BlindedBlockSlots.Equal(decimal.NewFromInt(1)) || !day.IncompletePayloadSlots.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("wrong ProposedBlocks, BlindedBlockSlots, IncompletePayloadSlots: %v, %v, %v != %v, 1, 1", day.ProposedBlocks, day.BlindedBlockSlots, day.IncompletePayloadSlots, want.ProposedBlocks)
+	}
+	if txFees := want.TxFeesSumWei.Sub(decimal.NewFromInt(10000e9)); !day.TxFeesSumWei.Equal(txFees) {
+		t.Errorf("wrong TxFeesSumWei: %v != %v", day.TxFeesSumWei, txFees)
+	}
+	// the deposit is in the body of the blinded block
+	if !validatorDays[4].DepositsSumGwei.Equal(wantValidatorDays[4].DepositsSumGwei) || wantValidatorDays[4].DepositsSumGwei.IsZero() {
+		t.Errorf("wrong DepositsSumGwei of validator 4: %v != %v", validatorDays[4].DepositsSumGwei, wantValidatorDays[4].DepositsSumGwei)
+	}
+}
+
+// newWithdrawalsServer returns a proxy to bnServer that serves the block at slot as a capella block with the json array
+// withdrawals as the withdrawals of its execution payload.
+func newWithdrawalsServer(t *testing.T, bnServer *httptest.Server, slot uint64, withdrawals string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fmt.Sprintf("/eth/v2/beacon/blocks/%d", slot) {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		body = bytes.Replace(body, []byte(`"version":"bellatrix"`), []byte(`"version":"capella"`), 1)
+		body = bytes.Replace(body, []byte(`"execution_payload":`), []byte(`"bls_to_execution_changes":[],"execution_payload":`), 1)
+		w.Write(regexp.MustCompile(`"transactions":\["0x[0-9a-f]*"\]`).ReplaceAllFunc(body, func(txs []byte) []byte {
+			// txs is a slice of body, appending to it would overwrite the rest of the block
+			return append(append([]byte{}, txs...), `,"withdrawals":`+withdrawals...)
+		}))
+	}))
+}
+
+func TestWithdrawalsByAddress(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the block at slot 72100 is a capella block with withdrawals of validators 6 and 7 to the same address and of
+	// validator 8 to another one, and of validator 1000, which is not accounted
+	withdrawals := `[{"index":"0","validator_index":"6","address":"0x8b0c2c4c8eb078bc6c01f48523764c8942c0c6c4","amount":"1000000"},` +
+		`{"index":"1","validator_index":"7","address":"0x8b0c2c4c8eb078bc6c01f48523764c8942c0c6c4","amount":"2000000"},` +
+		`{"index":"2","validator_index":"8","address":"0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f","amount":"4000000"},` +
+		`{"index":"3","validator_index":"1000","address":"0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f","amount":"8000000"}]`
+	server := newWithdrawalsServer(t, bnServer, 72100, withdrawals)
+	defer server.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[common.Address]phase0.Gwei{
+		common.HexToAddress("0x8b0c2c4c8eb078bc6c01f48523764c8942c0c6c4"): 3000000,
+		common.HexToAddress("0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f"): 4000000,
+	}
+	if !reflect.DeepEqual(day.WithdrawalsByAddress, want) {
+		t.Errorf("wrong WithdrawalsByAddress: %v != %v", day.WithdrawalsByAddress, want)
+	}
+	if !day.WithdrawalsSumGwei.Equal(decimal.NewFromInt(7000000)) || !validatorDays[7].WithdrawalsSumGwei.Equal(decimal.NewFromInt(2000000)) {
+		t.Errorf("wrong WithdrawalsSumGwei: %v, %v != %v, %v", day.WithdrawalsSumGwei, validatorDays[7].WithdrawalsSumGwei, 7000000, 2000000)
+	}
+	if validatorDays[7].WithdrawalsByAddress != nil {
+		t.Errorf("expected no WithdrawalsByAddress of validator days, got %v", validatorDays[7].WithdrawalsByAddress)
+	}
+
+	next := *day
+	next.Day = day.Day.Add(decimal.NewFromInt(1))
+	aggregate, err := AggregateDays([]*Day{day, &next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount := aggregate.WithdrawalsByAddress[common.HexToAddress("0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f")]; amount != 8000000 {
+		t.Errorf("wrong aggregated WithdrawalsByAddress: %v != %v", amount, 8000000)
+	}
+}
+
+func TestWithdrawalsAtDayBoundaries(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// validator 7 withdraws in the first block of day 10, validator 6 in the last one. Both are part of day 10, the
+	// mock fails the test if a block of another day (e.g. the first block of day 11 at slot 79200) is requested.
+	firstServer := newWithdrawalsServer(t, bnServer, 72000, `[{"index":"0","validator_index":"7","address":"0x8b0c2c4c8eb078bc6c01f48523764c8942c0c6c4","amount":"2000000"}]`)
+	defer firstServer.Close()
+	server := newWithdrawalsServer(t, firstServer, 79199, `[{"index":"1","validator_index":"6","address":"0x8b0c2c4c8eb078bc6c01f48523764c8942c0c6c4","amount":"1000000"}]`)
+	defer server.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.WithdrawalsSumGwei.Equal(decimal.NewFromInt(3000000)) {
+		t.Errorf("wrong WithdrawalsSumGwei: %v != %v", day.WithdrawalsSumGwei, 3000000)
+	}
+	if !validatorDays[6].WithdrawalsSumGwei.Equal(decimal.NewFromInt(1000000)) || !validatorDays[7].WithdrawalsSumGwei.Equal(decimal.NewFromInt(2000000)) {
+		t.Errorf("wrong WithdrawalsSumGwei of validators 6 and 7: %v, %v != %v, %v", validatorDays[6].WithdrawalsSumGwei, validatorDays[7].WithdrawalsSumGwei, 1000000, 2000000)
+	}
+}
+
+func TestProposerRewards(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// every block pays its proposer 1000 Gwei
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/rewards/blocks/") {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		fmt.Fprint(w, `{"execution_optimistic":false,"finalized":true,"data":{"proposer_index":"0","total":"1000","attestations":"900","sync_aggregate":"100","proposer_slashings":"0","attester_slashings":"0"}}`)
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithProposerRewards())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.ProposerConsensusRewardsGwei.Equal(decimal.NewFromInt(29*225*1000)) || !validatorDays[5].ProposerConsensusRewardsGwei.Equal(decimal.NewFromInt(225*1000)) {
+		t.Errorf("wrong ProposerConsensusRewardsGwei: %v, %v of validator 5 != %v, %v", day.ProposerConsensusRewardsGwei, validatorDays[5].ProposerConsensusRewardsGwei, 29*225*1000, 225*1000)
+	}
+	// the proposer rewards are part of the consensus rewards, which do not change
+	if !day.ConsensusRewardsGwei.Equal(want.ConsensusRewardsGwei) || !day.Apr.Equal(want.Apr) {
+		t.Errorf("wrong ConsensusRewardsGwei, Apr: %v, %v != %v, %v", day.ConsensusRewardsGwei, day.Apr, want.ConsensusRewardsGwei, want.Apr)
+	}
+	if !want.ProposerConsensusRewardsGwei.IsZero() {
+		t.Errorf("wrong ProposerConsensusRewardsGwei without WithProposerRewards: %v != %v", want.ProposerConsensusRewardsGwei, 0)
+	}
+}
+
+func TestBlockArchive(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the block at slot 72100 is archived, the node is not asked for it
+	resp, err := http.Get(bnServer.URL + "/eth/v2/beacon/blocks/72100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var res struct {
+		Data *bellatrix.SignedBeaconBlock `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	data, err := res.Data.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "72100.bellatrix.ssz"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	archivedRequests := uint64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/eth/v2/beacon/blocks/72100" {
+			atomic.AddUint64(&archivedRequests, 1)
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithBlockArchive(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadUint64(&archivedRequests); n != 0 {
+		t.Errorf("the archived block was requested from the node %v times", n)
+	}
+	if !day.Apr.Equal(want.Apr) || !day.TxFeesSumWei.Equal(want.TxFeesSumWei) || !day.ProposedBlocks.Equal(want.ProposedBlocks) {
+		t.Errorf("wrong Apr, TxFeesSumWei, ProposedBlocks: %v, %v, %v != %v, %v, %v", day.Apr, day.TxFeesSumWei, day.ProposedBlocks, want.Apr, want.TxFeesSumWei, want.ProposedBlocks)
+	}
+
+	// an archived block that can not be decoded fails the day
+	if err := os.WriteFile(filepath.Join(dir, "72101.bellatrix.ssz"), data[:100], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithBlockArchive(dir))
+	if err == nil || !strings.Contains(err.Error(), "72101.bellatrix.ssz") {
+		t.Errorf("expected error for the truncated archived block, got: %v", err)
+	}
+}
+
+func TestStateRootVerification(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	startRoot := "0x" + strings.Repeat("11", 32)
+	endRoot := "0x" + strings.Repeat("22", 32)
+	newServer := func(endRootAfter, headerRoot string) *httptest.Server {
+		endRootRequests := uint64(0)
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/eth/v1/beacon/states/72000/root":
+				fmt.Fprintf(w, `{"data":{"root":"%s"}}`, startRoot)
+			case "/eth/v1/beacon/states/79200/root":
+				root := endRoot
+				if atomic.AddUint64(&endRootRequests, 1) > 1 {
+					root = endRootAfter
+				}
+				fmt.Fprintf(w, `{"data":{"root":"%s"}}`, root)
+			case "/eth/v1/beacon/headers/79200":
+				// the slot is not part of the mocked blocks, as if it was missed
+				http.NotFound(w, r)
+			case "/eth/v1/beacon/headers/72000":
+				fmt.Fprintf(w, `{"data":{"root":"0x%s","canonical":true,"header":{"message":{"slot":"72000","proposer_index":"1","parent_root":"0x%s","state_root":"%s","body_root":"0x%s"},"signature":"0x%s"}}}`, strings.Repeat("33", 32), strings.Repeat("44", 32), headerRoot, strings.Repeat("55", 32), strings.Repeat("66", 96))
+			default:
+				http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			}
+		}))
+	}
+
+	server := newServer(endRoot, startRoot)
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithStateRootVerification())
+	server.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.StartStateRoot == nil || fmt.Sprintf("%#x", *day.StartStateRoot) != startRoot || day.EndStateRoot == nil || fmt.Sprintf("%#x", *day.EndStateRoot) != endRoot {
+		t.Errorf("wrong StartStateRoot, EndStateRoot: %v, %v != %v, %v", day.StartStateRoot, day.EndStateRoot, startRoot, endRoot)
+	}
+
+	// the end state changed while its validators were fetched
+	server = newServer(startRoot, startRoot)
+	_, _, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithStateRootVerification())
+	server.Close()
+	if err == nil || !strings.Contains(err.Error(), "changed while its validators were fetched") {
+		t.Errorf("expected error for a changed end state, got: %v", err)
+	}
+
+	// the start state is not the post-state of the block at its slot
+	server = newServer(endRoot, endRoot)
+	_, _, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithStateRootVerification())
+	server.Close()
+	if err == nil || !strings.Contains(err.Error(), "the block at slot 72000 has state root") {
+		t.Errorf("expected error for a start state of another block, got: %v", err)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	// invalid options are rejected before any request is done
+	_, _, err := Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithSlotsPerEpoch(0))
+	if err == nil {
+		t.Errorf("expected error for slotsPerEpoch of 0")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithSecondsPerSlot(1500*time.Millisecond))
+	if err == nil {
+		t.Errorf("expected error for secondsPerSlot of 1.5s")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithAprPrecision(-1))
+	if err == nil {
+		t.Errorf("expected error for aprPrecision of -1")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithReportTimezone(nil))
+	if err == nil {
+		t.Errorf("expected error for reportTimezone of nil")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithNominalBalanceBasis(0))
+	if err == nil {
+		t.Errorf("expected error for nominalBalanceBasis of 0")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithPeriodSlots(0))
+	if err == nil {
+		t.Errorf("expected error for periodSlots of 0")
+	}
+	_, err = CalculateRange(context.Background(), "http://localhost:0", "http://localhost:0", 10, 11, 1, mapStorage{}, WithPeriodSlots(32))
+	if err == nil {
+		t.Errorf("expected error for storage with WithPeriodSlots")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithPriceProvider(nil))
+	if err == nil {
+		t.Errorf("expected error for priceProvider of nil")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithCheckpoint(nil))
+	if err == nil {
+		t.Errorf("expected error for checkpoint store of nil")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithMaxInFlightBytes(0))
+	if err == nil {
+		t.Errorf("expected error for maxInFlightBytes of 0")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithDepositFilter(nil))
+	if err == nil {
+		t.Errorf("expected error for deposit filter of nil")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithRequestID(""))
+	if err == nil {
+		t.Errorf("expected error for empty request id")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithClientClassifier(nil))
+	if err == nil {
+		t.Errorf("expected error for client classifier of nil")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithAdaptiveConcurrency(4, 2))
+	if err == nil {
+		t.Errorf("expected error for adaptive concurrency bounds of [4, 2]")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithoutBlocks(), WithMissedAttestations())
+	if err == nil {
+		t.Errorf("expected error for WithoutBlocks with WithMissedAttestations")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithAprTrimFraction(0.5))
+	if err == nil {
+		t.Errorf("expected error for aprTrimFraction of 0.5")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithNegativeAprPolicy(NegativeAprExclude+1))
+	if err == nil {
+		t.Errorf("expected error for an unknown negativeAprPolicy")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithoutBlocks(), WithProposerRewards())
+	if err == nil {
+		t.Errorf("expected error for WithoutBlocks with WithProposerRewards")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithBlockArchive(""))
+	if err == nil {
+		t.Errorf("expected error for an empty block archive directory")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithTimeWeightedEffectiveBalance(0))
+	if err == nil {
+		t.Errorf("expected error for effective balance samples every 0 epochs")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithTimeWeightedEffectiveBalance(1), WithEffectiveBalanceSlot(3600))
+	if err == nil {
+		t.Errorf("expected error for WithTimeWeightedEffectiveBalance with WithEffectiveBalanceSlot")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithFeeStrategy(nil))
+	if err == nil {
+		t.Errorf("expected error for a nil fee strategy")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "", 1, WithStateRange("72000", "0x79200"))
+	if err == nil {
+		t.Errorf("expected error for an invalid state id")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "", 1, WithStateRange("72000", "79200"), WithEpochBoundaries())
+	if err == nil {
+		t.Errorf("expected error for WithStateRange with WithEpochBoundaries")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithoutBlocks(), WithInclusionDistance())
+	if err == nil {
+		t.Errorf("expected error for WithoutBlocks with WithInclusionDistance")
+	}
+	for name, opt := range map[string]Option{
+		"WithEpochBreakdown":       WithEpochBreakdown(),
+		"WithClientClassifier":     WithClientClassifier(func(graffiti [32]byte) string { return "" }),
+		"WithBalanceEvents":        WithBalanceEvents(),
+		"WithMevLastTxAttribution": WithMevLastTxAttribution(),
+		"WithBlockReceipts":        WithBlockReceipts(),
+	} {
+		_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithoutBlocks(), opt)
+		if err == nil {
+			t.Errorf("expected error for WithoutBlocks with %v", name)
+		}
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithExecutionRewardsRate(nil))
+	if err == nil {
+		t.Errorf("expected error for a nil execution rewards rate")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithTxCache(nil))
+	if err == nil {
+		t.Errorf("expected error for a nil tx cache")
+	}
+	_, _, err = Calculate(context.Background(), "http://localhost:0", "http://localhost:0", "10", 1, WithMinActivationAge(0))
+	if err == nil {
+		t.Errorf("expected error for a zero min activation age")
+	}
+}
+
+func TestNormalizeBeaconAddress(t *testing.T) {
+	address, err := normalizeBeaconAddress(" http://localhost:4000// ")
+	if err != nil || address != "http://localhost:4000" {
+		t.Errorf("wrong normalized address: %q (err: %v)", address, err)
+	}
+	for _, address := range []string{"", "localhost:4000", "ftp://localhost:4000", "http://", "http://local host"} {
+		_, err := normalizeBeaconAddress(address)
+		if !errors.Is(err, ErrInvalidBeaconAddress) {
+			t.Errorf("expected ErrInvalidBeaconAddress for %q, got: %v", address, err)
+		}
+	}
+}
+
+func TestWithdrawalAddress(t *testing.T) {
+	credentials := common.FromHex("0x010000000000000000000000b9d7934878b5fb9610b3fe8a5e441e8fad7e293f")
+	if a := withdrawalAddress(credentials); a != common.HexToAddress("0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f") {
+		t.Errorf("wrong withdrawal address: %v", a)
+	}
+	credentials = common.FromHex("0x000278f69b54118f70ff1ae310e465f0e3676ff585ddcbba448d4c9317d4cf32")
+	if a := withdrawalAddress(credentials); a != (common.Address{}) {
+		t.Errorf("wrong withdrawal address for 0x00 credentials: %v", a)
+	}
+	if ct := credentialType(credentials); ct != blsWithdrawalPrefix {
+		t.Errorf("wrong credential type of 0x00 credentials: %#02x", ct)
+	}
+	credentials = common.FromHex("0x020000000000000000000000b9d7934878b5fb9610b3fe8a5e441e8fad7e293f")
+	if a := withdrawalAddress(credentials); a != common.HexToAddress("0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f") {
+		t.Errorf("wrong withdrawal address for 0x02 credentials: %v", a)
+	}
+	if ct := credentialType(credentials); ct != compoundingWithdrawalPrefix {
+		t.Errorf("wrong credential type of 0x02 credentials: %#02x", ct)
+	}
+}
+
+func TestConsensusRewardsGweiNearInt64Boundary(t *testing.T) {
+	// summed balances of a large set can exceed math.MaxInt64, which would wrap around when cast to int64
+	start := phase0.Gwei(math.MaxInt64 - 1000)
+	end := phase0.Gwei(math.MaxInt64 + 5000)
+	deposits := phase0.Gwei(2000)
+	withdrawals := phase0.Gwei(500)
+
+	rewards := consensusRewardsGwei(start, end, deposits, withdrawals)
+	if !rewards.Equal(decimal.NewFromInt(4500)) {
+		t.Errorf("wrong consensusRewardsGwei: %v != %v", rewards, 4500)
+	}
+
+	rewards = consensusRewardsGwei(end, start, deposits, withdrawals)
+	if !rewards.Equal(decimal.NewFromInt(-7500)) {
+		t.Errorf("wrong negative consensusRewardsGwei: %v != %v", rewards, -7500)
+	}
+
+	if gweiToDecimal(end).String() != "9223372036854780807" {
+		t.Errorf("wrong gweiToDecimal: %v != %v", gweiToDecimal(end), "9223372036854780807")
+	}
+}
+
+func TestFinalityCheck(t *testing.T) {
+	// the chain finalized normally during day 10, so none of its epochs were in an inactivity leak
+	bnServer, elServer := newTestServers(t, true)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 1, WithFinalityCheck())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.DuringNonFinality || !day.InactivityLeakEpochs.IsZero() {
+		t.Errorf("wrong finality: DuringNonFinality: %v, InactivityLeakEpochs: %v", day.DuringNonFinality, day.InactivityLeakEpochs)
+	}
+}
+
+func TestIsInInactivityLeak(t *testing.T) {
+	tests := []struct {
+		epoch, finalizedEpoch uint64
+		want                  bool
+	}{
+		{epoch: 0, finalizedEpoch: 0, want: false},
+		{epoch: 100, finalizedEpoch: 98, want: false}, // finalizing normally
+		{epoch: 100, finalizedEpoch: 95, want: false}, // finality delay of 4
+		{epoch: 100, finalizedEpoch: 94, want: true},  // finality delay of 5
+		{epoch: 100, finalizedEpoch: 10, want: true},
+	}
+	for _, tt := range tests {
+		if got := isInInactivityLeak(tt.epoch, tt.finalizedEpoch); got != tt.want {
+			t.Errorf("isInInactivityLeak(%v, %v) = %v, want %v", tt.epoch, tt.finalizedEpoch, got, tt.want)
+		}
+	}
+}
+
+func TestParseSecondsPerSlot(t *testing.T) {
+	tests := []struct {
+		v       interface{}
+		want    uint64
+		wantErr bool
+	}{
+		{v: 12 * time.Second, want: 12},
+		{v: uint64(5), want: 5},
+		{v: "6", want: 6},
+		{v: 1500 * time.Millisecond, wantErr: true},
+		{v: -12 * time.Second, wantErr: true},
+		{v: uint64(0), wantErr: true},
+		{v: "12s", wantErr: true},
+		{v: 12, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSecondsPerSlot(tt.v)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSecondsPerSlot(%#v) = %v, %v, want %v (error: %v)", tt.v, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGetFinalizedDay(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the node finalized slot 80000 and has 6s slots, so a day has 14400 slots
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/config/spec":
+			resp, err := http.Get(bnServer.URL + r.URL.Path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			w.Write(bytes.Replace(body, []byte(`"SECONDS_PER_SLOT":"12"`), []byte(`"SECONDS_PER_SLOT":"6"`), 1))
+		case "/eth/v1/beacon/headers/finalized":
+			w.Write([]byte(`{"data":{"root":"0x3aee29bcfa7a9fdf01394a3dce74ae063c89023df71867ad1555f1e494d138ee","canonical":true,"header":{"message":{"slot":"80000","proposer_index":"1","parent_root":"0x4a451b6a4962bcbd619ee1f0b6a7d85dded49f049877de325122e21350e5d6f2","state_root":"0xf12219d8bcdb7ed125da01e4f7aa30754bff2c9fc0bf57dd728c0b02bb847a92","body_root":"0x31f4433e6e260a0fac6e80ad3f9df1998fbbab269408601a6da7a5d32ccbb258"},"signature":"0x8ccb90ff41ec1f82975fb12384f3d44194b27403f1454e878e9c07c9951df33968556e2ce0dfb8ce42e2e0bbac8c80e211d35d01617712292805bc8d9ac2e3429f821953cfc1dbb9d9ea359cd37b39850f4e29c81fc3d67e150985c609d4e826"}}}`))
+		default:
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+		}
+	}))
+	defer server.Close()
+
+	if day, err := GetFinalizedDay(context.Background(), server.URL); err != nil || day != 4 {
+		t.Errorf("wrong finalized day: %v (%v) != %v", day, err, 4)
+	}
+	if day, err := GetHeadDay(context.Background(), server.URL); err != nil || day != 5 {
+		t.Errorf("wrong head day: %v (%v) != %v", day, err, 5)
+	}
+
+	// the spec values go-eth2-client or a node may return for SECONDS_PER_SLOT
+	for _, v := range []interface{}{12 * time.Second, uint64(12), "12"} {
+		if slotsPerDay, err := specSlotsPerDay(map[string]interface{}{"SECONDS_PER_SLOT": v}); err != nil || slotsPerDay != 7200 {
+			t.Errorf("wrong slots per day of %#v: %v (%v) != %v", v, slotsPerDay, err, 7200)
+		}
+	}
+	if _, err := specSlotsPerDay(map[string]interface{}{}); err == nil {
+		t.Errorf("a spec without SECONDS_PER_SLOT should be an error")
+	}
+}
+
+func TestExactAnnualization(t *testing.T) {
+	// a day of 7s slots is 12342 slots, 6s short of 86400s
+	if got, want := exactAnnualizationDays(12342*7), decimal.NewFromInt(secondsPerYear).Div(decimal.NewFromInt(86394)); !got.Equal(want) || !got.GreaterThan(decimal.NewFromInt(365)) {
+		t.Errorf("wrong exactAnnualizationDays of 86394s: %v != %v", got, want)
+	}
+
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a complete day of 12s slots is exactly a 365th of a year
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithoutValidatorDays(), WithExactAnnualization())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.PeriodSeconds.IntPart() != 86400 || !want.PeriodSeconds.Equal(day.PeriodSeconds) {
+		t.Errorf("wrong PeriodSeconds: %v != %v", day.PeriodSeconds, 86400)
+	}
+	if !day.Apr.Equal(want.Apr) {
+		t.Errorf("wrong Apr with exact annualization: %v != %v", day.Apr, want.Apr)
+	}
+}
+
+func TestPeriodAnnualizationDays(t *testing.T) {
+	tests := []struct {
+		slotsPerDay, periodSlots uint64
+		want                     string
+	}{
+		{slotsPerDay: 7200, periodSlots: 7200, want: "365"},
+		{slotsPerDay: 7200, periodSlots: 32, want: "82125"},
+		{slotsPerDay: 7200, periodSlots: 14400, want: "182.5"},
+		{slotsPerDay: 17280, periodSlots: 16, want: "394200"},
+	}
+	for _, tt := range tests {
+		if got := periodAnnualizationDays(tt.slotsPerDay, tt.periodSlots); !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("periodAnnualizationDays(%v, %v) = %v, want %v", tt.slotsPerDay, tt.periodSlots, got, tt.want)
+		}
+	}
+}
+
+func TestIsActiveEndStatus(t *testing.T) {
+	tests := []struct {
+		status v1.ValidatorState
+		want   bool
+	}{
+		{status: v1.ValidatorStateUnknown, want: false},
+		{status: v1.ValidatorStatePendingInitialized, want: false},
+		{status: v1.ValidatorStatePendingQueued, want: false},
+		{status: v1.ValidatorStateActiveOngoing, want: true},
+		{status: v1.ValidatorStateActiveExiting, want: true},
+		{status: v1.ValidatorStateActiveSlashed, want: true},
+		{status: v1.ValidatorStateExitedUnslashed, want: true},
+		{status: v1.ValidatorStateExitedSlashed, want: true},
+		{status: v1.ValidatorStateWithdrawalPossible, want: false},
+		{status: v1.ValidatorStateWithdrawalDone, want: false},
+	}
+	for _, tt := range tests {
+		if got := isActiveEndStatus(tt.status); got != tt.want {
+			t.Errorf("isActiveEndStatus(%v) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestAlignToEpochs(t *testing.T) {
+	// 12s slots and 64 slots per epoch: a day has 7200 slots or 112.5 epochs
+	first, end := alignToEpochs(7200, 14400, 64)
+	if first != 7168 || end != 14400 {
+		t.Errorf("wrong slots for day 1: %v-%v != %v-%v", first, end, 7168, 14400)
+	}
+	first, end = alignToEpochs(14400, 21600, 64)
+	if first != 14400 || end != 21568 {
+		t.Errorf("wrong slots for day 2: %v-%v != %v-%v", first, end, 14400, 21568)
+	}
+	// day boundaries that are epoch boundaries are not changed
+	first, end = alignToEpochs(72000, 79200, 32)
+	if first != 72000 || end != 79200 {
+		t.Errorf("wrong slots for aligned day: %v-%v != %v-%v", first, end, 72000, 79200)
+	}
+}
+
+func TestLastSlotOfDay(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the deposit of validator 4 is moved from the block at slot 72003 to the block at slot 79199, the last slot of day 10
+	getBlock := func(slot int) map[string]interface{} {
+		resp, err := http.Get(fmt.Sprintf("%s/eth/v2/beacon/blocks/%d", bnServer.URL, slot))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		block := map[string]interface{}{}
+		d := json.NewDecoder(resp.Body)
+		d.UseNumber()
+		if err := d.Decode(&block); err != nil {
+			t.Fatal(err)
+		}
+		return block
+	}
+	body := func(block map[string]interface{}) map[string]interface{} {
+		return block["data"].(map[string]interface{})["message"].(map[string]interface{})["body"].(map[string]interface{})
+	}
+	depositBlock, lastBlock := getBlock(72003), getBlock(79199)
+	body(lastBlock)["deposits"] = body(depositBlock)["deposits"]
+	body(depositBlock)["deposits"] = []interface{}{}
+	requestedSlots := map[string]bool{}
+	var requestedSlotsMu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/eth/v2/beacon/blocks/") {
+			requestedSlotsMu.Lock()
+			requestedSlots[strings.TrimPrefix(r.URL.Path, "/eth/v2/beacon/blocks/")] = true
+			requestedSlotsMu.Unlock()
+		}
+		var block map[string]interface{}
+		switch r.URL.Path {
+		case "/eth/v2/beacon/blocks/72003":
+			block = depositBlock
+		case "/eth/v2/beacon/blocks/79199":
+			block = lastBlock
+		default:
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(block); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, validatorDays, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.DepositsSumGwei.Equal(want.DepositsSumGwei) || !validatorDays[4].DepositsSumGwei.Equal(decimal.NewFromInt(32e9)) || !day.Apr.Equal(want.Apr) {
+		t.Errorf("wrong DepositsSumGwei, DepositsSumGwei of validator 4, Apr: %v, %v, %v != %v, %v, %v", day.DepositsSumGwei, validatorDays[4].DepositsSumGwei, day.Apr, want.DepositsSumGwei, 32e9, want.Apr)
+	}
+	if !requestedSlots["72000"] || !requestedSlots["79199"] || requestedSlots["71999"] || requestedSlots["79200"] {
+		t.Errorf("wrong block range: got first slot %v, last slot %v, previous day %v, next day %v", requestedSlots["72000"], requestedSlots["79199"], requestedSlots["71999"], requestedSlots["79200"])
+	}
+	// the next day starts right after the last slot of the day, so no slot is scanned by both
+	_, lastSlot, _, _ := DayBounds(10, 32, 12)
+	if firstSlot, _, _, _ := DayBounds(11, 32, 12); firstSlot != lastSlot+1 {
+		t.Errorf("day 11 does not start after the last slot of day 10: %v != %v", firstSlot, lastSlot+1)
+	}
+}
+
+func TestDayBounds(t *testing.T) {
+	tests := []struct {
+		day, slotsPerEpoch, secondsPerSlot         uint64
+		firstSlot, lastSlot, firstEpoch, lastEpoch uint64
+	}{
+		{day: 0, slotsPerEpoch: 32, secondsPerSlot: 12, firstSlot: 0, lastSlot: 7199, firstEpoch: 0, lastEpoch: 224},
+		{day: 10, slotsPerEpoch: 32, secondsPerSlot: 12, firstSlot: 72000, lastSlot: 79199, firstEpoch: 2250, lastEpoch: 2474},
+		{day: 1, slotsPerEpoch: 16, secondsPerSlot: 5, firstSlot: 17280, lastSlot: 34559, firstEpoch: 1080, lastEpoch: 2159},
+		{day: 1, slotsPerEpoch: 32, secondsPerSlot: 5, firstSlot: 17280, lastSlot: 34559, firstEpoch: 540, lastEpoch: 1079},
+		// days of 7s slots do not start at an epoch boundary, the first and last epoch are only partially in the day
+		{day: 1, slotsPerEpoch: 5, secondsPerSlot: 7, firstSlot: 12342, lastSlot: 24683, firstEpoch: 2468, lastEpoch: 4936},
+	}
+	for _, tt := range tests {
+		firstSlot, lastSlot, firstEpoch, lastEpoch := DayBounds(tt.day, tt.slotsPerEpoch, tt.secondsPerSlot)
+		if firstSlot != tt.firstSlot || lastSlot != tt.lastSlot || firstEpoch != tt.firstEpoch || lastEpoch != tt.lastEpoch {
+			t.Errorf("DayBounds(%v, %v, %v) = %v, %v, %v, %v, want %v, %v, %v, %v", tt.day, tt.slotsPerEpoch, tt.secondsPerSlot, firstSlot, lastSlot, firstEpoch, lastEpoch, tt.firstSlot, tt.lastSlot, tt.firstEpoch, tt.lastEpoch)
+		}
+	}
+}
+
+func TestDayEpochs(t *testing.T) {
+	tests := []struct {
+		day, slotsPerEpoch, secondsPerSlot uint64
+		first, last                        phase0.Epoch
+	}{
+		{day: 0, slotsPerEpoch: 32, secondsPerSlot: 12, first: 0, last: 224},
+		{day: 10, slotsPerEpoch: 32, secondsPerSlot: 12, first: 2250, last: 2474},
+		// the last slot 24683 of day 1 is in epoch 4936, which day 2 starts in as well
+		{day: 1, slotsPerEpoch: 5, secondsPerSlot: 7, first: 2468, last: 4936},
+		{day: 2, slotsPerEpoch: 5, secondsPerSlot: 7, first: 4936, last: 7405},
+	}
+	for _, tt := range tests {
+		epochs := DayEpochs(tt.day, tt.slotsPerEpoch, tt.secondsPerSlot)
+		if want := int(tt.last - tt.first + 1); len(epochs) != want || epochs[0] != tt.first || epochs[len(epochs)-1] != tt.last {
+			t.Errorf("DayEpochs(%v, %v, %v) = %v epochs %v-%v, want %v epochs %v-%v", tt.day, tt.slotsPerEpoch, tt.secondsPerSlot, len(epochs), epochs[0], epochs[len(epochs)-1], want, tt.first, tt.last)
+		}
+		for i := 1; i < len(epochs); i++ {
+			if epochs[i] != epochs[i-1]+1 {
+				t.Fatalf("DayEpochs(%v, %v, %v) is not consecutive at %v", tt.day, tt.slotsPerEpoch, tt.secondsPerSlot, i)
+			}
+		}
+	}
+}
+
+func TestIsActiveDuring(t *testing.T) {
+	first, last := phase0.Epoch(2250), phase0.Epoch(2474)
+	tests := []struct {
+		name       string
+		activation phase0.Epoch
+		exit       phase0.Epoch
+		active     bool
+	}{
+		{"active, not exiting", 0, farFutureEpoch, true},
+		{"activated in first epoch", first, farFutureEpoch, true},
+		{"activated after first epoch", first + 1, farFutureEpoch, false},
+		{"pending activation", farFutureEpoch, farFutureEpoch, false},
+		{"exit in last epoch", 0, last, false},
+		{"exit in epoch after last epoch", 0, last + 1, true},
+		{"exited before first epoch", 0, first - 1, false},
+	}
+	for _, tt := range tests {
+		val := &phase0.Validator{ActivationEpoch: tt.activation, ExitEpoch: tt.exit}
+		if got := isActiveDuring(val, first, last); got != tt.active {
+			t.Errorf("%v: wrong isActiveDuring: %v != %v", tt.name, got, tt.active)
+		}
+	}
+}
+
+func TestCheckDeposit(t *testing.T) {
+	seen := map[phase0.Root]bool{}
+	d := &phase0.Deposit{
+		Proof: make([][]byte, 33),
+		Data:  &phase0.DepositData{WithdrawalCredentials: make([]byte, 32), Amount: 1},
+	}
+	for i := range d.Proof {
+		d.Proof[i] = make([]byte, 32)
+	}
+	err := checkDeposit(d, make([]byte, 32), seen)
+	if err == nil || !strings.Contains(err.Error(), "below the minimum") {
+		t.Errorf("expected error for deposit below the minimum amount, got: %v", err)
+	}
+	err = checkDeposit(d, make([]byte, 32), seen)
+	if err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("expected error for duplicate deposit, got: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	validators := []*Validator{
+		{EffectiveBalanceGwei: 32e9, StartBalanceGwei: 32e9, EndBalanceGwei: 32.0032e9, TxFeesSumWei: big.NewInt(1e15)},
+		{EffectiveBalanceGwei: 32e9, StartBalanceGwei: 32e9, EndBalanceGwei: 33.0032e9, DepositsSumGwei: 1e9, TxFeesSumWei: new(big.Int)},
+	}
+	day := &Day{
+		Validators:           decimal.NewFromInt(2),
+		EffectiveBalanceGwei: decimal.NewFromInt(64e9),
+		StartBalanceGwei:     decimal.NewFromInt(64e9),
+		EndBalanceGwei:       decimal.NewFromInt(65.0064e9),
+		DepositsSumGwei:      decimal.NewFromInt(1e9),
+		TxFeesSumWei:         decimal.NewFromInt(1e15),
+		ConsensusRewardsGwei: decimal.NewFromInt(6.4e6),
+		TotalRewardsWei:      decimal.NewFromInt(7.4e15),
+		Apr:                  decimal.NewFromInt(365).Mul(decimal.NewFromInt(7.4e15)).Div(decimal.New(64, 18)),
+	}
+	if err := Verify(day, validators, decimal.NewFromInt(365)); err != nil {
+		t.Errorf("day should match its validators: %v", err)
+	}
+
+	day.Apr = day.Apr.Add(decimal.New(1, -6))
+	day.TxFeesSumWei = decimal.Zero
+	err := Verify(day, validators, decimal.NewFromInt(365))
+	if err == nil || !strings.Contains(err.Error(), "Apr:") || !strings.Contains(err.Error(), "TxFeesSumWei:") {
+		t.Errorf("expected mismatches of Apr and TxFeesSumWei, got: %v", err)
+	}
+}
+
+func TestAggregateDays(t *testing.T) {
+	// the pool tripled on the second day, so the apr of the second day weighs three times as much
+	days := []*Day{
+		{Day: decimal.NewFromInt(10), Validators: decimal.NewFromInt(10), EffectiveBalanceGwei: decimal.NewFromInt(320e9), StartBalanceGwei: decimal.NewFromInt(320e9), EndBalanceGwei: decimal.NewFromInt(320035e6), Apr: decimal.RequireFromString("0.04"), TotalRewardsWei: decimal.New(35, 15), ProposedBlocks: decimal.NewFromInt(7100), MissedSlots: decimal.NewFromInt(100)},
+		{Day: decimal.NewFromInt(11), Validators: decimal.NewFromInt(30), EffectiveBalanceGwei: decimal.NewFromInt(960e9), StartBalanceGwei: decimal.NewFromInt(960035e6), EndBalanceGwei: decimal.NewFromInt(960166e6), Apr: decimal.RequireFromString("0.05"), TotalRewardsWei: decimal.New(131, 15), ProposedBlocks: decimal.NewFromInt(7200), PossiblyReorged: true},
+	}
+	p, err := AggregateDays(days)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Apr.Equal(decimal.RequireFromString("0.0475")) {
+		t.Errorf("wrong Apr: %v != %v", p.Apr, "0.0475")
+	}
+	if !p.Validators.Equal(decimal.NewFromInt(20)) || !p.EffectiveBalanceGwei.Equal(decimal.NewFromInt(640e9)) {
+		t.Errorf("wrong Validators and EffectiveBalanceGwei: %v, %v != %v, %v", p.Validators, p.EffectiveBalanceGwei, 20, 640e9)
+	}
+	if !p.StartBalanceGwei.Equal(days[0].StartBalanceGwei) || !p.EndBalanceGwei.Equal(days[1].EndBalanceGwei) {
+		t.Errorf("wrong StartBalanceGwei and EndBalanceGwei: %v, %v", p.StartBalanceGwei, p.EndBalanceGwei)
+	}
+	if !p.TotalRewardsWei.Equal(decimal.New(166, 15)) || !p.ProposedBlocks.Equal(decimal.NewFromInt(14300)) || !p.MissedSlots.Equal(decimal.NewFromInt(100)) || !p.PossiblyReorged {
+		t.Errorf("wrong sums: %+v", p)
+	}
+	if !p.Day.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("wrong Day: %v != %v", p.Day, 10)
+	}
+	if p.MethodologyVersion != "" || !p.ComputedAt.IsZero() {
+		t.Errorf("wrong MethodologyVersion and ComputedAt of days without: %q, %v", p.MethodologyVersion, p.ComputedAt)
+	}
+	days[0].MethodologyVersion, days[0].ComputedAt = MethodologyVersion, time.Unix(1607774423, 0)
+	days[1].MethodologyVersion, days[1].ComputedAt = MethodologyVersion, time.Unix(1607774000, 0)
+	p, err = AggregateDays(days)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MethodologyVersion != MethodologyVersion || !p.ComputedAt.Equal(days[1].ComputedAt) {
+		t.Errorf("wrong MethodologyVersion and ComputedAt: %q, %v != %q, %v", p.MethodologyVersion, p.ComputedAt, MethodologyVersion, days[1].ComputedAt)
+	}
+
+	if _, err := AggregateDays(nil); err == nil {
+		t.Errorf("expected error for no days")
+	}
+	if _, err := AggregateDays([]*Day{days[1], days[0]}); err == nil {
+		t.Errorf("expected error for days that are not consecutive")
+	}
+}
+
+func TestCompareToReference(t *testing.T) {
+	day := &Day{Day: decimal.NewFromInt(10), Apr: decimal.RequireFromString("0.0621640625"), Validators: decimal.NewFromInt(29), ConsensusRewardsGwei: decimal.NewFromInt(92800000), TxFeesSumWei: decimal.NewFromInt(65250000000000000), TotalRewardsWei: decimal.NewFromInt(158050000000000000)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/ethstore/10":
+			// the reference includes the burnt base fee in the tx fees
+			w.Write([]byte(`{"status":"OK","data":{"day":10,"apr":0.0721640625,"validators":29,"consensus_rewards_sum_wei":92800000000000000,"tx_fees_sum_wei":73250000000000000,"total_rewards_wei":166050000000000000}}`))
+		case "/days/10.json":
+			data, err := json.Marshal(day)
+			if err != nil {
+				t.Error(err)
+			}
+			w.Write(data)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	diff, err := CompareToReference(context.Background(), day, server.URL+"/api/v1/ethstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.Material || len(diff.Notes) != 1 || !strings.Contains(diff.Notes[0], "burnt base fee") {
+		t.Errorf("expected a material difference of the tx fees: %+v", diff)
+	}
+	if !diff.TxFeesSumWei.Equal(decimal.NewFromInt(-8e15)) || !diff.Apr.Equal(decimal.RequireFromString("-0.01")) {
+		t.Errorf("wrong differences: %v, %v != %v, %v", diff.TxFeesSumWei, diff.Apr, -8e15, "-0.01")
+	}
+
+	diff, err = CompareToReference(context.Background(), day, server.URL+"/days/{day}.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Material || !diff.Apr.IsZero() {
+		t.Errorf("expected no difference to the same day: %+v", diff)
+	}
+
+	if _, err := CompareToReference(context.Background(), &Day{Day: decimal.NewFromInt(11)}, server.URL+"/api/v1/ethstore"); err == nil {
+		t.Errorf("expected error for a missing reference")
+	}
+}
+
+func TestDayDelta(t *testing.T) {
+	prev := &Day{Apr: decimal.RequireFromString("0.04"), Validators: decimal.NewFromInt(100), EffectiveBalanceGwei: decimal.NewFromInt(3200e9)}
+	today := &Day{Apr: decimal.RequireFromString("0.05"), Validators: decimal.NewFromInt(100), EffectiveBalanceGwei: decimal.NewFromInt(3232e9)}
+	delta := today.Delta(prev)
+	for name, c := range map[string]struct{ got, want decimal.Decimal }{
+		"Apr":                          {delta.Apr, decimal.RequireFromString("0.01")},
+		"AprRelative":                  {delta.AprRelative, decimal.RequireFromString("0.25")},
+		"Validators":                   {delta.Validators, decimal.Zero},
+		"ValidatorsRelative":           {delta.ValidatorsRelative, decimal.Zero},
+		"EffectiveBalanceGwei":         {delta.EffectiveBalanceGwei, decimal.NewFromInt(32e9)},
+		"EffectiveBalanceGweiRelative": {delta.EffectiveBalanceGweiRelative, decimal.RequireFromString("0.01")},
+	} {
+		if !c.got.Equal(c.want) {
+			t.Errorf("%s: got %v, want %v", name, c.got, c.want)
+		}
+	}
+
+	delta = today.Delta(&Day{})
+	if !delta.AprRelative.IsZero() || !delta.Apr.Equal(today.Apr) {
+		t.Errorf("delta to an empty day should have the apr of today and no relative change, got %+v", delta)
+	}
+}
+
+func TestComputeApr(t *testing.T) {
+	// 0.0032 Eth consensus rewards and 0.001 Eth tx fees on 32 Eth: 365 * 0.0042 / 32 = 0.04790625
+	apr := ComputeApr(32e9, 32.0032e9, 0, 0, big.NewInt(1e15), 32e9, decimal.NewFromInt(365))
+	if !apr.Equal(decimal.RequireFromString("0.04790625")) {
+		t.Errorf("wrong apr: %v != %v", apr, "0.04790625")
+	}
+	// deposits are not rewards, withdrawals are
+	apr = ComputeApr(32e9, 33.0032e9, 2e9, 1e9, nil, 32e9, decimal.NewFromInt(365))
+	if !apr.Equal(decimal.RequireFromString("0.0365")) {
+		t.Errorf("wrong apr with deposits and withdrawals: %v != %v", apr, "0.0365")
+	}
+	// penalties result in a negative apr
+	apr = ComputeApr(32e9, 31.9968e9, 0, 0, nil, 32e9, decimal.NewFromInt(365))
+	if !apr.Equal(decimal.RequireFromString("-0.0365")) {
+		t.Errorf("wrong apr with penalties: %v != %v", apr, "-0.0365")
+	}
+	if apr := ComputeApr(0, 0, 0, 0, nil, 0, decimal.NewFromInt(365)); !apr.IsZero() {
+		t.Errorf("wrong apr without effective balance: %v != %v", apr, 0)
+	}
+}
+
+func TestRoundApr(t *testing.T) {
+	// rounded separately the parts would be 0.0013 and 0.0013, which do not add up to the rounded apr of 0.0025
+	d := &Day{Apr: decimal.RequireFromString("0.00251"), ConsensusApr: decimal.RequireFromString("0.00125"), ExecutionApr: decimal.RequireFromString("0.00126")}
+	d.roundApr(4)
+	if !d.Apr.Equal(decimal.RequireFromString("0.0025")) || !d.ConsensusApr.Add(d.ExecutionApr).Equal(d.Apr) {
+		t.Errorf("the rounded parts should add up to the rounded apr: %v + %v != %v", d.ConsensusApr, d.ExecutionApr, d.Apr)
+	}
+}
+
+func TestDetailedTimings(t *testing.T) {
+	// every phase of WithTimings is printed, in the order of the calculation
+	d := &Day{Timings: map[string]time.Duration{"total": 3 * time.Second, "attestations": time.Second, "blocks": 2 * time.Second}}
+	detailed := d.Detailed()
+	blocks, attestations, total := strings.Index(detailed, "timing blocks:"), strings.Index(detailed, "timing attestations:"), strings.Index(detailed, "timing total:")
+	if blocks < 0 || attestations < blocks || total < attestations {
+		t.Errorf("wrong timings in Detailed: %q", detailed)
+	}
+}
+
+func TestDayString(t *testing.T) {
+	d := &Day{
+		Day:             decimal.NewFromInt(10),
+		DayTime:         time.Date(2020, 12, 11, 12, 0, 23, 0, time.UTC),
+		Apr:             decimal.RequireFromString("0.0621640625"),
+		Validators:      decimal.NewFromInt(29),
+		TotalRewardsWei: decimal.RequireFromString("4939600000000000000"),
+	}
+	want := "day 10 (2020-12-11): apr 6.2164%, 29 validators, 4.939600 ETH rewards"
+	if d.String() != want {
+		t.Errorf("wrong String: %q != %q", d.String(), want)
+	}
+	if detailed := d.Detailed(); !strings.HasPrefix(detailed, want+"\n") || !strings.Contains(detailed, "totalRewards:") {
+		t.Errorf("wrong Detailed: %q", detailed)
+	}
+	if (*Day)(nil).String() != "<nil>" {
+		t.Errorf("wrong String of nil day: %q", (*Day)(nil).String())
+	}
+}
+
+func TestApy(t *testing.T) {
+	apr := decimal.RequireFromString("0.0365")
+	want := math.Pow(1.0001, 365) - 1
+	got := apy(apr).InexactFloat64()
+	if math.Abs(got-want) > 1e-12 {
+		t.Errorf("wrong apy: %v != %v", got, want)
+	}
+	if !apy(decimal.Zero).IsZero() {
+		t.Errorf("wrong apy for apr of 0: %v", apy(decimal.Zero))
+	}
+	if !apy(decimal.NewFromInt(-365)).Equal(decimal.NewFromInt(-1)) {
+		t.Errorf("wrong apy for total loss: %v", apy(decimal.NewFromInt(-365)))
+	}
+}
+
+func TestAssessQuality(t *testing.T) {
+	for _, tt := range []struct {
+		day                   *Day
+		unaccountedValidators int
+		anchorID              string
+		anchorSlot            uint64
+		finalizedSlot         uint64
+		score                 string
+		warnings              int
+	}{
+		{&Day{Validators: decimal.NewFromInt(29), ProposedBlocks: decimal.NewFromInt(7200)}, 0, "finalized", 80000, 80000, "1", 0},
+		{&Day{Validators: decimal.NewFromInt(29), WithoutBlocks: true}, 0, "finalized", 80000, 80000, "0.5", 1},
+		{&Day{Validators: decimal.NewFromInt(29), ProposedBlocks: decimal.NewFromInt(7200), IncompletePayloadSlots: decimal.NewFromInt(720)}, 0, "finalized", 80000, 80000, "0.95", 1},
+		{&Day{Validators: decimal.NewFromInt(29), ProposedBlocks: decimal.NewFromInt(7200)}, 0, "head", 80010, 80000, "0.9", 1},
+		{&Day{Validators: decimal.NewFromInt(29), ProposedBlocks: decimal.NewFromInt(7200), PossiblyReorged: true}, 0, "head", 80010, 80000, "0.5", 1},
+		{&Day{Validators: decimal.NewFromInt(3), ProposedBlocks: decimal.NewFromInt(7200)}, 1, "finalized", 80000, 80000, "0.9375", 1},
+		{&Day{Validators: decimal.NewFromInt(29), ProposedBlocks: decimal.NewFromInt(7200), InactivityLeakEpochs: decimal.NewFromInt(5)}, 0, "finalized", 80000, 80000, "1", 1},
+		// a day anchored at head is final once its anchor is finalized
+		{&Day{Validators: decimal.NewFromInt(29), ProposedBlocks: decimal.NewFromInt(7200)}, 0, "head", 80000, 80010, "1", 0},
+		// the penalties add up to more than 1
+		{&Day{Validators: decimal.NewFromInt(29), WithoutBlocks: true, PossiblyReorged: true, IncompletePayloadSlots: decimal.NewFromInt(1), ProposedBlocks: decimal.NewFromInt(1)}, 0, "head", 80010, 80000, "0", 3},
+	} {
+		score, warnings := assessQuality(tt.day, tt.unaccountedValidators, tt.anchorID, tt.anchorSlot, tt.finalizedSlot)
+		if !score.Equal(decimal.RequireFromString(tt.score)) || len(warnings) != tt.warnings {
+			t.Errorf("wrong quality of %+v: %v, %q != %v, %v warnings", tt.day, score, warnings, tt.score, tt.warnings)
+		}
+	}
+}
+
+func TestQualityScore(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithQualityScore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.QualityScore == nil || !day.QualityScore.Equal(decimal.NewFromInt(1)) || day.Warnings != nil {
+		t.Errorf("wrong QualityScore and Warnings of a complete day: %v, %q", day.QualityScore, day.Warnings)
+	}
+
+	// validator 5 only exists at the start of the day, 1 of 29 validators is not accounted
+	server := newModifiedValidatorsServer(t, bnServer, "72000", func(vals []map[string]interface{}) {
+		vals[5]["validator"].(map[string]interface{})["pubkey"] = fmt.Sprintf("%#x", make([]byte, 48))
+	})
+	defer server.Close()
+	day, _, err = Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithQualityScore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decimal.NewFromInt(1).Sub(decimal.RequireFromString("0.25").Div(decimal.NewFromInt(29)))
+	if day.QualityScore == nil || !day.QualityScore.Equal(want) || len(day.Warnings) != 1 {
+		t.Errorf("wrong QualityScore and Warnings: %v, %q != %v", day.QualityScore, day.Warnings, want)
+	}
+	if !strings.Contains(day.Detailed(), "qualityScore") {
+		t.Errorf("expected the quality score in the details: %v", day.Detailed())
+	}
+
+	next := *day
+	next.Day = day.Day.Add(decimal.NewFromInt(1))
+	next.QualityScore = nil
+	next.Warnings = nil
+	aggregate, err := AggregateDays([]*Day{day, &next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregate.QualityScore == nil || !aggregate.QualityScore.Equal(want) || len(aggregate.Warnings) != 1 || !strings.HasPrefix(aggregate.Warnings[0], "day 10: ") {
+		t.Errorf("wrong aggregated QualityScore and Warnings: %v, %q", aggregate.QualityScore, aggregate.Warnings)
+	}
+}
+
+func TestDayDiff(t *testing.T) {
+	a := &Day{Day: decimal.NewFromInt(10), Apr: decimal.RequireFromString("0.05"), DayTime: time.Unix(1606824023, 0).UTC()}
+	b := &Day{Day: decimal.NewFromInt(10), Apr: decimal.RequireFromString("0.0500"), DayTime: time.Unix(1606824023, 0).In(time.FixedZone("UTC+2", 7200)), ComputedAt: time.Now()}
+	if !a.Equal(b) {
+		t.Errorf("days should be equal: %v", Diff(a, b))
+	}
+
+	b.Apr = decimal.RequireFromString("0.06")
+	b.Validators = decimal.NewFromInt(1)
+	diffs := Diff(a, b)
+	if len(diffs) != 2 || diffs[0].Field != "Apr" || diffs[1].Field != "Validators" {
+		t.Errorf("wrong diffs: %v", diffs)
+	}
+	if a.Equal(b) {
+		t.Errorf("days should not be equal")
+	}
+}
+
+func TestDayEthHelpers(t *testing.T) {
+	d := &Day{
+		TxFeesSumWei:         decimal.RequireFromString("72000000000000000001"),
+		ConsensusRewardsGwei: decimal.RequireFromString("92800001"),
+		TotalRewardsWei:      decimal.RequireFromString("164800001000000000"),
+		EffectiveBalanceGwei: decimal.NewFromInt(928e9),
+	}
+	for _, test := range []struct {
+		name string
+		got  decimal.Decimal
+		want string
+	}{
+		{name: "TxFeesEth", got: d.TxFeesEth(), want: "72.000000000000000001"},
+		{name: "ConsensusRewardsEth", got: d.ConsensusRewardsEth(), want: "0.092800001"},
+		{name: "TotalRewardsEth", got: d.TotalRewardsEth(), want: "0.164800001"},
+		{name: "EffectiveBalanceEth", got: d.EffectiveBalanceEth(), want: "928"},
+	} {
+		if !test.got.Equal(decimal.RequireFromString(test.want)) {
+			t.Errorf("wrong %v: %v != %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func createTx(feeGwei uint64) []byte {
+	privateKey, err := crypto.HexToECDSA("fad9c8855b740a0b7ed4c221dbad0f33a83a49cad6b3fe8d5817ac83d38b6a19")
+	if err != nil {
+		log.Fatal(err)
+	}
+	value := big.NewInt(1e18)
+	gasLimit := uint64(feeGwei)
+	toAddress := common.HexToAddress("0x4592d8f8d7b001e72cb26a73e4fa1806a51ac79d")
+	var data []byte
+	tx := types.NewTransaction(1, toAddress, value, gasLimit, new(big.Int).SetInt64(1e9), data)
+	chainID := new(big.Int).SetInt64(11155111)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := signedTx.MarshalBinary()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return b
+}
+
+func TestLoadValidatorSetFromFile(t *testing.T) {
+	pubkey1 := "0x" + strings.Repeat("a1", 48)
+	pubkey2 := "0x" + strings.Repeat("b2", 48)
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		name    string
+		content string
+		err     bool
+	}{
+		{"pubkeys.txt", "# validators\n" + pubkey1 + "\n\n" + strings.ToUpper(pubkey2[2:]) + "\n" + pubkey1 + "\n", false},
+		{"pubkeys.json", `["` + pubkey1 + `","` + pubkey2 + `"]`, false},
+		{"keystores.json", `{"data":[{"validating_pubkey":"` + pubkey1 + `","derivation_path":"m/12381/3600/0/0/0","readonly":false},{"validating_pubkey":"` + pubkey2 + `","readonly":true}]}`, false},
+		{"validator_definitions.yml", "---\n- enabled: true\n  voting_public_key: \"" + pubkey1 + "\"\n  type: local_keystore\n- enabled: false\n  voting_public_key: " + pubkey2 + "\n  type: web3signer\n", false},
+		{"invalid.txt", pubkey1 + "\n0x1234\n", true},
+	} {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		pubkeys, err := LoadValidatorSetFromFile(path)
+		if tt.err {
+			if err == nil {
+				t.Errorf("no error for %v: %v", tt.name, pubkeys)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(pubkeys, []string{pubkey1, pubkey2}) {
+			t.Errorf("wrong pubkeys of %v: %v, %v", tt.name, pubkeys, err)
+		}
+	}
+
+	keystores := filepath.Join(dir, "keystores")
+	if err := os.Mkdir(keystores, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i, pubkey := range []string{pubkey1, pubkey2} {
+		keystore := `{"crypto":{},"path":"m/12381/3600/` + strconv.Itoa(i) + `/0/0","pubkey":"` + pubkey[2:] + `","version":4}`
+		if err := os.WriteFile(filepath.Join(keystores, fmt.Sprintf("keystore-%d.json", i)), []byte(keystore), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pubkeys, err := LoadValidatorSetFromFile(keystores)
+	if err != nil || !reflect.DeepEqual(pubkeys, []string{pubkey1, pubkey2}) {
+		t.Errorf("wrong pubkeys of keystores: %v, %v", pubkeys, err)
+	}
+}
+
+func TestResolveValidatorIndices(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	pubkey4 := "0xb07210c8839f03532d8b7e27a1b0ec9503454fa29a2cbe563896636757214247699420553ce51f78fa9d72d79d0a2fc1"
+	pubkeys := []string{fmt.Sprintf("%#096x", 7), strings.ToUpper(pubkey4[2:]), fmt.Sprintf("%#096x", 5)}
+	indices, err := ResolveValidatorIndices(context.Background(), bnServer.URL, "79200", pubkeys)
+	if err != nil || !reflect.DeepEqual(indices, []uint64{7, 4, 5}) {
+		t.Errorf("wrong indices: %v, %v != [7 4 5]", indices, err)
+	}
+
+	// the resolved indices are the validator set of the pubkeys
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithValidatorSets(map[string][]uint64{"a": indices}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := day.ValidatorSetMeta["a"]; meta.Matched != 3 {
+		t.Errorf("wrong Matched of set a: %v != %v", meta.Matched, 3)
+	}
+
+	if indices, err := ResolveValidatorIndices(context.Background(), bnServer.URL, "79200", append(pubkeys, "0x"+strings.Repeat("a1", 48))); err == nil {
+		t.Errorf("no error for a pubkey that is not a validator: %v", indices)
+	}
+	if indices, err := ResolveValidatorIndices(context.Background(), bnServer.URL, "79200", []string{"0x1234"}); err == nil {
+		t.Errorf("no error for an invalid pubkey: %v", indices)
+	}
+}
+
+func TestHashSets(t *testing.T) {
+	key := HashSets(map[string][]uint64{"a": {3, 1, 2}, "b": {7}})
+	if len(key) != 64 {
+		t.Errorf("wrong key length: %v", key)
+	}
+	if other := HashSets(map[string][]uint64{"b": {7, 7}, "a": {2, 3, 1}}); other != key {
+		t.Errorf("different keys of the same sets: %v != %v", other, key)
+	}
+	for _, sets := range []map[string][]uint64{
+		{"a": {1, 2, 3}, "b": {8}},
+		{"a": {1, 2, 3}, "c": {7}},
+		{"a": {1, 2, 3, 7}},
+		{"ab": {1, 2, 3}, "": {7}},
+		{"a": {1, 2, 3}, "b": {7}, "c": nil},
+	} {
+		if HashSets(sets) == key {
+			t.Errorf("same key of different sets %v", sets)
+		}
+	}
+	if HashSets(nil) != HashSets(map[string][]uint64{}) {
+		t.Errorf("different keys of no sets")
+	}
+}
+
+func TestValidatorSets(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	all := make([]uint64, 0, 29)
+	for i := uint64(4); i <= 32; i++ {
+		all = append(all, i)
+	}
+	// validator 0 exited before the day and 1000 does not exist
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithValidatorSets(map[string][]uint64{"pool": {5, 4, 5, 0, 1000}, "all": all}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(day.ValidatorSets) != 2 || len(day.ValidatorSetMeta) != 2 {
+		t.Fatalf("wrong ValidatorSets: %v, %v", day.ValidatorSets, day.ValidatorSetMeta)
+	}
+	pool := day.ValidatorSets["pool"]
+	rewardsWei := validatorDays[4].TotalRewardsWei.Add(validatorDays[5].TotalRewardsWei)
+	apr := decimal.NewFromInt(365).Mul(rewardsWei).Div(decimal.NewFromInt(64e9).Mul(decimal.NewFromInt(1e9)))
+	if pool.Validators.IntPart() != 2 || !pool.TotalRewardsWei.Equal(rewardsWei) || !pool.Apr.Equal(apr) || !pool.DepositsSumGwei.Equal(validatorDays[4].DepositsSumGwei) {
+		t.Errorf("wrong day of the pool: %v validators, %v rewards, apr %v, %v deposits != 2, %v, %v, %v", pool.Validators, pool.TotalRewardsWei, pool.Apr, pool.DepositsSumGwei, rewardsWei, apr, validatorDays[4].DepositsSumGwei)
+	}
+	// 4 and 5 are in both sets and 5 is passed twice
+	if meta := day.ValidatorSetMeta["pool"]; meta.Requested != 4 || meta.Matched != 2 || !reflect.DeepEqual(meta.Missing, []uint64{0, 1000}) || !reflect.DeepEqual(meta.OutOfRange, []uint64{1000}) || meta.Duplicates != 1 || meta.Overlapping != 2 {
+		t.Errorf("wrong meta of the pool: %+v", meta)
+	}
+	// a set of all accounted validators is the day of all validators
+	if s := day.ValidatorSets["all"]; !s.Apr.Equal(day.Apr) || !s.TotalRewardsWei.Equal(day.TotalRewardsWei) || !s.SyncParticipationRate.Equal(day.SyncParticipationRate) || !s.ProposedBlocks.Equal(day.ProposedBlocks) {
+		t.Errorf("wrong day of all validators: %v != %v", s, day)
+	}
+	if meta := day.ValidatorSetMeta["all"]; meta.Requested != 29 || meta.Matched != 29 || meta.Missing != nil || meta.OutOfRange != nil || meta.Duplicates != 0 || meta.Overlapping != 2 {
+		t.Errorf("wrong meta of all validators: %+v", meta)
+	}
+	if !strings.Contains(day.Detailed(), "set pool:") {
+		t.Errorf("no set in Detailed: %v", day.Detailed())
+	}
+
+	// the sets are aggregated like the days
+	next, nextPool := *day, *pool
+	next.Day = day.Day.Add(decimal.NewFromInt(1))
+	nextPool.Day = next.Day
+	next.ValidatorSets = map[string]*Day{"pool": &nextPool}
+	aggregate, err := AggregateDays([]*Day{day, &next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := aggregate.ValidatorSets["pool"]; s == nil || !s.TotalRewardsWei.Equal(rewardsWei.Mul(decimal.NewFromInt(2))) || !s.Apr.Equal(apr) {
+		t.Errorf("wrong aggregated day of the pool: %v", s)
+	}
+	// no sets are like no WithValidatorSets
+	day, _, err = Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithValidatorSets(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.ValidatorSets != nil || day.ValidatorSetMeta != nil {
+		t.Errorf("expected no ValidatorSets without sets, got %v, %v", day.ValidatorSets, day.ValidatorSetMeta)
+	}
+
+	// only next has no set of all validators
+	if _, exists := aggregate.ValidatorSets["all"]; exists {
+		t.Errorf("set of all validators aggregated without a day of next")
+	}
+}
+
+func TestWithValidatorSetMaps(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithValidatorSets(map[string][]uint64{"pool": {4, 5, 1000}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// entries that are false are not in the set
+	sets := map[string]map[phase0.ValidatorIndex]bool{"pool": {4: true, 5: true, 6: false, 1000: true}}
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithValidatorSetMaps(sets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.ValidatorSets["pool"].Equal(want.ValidatorSets["pool"]) || !reflect.DeepEqual(day.ValidatorSetMeta, want.ValidatorSetMeta) {
+		t.Errorf("wrong day of the pool: %v, %+v != %v, %+v", day.ValidatorSets["pool"], day.ValidatorSetMeta, want.ValidatorSets["pool"], want.ValidatorSetMeta)
+	}
+	if len(sets["pool"]) != 4 {
+		t.Errorf("sets modified: %v", sets)
+	}
+}
+
+func TestAprExMev(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// no builder payments are attributed in the test data, so the apr without them is the apr
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithMevLastTxAttribution(), WithValidatorSets(map[string][]uint64{"pool": {4, 5}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.MevRewardsWei.IsZero() || !day.AprExMev.Equal(day.Apr) || !validatorDays[5].AprExMev.Equal(validatorDays[5].Apr) || !day.ValidatorSets["pool"].AprExMev.Equal(day.ValidatorSets["pool"].Apr) {
+		t.Errorf("wrong AprExMev without mev rewards: %v, %v, %v != %v, %v, %v", day.AprExMev, validatorDays[5].AprExMev, day.ValidatorSets["pool"].AprExMev, day.Apr, validatorDays[5].Apr, day.ValidatorSets["pool"].Apr)
+	}
+
+	// 2 validators with 1 and 0 ETH of builder payments besides 0.01 ETH of tx fees each
+	vals := []*Validator{
+		{Index: 1, EffectiveBalanceGwei: 32e9, StartBalanceGwei: 32e9, EndBalanceGwei: 32e9 + 3e6, TxFeesSumWei: big.NewInt(1e16), MevRewardsWei: big.NewInt(1e18)},
+		{Index: 2, EffectiveBalanceGwei: 32e9, StartBalanceGwei: 32e9, EndBalanceGwei: 32e9 + 3e6, TxFeesSumWei: big.NewInt(1e16)},
+	}
+	setDay := validatorSetDay(&Day{Day: decimal.NewFromInt(10)}, vals, 0, decimal.NewFromInt(365), defaultAprTrimFraction)
+	aprExMev := ComputeApr(64e9, 64e9+6e6, 0, 0, big.NewInt(2e16), 64e9, decimal.NewFromInt(365))
+	mevApr := decimal.NewFromInt(365).Mul(decimal.NewFromInt(1e18)).Div(decimal.NewFromInt(64e9).Mul(decimal.NewFromInt(1e9)))
+	if !setDay.AprExMev.Equal(aprExMev) || !setDay.Apr.Sub(setDay.AprExMev).Equal(mevApr) {
+		t.Errorf("wrong AprExMev: %v (apr %v) != %v (apr %v)", setDay.AprExMev, setDay.Apr, aprExMev, aprExMev.Add(mevApr))
+	}
+
+	// the aggregated AprExMev is weighted by the effective balance like the apr
+	next := *setDay
+	next.Day = setDay.Day.Add(decimal.NewFromInt(1))
+	next.EffectiveBalanceGwei = setDay.EffectiveBalanceGwei.Mul(decimal.NewFromInt(3))
+	next.AprExMev = decimal.Zero
+	aggregate, err := AggregateDays([]*Day{setDay, &next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := aprExMev.Div(decimal.NewFromInt(4)); !aggregate.AprExMev.Equal(want) {
+		t.Errorf("wrong aggregated AprExMev: %v != %v", aggregate.AprExMev, want)
+	}
+}
+
+func TestWithdrawalRequests(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// electra activates at epoch 2300 of day 10 and the blocks at slots 72100 (before the fork) and 74000 have
+	// withdrawal requests: a partial withdrawal and a full exit of accounted validators and a partial withdrawal of
+	// validator 2, which is not accounted
+	request := func(index int, amount string) string {
+		return fmt.Sprintf(`{"source_address":"0x9709ae4129ed4bb3fa6678e83a9976b7cc81abd1","validator_pubkey":"%#096x","amount":"%s"}`, index, amount)
+	}
+	requests := map[string]string{
+		"/eth/v2/beacon/blocks/72100": request(5, "7000000000"),
+		"/eth/v2/beacon/blocks/74000": request(5, "1000000000") + "," + request(6, "0") + "," + request(2, "5000000000"),
+	}
+	electraServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		withdrawals, isRequestBlock := requests[r.URL.Path]
+		if r.URL.Path != "/eth/v1/config/spec" && !isRequestBlock {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		resp, err := http.Get(bnServer.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if isRequestBlock {
+			b = bytes.Replace(b, []byte(`"body":{`), []byte(`"body":{"execution_requests":{"deposits":[],"withdrawals":[`+withdrawals+`],"consolidations":[]},`), 1)
+		} else {
+			b = bytes.Replace(b, []byte(`{"data":{`), []byte(`{"data":{"ELECTRA_FORK_EPOCH":"2300",`), 1)
+		}
+		w.Write(b)
+	}))
+	defer electraServer.Close()
+
+	day, validatorDays, err := Calculate(context.Background(), electraServer.URL, elServer.URL, "10", 4, WithWithdrawalRequests(), WithValidatorSets(map[string][]uint64{"pool": {5, 6}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.WithdrawalRequestsSumGwei.Equal(decimal.NewFromInt(1e9)) || !validatorDays[5].WithdrawalRequestsSumGwei.Equal(decimal.NewFromInt(1e9)) || !validatorDays[6].WithdrawalRequestsSumGwei.IsZero() || !day.ValidatorSets["pool"].WithdrawalRequestsSumGwei.Equal(decimal.NewFromInt(1e9)) {
+		t.Errorf("wrong WithdrawalRequestsSumGwei: %v, %v, %v, %v != %v, %v, 0, %v", day.WithdrawalRequestsSumGwei, validatorDays[5].WithdrawalRequestsSumGwei, validatorDays[6].WithdrawalRequestsSumGwei, day.ValidatorSets["pool"].WithdrawalRequestsSumGwei, 1e9, 1e9, 1e9)
+	}
+	// the requests do not change the balances or the rewards
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.TotalRewardsWei.Equal(want.TotalRewardsWei) || !want.WithdrawalRequestsSumGwei.IsZero() {
+		t.Errorf("wrong day with withdrawal requests: %v rewards, %v requested != %v, 0", day.TotalRewardsWei, want.WithdrawalRequestsSumGwei, want.TotalRewardsWei)
+	}
+
+	if _, _, err := Calculate(context.Background(), electraServer.URL, elServer.URL, "10", 4, WithWithdrawalRequests(), WithoutBlocks()); err == nil {
+		t.Errorf("expected error for WithWithdrawalRequests without blocks")
+	}
+}
+
+func TestActiveFractionNormalization(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, wantValidatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithActiveFractionNormalization())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the day and the days of the accounted validators do not change
+	if !day.Equal(want) || !validatorDays[5].Equal(wantValidatorDays[5]) {
+		t.Errorf("the day changed: %v, %v", Diff(day, want), Diff(validatorDays[5], wantValidatorDays[5]))
+	}
+	if len(validatorDays) != len(wantValidatorDays)+1 {
+		t.Fatalf("wrong number of validator days: %v != %v", len(validatorDays), len(wantValidatorDays)+1)
+	}
+
+	// validator 2 activated on the second epoch of day 10, so it was active in 224 of its 225 epochs
+	d := validatorDays[2]
+	activeFraction := decimal.NewFromInt(224).Div(decimal.NewFromInt(225))
+	if d == nil || d.ActiveFraction == nil || !d.ActiveFraction.Equal(activeFraction) {
+		t.Fatalf("wrong day of validator 2: %v", d)
+	}
+	normalizedTxFeesWei := d.TxFeesSumWei.Div(activeFraction).BigInt()
+	apr := ComputeApr(32e9, 32003200000, 0, 0, normalizedTxFeesWei, 32e9, decimal.NewFromInt(365))
+	consensusApr := ComputeApr(32e9, 32003200000, 0, 0, nil, 32e9, decimal.NewFromInt(365))
+	if !d.TxFeesSumWei.IsPositive() || !d.Apr.Equal(apr) || !d.ConsensusApr.Equal(consensusApr) || !d.ConsensusApr.Add(d.ExecutionApr).Equal(d.Apr) {
+		t.Errorf("wrong apr of validator 2: %v (consensus %v, execution %v) != %v (consensus %v)", d.Apr, d.ConsensusApr, d.ExecutionApr, apr, consensusApr)
+	}
+	// the sums are not normalized
+	if !d.TotalRewardsWei.Equal(d.TxFeesSumWei.Add(d.ConsensusRewardsGwei.Mul(decimal.NewFromInt(1e9)))) || validatorDays[5].ActiveFraction != nil {
+		t.Errorf("wrong TotalRewardsWei of validator 2 or ActiveFraction of validator 5: %v, %v", d.TotalRewardsWei, validatorDays[5].ActiveFraction)
+	}
+
+	for _, opt := range []Option{WithoutValidatorDays(), WithoutBlocks()} {
+		if _, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithActiveFractionNormalization(), opt); err == nil {
+			t.Errorf("expected error for invalid options")
+		}
+	}
+}
+
+func TestWithOperatorSets(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	pool := []uint64{4, 5, 6, 7}
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithOperatorSets(pool, map[string][]uint64{"a": {4, 5}, "b": {6}, "c": {7, 7}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	poolDay, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithValidatorSets(map[string][]uint64{"pool": pool}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := poolDay.ValidatorSets["pool"]
+	rewardsWei, effectiveBalanceGwei, weightedAprSum := decimal.Zero, decimal.Zero, decimal.Zero
+	for _, d := range day.ValidatorSets {
+		rewardsWei = rewardsWei.Add(d.TotalRewardsWei)
+		effectiveBalanceGwei = effectiveBalanceGwei.Add(d.EffectiveBalanceGwei)
+		weightedAprSum = weightedAprSum.Add(d.Apr.Mul(d.EffectiveBalanceGwei))
+	}
+	if len(day.ValidatorSets) != 3 || !rewardsWei.Equal(want.TotalRewardsWei) || !effectiveBalanceGwei.Equal(want.EffectiveBalanceGwei) || !weightedAprSum.Div(effectiveBalanceGwei).Equal(want.Apr) {
+		t.Errorf("the operators do not add up to the pool: %v, %v, %v != %v, %v, %v", rewardsWei, effectiveBalanceGwei, weightedAprSum.Div(effectiveBalanceGwei), want.TotalRewardsWei, want.EffectiveBalanceGwei, want.Apr)
+	}
+
+	for _, operators := range []map[string][]uint64{
+		{"a": {4, 5}, "b": {5, 6, 7}},
+		{"a": {4, 5}, "b": {6}},
+		{"a": {4, 5}, "b": {6, 7, 8}},
+	} {
+		if _, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithOperatorSets(pool, operators)); err == nil {
+			t.Errorf("expected error for operators %v that do not partition the pool", operators)
+		}
+	}
+}
+
+func TestCalculateSlots(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// the block of slot 72003 includes the 32 Eth deposit of validator 4, slot 72010 is accounted once
+	slots := []uint64{79199, 72010, 72003, 72010}
+	day, err := CalculateSlots(context.Background(), bnServer.URL, elServer.URL, slots, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Day.Equal(decimal.NewFromInt(10)) || !day.DayTime.Equal(time.Unix(1606824023+72000*12, 0)) {
+		t.Errorf("got day %v at %v, want day 10 at %v", day.Day, day.DayTime, time.Unix(1606824023+72000*12, 0))
+	}
+	if !day.ProposedBlocks.Equal(decimal.NewFromInt(3)) || !day.MissedSlots.IsZero() || !day.TotalTxCount.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("got %v proposed blocks, %v missed slots and %v txs, want 3, 0 and 3", day.ProposedBlocks, day.MissedSlots, day.TotalTxCount)
+	}
+	if !day.TxFeesSumWei.Equal(decimal.NewFromInt(3*10000e9)) || !day.TotalRewardsWei.Equal(day.TxFeesSumWei) {
+		t.Errorf("got tx fees %v and total rewards %v, want %v", day.TxFeesSumWei, day.TotalRewardsWei, 3*10000e9)
+	}
+	if !day.DepositsSumGwei.Equal(decimal.NewFromInt(32e9)) {
+		t.Errorf("got deposits %v, want 32e9", day.DepositsSumGwei)
+	}
+
+	// the periods of 3600 slots number the first slot 72003 into period 20, which starts at slot 72000 as well
+	day, err = CalculateSlots(context.Background(), bnServer.URL, elServer.URL, slots, 4, WithPeriodSlots(3600))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Day.Equal(decimal.NewFromInt(20)) || !day.DayTime.Equal(time.Unix(1606824023+72000*12, 0)) {
+		t.Errorf("got period %v at %v, want period 20 at %v", day.Day, day.DayTime, time.Unix(1606824023+72000*12, 0))
+	}
+}
+
+func TestCanonicalBlockRootsConcurrency(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// a chain with the anchor at slot 200, slot 120 was missed and the node has another block at slot 150 than the
+	// chain of the anchor. The block roots start with 0xaa followed by the slot, those of the other fork with 0xcc.
+	root := func(prefix string, slot uint64) string { return fmt.Sprintf("0x%s%062x", prefix, slot) }
+	header := func(prefix string, slot uint64) string {
+		parent := slot - 1
+		if parent == 120 {
+			parent = 119
+		}
+		return fmt.Sprintf(`{"data":{"root":"%s","canonical":true,"header":{"message":{"slot":"%d","proposer_index":"1","parent_root":"%s","state_root":"%s","body_root":"%#064x"},"signature":"%#0192x"}}}`, root(prefix, slot), slot, root("aa", parent), root("bb", slot), 0, 0)
+	}
+	var inFlight, maxInFlight, rootRequests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/eth/v1/beacon/headers/")
+		if id == r.URL.Path {
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+			return
+		}
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			m := atomic.LoadInt64(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt64(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if strings.HasPrefix(id, "0x") {
+			atomic.AddInt64(&rootRequests, 1)
+			slot, err := strconv.ParseUint(id[4:], 16, 64)
+			if err != nil || !strings.HasPrefix(id, "0xaa") {
+				t.Errorf("unexpected header request: %v", r.URL.Path)
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(header("aa", slot)))
+			return
+		}
+		slot, err := strconv.ParseUint(id, 10, 64)
+		switch {
+		case err != nil:
+			t.Errorf("unexpected header request: %v", r.URL.Path)
+			http.NotFound(w, r)
+		case slot == 120:
+			http.NotFound(w, r)
+		case slot == 150:
+			w.Write([]byte(header("cc", slot)))
+		default:
+			w.Write([]byte(header("aa", slot)))
+		}
+	}))
+	defer server.Close()
+
+	client, err := newConsClient(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anchorHeader, err := client.BeaconBlockHeader(context.Background(), "200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt64(&maxInFlight, 0)
+	roots, stateRoots, err := getCanonicalBlockRoots(context.Background(), client, anchorHeader, 100, 190, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(&maxInFlight); n < 2 || n > 4 {
+		t.Errorf("got %v concurrent header requests, want 2 to 4", n)
+	}
+	// only the block at slot 150 of the chain of the anchor is fetched by its root
+	if n := atomic.LoadInt64(&rootRequests); n != 1 {
+		t.Errorf("got %v header requests by root, want 1", n)
+	}
+	if len(roots) != 89 || len(stateRoots) != 90 {
+		t.Errorf("got %v roots and %v state roots, want 89 and 90", len(roots), len(stateRoots))
+	}
+	for slot := uint64(100); slot <= 190; slot++ {
+		if _, exists := stateRoots[slot]; exists == (slot == 120) {
+			t.Errorf("state root of slot %v exists: %v", slot, exists)
+		}
+		if got, exists := roots[slot]; slot < 190 && slot != 120 && fmt.Sprintf("%#x", got) != root("aa", slot) {
+			t.Errorf("got root %#x (%v) of slot %v, want %v", got, exists, slot, root("aa", slot))
+		}
+	}
+}
+
+func TestEpochBoundaries(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// period 10 of 7199 slots spans the slots 71990-79188, aligned to epochs 2249-2473 it spans the slots 71968-79167.
+	// The node serves the states at the aligned slots with the validators at the start and end of day 10, and the
+	// slots before 72000 were missed.
+	var mu sync.Mutex
+	states := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/states/"):
+			mu.Lock()
+			states[parts[5]] = true
+			mu.Unlock()
+			switch parts[5] {
+			case "71968":
+				parts[5] = "72000"
+			case "79168":
+				parts[5] = "79200"
+			default:
+				t.Errorf("unexpected state request: %v", r.URL.Path)
+				http.NotFound(w, r)
+				return
+			}
+			http.Redirect(w, r, bnServer.URL+strings.Join(parts, "/"), http.StatusTemporaryRedirect)
+		case strings.HasPrefix(r.URL.Path, "/eth/v2/beacon/blocks/"):
+			slot, err := strconv.ParseUint(parts[len(parts)-1], 10, 64)
+			if err != nil || slot < 71968 || slot >= 79168 {
+				t.Errorf("unexpected block request: %v", r.URL.Path)
+			}
+			if slot < 72000 {
+				http.NotFound(w, r)
+				return
+			}
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+		default:
+			http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+		}
+	}))
+	defer server.Close()
+
+	day, _, err := Calculate(context.Background(), server.URL, elServer.URL, "10", 4, WithPeriodSlots(7199), WithEpochBoundaries())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !states["71968"] || !states["79168"] {
+		t.Errorf("the balances should be sampled at the aligned slots 71968 and 79168, got the states %v", states)
+	}
+	if !day.StartEpoch.Equal(decimal.NewFromInt(2249)) {
+		t.Errorf("got start epoch %v, want 2249", day.StartEpoch)
+	}
+	// validator 1 exits at epoch 2474 after the aligned period and is accounted with its balances of 32 and 32.0032 Eth
+	wantStartBalance := want.StartBalanceGwei.Add(decimal.NewFromInt(32e9))
+	wantEndBalance := want.EndBalanceGwei.Add(decimal.NewFromInt(32003200000))
+	if !day.StartBalanceGwei.Equal(wantStartBalance) || !day.EndBalanceGwei.Equal(wantEndBalance) || day.Validators.IntPart() != 30 {
+		t.Errorf("got balances %v-%v of %v validators, want %v-%v of 30", day.StartBalanceGwei, day.EndBalanceGwei, day.Validators, wantStartBalance, wantEndBalance)
+	}
+	// the blocks of the slots 72000-79167, 2 of every 32 were proposed by the validators 2 and 3 that are not accounted
+	if !day.ProposedBlocks.Equal(decimal.NewFromInt(7168)) || !day.TxFeesSumWei.Equal(decimal.NewFromInt(6720*10000e9)) {
+		t.Errorf("got %v blocks with tx fees of %v, want 7168 blocks with the fees of 6720", day.ProposedBlocks, day.TxFeesSumWei)
+	}
+}
+
+func TestCalculateDeposits(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	// only the blocks and the state at the end of the day are read
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/states/") && !strings.HasPrefix(r.URL.Path, "/eth/v1/beacon/states/79200/") {
+			t.Errorf("unexpected state request: %v", r.URL.Path)
+		}
+		http.Redirect(w, r, bnServer.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	deposits, err := CalculateDeposits(context.Background(), server.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deposits) != 1 || deposits[4] != 32e9 {
+		t.Errorf("got deposits %v, want the 32 Eth of validator 4", deposits)
+	}
+
+	deposits, err = CalculateDeposits(context.Background(), server.URL, "10", 4, WithDepositFilter(func(d *phase0.Deposit) bool { return false }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deposits) != 0 {
+		t.Errorf("all deposits should have been filtered: %v", deposits)
+	}
+}
+
+func TestRequestsPerSecond(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+	defer SetRequestsPerSecond(0)
+
+	// a burst of 100 requests passes at once, the next 50 requests wait for half a second
+	SetRequestsPerSecond(100)
+	start := time.Now()
+	for i := 0; i < 150; i++ {
+		if err := waitForRequest(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("150 requests at 100 per second took only %v", elapsed)
+	}
+
+	// with one request per second the calculation can not finish before the deadline of the context
+	SetRequestsPerSecond(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := CalculateSlots(ctx, bnServer.URL, elServer.URL, []uint64{72000, 72001, 72002}, 4); err == nil {
+		t.Errorf("expected error for requests that exceed the deadline of the context")
+	}
+
+	SetRequestsPerSecond(0)
+	if _, err := CalculateSlots(context.Background(), bnServer.URL, elServer.URL, []uint64{72000, 72001, 72002}, 4); err != nil {
+		t.Errorf("the requests should not be limited anymore: %v", err)
+	}
+}
+
+func TestAprPrecision(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	want, wantValidatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, validatorDays, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithAprPrecision(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Apr.Equal(want.Apr.Round(4)) {
+		t.Fatalf("the apr %v of the test data should have more than 4 decimal places", want.Apr)
+	}
+	if !day.Apr.Equal(want.Apr.Round(4)) || !day.Apy.Equal(want.Apy.Round(4)) || !day.ConsensusApr.Add(day.ExecutionApr).Equal(day.Apr) {
+		t.Errorf("got apr %v (%v + %v) and apy %v, want %v and %v", day.Apr, day.ConsensusApr, day.ExecutionApr, day.Apy, want.Apr.Round(4), want.Apy.Round(4))
+	}
+	for index, d := range validatorDays {
+		if !d.Apr.Equal(wantValidatorDays[index].Apr.Round(4)) {
+			t.Errorf("got apr %v of validator %v, want %v", d.Apr, index, wantValidatorDays[index].Apr.Round(4))
+		}
+		// the performance score is derived from the unrounded aprs
+		if !d.PerformanceScore.Equal(wantValidatorDays[index].PerformanceScore) {
+			t.Errorf("got performance score %v of validator %v, want %v", d.PerformanceScore, index, wantValidatorDays[index].PerformanceScore)
+		}
+	}
+	if !day.TotalRewardsWei.Equal(want.TotalRewardsWei) {
+		t.Errorf("the rewards should not be rounded: %v != %v", day.TotalRewardsWei, want.TotalRewardsWei)
+	}
+}
+
+func TestTimings(t *testing.T) {
+	bnServer, elServer := newTestServers(t, false)
+	defer bnServer.Close()
+	defer elServer.Close()
+
+	day, _, err := Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithoutValidatorDays())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.Timings != nil {
+		t.Errorf("timings should only be recorded with WithTimings: %v", day.Timings)
+	}
+
+	day, _, err = Calculate(context.Background(), bnServer.URL, elServer.URL, "10", 4, WithoutValidatorDays(), WithTimings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	phases := []string{"spec", "validators", "blocks", "reorgCheck", "aggregation"}
+	if len(day.Timings) != len(phases)+1 {
+		t.Errorf("got timings %v, want the phases %v and total", day.Timings, phases)
+	}
+	sum := time.Duration(0)
+	for _, phase := range phases {
+		d, exists := day.Timings[phase]
+		if !exists || d < 0 {
+			t.Errorf("no timing of phase %v: %v", phase, day.Timings)
+		}
+		sum += d
+	}
+	if total := day.Timings["total"]; total < sum || total <= 0 {
+		t.Errorf("total %v should be at least the sum %v of the phases", total, sum)
 	}
-	return b
 }
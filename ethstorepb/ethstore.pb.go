@@ -0,0 +1,1395 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: ethstore.proto
+
+package ethstorepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Day is an ethstore.Day, of the network or of a single validator.
+type Day struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Day                   string                 `protobuf:"bytes,1,opt,name=day,proto3" json:"day,omitempty"`
+	DayTime               *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=day_time,json=dayTime,proto3" json:"day_time,omitempty"`
+	Apr                   string                 `protobuf:"bytes,3,opt,name=apr,proto3" json:"apr,omitempty"`
+	Apy                   string                 `protobuf:"bytes,4,opt,name=apy,proto3" json:"apy,omitempty"`
+	Validators            string                 `protobuf:"bytes,5,opt,name=validators,proto3" json:"validators,omitempty"`
+	StartEpoch            string                 `protobuf:"bytes,6,opt,name=start_epoch,json=startEpoch,proto3" json:"start_epoch,omitempty"`
+	EffectiveBalanceGwei  string                 `protobuf:"bytes,7,opt,name=effective_balance_gwei,json=effectiveBalanceGwei,proto3" json:"effective_balance_gwei,omitempty"`
+	StartBalanceGwei      string                 `protobuf:"bytes,8,opt,name=start_balance_gwei,json=startBalanceGwei,proto3" json:"start_balance_gwei,omitempty"`
+	EndBalanceGwei        string                 `protobuf:"bytes,9,opt,name=end_balance_gwei,json=endBalanceGwei,proto3" json:"end_balance_gwei,omitempty"`
+	DepositsSumGwei       string                 `protobuf:"bytes,10,opt,name=deposits_sum_gwei,json=depositsSumGwei,proto3" json:"deposits_sum_gwei,omitempty"`
+	WithdrawalsSumGwei    string                 `protobuf:"bytes,11,opt,name=withdrawals_sum_gwei,json=withdrawalsSumGwei,proto3" json:"withdrawals_sum_gwei,omitempty"`
+	ConsensusRewardsGwei  string                 `protobuf:"bytes,12,opt,name=consensus_rewards_gwei,json=consensusRewardsGwei,proto3" json:"consensus_rewards_gwei,omitempty"`
+	TxFeesSumWei          string                 `protobuf:"bytes,13,opt,name=tx_fees_sum_wei,json=txFeesSumWei,proto3" json:"tx_fees_sum_wei,omitempty"`
+	MevRewardsWei         string                 `protobuf:"bytes,14,opt,name=mev_rewards_wei,json=mevRewardsWei,proto3" json:"mev_rewards_wei,omitempty"`
+	TotalRewardsWei       string                 `protobuf:"bytes,15,opt,name=total_rewards_wei,json=totalRewardsWei,proto3" json:"total_rewards_wei,omitempty"`
+	SyncCommitteeDuties   string                 `protobuf:"bytes,16,opt,name=sync_committee_duties,json=syncCommitteeDuties,proto3" json:"sync_committee_duties,omitempty"`
+	SyncParticipationRate string                 `protobuf:"bytes,17,opt,name=sync_participation_rate,json=syncParticipationRate,proto3" json:"sync_participation_rate,omitempty"`
+	PossiblyReorged       bool                   `protobuf:"varint,18,opt,name=possibly_reorged,json=possiblyReorged,proto3" json:"possibly_reorged,omitempty"`
+	ProposedBlocks        string                 `protobuf:"bytes,19,opt,name=proposed_blocks,json=proposedBlocks,proto3" json:"proposed_blocks,omitempty"`
+	MissedSlots           string                 `protobuf:"bytes,20,opt,name=missed_slots,json=missedSlots,proto3" json:"missed_slots,omitempty"`
+	PerformanceScore      string                 `protobuf:"bytes,21,opt,name=performance_score,json=performanceScore,proto3" json:"performance_score,omitempty"`
+	SkippedDeposits       string                 `protobuf:"bytes,22,opt,name=skipped_deposits,json=skippedDeposits,proto3" json:"skipped_deposits,omitempty"`
+	// timings are the durations of the steps of the calculation in nanoseconds.
+	Timings                map[string]int64 `protobuf:"bytes,23,rep,name=timings,proto3" json:"timings,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	IncompletePayloadSlots string           `protobuf:"bytes,24,opt,name=incomplete_payload_slots,json=incompletePayloadSlots,proto3" json:"incomplete_payload_slots,omitempty"`
+	PendingDepositsSumGwei string           `protobuf:"bytes,25,opt,name=pending_deposits_sum_gwei,json=pendingDepositsSumGwei,proto3" json:"pending_deposits_sum_gwei,omitempty"`
+	BalanceEvents          []*BalanceEvent  `protobuf:"bytes,26,rep,name=balance_events,json=balanceEvents,proto3" json:"balance_events,omitempty"`
+	// withdrawal_address is the 20 byte address, empty if the day has none.
+	WithdrawalAddress         []byte            `protobuf:"bytes,27,opt,name=withdrawal_address,json=withdrawalAddress,proto3" json:"withdrawal_address,omitempty"`
+	DuringNonFinality         bool              `protobuf:"varint,28,opt,name=during_non_finality,json=duringNonFinality,proto3" json:"during_non_finality,omitempty"`
+	InactivityLeakEpochs      string            `protobuf:"bytes,29,opt,name=inactivity_leak_epochs,json=inactivityLeakEpochs,proto3" json:"inactivity_leak_epochs,omitempty"`
+	EpochBreakdown            []*EpochApr       `protobuf:"bytes,30,rep,name=epoch_breakdown,json=epochBreakdown,proto3" json:"epoch_breakdown,omitempty"`
+	TotalRewardsFiat          string            `protobuf:"bytes,31,opt,name=total_rewards_fiat,json=totalRewardsFiat,proto3" json:"total_rewards_fiat,omitempty"`
+	AvgRewardsPerValidatorEth string            `protobuf:"bytes,32,opt,name=avg_rewards_per_validator_eth,json=avgRewardsPerValidatorEth,proto3" json:"avg_rewards_per_validator_eth,omitempty"`
+	ExitedDepositsSumGwei     string            `protobuf:"bytes,33,opt,name=exited_deposits_sum_gwei,json=exitedDepositsSumGwei,proto3" json:"exited_deposits_sum_gwei,omitempty"`
+	AprStdDev                 string            `protobuf:"bytes,34,opt,name=apr_std_dev,json=aprStdDev,proto3" json:"apr_std_dev,omitempty"`
+	MissedAttestations        string            `protobuf:"bytes,35,opt,name=missed_attestations,json=missedAttestations,proto3" json:"missed_attestations,omitempty"`
+	Ledger                    []*LedgerEntry    `protobuf:"bytes,36,rep,name=ledger,proto3" json:"ledger,omitempty"`
+	ActivatedValidators       string            `protobuf:"bytes,37,opt,name=activated_validators,json=activatedValidators,proto3" json:"activated_validators,omitempty"`
+	ExitedValidators          string            `protobuf:"bytes,38,opt,name=exited_validators,json=exitedValidators,proto3" json:"exited_validators,omitempty"`
+	RewardsByClient           map[string]string `protobuf:"bytes,39,rep,name=rewards_by_client,json=rewardsByClient,proto3" json:"rewards_by_client,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	ActivationDepositsSumGwei string            `protobuf:"bytes,40,opt,name=activation_deposits_sum_gwei,json=activationDepositsSumGwei,proto3" json:"activation_deposits_sum_gwei,omitempty"`
+	TopUpDepositsSumGwei      string            `protobuf:"bytes,41,opt,name=top_up_deposits_sum_gwei,json=topUpDepositsSumGwei,proto3" json:"top_up_deposits_sum_gwei,omitempty"`
+	TotalTxCount              string            `protobuf:"bytes,42,opt,name=total_tx_count,json=totalTxCount,proto3" json:"total_tx_count,omitempty"`
+	PeriodSeconds             string            `protobuf:"bytes,43,opt,name=period_seconds,json=periodSeconds,proto3" json:"period_seconds,omitempty"`
+	CredentialType            *uint32           `protobuf:"varint,44,opt,name=credential_type,json=credentialType,proto3,oneof" json:"credential_type,omitempty"`
+	WithoutBlocks             bool              `protobuf:"varint,45,opt,name=without_blocks,json=withoutBlocks,proto3" json:"without_blocks,omitempty"`
+	AprTrimmedMean            string            `protobuf:"bytes,46,opt,name=apr_trimmed_mean,json=aprTrimmedMean,proto3" json:"apr_trimmed_mean,omitempty"`
+	BlindedBlockSlots         string            `protobuf:"bytes,47,opt,name=blinded_block_slots,json=blindedBlockSlots,proto3" json:"blinded_block_slots,omitempty"`
+	// withdrawals_by_address is keyed by the checksummed hex address.
+	WithdrawalsByAddress         map[string]uint64 `protobuf:"bytes,48,rep,name=withdrawals_by_address,json=withdrawalsByAddress,proto3" json:"withdrawals_by_address,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	ConsensusApr                 string            `protobuf:"bytes,49,opt,name=consensus_apr,json=consensusApr,proto3" json:"consensus_apr,omitempty"`
+	ExecutionApr                 string            `protobuf:"bytes,50,opt,name=execution_apr,json=executionApr,proto3" json:"execution_apr,omitempty"`
+	ProposerConsensusRewardsGwei string            `protobuf:"bytes,51,opt,name=proposer_consensus_rewards_gwei,json=proposerConsensusRewardsGwei,proto3" json:"proposer_consensus_rewards_gwei,omitempty"`
+	// start_state_root and end_state_root are the 32 byte roots, empty if they are not set.
+	StartStateRoot       []byte                 `protobuf:"bytes,52,opt,name=start_state_root,json=startStateRoot,proto3" json:"start_state_root,omitempty"`
+	EndStateRoot         []byte                 `protobuf:"bytes,53,opt,name=end_state_root,json=endStateRoot,proto3" json:"end_state_root,omitempty"`
+	AvgGasUtilization    string                 `protobuf:"bytes,54,opt,name=avg_gas_utilization,json=avgGasUtilization,proto3" json:"avg_gas_utilization,omitempty"`
+	PayloadBlocks        string                 `protobuf:"bytes,55,opt,name=payload_blocks,json=payloadBlocks,proto3" json:"payload_blocks,omitempty"`
+	AvgInclusionDistance string                 `protobuf:"bytes,56,opt,name=avg_inclusion_distance,json=avgInclusionDistance,proto3" json:"avg_inclusion_distance,omitempty"`
+	IncludedAttestations string                 `protobuf:"bytes,57,opt,name=included_attestations,json=includedAttestations,proto3" json:"included_attestations,omitempty"`
+	BlockVersions        map[string]uint64      `protobuf:"bytes,58,rep,name=block_versions,json=blockVersions,proto3" json:"block_versions,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	YoungValidators      string                 `protobuf:"bytes,59,opt,name=young_validators,json=youngValidators,proto3" json:"young_validators,omitempty"`
+	ComputedAt           *timestamppb.Timestamp `protobuf:"bytes,60,opt,name=computed_at,json=computedAt,proto3" json:"computed_at,omitempty"`
+	MethodologyVersion   string                 `protobuf:"bytes,61,opt,name=methodology_version,json=methodologyVersion,proto3" json:"methodology_version,omitempty"`
+	// quality_score is empty if the day has none.
+	QualityScore              string                       `protobuf:"bytes,62,opt,name=quality_score,json=qualityScore,proto3" json:"quality_score,omitempty"`
+	Warnings                  []string                     `protobuf:"bytes,63,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	ValidatorSets             map[string]*Day              `protobuf:"bytes,64,rep,name=validator_sets,json=validatorSets,proto3" json:"validator_sets,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	ValidatorSetMeta          map[string]*ValidatorSetMeta `protobuf:"bytes,65,rep,name=validator_set_meta,json=validatorSetMeta,proto3" json:"validator_set_meta,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	AprExMev                  string                       `protobuf:"bytes,66,opt,name=apr_ex_mev,json=aprExMev,proto3" json:"apr_ex_mev,omitempty"`
+	WithdrawalRequestsSumGwei string                       `protobuf:"bytes,67,opt,name=withdrawal_requests_sum_gwei,json=withdrawalRequestsSumGwei,proto3" json:"withdrawal_requests_sum_gwei,omitempty"`
+	// active_fraction is empty if the day has none.
+	ActiveFraction string `protobuf:"bytes,68,opt,name=active_fraction,json=activeFraction,proto3" json:"active_fraction,omitempty"`
+}
+
+func (x *Day) Reset() {
+	*x = Day{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ethstore_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Day) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Day) ProtoMessage() {}
+
+func (x *Day) ProtoReflect() protoreflect.Message {
+	mi := &file_ethstore_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Day.ProtoReflect.Descriptor instead.
+func (*Day) Descriptor() ([]byte, []int) {
+	return file_ethstore_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Day) GetDay() string {
+	if x != nil {
+		return x.Day
+	}
+	return ""
+}
+
+func (x *Day) GetDayTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DayTime
+	}
+	return nil
+}
+
+func (x *Day) GetApr() string {
+	if x != nil {
+		return x.Apr
+	}
+	return ""
+}
+
+func (x *Day) GetApy() string {
+	if x != nil {
+		return x.Apy
+	}
+	return ""
+}
+
+func (x *Day) GetValidators() string {
+	if x != nil {
+		return x.Validators
+	}
+	return ""
+}
+
+func (x *Day) GetStartEpoch() string {
+	if x != nil {
+		return x.StartEpoch
+	}
+	return ""
+}
+
+func (x *Day) GetEffectiveBalanceGwei() string {
+	if x != nil {
+		return x.EffectiveBalanceGwei
+	}
+	return ""
+}
+
+func (x *Day) GetStartBalanceGwei() string {
+	if x != nil {
+		return x.StartBalanceGwei
+	}
+	return ""
+}
+
+func (x *Day) GetEndBalanceGwei() string {
+	if x != nil {
+		return x.EndBalanceGwei
+	}
+	return ""
+}
+
+func (x *Day) GetDepositsSumGwei() string {
+	if x != nil {
+		return x.DepositsSumGwei
+	}
+	return ""
+}
+
+func (x *Day) GetWithdrawalsSumGwei() string {
+	if x != nil {
+		return x.WithdrawalsSumGwei
+	}
+	return ""
+}
+
+func (x *Day) GetConsensusRewardsGwei() string {
+	if x != nil {
+		return x.ConsensusRewardsGwei
+	}
+	return ""
+}
+
+func (x *Day) GetTxFeesSumWei() string {
+	if x != nil {
+		return x.TxFeesSumWei
+	}
+	return ""
+}
+
+func (x *Day) GetMevRewardsWei() string {
+	if x != nil {
+		return x.MevRewardsWei
+	}
+	return ""
+}
+
+func (x *Day) GetTotalRewardsWei() string {
+	if x != nil {
+		return x.TotalRewardsWei
+	}
+	return ""
+}
+
+func (x *Day) GetSyncCommitteeDuties() string {
+	if x != nil {
+		return x.SyncCommitteeDuties
+	}
+	return ""
+}
+
+func (x *Day) GetSyncParticipationRate() string {
+	if x != nil {
+		return x.SyncParticipationRate
+	}
+	return ""
+}
+
+func (x *Day) GetPossiblyReorged() bool {
+	if x != nil {
+		return x.PossiblyReorged
+	}
+	return false
+}
+
+func (x *Day) GetProposedBlocks() string {
+	if x != nil {
+		return x.ProposedBlocks
+	}
+	return ""
+}
+
+func (x *Day) GetMissedSlots() string {
+	if x != nil {
+		return x.MissedSlots
+	}
+	return ""
+}
+
+func (x *Day) GetPerformanceScore() string {
+	if x != nil {
+		return x.PerformanceScore
+	}
+	return ""
+}
+
+func (x *Day) GetSkippedDeposits() string {
+	if x != nil {
+		return x.SkippedDeposits
+	}
+	return ""
+}
+
+func (x *Day) GetTimings() map[string]int64 {
+	if x != nil {
+		return x.Timings
+	}
+	return nil
+}
+
+func (x *Day) GetIncompletePayloadSlots() string {
+	if x != nil {
+		return x.IncompletePayloadSlots
+	}
+	return ""
+}
+
+func (x *Day) GetPendingDepositsSumGwei() string {
+	if x != nil {
+		return x.PendingDepositsSumGwei
+	}
+	return ""
+}
+
+func (x *Day) GetBalanceEvents() []*BalanceEvent {
+	if x != nil {
+		return x.BalanceEvents
+	}
+	return nil
+}
+
+func (x *Day) GetWithdrawalAddress() []byte {
+	if x != nil {
+		return x.WithdrawalAddress
+	}
+	return nil
+}
+
+func (x *Day) GetDuringNonFinality() bool {
+	if x != nil {
+		return x.DuringNonFinality
+	}
+	return false
+}
+
+func (x *Day) GetInactivityLeakEpochs() string {
+	if x != nil {
+		return x.InactivityLeakEpochs
+	}
+	return ""
+}
+
+func (x *Day) GetEpochBreakdown() []*EpochApr {
+	if x != nil {
+		return x.EpochBreakdown
+	}
+	return nil
+}
+
+func (x *Day) GetTotalRewardsFiat() string {
+	if x != nil {
+		return x.TotalRewardsFiat
+	}
+	return ""
+}
+
+func (x *Day) GetAvgRewardsPerValidatorEth() string {
+	if x != nil {
+		return x.AvgRewardsPerValidatorEth
+	}
+	return ""
+}
+
+func (x *Day) GetExitedDepositsSumGwei() string {
+	if x != nil {
+		return x.ExitedDepositsSumGwei
+	}
+	return ""
+}
+
+func (x *Day) GetAprStdDev() string {
+	if x != nil {
+		return x.AprStdDev
+	}
+	return ""
+}
+
+func (x *Day) GetMissedAttestations() string {
+	if x != nil {
+		return x.MissedAttestations
+	}
+	return ""
+}
+
+func (x *Day) GetLedger() []*LedgerEntry {
+	if x != nil {
+		return x.Ledger
+	}
+	return nil
+}
+
+func (x *Day) GetActivatedValidators() string {
+	if x != nil {
+		return x.ActivatedValidators
+	}
+	return ""
+}
+
+func (x *Day) GetExitedValidators() string {
+	if x != nil {
+		return x.ExitedValidators
+	}
+	return ""
+}
+
+func (x *Day) GetRewardsByClient() map[string]string {
+	if x != nil {
+		return x.RewardsByClient
+	}
+	return nil
+}
+
+func (x *Day) GetActivationDepositsSumGwei() string {
+	if x != nil {
+		return x.ActivationDepositsSumGwei
+	}
+	return ""
+}
+
+func (x *Day) GetTopUpDepositsSumGwei() string {
+	if x != nil {
+		return x.TopUpDepositsSumGwei
+	}
+	return ""
+}
+
+func (x *Day) GetTotalTxCount() string {
+	if x != nil {
+		return x.TotalTxCount
+	}
+	return ""
+}
+
+func (x *Day) GetPeriodSeconds() string {
+	if x != nil {
+		return x.PeriodSeconds
+	}
+	return ""
+}
+
+func (x *Day) GetCredentialType() uint32 {
+	if x != nil && x.CredentialType != nil {
+		return *x.CredentialType
+	}
+	return 0
+}
+
+func (x *Day) GetWithoutBlocks() bool {
+	if x != nil {
+		return x.WithoutBlocks
+	}
+	return false
+}
+
+func (x *Day) GetAprTrimmedMean() string {
+	if x != nil {
+		return x.AprTrimmedMean
+	}
+	return ""
+}
+
+func (x *Day) GetBlindedBlockSlots() string {
+	if x != nil {
+		return x.BlindedBlockSlots
+	}
+	return ""
+}
+
+func (x *Day) GetWithdrawalsByAddress() map[string]uint64 {
+	if x != nil {
+		return x.WithdrawalsByAddress
+	}
+	return nil
+}
+
+func (x *Day) GetConsensusApr() string {
+	if x != nil {
+		return x.ConsensusApr
+	}
+	return ""
+}
+
+func (x *Day) GetExecutionApr() string {
+	if x != nil {
+		return x.ExecutionApr
+	}
+	return ""
+}
+
+func (x *Day) GetProposerConsensusRewardsGwei() string {
+	if x != nil {
+		return x.ProposerConsensusRewardsGwei
+	}
+	return ""
+}
+
+func (x *Day) GetStartStateRoot() []byte {
+	if x != nil {
+		return x.StartStateRoot
+	}
+	return nil
+}
+
+func (x *Day) GetEndStateRoot() []byte {
+	if x != nil {
+		return x.EndStateRoot
+	}
+	return nil
+}
+
+func (x *Day) GetAvgGasUtilization() string {
+	if x != nil {
+		return x.AvgGasUtilization
+	}
+	return ""
+}
+
+func (x *Day) GetPayloadBlocks() string {
+	if x != nil {
+		return x.PayloadBlocks
+	}
+	return ""
+}
+
+func (x *Day) GetAvgInclusionDistance() string {
+	if x != nil {
+		return x.AvgInclusionDistance
+	}
+	return ""
+}
+
+func (x *Day) GetIncludedAttestations() string {
+	if x != nil {
+		return x.IncludedAttestations
+	}
+	return ""
+}
+
+func (x *Day) GetBlockVersions() map[string]uint64 {
+	if x != nil {
+		return x.BlockVersions
+	}
+	return nil
+}
+
+func (x *Day) GetYoungValidators() string {
+	if x != nil {
+		return x.YoungValidators
+	}
+	return ""
+}
+
+func (x *Day) GetComputedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ComputedAt
+	}
+	return nil
+}
+
+func (x *Day) GetMethodologyVersion() string {
+	if x != nil {
+		return x.MethodologyVersion
+	}
+	return ""
+}
+
+func (x *Day) GetQualityScore() string {
+	if x != nil {
+		return x.QualityScore
+	}
+	return ""
+}
+
+func (x *Day) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *Day) GetValidatorSets() map[string]*Day {
+	if x != nil {
+		return x.ValidatorSets
+	}
+	return nil
+}
+
+func (x *Day) GetValidatorSetMeta() map[string]*ValidatorSetMeta {
+	if x != nil {
+		return x.ValidatorSetMeta
+	}
+	return nil
+}
+
+func (x *Day) GetAprExMev() string {
+	if x != nil {
+		return x.AprExMev
+	}
+	return ""
+}
+
+func (x *Day) GetWithdrawalRequestsSumGwei() string {
+	if x != nil {
+		return x.WithdrawalRequestsSumGwei
+	}
+	return ""
+}
+
+func (x *Day) GetActiveFraction() string {
+	if x != nil {
+		return x.ActiveFraction
+	}
+	return ""
+}
+
+// BalanceEvent is an ethstore.BalanceEvent, type is "deposit" or "withdrawal".
+type BalanceEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot           uint64 `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	ValidatorIndex uint64 `protobuf:"varint,2,opt,name=validator_index,json=validatorIndex,proto3" json:"validator_index,omitempty"`
+	Type           string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	AmountGwei     uint64 `protobuf:"varint,4,opt,name=amount_gwei,json=amountGwei,proto3" json:"amount_gwei,omitempty"`
+}
+
+func (x *BalanceEvent) Reset() {
+	*x = BalanceEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ethstore_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BalanceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BalanceEvent) ProtoMessage() {}
+
+func (x *BalanceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_ethstore_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BalanceEvent.ProtoReflect.Descriptor instead.
+func (*BalanceEvent) Descriptor() ([]byte, []int) {
+	return file_ethstore_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BalanceEvent) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *BalanceEvent) GetValidatorIndex() uint64 {
+	if x != nil {
+		return x.ValidatorIndex
+	}
+	return 0
+}
+
+func (x *BalanceEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *BalanceEvent) GetAmountGwei() uint64 {
+	if x != nil {
+		return x.AmountGwei
+	}
+	return 0
+}
+
+// EpochApr is an ethstore.EpochApr.
+type EpochApr struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Epoch uint64 `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Apr   string `protobuf:"bytes,2,opt,name=apr,proto3" json:"apr,omitempty"`
+}
+
+func (x *EpochApr) Reset() {
+	*x = EpochApr{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ethstore_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EpochApr) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EpochApr) ProtoMessage() {}
+
+func (x *EpochApr) ProtoReflect() protoreflect.Message {
+	mi := &file_ethstore_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EpochApr.ProtoReflect.Descriptor instead.
+func (*EpochApr) Descriptor() ([]byte, []int) {
+	return file_ethstore_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *EpochApr) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *EpochApr) GetApr() string {
+	if x != nil {
+		return x.Apr
+	}
+	return ""
+}
+
+// LedgerEntry is an ethstore.LedgerEntry.
+type LedgerEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ValidatorIndex       uint64 `protobuf:"varint,1,opt,name=validator_index,json=validatorIndex,proto3" json:"validator_index,omitempty"`
+	Pubkey               string `protobuf:"bytes,2,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
+	EffectiveBalanceGwei uint64 `protobuf:"varint,3,opt,name=effective_balance_gwei,json=effectiveBalanceGwei,proto3" json:"effective_balance_gwei,omitempty"`
+	StartBalanceGwei     uint64 `protobuf:"varint,4,opt,name=start_balance_gwei,json=startBalanceGwei,proto3" json:"start_balance_gwei,omitempty"`
+	EndBalanceGwei       uint64 `protobuf:"varint,5,opt,name=end_balance_gwei,json=endBalanceGwei,proto3" json:"end_balance_gwei,omitempty"`
+	DepositsSumGwei      uint64 `protobuf:"varint,6,opt,name=deposits_sum_gwei,json=depositsSumGwei,proto3" json:"deposits_sum_gwei,omitempty"`
+	WithdrawalsSumGwei   uint64 `protobuf:"varint,7,opt,name=withdrawals_sum_gwei,json=withdrawalsSumGwei,proto3" json:"withdrawals_sum_gwei,omitempty"`
+	ConsensusRewardsGwei string `protobuf:"bytes,8,opt,name=consensus_rewards_gwei,json=consensusRewardsGwei,proto3" json:"consensus_rewards_gwei,omitempty"`
+	TxFeesSumWei         string `protobuf:"bytes,9,opt,name=tx_fees_sum_wei,json=txFeesSumWei,proto3" json:"tx_fees_sum_wei,omitempty"`
+	MevRewardsWei        string `protobuf:"bytes,10,opt,name=mev_rewards_wei,json=mevRewardsWei,proto3" json:"mev_rewards_wei,omitempty"`
+	TotalRewardsWei      string `protobuf:"bytes,11,opt,name=total_rewards_wei,json=totalRewardsWei,proto3" json:"total_rewards_wei,omitempty"`
+}
+
+func (x *LedgerEntry) Reset() {
+	*x = LedgerEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ethstore_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LedgerEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LedgerEntry) ProtoMessage() {}
+
+func (x *LedgerEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_ethstore_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LedgerEntry.ProtoReflect.Descriptor instead.
+func (*LedgerEntry) Descriptor() ([]byte, []int) {
+	return file_ethstore_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LedgerEntry) GetValidatorIndex() uint64 {
+	if x != nil {
+		return x.ValidatorIndex
+	}
+	return 0
+}
+
+func (x *LedgerEntry) GetPubkey() string {
+	if x != nil {
+		return x.Pubkey
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetEffectiveBalanceGwei() uint64 {
+	if x != nil {
+		return x.EffectiveBalanceGwei
+	}
+	return 0
+}
+
+func (x *LedgerEntry) GetStartBalanceGwei() uint64 {
+	if x != nil {
+		return x.StartBalanceGwei
+	}
+	return 0
+}
+
+func (x *LedgerEntry) GetEndBalanceGwei() uint64 {
+	if x != nil {
+		return x.EndBalanceGwei
+	}
+	return 0
+}
+
+func (x *LedgerEntry) GetDepositsSumGwei() uint64 {
+	if x != nil {
+		return x.DepositsSumGwei
+	}
+	return 0
+}
+
+func (x *LedgerEntry) GetWithdrawalsSumGwei() uint64 {
+	if x != nil {
+		return x.WithdrawalsSumGwei
+	}
+	return 0
+}
+
+func (x *LedgerEntry) GetConsensusRewardsGwei() string {
+	if x != nil {
+		return x.ConsensusRewardsGwei
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetTxFeesSumWei() string {
+	if x != nil {
+		return x.TxFeesSumWei
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetMevRewardsWei() string {
+	if x != nil {
+		return x.MevRewardsWei
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetTotalRewardsWei() string {
+	if x != nil {
+		return x.TotalRewardsWei
+	}
+	return ""
+}
+
+// ValidatorSetMeta is an ethstore.ValidatorSetMeta.
+type ValidatorSetMeta struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Requested   uint64   `protobuf:"varint,1,opt,name=requested,proto3" json:"requested,omitempty"`
+	Matched     uint64   `protobuf:"varint,2,opt,name=matched,proto3" json:"matched,omitempty"`
+	Missing     []uint64 `protobuf:"varint,3,rep,packed,name=missing,proto3" json:"missing,omitempty"`
+	Duplicates  uint64   `protobuf:"varint,4,opt,name=duplicates,proto3" json:"duplicates,omitempty"`
+	Overlapping uint64   `protobuf:"varint,5,opt,name=overlapping,proto3" json:"overlapping,omitempty"`
+	OutOfRange  []uint64 `protobuf:"varint,6,rep,packed,name=out_of_range,json=outOfRange,proto3" json:"out_of_range,omitempty"`
+}
+
+func (x *ValidatorSetMeta) Reset() {
+	*x = ValidatorSetMeta{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ethstore_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidatorSetMeta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatorSetMeta) ProtoMessage() {}
+
+func (x *ValidatorSetMeta) ProtoReflect() protoreflect.Message {
+	mi := &file_ethstore_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatorSetMeta.ProtoReflect.Descriptor instead.
+func (*ValidatorSetMeta) Descriptor() ([]byte, []int) {
+	return file_ethstore_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ValidatorSetMeta) GetRequested() uint64 {
+	if x != nil {
+		return x.Requested
+	}
+	return 0
+}
+
+func (x *ValidatorSetMeta) GetMatched() uint64 {
+	if x != nil {
+		return x.Matched
+	}
+	return 0
+}
+
+func (x *ValidatorSetMeta) GetMissing() []uint64 {
+	if x != nil {
+		return x.Missing
+	}
+	return nil
+}
+
+func (x *ValidatorSetMeta) GetDuplicates() uint64 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
+
+func (x *ValidatorSetMeta) GetOverlapping() uint64 {
+	if x != nil {
+		return x.Overlapping
+	}
+	return 0
+}
+
+func (x *ValidatorSetMeta) GetOutOfRange() []uint64 {
+	if x != nil {
+		return x.OutOfRange
+	}
+	return nil
+}
+
+var File_ethstore_proto protoreflect.FileDescriptor
+
+var file_ethstore_proto_rawDesc = []byte{
+	0x0a, 0x0e, 0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x08, 0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xaf, 0x1d, 0x0a, 0x03,
+	0x44, 0x61, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x64, 0x61, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x64, 0x61, 0x79, 0x12, 0x35, 0x0a, 0x08, 0x64, 0x61, 0x79, 0x5f, 0x74, 0x69, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x07, 0x64, 0x61, 0x79, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03,
+	0x61, 0x70, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x72, 0x12, 0x10,
+	0x0a, 0x03, 0x61, 0x70, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x79,
+	0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73,
+	0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x45, 0x70, 0x6f, 0x63,
+	0x68, 0x12, 0x34, 0x0a, 0x16, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x62,
+	0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x14, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x42, 0x61, 0x6c, 0x61,
+	0x6e, 0x63, 0x65, 0x47, 0x77, 0x65, 0x69, 0x12, 0x2c, 0x0a, 0x12, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x10, 0x73, 0x74, 0x61, 0x72, 0x74, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63,
+	0x65, 0x47, 0x77, 0x65, 0x69, 0x12, 0x28, 0x0a, 0x10, 0x65, 0x6e, 0x64, 0x5f, 0x62, 0x61, 0x6c,
+	0x61, 0x6e, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x65, 0x6e, 0x64, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x47, 0x77, 0x65, 0x69, 0x12,
+	0x2a, 0x0a, 0x11, 0x64, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73, 0x5f, 0x73, 0x75, 0x6d, 0x5f,
+	0x67, 0x77, 0x65, 0x69, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x64, 0x65, 0x70, 0x6f,
+	0x73, 0x69, 0x74, 0x73, 0x53, 0x75, 0x6d, 0x47, 0x77, 0x65, 0x69, 0x12, 0x30, 0x0a, 0x14, 0x77,
+	0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x73, 0x5f, 0x73, 0x75, 0x6d, 0x5f, 0x67,
+	0x77, 0x65, 0x69, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x77, 0x69, 0x74, 0x68, 0x64,
+	0x72, 0x61, 0x77, 0x61, 0x6c, 0x73, 0x53, 0x75, 0x6d, 0x47, 0x77, 0x65, 0x69, 0x12, 0x34, 0x0a,
+	0x16, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72,
+	0x64, 0x73, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x14, 0x63,
+	0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x47,
+	0x77, 0x65, 0x69, 0x12, 0x25, 0x0a, 0x0f, 0x74, 0x78, 0x5f, 0x66, 0x65, 0x65, 0x73, 0x5f, 0x73,
+	0x75, 0x6d, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x74, 0x78,
+	0x46, 0x65, 0x65, 0x73, 0x53, 0x75, 0x6d, 0x57, 0x65, 0x69, 0x12, 0x26, 0x0a, 0x0f, 0x6d, 0x65,
+	0x76, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x0e, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0d, 0x6d, 0x65, 0x76, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x57,
+	0x65, 0x69, 0x12, 0x2a, 0x0a, 0x11, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x72, 0x65, 0x77, 0x61,
+	0x72, 0x64, 0x73, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x57, 0x65, 0x69, 0x12, 0x32,
+	0x0a, 0x15, 0x73, 0x79, 0x6e, 0x63, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65,
+	0x5f, 0x64, 0x75, 0x74, 0x69, 0x65, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x73,
+	0x79, 0x6e, 0x63, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65, 0x44, 0x75, 0x74, 0x69,
+	0x65, 0x73, 0x12, 0x36, 0x0a, 0x17, 0x73, 0x79, 0x6e, 0x63, 0x5f, 0x70, 0x61, 0x72, 0x74, 0x69,
+	0x63, 0x69, 0x70, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x11, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x15, 0x73, 0x79, 0x6e, 0x63, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69,
+	0x70, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x61, 0x74, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x6f,
+	0x73, 0x73, 0x69, 0x62, 0x6c, 0x79, 0x5f, 0x72, 0x65, 0x6f, 0x72, 0x67, 0x65, 0x64, 0x18, 0x12,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x70, 0x6f, 0x73, 0x73, 0x69, 0x62, 0x6c, 0x79, 0x52, 0x65,
+	0x6f, 0x72, 0x67, 0x65, 0x64, 0x12, 0x27, 0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65,
+	0x64, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
+	0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x21,
+	0x0a, 0x0c, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x5f, 0x73, 0x6c, 0x6f, 0x74, 0x73, 0x18, 0x14,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x53, 0x6c, 0x6f, 0x74,
+	0x73, 0x12, 0x2b, 0x0a, 0x11, 0x70, 0x65, 0x72, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x6e, 0x63, 0x65,
+	0x5f, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x15, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x70, 0x65,
+	0x72, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x29,
+	0x0a, 0x10, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x64, 0x65, 0x70, 0x6f, 0x73, 0x69,
+	0x74, 0x73, 0x18, 0x16, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65,
+	0x64, 0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73, 0x12, 0x34, 0x0a, 0x07, 0x74, 0x69, 0x6d,
+	0x69, 0x6e, 0x67, 0x73, 0x18, 0x17, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x65, 0x74, 0x68,
+	0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x44, 0x61, 0x79, 0x2e, 0x54, 0x69, 0x6d, 0x69, 0x6e, 0x67,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x69, 0x6e, 0x67, 0x73, 0x12,
+	0x38, 0x0a, 0x18, 0x69, 0x6e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x5f, 0x70, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x73, 0x6c, 0x6f, 0x74, 0x73, 0x18, 0x18, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x16, 0x69, 0x6e, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x50, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x53, 0x6c, 0x6f, 0x74, 0x73, 0x12, 0x39, 0x0a, 0x19, 0x70, 0x65, 0x6e,
+	0x64, 0x69, 0x6e, 0x67, 0x5f, 0x64, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73, 0x5f, 0x73, 0x75,
+	0x6d, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x19, 0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x70, 0x65,
+	0x6e, 0x64, 0x69, 0x6e, 0x67, 0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73, 0x53, 0x75, 0x6d,
+	0x47, 0x77, 0x65, 0x69, 0x12, 0x3d, 0x0a, 0x0e, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x5f,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x1a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x65,
+	0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x52, 0x0d, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61,
+	0x6c, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x11, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x64, 0x75, 0x72, 0x69, 0x6e, 0x67, 0x5f, 0x6e, 0x6f, 0x6e,
+	0x5f, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x11, 0x64, 0x75, 0x72, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x6e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69,
+	0x74, 0x79, 0x12, 0x34, 0x0a, 0x16, 0x69, 0x6e, 0x61, 0x63, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79,
+	0x5f, 0x6c, 0x65, 0x61, 0x6b, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x73, 0x18, 0x1d, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x14, 0x69, 0x6e, 0x61, 0x63, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79, 0x4c, 0x65,
+	0x61, 0x6b, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x73, 0x12, 0x3b, 0x0a, 0x0f, 0x65, 0x70, 0x6f, 0x63,
+	0x68, 0x5f, 0x62, 0x72, 0x65, 0x61, 0x6b, 0x64, 0x6f, 0x77, 0x6e, 0x18, 0x1e, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x70, 0x6f,
+	0x63, 0x68, 0x41, 0x70, 0x72, 0x52, 0x0e, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x42, 0x72, 0x65, 0x61,
+	0x6b, 0x64, 0x6f, 0x77, 0x6e, 0x12, 0x2c, 0x0a, 0x12, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x72,
+	0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x5f, 0x66, 0x69, 0x61, 0x74, 0x18, 0x1f, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x46,
+	0x69, 0x61, 0x74, 0x12, 0x40, 0x0a, 0x1d, 0x61, 0x76, 0x67, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72,
+	0x64, 0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72,
+	0x5f, 0x65, 0x74, 0x68, 0x18, 0x20, 0x20, 0x01, 0x28, 0x09, 0x52, 0x19, 0x61, 0x76, 0x67, 0x52,
+	0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x50, 0x65, 0x72, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74,
+	0x6f, 0x72, 0x45, 0x74, 0x68, 0x12, 0x37, 0x0a, 0x18, 0x65, 0x78, 0x69, 0x74, 0x65, 0x64, 0x5f,
+	0x64, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73, 0x5f, 0x73, 0x75, 0x6d, 0x5f, 0x67, 0x77, 0x65,
+	0x69, 0x18, 0x21, 0x20, 0x01, 0x28, 0x09, 0x52, 0x15, 0x65, 0x78, 0x69, 0x74, 0x65, 0x64, 0x44,
+	0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73, 0x53, 0x75, 0x6d, 0x47, 0x77, 0x65, 0x69, 0x12, 0x1e,
+	0x0a, 0x0b, 0x61, 0x70, 0x72, 0x5f, 0x73, 0x74, 0x64, 0x5f, 0x64, 0x65, 0x76, 0x18, 0x22, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x70, 0x72, 0x53, 0x74, 0x64, 0x44, 0x65, 0x76, 0x12, 0x2f,
+	0x0a, 0x13, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x23, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x6d, 0x69, 0x73,
+	0x73, 0x65, 0x64, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
+	0x2d, 0x0a, 0x06, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x18, 0x24, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x4c, 0x65, 0x64, 0x67, 0x65,
+	0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x12, 0x31,
+	0x0a, 0x14, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x76, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x25, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x61, 0x63,
+	0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x64, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72,
+	0x73, 0x12, 0x2b, 0x0a, 0x11, 0x65, 0x78, 0x69, 0x74, 0x65, 0x64, 0x5f, 0x76, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x26, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x65, 0x78,
+	0x69, 0x74, 0x65, 0x64, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x4e,
+	0x0a, 0x11, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x5f, 0x62, 0x79, 0x5f, 0x63, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x18, 0x27, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x65, 0x74, 0x68, 0x73,
+	0x74, 0x6f, 0x72, 0x65, 0x2e, 0x44, 0x61, 0x79, 0x2e, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73,
+	0x42, 0x79, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0f, 0x72,
+	0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x42, 0x79, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x3f,
+	0x0a, 0x1c, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x64, 0x65, 0x70,
+	0x6f, 0x73, 0x69, 0x74, 0x73, 0x5f, 0x73, 0x75, 0x6d, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x28,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x19, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73, 0x53, 0x75, 0x6d, 0x47, 0x77, 0x65, 0x69, 0x12,
+	0x36, 0x0a, 0x18, 0x74, 0x6f, 0x70, 0x5f, 0x75, 0x70, 0x5f, 0x64, 0x65, 0x70, 0x6f, 0x73, 0x69,
+	0x74, 0x73, 0x5f, 0x73, 0x75, 0x6d, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x29, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x14, 0x74, 0x6f, 0x70, 0x55, 0x70, 0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73,
+	0x53, 0x75, 0x6d, 0x47, 0x77, 0x65, 0x69, 0x12, 0x24, 0x0a, 0x0e, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x5f, 0x74, 0x78, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x2a, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x54, 0x78, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a,
+	0x0e, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18,
+	0x2b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x53, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x73, 0x12, 0x2c, 0x0a, 0x0f, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69,
+	0x61, 0x6c, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x2c, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52,
+	0x0e, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x88,
+	0x01, 0x01, 0x12, 0x25, 0x0a, 0x0e, 0x77, 0x69, 0x74, 0x68, 0x6f, 0x75, 0x74, 0x5f, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x73, 0x18, 0x2d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x77, 0x69, 0x74, 0x68,
+	0x6f, 0x75, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x61, 0x70, 0x72,
+	0x5f, 0x74, 0x72, 0x69, 0x6d, 0x6d, 0x65, 0x64, 0x5f, 0x6d, 0x65, 0x61, 0x6e, 0x18, 0x2e, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x70, 0x72, 0x54, 0x72, 0x69, 0x6d, 0x6d, 0x65, 0x64, 0x4d,
+	0x65, 0x61, 0x6e, 0x12, 0x2e, 0x0a, 0x13, 0x62, 0x6c, 0x69, 0x6e, 0x64, 0x65, 0x64, 0x5f, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x73, 0x6c, 0x6f, 0x74, 0x73, 0x18, 0x2f, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x11, 0x62, 0x6c, 0x69, 0x6e, 0x64, 0x65, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x6c,
+	0x6f, 0x74, 0x73, 0x12, 0x5d, 0x0a, 0x16, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61,
+	0x6c, 0x73, 0x5f, 0x62, 0x79, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x30, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x44,
+	0x61, 0x79, 0x2e, 0x57, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x73, 0x42, 0x79,
+	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x14, 0x77, 0x69,
+	0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x73, 0x42, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x5f,
+	0x61, 0x70, 0x72, 0x18, 0x31, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x63, 0x6f, 0x6e, 0x73, 0x65,
+	0x6e, 0x73, 0x75, 0x73, 0x41, 0x70, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x65, 0x63, 0x75,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x70, 0x72, 0x18, 0x32, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x70, 0x72, 0x12, 0x45, 0x0a, 0x1f,
+	0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x5f, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73,
+	0x75, 0x73, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18,
+	0x33, 0x20, 0x01, 0x28, 0x09, 0x52, 0x1c, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x43,
+	0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x47,
+	0x77, 0x65, 0x69, 0x12, 0x28, 0x0a, 0x10, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x73, 0x74, 0x61,
+	0x74, 0x65, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x34, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0e, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x24, 0x0a,
+	0x0e, 0x65, 0x6e, 0x64, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18,
+	0x35, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x65, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52,
+	0x6f, 0x6f, 0x74, 0x12, 0x2e, 0x0a, 0x13, 0x61, 0x76, 0x67, 0x5f, 0x67, 0x61, 0x73, 0x5f, 0x75,
+	0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x36, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x11, 0x61, 0x76, 0x67, 0x47, 0x61, 0x73, 0x55, 0x74, 0x69, 0x6c, 0x69, 0x7a, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x37, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x34, 0x0a, 0x16, 0x61, 0x76,
+	0x67, 0x5f, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x64, 0x69, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x18, 0x38, 0x20, 0x01, 0x28, 0x09, 0x52, 0x14, 0x61, 0x76, 0x67, 0x49,
+	0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x12, 0x33, 0x0a, 0x15, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74,
+	0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x39, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x14, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x47, 0x0a, 0x0e, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x3a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x44, 0x61, 0x79, 0x2e, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
+	0x0d, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x29,
+	0x0a, 0x10, 0x79, 0x6f, 0x75, 0x6e, 0x67, 0x5f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f,
+	0x72, 0x73, 0x18, 0x3b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x79, 0x6f, 0x75, 0x6e, 0x67, 0x56,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x3b, 0x0a, 0x0b, 0x63, 0x6f, 0x6d,
+	0x70, 0x75, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x3c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70,
+	0x75, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x2f, 0x0a, 0x13, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64,
+	0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x3d, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x12, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x6f, 0x6c, 0x6f, 0x67, 0x79,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x0a, 0x0d, 0x71, 0x75, 0x61, 0x6c, 0x69,
+	0x74, 0x79, 0x5f, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x3e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x3f, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08,
+	0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x47, 0x0a, 0x0e, 0x76, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x73, 0x65, 0x74, 0x73, 0x18, 0x40, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x20, 0x2e, 0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x44, 0x61, 0x79, 0x2e,
+	0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x0d, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74,
+	0x73, 0x12, 0x51, 0x0a, 0x12, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x73,
+	0x65, 0x74, 0x5f, 0x6d, 0x65, 0x74, 0x61, 0x18, 0x41, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e,
+	0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x44, 0x61, 0x79, 0x2e, 0x56, 0x61, 0x6c,
+	0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x10, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74,
+	0x4d, 0x65, 0x74, 0x61, 0x12, 0x1c, 0x0a, 0x0a, 0x61, 0x70, 0x72, 0x5f, 0x65, 0x78, 0x5f, 0x6d,
+	0x65, 0x76, 0x18, 0x42, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x61, 0x70, 0x72, 0x45, 0x78, 0x4d,
+	0x65, 0x76, 0x12, 0x3f, 0x0a, 0x1c, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c,
+	0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x73, 0x75, 0x6d, 0x5f, 0x67, 0x77,
+	0x65, 0x69, 0x18, 0x43, 0x20, 0x01, 0x28, 0x09, 0x52, 0x19, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72,
+	0x61, 0x77, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x53, 0x75, 0x6d, 0x47,
+	0x77, 0x65, 0x69, 0x12, 0x27, 0x0a, 0x0f, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x66, 0x72,
+	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x44, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x63,
+	0x74, 0x69, 0x76, 0x65, 0x46, 0x72, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x3a, 0x0a, 0x0c,
+	0x54, 0x69, 0x6d, 0x69, 0x6e, 0x67, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x42, 0x0a, 0x14, 0x52, 0x65, 0x77, 0x61,
+	0x72, 0x64, 0x73, 0x42, 0x79, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x47, 0x0a, 0x19,
+	0x57, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x73, 0x42, 0x79, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x40, 0x0a, 0x12, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x4f, 0x0a, 0x12, 0x56, 0x61, 0x6c, 0x69, 0x64,
+	0x61, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x23, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d,
+	0x2e, 0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x44, 0x61, 0x79, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5f, 0x0a, 0x15, 0x56, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x30, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x65, 0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x56, 0x61,
+	0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x63, 0x72,
+	0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x22, 0x80, 0x01,
+	0x0a, 0x0c, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x6c,
+	0x6f, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x5f,
+	0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x76, 0x61, 0x6c,
+	0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x1f, 0x0a, 0x0b, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x47, 0x77, 0x65, 0x69,
+	0x22, 0x32, 0x0a, 0x08, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x41, 0x70, 0x72, 0x12, 0x14, 0x0a, 0x05,
+	0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x65, 0x70, 0x6f,
+	0x63, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x61, 0x70, 0x72, 0x22, 0xeb, 0x03, 0x0a, 0x0b, 0x4c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x27, 0x0a, 0x0f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f,
+	0x72, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x76,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x16, 0x0a,
+	0x06, 0x70, 0x75, 0x62, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70,
+	0x75, 0x62, 0x6b, 0x65, 0x79, 0x12, 0x34, 0x0a, 0x16, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x14, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65,
+	0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x47, 0x77, 0x65, 0x69, 0x12, 0x2c, 0x0a, 0x12, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65,
+	0x69, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x73, 0x74, 0x61, 0x72, 0x74, 0x42, 0x61,
+	0x6c, 0x61, 0x6e, 0x63, 0x65, 0x47, 0x77, 0x65, 0x69, 0x12, 0x28, 0x0a, 0x10, 0x65, 0x6e, 0x64,
+	0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0e, 0x65, 0x6e, 0x64, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x47,
+	0x77, 0x65, 0x69, 0x12, 0x2a, 0x0a, 0x11, 0x64, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73, 0x5f,
+	0x73, 0x75, 0x6d, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0f,
+	0x64, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x73, 0x53, 0x75, 0x6d, 0x47, 0x77, 0x65, 0x69, 0x12,
+	0x30, 0x0a, 0x14, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x73, 0x5f, 0x73,
+	0x75, 0x6d, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x12, 0x77,
+	0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x73, 0x53, 0x75, 0x6d, 0x47, 0x77, 0x65,
+	0x69, 0x12, 0x34, 0x0a, 0x16, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x5f, 0x72,
+	0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x14, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x52, 0x65, 0x77, 0x61,
+	0x72, 0x64, 0x73, 0x47, 0x77, 0x65, 0x69, 0x12, 0x25, 0x0a, 0x0f, 0x74, 0x78, 0x5f, 0x66, 0x65,
+	0x65, 0x73, 0x5f, 0x73, 0x75, 0x6d, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0c, 0x74, 0x78, 0x46, 0x65, 0x65, 0x73, 0x53, 0x75, 0x6d, 0x57, 0x65, 0x69, 0x12, 0x26,
+	0x0a, 0x0f, 0x6d, 0x65, 0x76, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x5f, 0x77, 0x65,
+	0x69, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6d, 0x65, 0x76, 0x52, 0x65, 0x77, 0x61,
+	0x72, 0x64, 0x73, 0x57, 0x65, 0x69, 0x12, 0x2a, 0x0a, 0x11, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f,
+	0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x57,
+	0x65, 0x69, 0x22, 0xc8, 0x01, 0x0a, 0x10, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72,
+	0x53, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x12,
+	0x18, 0x0a, 0x07, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x03, 0x28, 0x04,
+	0x52, 0x07, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x75, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x64,
+	0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x6f, 0x76, 0x65,
+	0x72, 0x6c, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b,
+	0x6f, 0x76, 0x65, 0x72, 0x6c, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x12, 0x20, 0x0a, 0x0c, 0x6f,
+	0x75, 0x74, 0x5f, 0x6f, 0x66, 0x5f, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x06, 0x20, 0x03, 0x28,
+	0x04, 0x52, 0x0a, 0x6f, 0x75, 0x74, 0x4f, 0x66, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x42, 0x2a, 0x5a,
+	0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x62, 0x69,
+	0x74, 0x66, 0x6c, 0x79, 0x2f, 0x65, 0x74, 0x68, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2f, 0x65,
+	0x74, 0x68, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_ethstore_proto_rawDescOnce sync.Once
+	file_ethstore_proto_rawDescData = file_ethstore_proto_rawDesc
+)
+
+func file_ethstore_proto_rawDescGZIP() []byte {
+	file_ethstore_proto_rawDescOnce.Do(func() {
+		file_ethstore_proto_rawDescData = protoimpl.X.CompressGZIP(file_ethstore_proto_rawDescData)
+	})
+	return file_ethstore_proto_rawDescData
+}
+
+var file_ethstore_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_ethstore_proto_goTypes = []any{
+	(*Day)(nil),                   // 0: ethstore.Day
+	(*BalanceEvent)(nil),          // 1: ethstore.BalanceEvent
+	(*EpochApr)(nil),              // 2: ethstore.EpochApr
+	(*LedgerEntry)(nil),           // 3: ethstore.LedgerEntry
+	(*ValidatorSetMeta)(nil),      // 4: ethstore.ValidatorSetMeta
+	nil,                           // 5: ethstore.Day.TimingsEntry
+	nil,                           // 6: ethstore.Day.RewardsByClientEntry
+	nil,                           // 7: ethstore.Day.WithdrawalsByAddressEntry
+	nil,                           // 8: ethstore.Day.BlockVersionsEntry
+	nil,                           // 9: ethstore.Day.ValidatorSetsEntry
+	nil,                           // 10: ethstore.Day.ValidatorSetMetaEntry
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_ethstore_proto_depIdxs = []int32{
+	11, // 0: ethstore.Day.day_time:type_name -> google.protobuf.Timestamp
+	5,  // 1: ethstore.Day.timings:type_name -> ethstore.Day.TimingsEntry
+	1,  // 2: ethstore.Day.balance_events:type_name -> ethstore.BalanceEvent
+	2,  // 3: ethstore.Day.epoch_breakdown:type_name -> ethstore.EpochApr
+	3,  // 4: ethstore.Day.ledger:type_name -> ethstore.LedgerEntry
+	6,  // 5: ethstore.Day.rewards_by_client:type_name -> ethstore.Day.RewardsByClientEntry
+	7,  // 6: ethstore.Day.withdrawals_by_address:type_name -> ethstore.Day.WithdrawalsByAddressEntry
+	8,  // 7: ethstore.Day.block_versions:type_name -> ethstore.Day.BlockVersionsEntry
+	11, // 8: ethstore.Day.computed_at:type_name -> google.protobuf.Timestamp
+	9,  // 9: ethstore.Day.validator_sets:type_name -> ethstore.Day.ValidatorSetsEntry
+	10, // 10: ethstore.Day.validator_set_meta:type_name -> ethstore.Day.ValidatorSetMetaEntry
+	0,  // 11: ethstore.Day.ValidatorSetsEntry.value:type_name -> ethstore.Day
+	4,  // 12: ethstore.Day.ValidatorSetMetaEntry.value:type_name -> ethstore.ValidatorSetMeta
+	13, // [13:13] is the sub-list for method output_type
+	13, // [13:13] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_ethstore_proto_init() }
+func file_ethstore_proto_init() {
+	if File_ethstore_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_ethstore_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Day); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ethstore_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*BalanceEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ethstore_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*EpochApr); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ethstore_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*LedgerEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ethstore_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*ValidatorSetMeta); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_ethstore_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_ethstore_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_ethstore_proto_goTypes,
+		DependencyIndexes: file_ethstore_proto_depIdxs,
+		MessageInfos:      file_ethstore_proto_msgTypes,
+	}.Build()
+	File_ethstore_proto = out.File
+	file_ethstore_proto_rawDesc = nil
+	file_ethstore_proto_goTypes = nil
+	file_ethstore_proto_depIdxs = nil
+}
@@ -0,0 +1,350 @@
+// Package ethstorepb is the protobuf representation of eth.store days (ethstore.proto), e.g. to serve them with gRPC.
+//
+// It is a package of the eth.store module since protobuf is a dependency of eth.store anyway (of go-eth2-client and
+// prysm), so it adds no dependency. The decimal values of a day are strings with their full precision since the
+// balances and rewards in Wei exceed the range of int64, a day converted with ToProto and back with DayFromProto
+// equals the original day.
+package ethstorepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative ethstore.proto
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	ethstore "github.com/gobitfly/eth.store"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ToProto returns the protobuf message of d.
+func ToProto(d *ethstore.Day) *Day {
+	p := &Day{
+		Day:                          d.Day.String(),
+		DayTime:                      timestamppb.New(d.DayTime),
+		Apr:                          d.Apr.String(),
+		Apy:                          d.Apy.String(),
+		Validators:                   d.Validators.String(),
+		StartEpoch:                   d.StartEpoch.String(),
+		EffectiveBalanceGwei:         d.EffectiveBalanceGwei.String(),
+		StartBalanceGwei:             d.StartBalanceGwei.String(),
+		EndBalanceGwei:               d.EndBalanceGwei.String(),
+		DepositsSumGwei:              d.DepositsSumGwei.String(),
+		WithdrawalsSumGwei:           d.WithdrawalsSumGwei.String(),
+		ConsensusRewardsGwei:         d.ConsensusRewardsGwei.String(),
+		TxFeesSumWei:                 d.TxFeesSumWei.String(),
+		MevRewardsWei:                d.MevRewardsWei.String(),
+		TotalRewardsWei:              d.TotalRewardsWei.String(),
+		SyncCommitteeDuties:          d.SyncCommitteeDuties.String(),
+		SyncParticipationRate:        d.SyncParticipationRate.String(),
+		PossiblyReorged:              d.PossiblyReorged,
+		ProposedBlocks:               d.ProposedBlocks.String(),
+		MissedSlots:                  d.MissedSlots.String(),
+		PerformanceScore:             d.PerformanceScore.String(),
+		SkippedDeposits:              d.SkippedDeposits.String(),
+		IncompletePayloadSlots:       d.IncompletePayloadSlots.String(),
+		PendingDepositsSumGwei:       d.PendingDepositsSumGwei.String(),
+		DuringNonFinality:            d.DuringNonFinality,
+		InactivityLeakEpochs:         d.InactivityLeakEpochs.String(),
+		TotalRewardsFiat:             d.TotalRewardsFiat.String(),
+		AvgRewardsPerValidatorEth:    d.AvgRewardsPerValidatorEth.String(),
+		ExitedDepositsSumGwei:        d.ExitedDepositsSumGwei.String(),
+		AprStdDev:                    d.AprStdDev.String(),
+		MissedAttestations:           d.MissedAttestations.String(),
+		ActivatedValidators:          d.ActivatedValidators.String(),
+		ExitedValidators:             d.ExitedValidators.String(),
+		ActivationDepositsSumGwei:    d.ActivationDepositsSumGwei.String(),
+		TopUpDepositsSumGwei:         d.TopUpDepositsSumGwei.String(),
+		TotalTxCount:                 d.TotalTxCount.String(),
+		PeriodSeconds:                d.PeriodSeconds.String(),
+		WithoutBlocks:                d.WithoutBlocks,
+		AprTrimmedMean:               d.AprTrimmedMean.String(),
+		BlindedBlockSlots:            d.BlindedBlockSlots.String(),
+		ConsensusApr:                 d.ConsensusApr.String(),
+		ExecutionApr:                 d.ExecutionApr.String(),
+		AprExMev:                     d.AprExMev.String(),
+		WithdrawalRequestsSumGwei:    d.WithdrawalRequestsSumGwei.String(),
+		ProposerConsensusRewardsGwei: d.ProposerConsensusRewardsGwei.String(),
+		AvgGasUtilization:            d.AvgGasUtilization.String(),
+		PayloadBlocks:                d.PayloadBlocks.String(),
+		AvgInclusionDistance:         d.AvgInclusionDistance.String(),
+		IncludedAttestations:         d.IncludedAttestations.String(),
+		YoungValidators:              d.YoungValidators.String(),
+		MethodologyVersion:           d.MethodologyVersion,
+		Warnings:                     d.Warnings,
+	}
+	if d.ActiveFraction != nil {
+		p.ActiveFraction = d.ActiveFraction.String()
+	}
+	if d.QualityScore != nil {
+		p.QualityScore = d.QualityScore.String()
+	}
+	if !d.ComputedAt.IsZero() {
+		p.ComputedAt = timestamppb.New(d.ComputedAt)
+	}
+	if d.Timings != nil {
+		p.Timings = make(map[string]int64, len(d.Timings))
+		for k, v := range d.Timings {
+			p.Timings[k] = int64(v)
+		}
+	}
+	for _, e := range d.BalanceEvents {
+		p.BalanceEvents = append(p.BalanceEvents, &BalanceEvent{
+			Slot:           e.Slot,
+			ValidatorIndex: uint64(e.ValidatorIndex),
+			Type:           string(e.Type),
+			AmountGwei:     uint64(e.AmountGwei),
+		})
+	}
+	if d.WithdrawalAddress != nil {
+		p.WithdrawalAddress = d.WithdrawalAddress.Bytes()
+	}
+	for _, e := range d.EpochBreakdown {
+		p.EpochBreakdown = append(p.EpochBreakdown, &EpochApr{Epoch: e.Epoch, Apr: e.Apr.String()})
+	}
+	for _, e := range d.Ledger {
+		p.Ledger = append(p.Ledger, &LedgerEntry{
+			ValidatorIndex:       uint64(e.ValidatorIndex),
+			Pubkey:               e.Pubkey,
+			EffectiveBalanceGwei: uint64(e.EffectiveBalanceGwei),
+			StartBalanceGwei:     uint64(e.StartBalanceGwei),
+			EndBalanceGwei:       uint64(e.EndBalanceGwei),
+			DepositsSumGwei:      uint64(e.DepositsSumGwei),
+			WithdrawalsSumGwei:   uint64(e.WithdrawalsSumGwei),
+			ConsensusRewardsGwei: e.ConsensusRewardsGwei.String(),
+			TxFeesSumWei:         e.TxFeesSumWei.String(),
+			MevRewardsWei:        e.MevRewardsWei.String(),
+			TotalRewardsWei:      e.TotalRewardsWei.String(),
+		})
+	}
+	if d.RewardsByClient != nil {
+		p.RewardsByClient = make(map[string]string, len(d.RewardsByClient))
+		for k, v := range d.RewardsByClient {
+			p.RewardsByClient[k] = v.String()
+		}
+	}
+	if d.CredentialType != nil {
+		credentialType := uint32(*d.CredentialType)
+		p.CredentialType = &credentialType
+	}
+	if d.WithdrawalsByAddress != nil {
+		p.WithdrawalsByAddress = make(map[string]uint64, len(d.WithdrawalsByAddress))
+		for k, v := range d.WithdrawalsByAddress {
+			p.WithdrawalsByAddress[k.Hex()] = uint64(v)
+		}
+	}
+	if d.StartStateRoot != nil {
+		p.StartStateRoot = d.StartStateRoot[:]
+	}
+	if d.BlockVersions != nil {
+		p.BlockVersions = make(map[string]uint64, len(d.BlockVersions))
+		for k, v := range d.BlockVersions {
+			p.BlockVersions[k] = v
+		}
+	}
+	if d.EndStateRoot != nil {
+		p.EndStateRoot = d.EndStateRoot[:]
+	}
+	if d.ValidatorSets != nil {
+		p.ValidatorSets = make(map[string]*Day, len(d.ValidatorSets))
+		for k, v := range d.ValidatorSets {
+			p.ValidatorSets[k] = ToProto(v)
+		}
+	}
+	if d.ValidatorSetMeta != nil {
+		p.ValidatorSetMeta = make(map[string]*ValidatorSetMeta, len(d.ValidatorSetMeta))
+		for k, v := range d.ValidatorSetMeta {
+			p.ValidatorSetMeta[k] = &ValidatorSetMeta{Requested: uint64(v.Requested), Matched: uint64(v.Matched), Missing: v.Missing, Duplicates: uint64(v.Duplicates), Overlapping: uint64(v.Overlapping), OutOfRange: v.OutOfRange}
+		}
+	}
+	return p
+}
+
+// DayFromProto returns the day of the protobuf message p. Empty decimal strings are zero, since proto3 omits default
+// values, it errors if a decimal, address or root is malformed.
+func DayFromProto(p *Day) (*ethstore.Day, error) {
+	var err error
+	dec := func(field, s string) decimal.Decimal {
+		if s == "" || err != nil {
+			return decimal.Zero
+		}
+		v, e := decimal.NewFromString(s)
+		if e != nil {
+			err = fmt.Errorf("error parsing %v %q: %w", field, s, e)
+		}
+		return v
+	}
+	d := &ethstore.Day{
+		Day:                          dec("day", p.Day),
+		Apr:                          dec("apr", p.Apr),
+		Apy:                          dec("apy", p.Apy),
+		Validators:                   dec("validators", p.Validators),
+		StartEpoch:                   dec("start_epoch", p.StartEpoch),
+		EffectiveBalanceGwei:         dec("effective_balance_gwei", p.EffectiveBalanceGwei),
+		StartBalanceGwei:             dec("start_balance_gwei", p.StartBalanceGwei),
+		EndBalanceGwei:               dec("end_balance_gwei", p.EndBalanceGwei),
+		DepositsSumGwei:              dec("deposits_sum_gwei", p.DepositsSumGwei),
+		WithdrawalsSumGwei:           dec("withdrawals_sum_gwei", p.WithdrawalsSumGwei),
+		ConsensusRewardsGwei:         dec("consensus_rewards_gwei", p.ConsensusRewardsGwei),
+		TxFeesSumWei:                 dec("tx_fees_sum_wei", p.TxFeesSumWei),
+		MevRewardsWei:                dec("mev_rewards_wei", p.MevRewardsWei),
+		TotalRewardsWei:              dec("total_rewards_wei", p.TotalRewardsWei),
+		SyncCommitteeDuties:          dec("sync_committee_duties", p.SyncCommitteeDuties),
+		SyncParticipationRate:        dec("sync_participation_rate", p.SyncParticipationRate),
+		PossiblyReorged:              p.PossiblyReorged,
+		ProposedBlocks:               dec("proposed_blocks", p.ProposedBlocks),
+		MissedSlots:                  dec("missed_slots", p.MissedSlots),
+		PerformanceScore:             dec("performance_score", p.PerformanceScore),
+		SkippedDeposits:              dec("skipped_deposits", p.SkippedDeposits),
+		IncompletePayloadSlots:       dec("incomplete_payload_slots", p.IncompletePayloadSlots),
+		PendingDepositsSumGwei:       dec("pending_deposits_sum_gwei", p.PendingDepositsSumGwei),
+		DuringNonFinality:            p.DuringNonFinality,
+		InactivityLeakEpochs:         dec("inactivity_leak_epochs", p.InactivityLeakEpochs),
+		TotalRewardsFiat:             dec("total_rewards_fiat", p.TotalRewardsFiat),
+		AvgRewardsPerValidatorEth:    dec("avg_rewards_per_validator_eth", p.AvgRewardsPerValidatorEth),
+		ExitedDepositsSumGwei:        dec("exited_deposits_sum_gwei", p.ExitedDepositsSumGwei),
+		AprStdDev:                    dec("apr_std_dev", p.AprStdDev),
+		MissedAttestations:           dec("missed_attestations", p.MissedAttestations),
+		ActivatedValidators:          dec("activated_validators", p.ActivatedValidators),
+		ExitedValidators:             dec("exited_validators", p.ExitedValidators),
+		ActivationDepositsSumGwei:    dec("activation_deposits_sum_gwei", p.ActivationDepositsSumGwei),
+		TopUpDepositsSumGwei:         dec("top_up_deposits_sum_gwei", p.TopUpDepositsSumGwei),
+		TotalTxCount:                 dec("total_tx_count", p.TotalTxCount),
+		PeriodSeconds:                dec("period_seconds", p.PeriodSeconds),
+		WithoutBlocks:                p.WithoutBlocks,
+		AprTrimmedMean:               dec("apr_trimmed_mean", p.AprTrimmedMean),
+		BlindedBlockSlots:            dec("blinded_block_slots", p.BlindedBlockSlots),
+		ConsensusApr:                 dec("consensus_apr", p.ConsensusApr),
+		ExecutionApr:                 dec("execution_apr", p.ExecutionApr),
+		AprExMev:                     dec("apr_ex_mev", p.AprExMev),
+		WithdrawalRequestsSumGwei:    dec("withdrawal_requests_sum_gwei", p.WithdrawalRequestsSumGwei),
+		ProposerConsensusRewardsGwei: dec("proposer_consensus_rewards_gwei", p.ProposerConsensusRewardsGwei),
+		AvgGasUtilization:            dec("avg_gas_utilization", p.AvgGasUtilization),
+		PayloadBlocks:                dec("payload_blocks", p.PayloadBlocks),
+		AvgInclusionDistance:         dec("avg_inclusion_distance", p.AvgInclusionDistance),
+		IncludedAttestations:         dec("included_attestations", p.IncludedAttestations),
+		YoungValidators:              dec("young_validators", p.YoungValidators),
+		MethodologyVersion:           p.MethodologyVersion,
+		Warnings:                     p.Warnings,
+	}
+	if p.DayTime != nil {
+		d.DayTime = p.DayTime.AsTime()
+	}
+	if p.ComputedAt != nil {
+		d.ComputedAt = p.ComputedAt.AsTime()
+	}
+	if p.Timings != nil {
+		d.Timings = make(map[string]time.Duration, len(p.Timings))
+		for k, v := range p.Timings {
+			d.Timings[k] = time.Duration(v)
+		}
+	}
+	for _, e := range p.BalanceEvents {
+		d.BalanceEvents = append(d.BalanceEvents, ethstore.BalanceEvent{
+			Slot:           e.Slot,
+			ValidatorIndex: phase0.ValidatorIndex(e.ValidatorIndex),
+			Type:           ethstore.BalanceEventType(e.Type),
+			AmountGwei:     phase0.Gwei(e.AmountGwei),
+		})
+	}
+	if len(p.WithdrawalAddress) != 0 {
+		if len(p.WithdrawalAddress) != common.AddressLength {
+			return nil, fmt.Errorf("error parsing withdrawal_address: wrong length %v", len(p.WithdrawalAddress))
+		}
+		withdrawalAddress := common.BytesToAddress(p.WithdrawalAddress)
+		d.WithdrawalAddress = &withdrawalAddress
+	}
+	for _, e := range p.EpochBreakdown {
+		d.EpochBreakdown = append(d.EpochBreakdown, ethstore.EpochApr{Epoch: e.Epoch, Apr: dec("epoch_breakdown.apr", e.Apr)})
+	}
+	for _, e := range p.Ledger {
+		d.Ledger = append(d.Ledger, ethstore.LedgerEntry{
+			ValidatorIndex:       phase0.ValidatorIndex(e.ValidatorIndex),
+			Pubkey:               e.Pubkey,
+			EffectiveBalanceGwei: phase0.Gwei(e.EffectiveBalanceGwei),
+			StartBalanceGwei:     phase0.Gwei(e.StartBalanceGwei),
+			EndBalanceGwei:       phase0.Gwei(e.EndBalanceGwei),
+			DepositsSumGwei:      phase0.Gwei(e.DepositsSumGwei),
+			WithdrawalsSumGwei:   phase0.Gwei(e.WithdrawalsSumGwei),
+			ConsensusRewardsGwei: dec("ledger.consensus_rewards_gwei", e.ConsensusRewardsGwei),
+			TxFeesSumWei:         dec("ledger.tx_fees_sum_wei", e.TxFeesSumWei),
+			MevRewardsWei:        dec("ledger.mev_rewards_wei", e.MevRewardsWei),
+			TotalRewardsWei:      dec("ledger.total_rewards_wei", e.TotalRewardsWei),
+		})
+	}
+	if p.RewardsByClient != nil {
+		d.RewardsByClient = make(map[string]decimal.Decimal, len(p.RewardsByClient))
+		for k, v := range p.RewardsByClient {
+			d.RewardsByClient[k] = dec("rewards_by_client", v)
+		}
+	}
+	if p.CredentialType != nil {
+		if *p.CredentialType > 0xff {
+			return nil, fmt.Errorf("error parsing credential_type: %v is not a byte", *p.CredentialType)
+		}
+		credentialType := uint8(*p.CredentialType)
+		d.CredentialType = &credentialType
+	}
+	if p.WithdrawalsByAddress != nil {
+		d.WithdrawalsByAddress = make(map[common.Address]phase0.Gwei, len(p.WithdrawalsByAddress))
+		for k, v := range p.WithdrawalsByAddress {
+			if !common.IsHexAddress(k) {
+				return nil, fmt.Errorf("error parsing withdrawals_by_address: invalid address %q", k)
+			}
+			d.WithdrawalsByAddress[common.HexToAddress(k)] = phase0.Gwei(v)
+		}
+	}
+	if p.BlockVersions != nil {
+		d.BlockVersions = make(map[string]uint64, len(p.BlockVersions))
+		for k, v := range p.BlockVersions {
+			d.BlockVersions[k] = v
+		}
+	}
+	if p.ActiveFraction != "" {
+		activeFraction := dec("active_fraction", p.ActiveFraction)
+		d.ActiveFraction = &activeFraction
+	}
+	if p.QualityScore != "" {
+		score := dec("quality_score", p.QualityScore)
+		d.QualityScore = &score
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.StartStateRoot, err = root("start_state_root", p.StartStateRoot); err != nil {
+		return nil, err
+	}
+	if d.EndStateRoot, err = root("end_state_root", p.EndStateRoot); err != nil {
+		return nil, err
+	}
+	if p.ValidatorSets != nil {
+		d.ValidatorSets = make(map[string]*ethstore.Day, len(p.ValidatorSets))
+		for k, v := range p.ValidatorSets {
+			if d.ValidatorSets[k], err = DayFromProto(v); err != nil {
+				return nil, fmt.Errorf("error parsing validator_sets %q: %w", k, err)
+			}
+		}
+	}
+	if p.ValidatorSetMeta != nil {
+		d.ValidatorSetMeta = make(map[string]ethstore.ValidatorSetMeta, len(p.ValidatorSetMeta))
+		for k, v := range p.ValidatorSetMeta {
+			d.ValidatorSetMeta[k] = ethstore.ValidatorSetMeta{Requested: int(v.Requested), Matched: int(v.Matched), Missing: v.Missing, Duplicates: int(v.Duplicates), Overlapping: int(v.Overlapping), OutOfRange: v.OutOfRange}
+		}
+	}
+	return d, nil
+}
+
+// root returns the root of b, nil if b is empty.
+func root(field string, b []byte) (*phase0.Root, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if len(b) != len(phase0.Root{}) {
+		return nil, fmt.Errorf("error parsing %v: wrong length %v", field, len(b))
+	}
+	var r phase0.Root
+	copy(r[:], b)
+	return &r, nil
+}
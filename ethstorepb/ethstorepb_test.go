@@ -0,0 +1,90 @@
+package ethstorepb
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	ethstore "github.com/gobitfly/eth.store"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRoundTrip(t *testing.T) {
+	withdrawalAddress := common.HexToAddress("0x9709ae4129ed4bb3fa6678e83a9976b7cc81abd1")
+	credentialType := uint8(1)
+	root := phase0.Root{1, 2, 3}
+	qualityScore := decimal.RequireFromString("0.75")
+	activeFraction := decimal.RequireFromString("0.5")
+	day := &ethstore.Day{
+		Day:                       decimal.NewFromInt(10),
+		DayTime:                   time.Unix(1607688023, 0).UTC(),
+		Apr:                       decimal.RequireFromString("0.0621640625"),
+		AprExMev:                  decimal.RequireFromString("0.0601640625"),
+		WithdrawalRequestsSumGwei: decimal.NewFromInt(1e9),
+		TotalRewardsWei:           decimal.RequireFromString("170322400000000000000000"),
+		Timings:                   map[string]time.Duration{"blocks": 3 * time.Second},
+		BalanceEvents:             []ethstore.BalanceEvent{{Slot: 72003, ValidatorIndex: 4, Type: ethstore.BalanceEventDeposit, AmountGwei: 32e9}},
+		EpochBreakdown:            []ethstore.EpochApr{{Epoch: 2250, Apr: decimal.RequireFromString("0.05")}},
+		Ledger: []ethstore.LedgerEntry{{
+			ValidatorIndex:       4,
+			EndBalanceGwei:       32e9,
+			ConsensusRewardsGwei: decimal.RequireFromString("-1"),
+			TxFeesSumWei:         decimal.RequireFromString("10000000000000"),
+		}},
+		RewardsByClient:      map[string]decimal.Decimal{"lighthouse": decimal.RequireFromString("10000000000000")},
+		WithdrawalAddress:    &withdrawalAddress,
+		CredentialType:       &credentialType,
+		WithdrawalsByAddress: map[common.Address]phase0.Gwei{withdrawalAddress: 5},
+		StartStateRoot:       &root,
+		AvgGasUtilization:    decimal.RequireFromString("0.0076933333333333"),
+		BlockVersions:        map[string]uint64{"bellatrix": 7200},
+		ComputedAt:           time.Unix(1607774423, 0).UTC(),
+		MethodologyVersion:   ethstore.MethodologyVersion,
+		QualityScore:         &qualityScore,
+		ActiveFraction:       &activeFraction,
+		Warnings:             []string{"0 of 7200 blocks have no complete execution payload"},
+		ValidatorSets: map[string]*ethstore.Day{"pool": {
+			Day:             decimal.NewFromInt(10),
+			DayTime:         time.Unix(1607688023, 0).UTC(),
+			Validators:      decimal.NewFromInt(2),
+			TotalRewardsWei: decimal.RequireFromString("11744820000000000000000"),
+		}},
+		ValidatorSetMeta: map[string]ethstore.ValidatorSetMeta{"pool": {Requested: 3, Matched: 2, Missing: []uint64{100}, Duplicates: 1, Overlapping: 2, OutOfRange: []uint64{100}}},
+	}
+
+	b, err := proto.Marshal(ToProto(day))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Day{}
+	if err := proto.Unmarshal(b, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.TotalRewardsWei != "170322400000000000000000" || p.Apr != "0.0621640625" {
+		t.Errorf("wrong message: %+v", p)
+	}
+	got, err := DayFromProto(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the decimals equal but may differ in their representation, so the days are compared as json
+	want, _ := json.Marshal(day)
+	gotJSON, _ := json.Marshal(got)
+	if string(gotJSON) != string(want) {
+		t.Errorf("wrong day after the round trip:\n%s\n!=\n%s", gotJSON, want)
+	}
+
+	p.Apr = "0.06x"
+	if _, err := DayFromProto(p); err == nil || !strings.Contains(err.Error(), "error parsing apr") {
+		t.Errorf("expected an error for the malformed apr, got %v", err)
+	}
+	p.Apr = ""
+	p.EndStateRoot = []byte{1}
+	if _, err := DayFromProto(p); err == nil || !strings.Contains(err.Error(), "end_state_root") {
+		t.Errorf("expected an error for the malformed end state root, got %v", err)
+	}
+}
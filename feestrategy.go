@@ -0,0 +1,40 @@
+package ethstore
+
+import (
+	"fmt"
+	"math/big"
+
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// FeeStrategy attributes the fees of the transactions of a block to its proposer, see WithFeeStrategy.
+type FeeStrategy interface {
+	// ProposerFeeWei returns the Wei of the fee of tx that are paid to the proposer. baseFee is the base fee per gas of
+	// the block and receipt the receipt of tx from the execution client.
+	ProposerFeeWei(tx *gethTypes.Transaction, baseFee *big.Int, receipt *TxReceipt) (*big.Int, error)
+}
+
+// TipFeeStrategy attributes the priority fee (EIP-1559) to the proposer: the gas used by the transaction times the
+// price per gas it paid above the base fee, which is burnt.
+type TipFeeStrategy struct{}
+
+func (TipFeeStrategy) ProposerFeeWei(tx *gethTypes.Transaction, baseFee *big.Int, receipt *TxReceipt) (*big.Int, error) {
+	gasPrice := effectiveGasPrice(tx, receipt, baseFee)
+	if gasPrice == nil {
+		return nil, fmt.Errorf("no EffectiveGasPrice for tx %v of type %v", tx.Hash(), tx.Type())
+	}
+	tip := new(big.Int).Sub(gasPrice, baseFee)
+	return tip.Mul(tip, new(big.Int).SetUint64(uint64(receipt.GasUsed))), nil
+}
+
+// FullGasFeeStrategy attributes the whole fee of the transaction to the proposer, the gas used times the price per
+// gas including the base fee. This is the attribution before the london fork, when there was no base fee to burn.
+type FullGasFeeStrategy struct{}
+
+func (FullGasFeeStrategy) ProposerFeeWei(tx *gethTypes.Transaction, baseFee *big.Int, receipt *TxReceipt) (*big.Int, error) {
+	gasPrice := effectiveGasPrice(tx, receipt, baseFee)
+	if gasPrice == nil {
+		return nil, fmt.Errorf("no EffectiveGasPrice for tx %v of type %v", tx.Hash(), tx.Type())
+	}
+	return new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(uint64(receipt.GasUsed))), nil
+}
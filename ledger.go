@@ -0,0 +1,84 @@
+package ethstore
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/shopspring/decimal"
+)
+
+// LedgerEntry holds the balances and rewards of an accounted validator that the apr of a day is derived from, see
+// WithLedger. The sums of the entries of a day are the totals of the day.
+type LedgerEntry struct {
+	ValidatorIndex       phase0.ValidatorIndex `json:"validatorIndex"`
+	Pubkey               string                `json:"pubkey"`
+	EffectiveBalanceGwei phase0.Gwei           `json:"effectiveBalanceGwei"`
+	StartBalanceGwei     phase0.Gwei           `json:"startBalanceGwei"`
+	EndBalanceGwei       phase0.Gwei           `json:"endBalanceGwei"`
+	DepositsSumGwei      phase0.Gwei           `json:"depositsSumGwei"`
+	WithdrawalsSumGwei   phase0.Gwei           `json:"withdrawalsSumGwei"`
+	ConsensusRewardsGwei decimal.Decimal       `json:"consensusRewardsGwei"`
+	TxFeesSumWei         decimal.Decimal       `json:"txFeesSumWei"`
+	MevRewardsWei        decimal.Decimal       `json:"mevRewardsWei"`
+	TotalRewardsWei      decimal.Decimal       `json:"totalRewardsWei"`
+}
+
+// ledgerCSVHeader are the columns of WriteLedgerCSV.
+var ledgerCSVHeader = []string{"validator_index", "pubkey", "effective_balance_gwei", "start_balance_gwei", "end_balance_gwei", "deposits_sum_gwei", "withdrawals_sum_gwei", "consensus_rewards_gwei", "tx_fees_sum_wei", "mev_rewards_wei", "total_rewards_wei"}
+
+func newLedgerEntry(v *Validator) LedgerEntry {
+	consensusRewards := consensusRewardsGwei(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei)
+	mevRewardsWei := decimal.Zero
+	if v.MevRewardsWei != nil {
+		mevRewardsWei = decimal.NewFromBigInt(v.MevRewardsWei, 0)
+	}
+	txFeesSumWei := decimal.NewFromBigInt(v.TxFeesSumWei, 0)
+	return LedgerEntry{
+		ValidatorIndex:       v.Index,
+		Pubkey:               fmt.Sprintf("%#x", v.Pubkey),
+		EffectiveBalanceGwei: v.EffectiveBalanceGwei,
+		StartBalanceGwei:     v.StartBalanceGwei,
+		EndBalanceGwei:       v.EndBalanceGwei,
+		DepositsSumGwei:      v.DepositsSumGwei,
+		WithdrawalsSumGwei:   v.WithdrawalsSumGwei,
+		ConsensusRewardsGwei: consensusRewards,
+		TxFeesSumWei:         txFeesSumWei,
+		MevRewardsWei:        mevRewardsWei,
+		TotalRewardsWei:      consensusRewards.Mul(decimal.NewFromInt(1e9)).Add(txFeesSumWei).Add(mevRewardsWei),
+	}
+}
+
+// WriteLedgerCSV writes the ledger of a day (see WithLedger) to w as csv with a header row, one row per validator in
+// the order of ledger.
+func WriteLedgerCSV(w io.Writer, ledger []LedgerEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ledgerCSVHeader); err != nil {
+		return fmt.Errorf("error writing ledger: %w", err)
+	}
+	for _, e := range ledger {
+		err := cw.Write([]string{
+			strconv.FormatUint(uint64(e.ValidatorIndex), 10),
+			e.Pubkey,
+			strconv.FormatUint(uint64(e.EffectiveBalanceGwei), 10),
+			strconv.FormatUint(uint64(e.StartBalanceGwei), 10),
+			strconv.FormatUint(uint64(e.EndBalanceGwei), 10),
+			strconv.FormatUint(uint64(e.DepositsSumGwei), 10),
+			strconv.FormatUint(uint64(e.WithdrawalsSumGwei), 10),
+			e.ConsensusRewardsGwei.String(),
+			e.TxFeesSumWei.String(),
+			e.MevRewardsWei.String(),
+			e.TotalRewardsWei.String(),
+		})
+		if err != nil {
+			return fmt.Errorf("error writing ledger entry of validator %v: %w", e.ValidatorIndex, err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("error writing ledger: %w", err)
+	}
+	return nil
+}
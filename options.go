@@ -0,0 +1,832 @@
+package ethstore
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/shopspring/decimal"
+)
+
+// Option configures a single call of Calculate, settings that apply to all calls are set with the Set* functions.
+type Option func(*options)
+
+type options struct {
+	// err is set by options that were given invalid arguments and returned by Calculate before doing any request
+	err error
+
+	slotsPerEpoch  uint64
+	secondsPerSlot uint64
+	aprPrecision   *int32
+	canonicalChain bool
+	timings        bool
+
+	withoutValidatorDays bool
+	balanceEvents        bool
+	reportTimezone       *time.Location
+	mevLastTxAttribution bool
+	blockReceipts        bool
+	epochBoundaries      bool
+	finalityCheck        bool
+	nominalBalanceGwei   phase0.Gwei
+	periodSlots          uint64
+
+	effectiveBalanceSlotOffset  uint64
+	archiveNode                 string
+	epochBreakdown              bool
+	priceProvider               func(day time.Time) (decimal.Decimal, error)
+	checkpointStore             CheckpointStore
+	requireFinalized            bool
+	maxInFlightBytes            uint64
+	orderedBlocks               bool
+	depositFilter               func(*phase0.Deposit) bool
+	missedAttestations          bool
+	requestID                   string
+	ledger                      bool
+	clientClassifier            func(graffiti [32]byte) string
+	adaptiveConcurrencyMin      int
+	adaptiveConcurrencyMax      int
+	exactAnnualization          bool
+	withoutBlocks               bool
+	aprTrimFraction             *float64
+	negativeAprPolicy           NegativeAprPolicy
+	proposerRewards             bool
+	withdrawalRequests          bool
+	activeFractionNormalization bool
+	blockArchive                string
+	stateRootVerification       bool
+	effectiveBalanceEpochs      uint64
+	feeStrategy                 FeeStrategy
+	startStateID                string
+	endStateID                  string
+	inclusionDistance           bool
+	preset                      *Preset
+	executionRewardsRate        func(day time.Time) (decimal.Decimal, error)
+	txCache                     *TxCache
+	minActivationAge            uint64
+	rangePrefetch               bool
+	prefetched                  *validatorsPrefetch
+	nextPrefetch                *validatorsPrefetch
+	qualityScore                bool
+	validatorSets               map[string]map[phase0.ValidatorIndex]bool
+	validatorSetDuplicates      map[string]int
+}
+
+func newOptions(opts []Option) (*options, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.err != nil {
+		return nil, o.err
+	}
+	if o.withoutBlocks {
+		// the options that only change how the blocks of the day are processed
+		for _, blockOption := range []struct {
+			name string
+			set  bool
+		}{
+			{"WithMissedAttestations", o.missedAttestations},
+			{"WithProposerRewards", o.proposerRewards},
+			{"WithInclusionDistance", o.inclusionDistance},
+			{"WithEpochBreakdown", o.epochBreakdown},
+			{"WithClientClassifier", o.clientClassifier != nil},
+			{"WithBalanceEvents", o.balanceEvents},
+			{"WithMevLastTxAttribution", o.mevLastTxAttribution},
+			{"WithFeeStrategy", o.feeStrategy != nil},
+			{"WithBlockReceipts", o.blockReceipts},
+			{"WithTxCache", o.txCache != nil},
+			{"WithBlockArchive", o.blockArchive != ""},
+			{"WithMaxInFlightBytes", o.maxInFlightBytes != 0},
+			{"WithExecutionRewardsRate", o.executionRewardsRate != nil},
+			{"WithWithdrawalRequests", o.withdrawalRequests},
+			{"WithActiveFractionNormalization", o.activeFractionNormalization},
+		} {
+			if blockOption.set {
+				return nil, fmt.Errorf("invalid options: %v needs the blocks that WithoutBlocks skips", blockOption.name)
+			}
+		}
+	}
+	if o.effectiveBalanceEpochs != 0 && o.effectiveBalanceSlotOffset != 0 {
+		return nil, fmt.Errorf("invalid options: WithTimeWeightedEffectiveBalance and WithEffectiveBalanceSlot both set the effective balances")
+	}
+	if o.activeFractionNormalization && o.withoutValidatorDays {
+		return nil, fmt.Errorf("invalid options: WithActiveFractionNormalization only adds validator days, which WithoutValidatorDays skips")
+	}
+	if o.activeFractionNormalization && o.checkpointStore != nil {
+		return nil, fmt.Errorf("invalid options: the sums of WithActiveFractionNormalization are not stored in the checkpoints of WithCheckpoint")
+	}
+	if o.startStateID != "" && (o.epochBoundaries || o.periodSlots != 0) {
+		return nil, fmt.Errorf("invalid options: WithStateRange sets the slots that WithEpochBoundaries and WithPeriodSlots derive from the day")
+	}
+	return o, nil
+}
+
+// WithSlotsPerEpoch overrides SLOTS_PER_EPOCH of the spec. If WithSecondsPerSlot is set as well the spec is
+// not queried at all, the genesis fork version is then taken from the genesis and the remaining constants
+// (DOMAIN_DEPOSIT, EPOCHS_PER_SYNC_COMMITTEE_PERIOD) from the mainnet preset.
+func WithSlotsPerEpoch(n uint64) Option {
+	return func(o *options) {
+		if n == 0 {
+			o.err = fmt.Errorf("invalid slotsPerEpoch: must be positive")
+			return
+		}
+		o.slotsPerEpoch = n
+	}
+}
+
+// WithSecondsPerSlot overrides SECONDS_PER_SLOT of the spec, see WithSlotsPerEpoch.
+func WithSecondsPerSlot(d time.Duration) Option {
+	return func(o *options) {
+		if d < time.Second || d%time.Second != 0 {
+			o.err = fmt.Errorf("invalid secondsPerSlot %v: must be a positive number of whole seconds", d)
+			return
+		}
+		o.secondsPerSlot = uint64(d / time.Second)
+	}
+}
+
+// WithAprPrecision rounds Apr and Apy of the returned days to the given number of decimal places, by default they
+// are returned with full precision. Values derived from the apr (like PerformanceScore) use the unrounded apr.
+func WithAprPrecision(places int32) Option {
+	return func(o *options) {
+		if places < 0 {
+			o.err = fmt.Errorf("invalid aprPrecision %v: must not be negative", places)
+			return
+		}
+		o.aprPrecision = &places
+	}
+}
+
+// WithCanonicalChain fetches the blocks of the day by walking the parent roots from the anchor block instead of
+// fetching them by slot, so that all counted blocks belong to the chain of the anchor even if the node reorgs
+// while the day is calculated. The states of the validators (at the start and end of the day, the slot of
+// WithEffectiveBalanceSlot and the epoch boundaries of WithEpochBreakdown) are pinned to the same chain by the state
+// roots of the blocks at their slots (unless the slot was missed), so a head that is resolved once at the start is not
+// mixed with the states of a later head. Days that are anchored at the finalized checkpoint (all numbered days) can not be
+// reorged and are always fetched by slot, the walk only applies to days given as a block id.
+func WithCanonicalChain() Option {
+	return func(o *options) {
+		o.canonicalChain = true
+	}
+}
+
+// WithTimings records the elapsed time per phase of the calculation in Day.Timings of the returned day. The phases
+// are spec (spec, genesis and resolving the day), validators (validators and balances at the start and the end of
+// the day), blocks (the block loop), attestations (only with WithMissedAttestations or WithInclusionDistance),
+// epochBreakdown (only with WithEpochBreakdown), reorgCheck, finalityCheck (only with WithFinalityCheck), aggregation
+// and total.
+func WithTimings() Option {
+	return func(o *options) {
+		o.timings = true
+	}
+}
+
+// WithoutValidatorDays skips building the days of the single validators, Calculate then only returns the day of all
+// validators and a nil map. This saves a Day with all its decimals per validator, which adds up for the whole
+// network. Values that are only derived for the validator days (like PerformanceScore) are not calculated.
+func WithoutValidatorDays() Option {
+	return func(o *options) {
+		o.withoutValidatorDays = true
+	}
+}
+
+// WithBalanceEvents collects the deposits and withdrawals of the accounted validators in Day.BalanceEvents of the
+// returned day, sorted by slot. Skipped deposits are not included. Since this keeps one event per deposit and
+// withdrawal (up to 16 withdrawals per block), it is disabled by default.
+func WithBalanceEvents() Option {
+	return func(o *options) {
+		o.balanceEvents = true
+	}
+}
+
+// WithReportTimezone sets the location of Day.DayTime for display purposes. It does not change the instant of
+// DayTime nor the boundaries of the day, days always start at genesis + day * 24h regardless of the timezone.
+func WithReportTimezone(loc *time.Location) Option {
+	return func(o *options) {
+		if loc == nil {
+			o.err = fmt.Errorf("invalid reportTimezone: must not be nil")
+			return
+		}
+		o.reportTimezone = loc
+	}
+}
+
+// WithMevLastTxAttribution attributes the value of the last transaction of a block to the proposer if it is sent to
+// the fee recipient of the block, which is how builders commonly pay the proposer. The payments are returned in
+// MevRewardsWei and are part of TotalRewardsWei and the apr, Day.AprExMev is the apr without them. Since not every
+// such transfer is a builder payment, the attribution is disabled by default.
+func WithMevLastTxAttribution() Option {
+	return func(o *options) {
+		o.mevLastTxAttribution = true
+	}
+}
+
+// WithBlockReceipts fetches the receipts of each block with a single eth_getBlockReceipts call instead of a batch of
+// eth_getTransactionReceipt calls, which is considerably cheaper for execution clients that index receipts by block
+// (like erigon and reth). The fees are computed from the receipts the same way. If the execution client does not
+// support eth_getBlockReceipts the receipts are fetched per transaction, for the rest of the calculation once the
+// first call failed.
+func WithBlockReceipts() Option {
+	return func(o *options) {
+		o.blockReceipts = true
+	}
+}
+
+// WithEpochBoundaries samples the balances at epoch boundaries instead of the exact day boundaries: the start balances
+// are taken from the state at the first slot of the epoch that contains the first slot of the day, the end balances
+// from the state at the first slot of the epoch that contains the first slot of the next day, and the blocks in
+// between are accounted. Consecutive days then consist of whole epochs without gaps or overlaps. On networks where a
+// day is a whole number of epochs (like mainnet, 225 epochs) the boundaries are the same. By default the canonical
+// definition is used, which samples exactly at genesis + day * 24h; results with this option are not comparable
+// with other eth.store implementations on networks where the boundaries differ.
+func WithEpochBoundaries() Option {
+	return func(o *options) {
+		o.epochBoundaries = true
+	}
+}
+
+// WithFinalityCheck checks for every epoch of the day whether its rewards were processed in an inactivity leak (the
+// chain did not finalize for more than MIN_EPOCHS_TO_INACTIVITY_PENALTY epochs) and sets Day.DuringNonFinality and
+// Day.InactivityLeakEpochs. During a leak the balance deltas include inactivity penalties, which depresses the apr
+// of the day; the penalties are not separated from the rewards. This reads the finality checkpoints of one state per
+// epoch (225 on mainnet), so it is disabled by default.
+func WithFinalityCheck() Option {
+	return func(o *options) {
+		o.finalityCheck = true
+	}
+}
+
+// WithNominalBalanceBasis computes the apr as if every validator had the given effective balance (usually 32e9 Gwei):
+// the rewards are divided by validators * gwei instead of the summed effective balances, and for the days of the
+// single validators by gwei instead of their effective balance. This deviates from the canonical definition, which
+// yields the return on the capital that is actually at stake, but makes sets and days with different effective
+// balances (e.g. after slashings or with underfunded validators) comparable per validator. EffectiveBalanceGwei of
+// the returned days is not changed.
+func WithNominalBalanceBasis(gwei phase0.Gwei) Option {
+	return func(o *options) {
+		if gwei == 0 {
+			o.err = fmt.Errorf("invalid nominalBalanceBasis: must be positive")
+			return
+		}
+		o.nominalBalanceGwei = gwei
+	}
+}
+
+// WithPeriodSlots calculates periods of n slots instead of calendar days (86400 / SECONDS_PER_SLOT slots, the
+// default): the day argument and Day.Day are then the index of the period, period d spans the slots [d*n, (d+1)*n),
+// and the rewards are annualized with the number of periods in 365 calendar days instead of 365. GetFinalizedDay and
+// GetHeadDay still return calendar days, so Backfill does not support this option.
+func WithPeriodSlots(n uint64) Option {
+	return func(o *options) {
+		if n == 0 {
+			o.err = fmt.Errorf("invalid periodSlots: must be positive")
+			return
+		}
+		o.periodSlots = n
+	}
+}
+
+// WithEffectiveBalanceSlot reads the effective balances from the state at the given offset from the first slot of the
+// day instead of the state at the first slot of the day (offset 0, the default). An offset of half the slots of a day
+// reads them at the midpoint, an offset of the slots of a day at the first slot of the next day, which is the state
+// the end balances are read from. Offsets after the end of the day are rejected. Offsets other than 0 and the end of
+// the day fetch the validators of an additional state, which is one of the most expensive requests of a calculation
+// (all validators of the network with their balances).
+func WithEffectiveBalanceSlot(offset uint64) Option {
+	return func(o *options) {
+		o.effectiveBalanceSlotOffset = offset
+	}
+}
+
+// WithArchiveNode queries the historical states (the validators and balances at the start and the end of the day,
+// the sync committees and the finality checkpoints) from the beacon node at address, while the spec, the headers and
+// the blocks are still queried from the primary node. This pairs a fast (pruned) node for the blocks with a slower
+// archive node for the states that a pruned node does not have. The nodes have to be on the same network, which is
+// checked by their genesis validators root.
+func WithArchiveNode(address string) Option {
+	return func(o *options) {
+		normalized, err := normalizeBeaconAddress(address)
+		if err != nil {
+			o.err = fmt.Errorf("invalid archiveNode: %w", err)
+			return
+		}
+		o.archiveNode = normalized
+	}
+}
+
+// WithEpochBreakdown returns the apr of each epoch of the day in Day.EpochBreakdown. The balances of the accounted
+// validators are sampled at the first slot of each epoch during the day, the rewards of an epoch (balance delta,
+// deposits, withdrawals and the execution rewards of its blocks) are annualized as the rewards of the day, relative to
+// the effective balance of the day. This fetches the validators of one additional state per epoch (224 on mainnet),
+// each of which holds all validators of the network, so it is disabled by default and best used with an archive node.
+func WithEpochBreakdown() Option {
+	return func(o *options) {
+		o.epochBreakdown = true
+	}
+}
+
+// WithPriceProvider sets Day.TotalRewardsFiat of the returned days to the total rewards in ETH times the price of
+// one ETH returned by price for the start of the day (Day.DayTime). The currency is up to the provider, the package
+// does not fetch prices itself. An error of the provider fails the calculation.
+func WithPriceProvider(price func(day time.Time) (decimal.Decimal, error)) Option {
+	return func(o *options) {
+		if price == nil {
+			o.err = fmt.Errorf("invalid priceProvider: must not be nil")
+			return
+		}
+		o.priceProvider = price
+	}
+}
+
+// WithCheckpoint stores the progress of the block loop of a day in store every 1024 slots and after the last slot: the
+// next slot to process and the sums accumulated so far. A calculation of the same day with a checkpoint in store
+// resumes after the processed slots instead of fetching all blocks again, which saves most of the work when a long
+// calculation failed. The balances are still fetched from the states. Checkpoints of other slot ranges (e.g. with
+// WithPeriodSlots) or written with other options that change the accumulated sums (WithMevLastTxAttribution,
+// WithBalanceEvents, WithEpochBreakdown, WithDepositFilter, WithMissedAttestations, WithClientClassifier,
+// WithProposerRewards, WithFeeStrategy, WithInclusionDistance, WithExecutionRewardsRate, WithMinActivationAge) are
+// ignored. With WithDepositFilter or WithClientClassifier checkpoints are written but never resumed, since the function
+// of a checkpoint can not be compared with the function of the calculation. Errors of store are logged and do not fail
+// the calculation, the package does not delete checkpoints.
+func WithCheckpoint(store CheckpointStore) Option {
+	return func(o *options) {
+		if store == nil {
+			o.err = fmt.Errorf("invalid checkpoint store: must not be nil")
+			return
+		}
+		o.checkpointStore = store
+	}
+}
+
+// WithRequireFinalized fails the calculation of a day that is not finalized, so that the returned day can not change
+// anymore and can be cached. Numbered days are always complete at the finalized checkpoint, the option applies to days
+// given as a block id (like head or justified): their end balances have to be at or before the finalized slot and
+// their anchor has to be the canonical block at its slot. By default such days are calculated on the chain of the
+// anchor whether it is finalized or not.
+func WithRequireFinalized() Option {
+	return func(o *options) {
+		o.requireFinalized = true
+	}
+}
+
+// WithMaxInFlightBytes limits the memory of the blocks that are processed concurrently in addition to the concurrency of
+// Calculate: no further block is requested while the blocks in flight add up to n bytes or more (measured by their ssz
+// size, which is about the size of the decoded block). Since the size of a block is only known once it is fetched, the
+// requests that are already running when the limit is reached can exceed it by up to concurrency blocks.
+func WithMaxInFlightBytes(n uint64) Option {
+	return func(o *options) {
+		if n == 0 {
+			o.err = fmt.Errorf("invalid maxInFlightBytes: must be positive")
+			return
+		}
+		o.maxInFlightBytes = n
+	}
+}
+
+// WithOrderedBlocks requests the blocks of the day in a window of concurrency consecutive slots: a slot is only
+// requested once the slot concurrency slots before it is processed. Without it the slots are requested in ascending
+// order as well, but a slot starts as soon as any slot in flight is done, so behind a slow slot the slots in flight
+// spread over the day. Nodes that serve consecutive blocks from their caches may answer faster within the window, at
+// the cost of the requests the window holds back while it waits for a slow slot. With WithAdaptiveConcurrency the
+// window is the maximum concurrency.
+func WithOrderedBlocks() Option {
+	return func(o *options) {
+		o.orderedBlocks = true
+	}
+}
+
+// WithDepositFilter only accounts the deposits for which filter returns true, e.g. to restrict the tracked capital to
+// the deposits of a staking provider or of one of several deposit contracts. Deposits that are filtered out are ignored
+// as if they were not in the block: they are neither accounted nor counted as skipped. The filter is applied by
+// Calculate, CalculateDeposits and CalculateSlots before the checks of the deposit, it is not called concurrently.
+func WithDepositFilter(filter func(*phase0.Deposit) bool) Option {
+	return func(o *options) {
+		if filter == nil {
+			o.err = fmt.Errorf("invalid deposit filter: must not be nil")
+			return
+		}
+		o.depositFilter = filter
+	}
+}
+
+// WithMissedAttestations counts the attestation duties of the accounted validators whose attestation was not included
+// in a block of the day in Validator.MissedAttestations and Day.MissedAttestations, which explains the validators
+// that lower the apr of a day. The committees of every epoch are fetched from the node, so the option costs one request
+// per epoch. An attestation can be included up to an epoch after its slot, so only the duties of the epochs before
+// the last epoch of the day are counted.
+func WithMissedAttestations() Option {
+	return func(o *options) {
+		o.missedAttestations = true
+	}
+}
+
+// WithRequestID sends id in the X-Request-ID header of all requests to the beacon nodes (including the archive node of
+// WithArchiveNode), so that the requests of a calculation can be found in the logs of a node provider.
+func WithRequestID(id string) Option {
+	return func(o *options) {
+		if id == "" {
+			o.err = fmt.Errorf("invalid request id: must not be empty")
+			return
+		}
+		o.requestID = id
+	}
+}
+
+// WithLedger returns the balances, deposits, withdrawals and rewards of every accounted validator in Day.Ledger of the
+// returned day, the ledger the apr is derived from, e.g. to verify a day externally (see WriteLedgerCSV). Unlike the
+// validator days the ledger holds the raw sums, it is also returned with WithoutValidatorDays.
+func WithLedger() Option {
+	return func(o *options) {
+		o.ledger = true
+	}
+}
+
+// WithClientClassifier sums the execution rewards of the blocks of the accounted validators in Day.RewardsByClient by
+// the client that classify returns for the graffiti of the block, e.g. to compare the rewards of client
+// implementations. The graffiti is set by the proposer and not verified, so the mapping is left to the caller;
+// classify can return the same name (like "unknown") for all graffiti it does not recognize. It is not called
+// concurrently.
+func WithClientClassifier(classify func(graffiti [32]byte) string) Option {
+	return func(o *options) {
+		if classify == nil {
+			o.err = fmt.Errorf("invalid client classifier: must not be nil")
+			return
+		}
+		o.clientClassifier = classify
+	}
+}
+
+// WithAdaptiveConcurrency lets the concurrency of the block loop of Calculate follow the health of the node instead of
+// being fixed: it starts at the concurrency given to Calculate (clamped to the bounds), is halved when a block request
+// fails (including the attempts that are retried) and is raised by one after as many requests in a row succeeded as
+// are allowed in flight. This keeps long backfills fast on a healthy node and backs off when it errors under load.
+// The adjustments are logged at debug level.
+func WithAdaptiveConcurrency(minConcurrency, maxConcurrency int) Option {
+	return func(o *options) {
+		if minConcurrency < 1 || maxConcurrency < minConcurrency {
+			o.err = fmt.Errorf("invalid adaptive concurrency bounds [%v, %v]: must be positive and ordered", minConcurrency, maxConcurrency)
+			return
+		}
+		o.adaptiveConcurrencyMin = minConcurrency
+		o.adaptiveConcurrencyMax = maxConcurrency
+	}
+}
+
+// WithExactAnnualization annualizes the rewards with secondsPerYear / Day.PeriodSeconds (a year of 365 days) instead of
+// 365 (or the periods of WithPeriodSlots in 365 days). Both are the same for complete days of a network whose
+// SECONDS_PER_SLOT divides a day. They differ for days that are cut short (the current day of "head"), for days whose
+// slots WithEpochBoundaries moves to epochs of another total length and for slot durations that do not divide a day.
+func WithExactAnnualization() Option {
+	return func(o *options) {
+		o.exactAnnualization = true
+	}
+}
+
+// WithoutBlocks skips the block loop of Calculate and derives the apr from the balances of the states of the day alone,
+// which makes consensus-only backfills much faster. It asserts that the accounted validators received no deposits
+// and made no withdrawals during the day: neither is counted, so a deposit is accounted as reward and a withdrawal as
+// penalty. Use it only for days without deposits to the validators and before withdrawals were enabled (Capella).
+// Execution rewards, sync committee duties, proposed blocks and missed slots are not counted either (they are 0) and
+// Day.WithoutBlocks is set. It can not be combined with the options that process the blocks (WithMissedAttestations,
+// WithProposerRewards, WithInclusionDistance, WithEpochBreakdown, WithClientClassifier, WithBalanceEvents,
+// WithMevLastTxAttribution, WithFeeStrategy, WithBlockReceipts, WithTxCache, WithBlockArchive, WithMaxInFlightBytes,
+// WithExecutionRewardsRate, WithWithdrawalRequests and WithActiveFractionNormalization). WithCheckpoint has no effect and
+// WithCanonicalChain does not walk the blocks, so the states are fetched by slot.
+func WithoutBlocks() Option {
+	return func(o *options) {
+		o.withoutBlocks = true
+	}
+}
+
+// WithAprTrimFraction sets the fraction of the validators with the highest and the lowest aprs each that
+// Day.AprTrimmedMean discards, 0.01 by default. A fraction of 0 keeps all validators, fractions of 0.5 or more would
+// discard all of them and are rejected.
+func WithAprTrimFraction(fraction float64) Option {
+	return func(o *options) {
+		if !(fraction >= 0 && fraction < 0.5) {
+			o.err = fmt.Errorf("invalid aprTrimFraction %v: must be in [0, 0.5)", fraction)
+			return
+		}
+		o.aprTrimFraction = &fraction
+	}
+}
+
+// NegativeAprPolicy is how Calculate reports negative aprs, see WithNegativeAprPolicy.
+type NegativeAprPolicy int
+
+const (
+	// NegativeAprAsIs reports negative aprs unchanged, it is the default.
+	NegativeAprAsIs NegativeAprPolicy = iota
+	// NegativeAprClampZero reports negative aprs (and apys) of the day and of the validator days as 0, the
+	// ConsensusApr and ExecutionApr of a clamped day are 0 as well.
+	NegativeAprClampZero
+	// NegativeAprExclude omits the days of validators with a negative apr from the validator days. The day itself is
+	// reported unchanged, it is the total of all accounted validators including the omitted ones.
+	NegativeAprExclude
+)
+
+// WithNegativeAprPolicy sets how negative aprs are reported, for example of days with a slashing or an inactivity
+// leak. Negative aprs are correct, the policy only changes their presentation: the rewards (ConsensusRewardsGwei,
+// TotalRewardsWei) as well as AprStdDev, AprTrimmedMean and the performance scores are derived from the unchanged aprs.
+func WithNegativeAprPolicy(policy NegativeAprPolicy) Option {
+	return func(o *options) {
+		if policy < NegativeAprAsIs || policy > NegativeAprExclude {
+			o.err = fmt.Errorf("invalid negativeAprPolicy %v", policy)
+			return
+		}
+		o.negativeAprPolicy = policy
+	}
+}
+
+// WithProposerRewards fetches the consensus rewards of every block proposed by an accounted validator from the block
+// rewards endpoint of the node (/eth/v1/beacon/rewards/blocks/{block_id}) into Validator.ProposerConsensusRewardsGwei
+// and Day.ProposerConsensusRewardsGwei. These are the rewards for including attestations, the sync aggregate and
+// slashings, which are part of ConsensusRewardsGwei, isolated from the attestation and sync committee rewards of the
+// balance delta. The option costs one request per such block, the node has to serve the endpoint for the slots of
+// the day (which many nodes only do from altair on and, without an archive, for recent slots). It can not be combined
+// with WithoutBlocks.
+func WithProposerRewards() Option {
+	return func(o *options) {
+		o.proposerRewards = true
+	}
+}
+
+// WithBlockArchive reads the blocks of the block loop of Calculate from the SSZ-encoded files in dir where they exist,
+// which takes the load of the block requests off the node for backfills of days whose blocks have been downloaded.
+// The file of a block is named by its slot and fork version, e.g. 72100.bellatrix.ssz (the versions are phase0,
+// altair, bellatrix and capella), since SSZ does not encode the version. Slots without a file (including missed
+// slots, which have no block to archive) are requested from the node. With WithCanonicalChain archived blocks that
+// are not on the chain of the anchor are ignored.
+func WithBlockArchive(dir string) Option {
+	return func(o *options) {
+		if dir == "" {
+			o.err = fmt.Errorf("invalid blockArchive: must not be empty")
+			return
+		}
+		o.blockArchive = dir
+	}
+}
+
+// WithStateRootVerification checks that the start and end balances of Calculate are read from a single state each:
+// the root of the state is requested before and after its validators (which are then not taken from the cache of
+// GetValidators) and has to be the same, and has to be the state root of the block at the slot of the state if there
+// is one. This catches states that change between the requests of the validators (chunked requests, see
+// SetValidatorsChunkSize, or a reorg of the slot) and states of another slot than requested. The roots are returned
+// in Day.StartStateRoot and Day.EndStateRoot. It costs three requests per state (two of the root and one of the block
+// header). The balances are not proven against the root with merkle proofs, since the beacon-node-api has no
+// endpoint for them.
+func WithStateRootVerification() Option {
+	return func(o *options) {
+		o.stateRootVerification = true
+	}
+}
+
+// WithTimeWeightedEffectiveBalance uses the average of the effective balances of a validator over the day, weighted
+// by time, as its effective balance (the denominator of the apr) instead of the effective balance at the start of the
+// day. The effective balances are sampled every epochs epochs from the first slot of the day on, each sample is
+// weighted by the slots until the next one. Since effective balances only change at epoch boundaries, epochs 1 is
+// exact. Every sample but the first fetches the validators of an additional state, which is one of the most expensive
+// requests of a calculation (all validators of the network with their balances): ceil(slots per day / (epochs * slots
+// per epoch)) - 1 additional requests, 224 with epochs 1 and 32 slots per epoch, 3 with epochs 64. Up to concurrency
+// samples are fetched at a time and held in memory until they are added up, they are not cached by GetValidators. The
+// default is the single snapshot at the start of the day. It can not be combined with WithEffectiveBalanceSlot.
+func WithTimeWeightedEffectiveBalance(epochs uint64) Option {
+	return func(o *options) {
+		if epochs == 0 {
+			o.err = fmt.Errorf("invalid effectiveBalanceEpochs: must be positive")
+			return
+		}
+		o.effectiveBalanceEpochs = epochs
+	}
+}
+
+// WithFeeStrategy attributes the fees of the transactions of the blocks to their proposers with s, e.g.
+// TipFeeStrategy or FullGasFeeStrategy, instead of the default: the fees paid by the transactions (gas used times the
+// effective gas price of their receipts) minus the base fee of the block times the gas used by the block, which is
+// burnt. The default is the same as TipFeeStrategy as long as the gas used by the receipts adds up to the gas used
+// by the block.
+func WithFeeStrategy(s FeeStrategy) Option {
+	return func(o *options) {
+		if s == nil {
+			o.err = fmt.Errorf("invalid feeStrategy: must not be nil")
+			return
+		}
+		o.feeStrategy = s
+	}
+}
+
+// WithStateRange calculates the rewards between the states startStateID and endStateID instead of a day, Calculate
+// and CalculateDeposits then have to be called with an empty day. A state id is either a slot or a block id (see
+// IsBlockID), whose state is the post-state of the block. The start and end balances are taken from the two states,
+// the blocks of the slots in between (from the slot of the start state to the slot before the end state) are accounted
+// and the rewards are annualized with the time between the two states (as with WithExactAnnualization). Day.Day is the
+// day the slot of the start state is in. The end state has to be at or before the finalized checkpoint unless it is
+// given as a block id, which then anchors the range like a day given as a block id.
+//
+// A day is the special case of the states at its first slot and at the first slot of the next day: the range of
+// "72000" and "79200" on mainnet is day 10. It can not be combined with WithEpochBoundaries and WithPeriodSlots, which
+// derive the slots from the day.
+func WithStateRange(startStateID, endStateID string) Option {
+	return func(o *options) {
+		for _, id := range []string{startStateID, endStateID} {
+			if _, err := strconv.ParseUint(id, 10, 64); err != nil && !IsBlockID(id) {
+				o.err = fmt.Errorf("invalid state id %q: must be a slot or a block id", id)
+				return
+			}
+		}
+		o.startStateID = startStateID
+		o.endStateID = endStateID
+	}
+}
+
+// WithInclusionDistance averages the inclusion distances of the attestations of the accounted validators in
+// Validator.AvgInclusionDistance and Day.AvgInclusionDistance: the slot of the first block of the day that includes an
+// attestation minus the slot of the attestation, 1 is the best possible distance. Late attestations earn smaller
+// timeliness rewards, so this explains reward shortfalls that do not show up as MissedAttestations. Like
+// WithMissedAttestations it fetches the committees of every epoch and only covers the duties of the epochs before the
+// last epoch of the day, both share the requests of the committees.
+func WithInclusionDistance() Option {
+	return func(o *options) {
+		o.inclusionDistance = true
+	}
+}
+
+// WithPreset takes the constants of the spec from p (e.g. GnosisPreset) instead of querying the spec of the node, like
+// WithSlotsPerEpoch and WithSecondsPerSlot together but with the remaining constants of p instead of mainnet. It is
+// meant for nodes whose spec endpoint is not usable, the constants of the node are read from its spec otherwise, which
+// handles any network.
+func WithPreset(p Preset) Option {
+	return func(o *options) {
+		if p.SlotsPerEpoch == 0 || p.SecondsPerSlot == 0 || p.EpochsPerSyncCommitteePeriod == 0 {
+			o.err = fmt.Errorf("invalid preset %+v: constants must be positive", p)
+			return
+		}
+		o.preset = &p
+		o.slotsPerEpoch = p.SlotsPerEpoch
+		o.secondsPerSlot = p.SecondsPerSlot
+	}
+}
+
+// WithExecutionRewardsRate converts the execution rewards (tx fees and mev payments in Wei of the token of the
+// execution layer) to Wei of the token of the balances with the rate returned by rate for the start of the day
+// (Day.DayTime), before they are added to the rewards and the aprs. This is needed on networks where the two differ,
+// e.g. xDAI and mGNO on Gnosis Chain (see GnosisPreset), where the rate is the price of 1 xDAI in mGNO. The tx fees
+// and mev rewards of the returned days are then in Wei of the token of the balances as well. On ethereum both are ETH
+// and the rate is 1, the default. An error of rate or a rate that is not positive fails the calculation.
+func WithExecutionRewardsRate(rate func(day time.Time) (decimal.Decimal, error)) Option {
+	return func(o *options) {
+		if rate == nil {
+			o.err = fmt.Errorf("invalid executionRewardsRate: must not be nil")
+			return
+		}
+		o.executionRewardsRate = rate
+	}
+}
+
+// WithTxCache caches the decoded transactions and the receipts of the blocks in c, keyed by the block hash of their
+// execution payload, which identifies the transactions independent of the slot and the beacon block they are served
+// with. Calls that process the same blocks again (re-runs of a day, overlapping slots of CalculateSlots, a day resumed
+// from a checkpoint in the same process) then do not request their receipts from the execution client again and the
+// transactions are decoded once. The fees are still attributed on every call, so the options that change them (e.g.
+// WithFeeStrategy) apply to cached blocks as well. c is bounded (see NewTxCache) and can be shared by concurrent calls.
+func WithTxCache(c *TxCache) Option {
+	return func(o *options) {
+		if c == nil {
+			o.err = fmt.Errorf("invalid txCache: must not be nil")
+			return
+		}
+		o.txCache = c
+	}
+}
+
+// WithMinActivationAge does not account validators that were activated less than epochs epochs before the first
+// epoch of the day, whose first days are often anomalous (e.g. the first attestations of a validator are only
+// rewarded from its activation on). They are excluded from the Validators, the balances and the rewards of the day
+// like validators that are not active the whole day, so the apr is the yield of the seasoned validators only: the
+// denominator (the effective balances) shrinks by their effective balances and the numerator by their rewards. Their
+// number is reported in Day.YoungValidators and deposits to them in Day.PendingDepositsSumGwei. epochs 1 excludes
+// only the validators activated in the first epoch of the day, which are otherwise accounted.
+func WithMinActivationAge(epochs uint64) Option {
+	return func(o *options) {
+		if epochs == 0 {
+			o.err = fmt.Errorf("invalid minActivationAge: must be positive")
+			return
+		}
+		o.minActivationAge = epochs
+	}
+}
+
+// WithRangePrefetch makes CalculateRange and CalculateRangeFunc fetch the validators of the state at the end of the next
+// day while the blocks of the current day are processed, so that the most expensive request of the next day (all
+// validators of the network with their balances) overlaps with the current one instead of delaying the next day. The
+// state at the start of the next day is the end state of the current day, which is reused anyway. Only one state is
+// prefetched at a time across all calculations, still the node has to serve the prefetch in addition to the blocks,
+// which is why it is opt-in. Days loaded from the storage are not prefetched, and neither are the states with
+// WithStateRootVerification, which fetches them between requests of their roots. It has no effect on Calculate.
+func WithRangePrefetch() Option {
+	return func(o *options) {
+		o.rangePrefetch = true
+	}
+}
+
+// WithQualityScore summarizes the gaps and approximations of the day of all validators in Day.QualityScore, from 1 for
+// a complete day to 0, and Day.Warnings, one message per issue, as a single signal whether to publish a day. The score
+// is 1 minus the penalties of the issues (at least 0):
+//
+//   - 0.5 if the day was calculated WithoutBlocks
+//   - 0.5 times the fraction of the proposed blocks whose execution payload is incomplete (IncompletePayloadSlots,
+//     which includes the blinded blocks), since their fees are missing
+//   - 0.5 if the day is PossiblyReorged, 0.1 if it is otherwise anchored at a block after the finalized checkpoint
+//   - 0.25 times the fraction of the validators that were active at the start of the day and are not accounted since
+//     they are missing in the state at its end (or not active in it)
+//
+// A day during an inactivity leak (DuringNonFinality) gets a warning but no penalty, its rewards are exact. Slots whose
+// block can not be fetched are not scored since they fail the calculation instead of leaving a gap, and neither are
+// validators that are pending during the day, they are not accounted by definition (see PendingDepositsSumGwei).
+func WithQualityScore() Option {
+	return func(o *options) {
+		o.qualityScore = true
+	}
+}
+
+// WithValidatorSets calculates the days of the given validator sets (by name, the validator indices of each set) in
+// Day.ValidatorSets of the returned day, in addition to the day of all validators. The day of a set sums the accounted
+// validators of the set like the day of all validators. Indices of validators that are not accounted, since they were
+// not active the whole day or since the index is stale or mistyped, are listed in Day.ValidatorSetMeta instead of
+// silently shrinking the set. Indices that are not even a validator at the end of the day are out of range and logged
+// as a warning. Callers that calculate repeatedly with the same large sets can build them once and pass them with
+// WithValidatorSetMaps instead, for sets that partition a pool see WithOperatorSets.
+func WithValidatorSets(sets map[string][]uint64) Option {
+	validatorSets := make(map[string]map[phase0.ValidatorIndex]bool, len(sets))
+	duplicates := make(map[string]int)
+	for name, indices := range sets {
+		set := make(map[phase0.ValidatorIndex]bool, len(indices))
+		for _, index := range indices {
+			if set[phase0.ValidatorIndex(index)] {
+				duplicates[name]++
+			}
+			set[phase0.ValidatorIndex(index)] = true
+		}
+		validatorSets[name] = set
+	}
+	withSets := WithValidatorSetMaps(validatorSets)
+	return func(o *options) {
+		withSets(o)
+		o.validatorSetDuplicates = duplicates
+	}
+}
+
+// WithValidatorSetMaps is WithValidatorSets with the sets already built as maps of the validator indices, so they are
+// not converted on every call. The maps are only read and may be shared between concurrent calculations.
+func WithValidatorSetMaps(sets map[string]map[phase0.ValidatorIndex]bool) Option {
+	return func(o *options) {
+		o.validatorSets = sets
+		o.validatorSetDuplicates = nil
+	}
+}
+
+// WithWithdrawalRequests sums the EIP-7002 withdrawal requests for the accounted validators that are included in the
+// execution requests of the blocks from ELECTRA_FORK_EPOCH of the spec on into Day.WithdrawalRequestsSumGwei. A
+// request only queues a withdrawal: the requested amount leaves the balance with the withdrawal sweep of a later block
+// and is counted in WithdrawalsSumGwei then, like any other withdrawal, so the consensus rewards do not depend on the
+// requests. Full exit requests have no amount and add nothing. The client does not decode the execution requests, so
+// the option costs one request of the raw block per block from the fork on. It can not be combined with
+// WithoutBlocks.
+func WithWithdrawalRequests() Option {
+	return func(o *options) {
+		o.withdrawalRequests = true
+	}
+}
+
+// WithActiveFractionNormalization adds the days of the validators that were activated during the day to the validator
+// days, for comparing them with the accounted validators. They are not accounted (see PendingDepositsSumGwei), so the
+// day of all validators and the statistics over the validator days (like AprStdDev) do not change. A validator that
+// activates mid-day can only propose in the slots after its activation, so its execution rewards are divided by its
+// Day.ActiveFraction (the epochs of the day from its activation on over all epochs of the day) in Apr, ExecutionApr and
+// AprExMev, the sums (TxFeesSumWei, TotalRewardsWei, ...) are the actual ones. The consensus rewards are not prorated:
+// ConsensusApr is the balance delta over the full effective balance, so only the execution part of the apr is
+// comparable with validators that were active the whole day. If the apr basis were prorated by the active fraction as
+// well, the execution rewards would be normalized twice. It can neither be combined with WithoutValidatorDays nor with
+// WithoutBlocks or WithCheckpoint.
+func WithActiveFractionNormalization() Option {
+	return func(o *options) {
+		o.activeFractionNormalization = true
+	}
+}
+
+// WithOperatorSets is WithValidatorSets for the node operators of a pool (DVT clusters, the operators of a staking
+// pool, ...): operators are the validator indices by operator and have to partition the validator indices of pool,
+// so that every validator of the pool belongs to exactly one operator. Then the days of the operators in
+// Day.ValidatorSets add up to the day of the pool (the sums, the aprs are weighted by the effective balances), also
+// when they are aggregated with AggregateDays. A validator in two operators, in no operator or of no validator of the
+// pool is an error.
+func WithOperatorSets(pool []uint64, operators map[string][]uint64) Option {
+	if err := checkPartition(pool, operators); err != nil {
+		return func(o *options) {
+			o.err = fmt.Errorf("invalid operator sets: %w", err)
+		}
+	}
+	return WithValidatorSets(operators)
+}
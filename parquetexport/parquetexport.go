@@ -0,0 +1,119 @@
+// Package parquetexport writes eth.store days as parquet files, e.g. to load long histories into DuckDB or Spark.
+//
+// It is a separate module so that eth.store itself does not depend on a parquet library. Days and the days of the
+// single validators share one schema (Row), the decimal values of a day are written as strings with their full
+// precision since the balances and rewards in Wei exceed the range of int64.
+package parquetexport
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	ethstore "github.com/gobitfly/eth.store"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Row is a row of the files written by this package. ValidatorIndex is null for network-wide days. Columns are only
+// ever appended to the schema, so files written by older versions stay readable with the same queries.
+type Row struct {
+	Day                    int64  `parquet:"day"`
+	DayTime                int64  `parquet:"day_time,timestamp(millisecond)"`
+	ValidatorIndex         *int64 `parquet:"validator_index,optional"`
+	Apr                    string `parquet:"apr"`
+	Apy                    string `parquet:"apy"`
+	Validators             string `parquet:"validators"`
+	StartEpoch             string `parquet:"start_epoch"`
+	EffectiveBalanceGwei   string `parquet:"effective_balance_gwei"`
+	StartBalanceGwei       string `parquet:"start_balance_gwei"`
+	EndBalanceGwei         string `parquet:"end_balance_gwei"`
+	DepositsSumGwei        string `parquet:"deposits_sum_gwei"`
+	WithdrawalsSumGwei     string `parquet:"withdrawals_sum_gwei"`
+	ConsensusRewardsGwei   string `parquet:"consensus_rewards_gwei"`
+	TxFeesSumWei           string `parquet:"tx_fees_sum_wei"`
+	MevRewardsWei          string `parquet:"mev_rewards_wei"`
+	TotalRewardsWei        string `parquet:"total_rewards_wei"`
+	SyncCommitteeDuties    string `parquet:"sync_committee_duties"`
+	SyncParticipationRate  string `parquet:"sync_participation_rate"`
+	ProposedBlocks         string `parquet:"proposed_blocks"`
+	MissedSlots            string `parquet:"missed_slots"`
+	PerformanceScore       string `parquet:"performance_score"`
+	SkippedDeposits        string `parquet:"skipped_deposits"`
+	IncompletePayloadSlots string `parquet:"incomplete_payload_slots"`
+	PendingDepositsSumGwei string `parquet:"pending_deposits_sum_gwei"`
+	PossiblyReorged        bool   `parquet:"possibly_reorged"`
+	DuringNonFinality      bool   `parquet:"during_non_finality"`
+	WithdrawalAddress      string `parquet:"withdrawal_address,optional"`
+}
+
+// NewRow returns the row of d, validatorIndex is nil for a network-wide day.
+func NewRow(d *ethstore.Day, validatorIndex *uint64) Row {
+	r := Row{
+		Day:                    d.Day.IntPart(),
+		DayTime:                d.DayTime.UnixMilli(),
+		Apr:                    d.Apr.String(),
+		Apy:                    d.Apy.String(),
+		Validators:             d.Validators.String(),
+		StartEpoch:             d.StartEpoch.String(),
+		EffectiveBalanceGwei:   d.EffectiveBalanceGwei.String(),
+		StartBalanceGwei:       d.StartBalanceGwei.String(),
+		EndBalanceGwei:         d.EndBalanceGwei.String(),
+		DepositsSumGwei:        d.DepositsSumGwei.String(),
+		WithdrawalsSumGwei:     d.WithdrawalsSumGwei.String(),
+		ConsensusRewardsGwei:   d.ConsensusRewardsGwei.String(),
+		TxFeesSumWei:           d.TxFeesSumWei.String(),
+		MevRewardsWei:          d.MevRewardsWei.String(),
+		TotalRewardsWei:        d.TotalRewardsWei.String(),
+		SyncCommitteeDuties:    d.SyncCommitteeDuties.String(),
+		SyncParticipationRate:  d.SyncParticipationRate.String(),
+		ProposedBlocks:         d.ProposedBlocks.String(),
+		MissedSlots:            d.MissedSlots.String(),
+		PerformanceScore:       d.PerformanceScore.String(),
+		SkippedDeposits:        d.SkippedDeposits.String(),
+		IncompletePayloadSlots: d.IncompletePayloadSlots.String(),
+		PendingDepositsSumGwei: d.PendingDepositsSumGwei.String(),
+		PossiblyReorged:        d.PossiblyReorged,
+		DuringNonFinality:      d.DuringNonFinality,
+	}
+	if validatorIndex != nil {
+		index := int64(*validatorIndex)
+		r.ValidatorIndex = &index
+	}
+	if d.WithdrawalAddress != nil {
+		r.WithdrawalAddress = d.WithdrawalAddress.Hex()
+	}
+	return r
+}
+
+// WriteDays writes the network-wide days to w as a parquet file, one row per day in the given order.
+func WriteDays(w io.Writer, days []*ethstore.Day) error {
+	rows := make([]Row, 0, len(days))
+	for _, d := range days {
+		rows = append(rows, NewRow(d, nil))
+	}
+	return write(w, rows)
+}
+
+// WriteValidatorDays writes the days of the single validators as returned by ethstore.Calculate to w as a parquet
+// file, one row per validator sorted by validator index.
+func WriteValidatorDays(w io.Writer, validatorDays map[uint64]*ethstore.Day) error {
+	indices := make([]uint64, 0, len(validatorDays))
+	for index := range validatorDays {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	rows := make([]Row, 0, len(indices))
+	for _, index := range indices {
+		index := index
+		rows = append(rows, NewRow(validatorDays[index], &index))
+	}
+	return write(w, rows)
+}
+
+func write(w io.Writer, rows []Row) error {
+	err := parquet.Write(w, rows, parquet.Compression(&parquet.Zstd))
+	if err != nil {
+		return fmt.Errorf("error writing parquet: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package parquetexport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethstore "github.com/gobitfly/eth.store"
+	"github.com/parquet-go/parquet-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestWriteValidatorDays(t *testing.T) {
+	withdrawalAddress := common.HexToAddress("0x9709ae4129ed4bb3fa6678e83a9976b7cc81abd1")
+	days := map[uint64]*ethstore.Day{
+		7: {Day: decimal.NewFromInt(10), DayTime: time.Unix(1607688023, 0), Apr: decimal.RequireFromString("0.0621640625"), TotalRewardsWei: decimal.RequireFromString("170322400000000000000000")},
+		3: {Day: decimal.NewFromInt(10), DayTime: time.Unix(1607688023, 0), WithdrawalAddress: &withdrawalAddress},
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteValidatorDays(buf, days); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := parquet.Read[Row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].ValidatorIndex == nil || *rows[0].ValidatorIndex != 3 || rows[1].ValidatorIndex == nil || *rows[1].ValidatorIndex != 7 {
+		t.Fatalf("wrong rows: %+v", rows)
+	}
+	if rows[1].TotalRewardsWei != "170322400000000000000000" || rows[1].Apr != "0.0621640625" || rows[1].DayTime != 1607688023000 {
+		t.Errorf("wrong row of validator 7: %+v", rows[1])
+	}
+	if rows[0].WithdrawalAddress != withdrawalAddress.Hex() {
+		t.Errorf("wrong WithdrawalAddress of validator 3: %v != %v", rows[0].WithdrawalAddress, withdrawalAddress.Hex())
+	}
+
+	buf.Reset()
+	if err := WriteDays(buf, []*ethstore.Day{days[7]}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = parquet.Read[Row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].ValidatorIndex != nil || rows[0].Day != 10 {
+		t.Errorf("wrong rows of the network-wide days: %+v", rows)
+	}
+}
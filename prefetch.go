@@ -0,0 +1,76 @@
+package ethstore
+
+import (
+	"context"
+	"log"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// prefetchSem bounds the prefetches of all calculations to one at a time, so that prefetching adds at most one request
+// of the validators of a state to the requests of the running calculations, see WithRangePrefetch.
+var prefetchSem = make(chan struct{}, 1)
+
+// validatorsPrefetch are the validators of a state that are fetched in the background while the previous day is
+// calculated, see WithRangePrefetch. done is closed once vals or err are set, or without either if nothing is
+// prefetched.
+type validatorsPrefetch struct {
+	done    chan struct{}
+	stateID string
+	vals    map[phase0.ValidatorIndex]*v1.Validator
+	err     error
+}
+
+func newValidatorsPrefetch() *validatorsPrefetch {
+	return &validatorsPrefetch{done: make(chan struct{})}
+}
+
+// start fetches the validators of the state stateID from client once no other prefetch is running.
+func (p *validatorsPrefetch) start(ctx context.Context, client *http.Service, stateID string) {
+	p.stateID = stateID
+	go func() {
+		defer close(p.done)
+		select {
+		case prefetchSem <- struct{}{}:
+		case <-ctx.Done():
+			p.err = ctx.Err()
+			return
+		}
+		defer func() { <-prefetchSem }()
+		p.vals, p.err = fetchValidators(ctx, client, stateID)
+	}()
+}
+
+// skip marks the prefetch as done without prefetching a state.
+func (p *validatorsPrefetch) skip() {
+	close(p.done)
+}
+
+// get waits for the prefetch and returns its validators if they are the validators of the state stateID. If the
+// prefetch failed or prefetched another state the validators have to be fetched as usual.
+func (p *validatorsPrefetch) get(ctx context.Context, stateID string) (map[phase0.ValidatorIndex]*v1.Validator, bool) {
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return nil, false
+	}
+	if p.stateID != stateID || p.vals == nil {
+		if p.err != nil && GetDebugLevel() > 0 {
+			log.Printf("DEBUG eth.store: error prefetching validators for state %v: %v", p.stateID, p.err)
+		}
+		return nil, false
+	}
+	return p.vals, true
+}
+
+// withValidatorsPrefetch passes the prefetch of CalculateRangeFunc to Calculate: the validators of prefetched are used
+// for the day if it is the state they were fetched for, and next is started for the end state of the following day.
+// Either may be nil.
+func withValidatorsPrefetch(prefetched, next *validatorsPrefetch) Option {
+	return func(o *options) {
+		o.prefetched = prefetched
+		o.nextPrefetch = next
+	}
+}
@@ -0,0 +1,165 @@
+package ethstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/attestantio/go-eth2-client/http"
+)
+
+// PreflightReport is the result of Preflight. Problems holds an actionable message per failed check, the other
+// fields are set as far as the checks got.
+type PreflightReport struct {
+	Reachable       bool
+	SpecAvailable   bool
+	Synced          bool
+	SyncDistance    uint64
+	Day             uint64
+	FirstSlot       uint64
+	EndSlot         uint64
+	StatesAvailable bool
+	Problems        []string
+}
+
+// OK reports whether all checks passed.
+func (r *PreflightReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Preflight checks that the beacon node at address can serve the calculation of the given day before starting it:
+// the node has to be reachable, serve the spec, be synced and have the states at the first slot of the day and the
+// first slot of the next day (which requires an archive node for days that are not recent). Failed checks are
+// reported in the returned report, an error is only returned for an invalid address or day.
+func Preflight(ctx context.Context, address, dayStr string) (*PreflightReport, error) {
+	if _, err := normalizeBeaconAddress(address); err != nil {
+		return nil, err
+	}
+	if _, err := strconv.ParseUint(dayStr, 10, 64); err != nil && !IsBlockID(dayStr) {
+		return nil, fmt.Errorf("invalid day %q: must be a day number, one of head, finalized, justified, genesis or a 0x-prefixed block root", dayStr)
+	}
+	report := &PreflightReport{}
+
+	client, err := newConsClient(ctx, address, nil)
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("beacon node is not reachable, check the address and that the beacon-api is enabled: %v", err))
+		return report, nil
+	}
+	report.Reachable = true
+
+	chainSpec, err := getChainSpec(ctx, client, &options{})
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("spec is not available: %v", err))
+		return report, nil
+	}
+	report.SpecAvailable = true
+
+	if err := waitForRequest(ctx); err != nil {
+		return nil, err
+	}
+	syncState, err := client.NodeSyncing(ctx)
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("error getting sync status: %v", err))
+	} else {
+		report.SyncDistance = uint64(syncState.SyncDistance)
+		report.Synced = !syncState.IsSyncing
+		if syncState.IsSyncing {
+			report.Problems = append(report.Problems, fmt.Sprintf("beacon node is syncing (sync distance: %v slots), wait until it is synced", syncState.SyncDistance))
+		}
+	}
+
+	dr, err := resolveDay(ctx, client, dayStr, 3600*24/chainSpec.SecondsPerSlot)
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("day can not be calculated yet: %v", err))
+		return report, nil
+	}
+	report.Day = dr.Day
+	report.FirstSlot = dr.FirstSlot
+	report.EndSlot = dr.EndSlot
+
+	report.StatesAvailable = true
+	for _, slot := range []uint64{dr.FirstSlot, dr.EndSlot} {
+		if err := waitForRequest(ctx); err != nil {
+			return nil, err
+		}
+		// the finality checkpoints are read from the state, so unlike the state root they are only served if the node
+		// has the state
+		finality, err := client.Finality(ctx, fmt.Sprintf("%d", slot))
+		if err != nil || finality == nil {
+			report.StatesAvailable = false
+			report.Problems = append(report.Problems, fmt.Sprintf("state at slot %v is not available, an archive node is needed for day %v: %v", slot, dr.Day, err))
+		}
+	}
+	return report, nil
+}
+
+// AvailableDays returns the days that the beacon node at address can calculate, e.g. for a day picker: lastDay is the
+// last day that is complete at the finalized checkpoint and firstDay the first day whose state at its first slot the
+// node serves. Pruning nodes only keep recent states (and the states since their checkpoint sync), archive nodes all
+// states since genesis. firstDay is found by a binary search over the days, which assumes that the node serves all
+// states from some slot on. An error is returned if the node is syncing or serves no state of lastDay.
+func AvailableDays(ctx context.Context, address string) (firstDay, lastDay uint64, err error) {
+	client, err := newConsClient(ctx, address, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	chainSpec, err := getChainSpec(ctx, client, &options{})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := waitForRequest(ctx); err != nil {
+		return 0, 0, err
+	}
+	syncState, err := client.NodeSyncing(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error getting sync status: %w", err)
+	}
+	if syncState.IsSyncing {
+		return 0, 0, fmt.Errorf("beacon node is syncing (sync distance: %v slots)", syncState.SyncDistance)
+	}
+
+	slotsPerDay := 3600 * 24 / chainSpec.SecondsPerSlot
+	dr, err := resolveDay(ctx, client, "finalized", slotsPerDay)
+	if err != nil {
+		return 0, 0, err
+	}
+	lastDay = dr.Day
+	available, err := stateAvailable(ctx, client, lastDay*slotsPerDay)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !available {
+		return 0, 0, fmt.Errorf("beacon node does not serve the state at slot %v of day %v, the last finalized day", lastDay*slotsPerDay, lastDay)
+	}
+
+	// the first day in [lo, hi] whose state is available, the state of hi is
+	lo, hi := uint64(0), lastDay
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		available, err := stateAvailable(ctx, client, mid*slotsPerDay)
+		if err != nil {
+			return 0, 0, err
+		}
+		if available {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, lastDay, nil
+}
+
+// stateAvailable reports whether the node serves the state at slot. Like in Preflight the finality checkpoints are
+// requested, which are read from the state, every error of the request counts as unavailable since nodes report pruned
+// states differently.
+func stateAvailable(ctx context.Context, client *http.Service, slot uint64) (bool, error) {
+	if err := waitForRequest(ctx); err != nil {
+		return false, err
+	}
+	finality, err := client.Finality(ctx, fmt.Sprintf("%d", slot))
+	if err != nil && ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	return err == nil && finality != nil, nil
+}
@@ -0,0 +1,42 @@
+package ethstore
+
+import (
+	"math/big"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/shopspring/decimal"
+)
+
+// Preset holds the constants of the spec of a network that Calculate needs, see WithPreset.
+type Preset struct {
+	SlotsPerEpoch                uint64
+	SecondsPerSlot               uint64
+	EpochsPerSyncCommitteePeriod uint64
+	DomainDeposit                phase0.DomainType
+}
+
+// MainnetPreset are the constants of ethereum mainnet, which are the defaults of WithSlotsPerEpoch and
+// WithSecondsPerSlot for the constants they do not set.
+var MainnetPreset = Preset{
+	SlotsPerEpoch:                32,
+	SecondsPerSlot:               12,
+	EpochsPerSyncCommitteePeriod: mainnetEpochsPerSyncCommitteePeriod,
+	DomainDeposit:                mainnetDomainDeposit,
+}
+
+// GnosisPreset are the constants of Gnosis Chain. The balances of its beacon chain are denominated in mGNO (a deposit of
+// 1 GNO is 32 mGNO, the balance of a validator of 32 "ETH"), so the ETH values of a day (e.g. EffectiveBalanceEth) are
+// mGNO and the Gwei values are Gwei of mGNO. The aprs of the consensus rewards do not depend on the denomination. The
+// execution rewards are paid in xDAI and only add up with the balances if they are converted with
+// WithExecutionRewardsRate.
+var GnosisPreset = Preset{
+	SlotsPerEpoch:                16,
+	SecondsPerSlot:               5,
+	EpochsPerSyncCommitteePeriod: 512,
+	DomainDeposit:                mainnetDomainDeposit,
+}
+
+// convertWei returns wei times rate, truncated to whole Wei, see WithExecutionRewardsRate.
+func convertWei(wei *big.Int, rate decimal.Decimal) *big.Int {
+	return decimal.NewFromBigInt(wei, 0).Mul(rate).BigInt()
+}
@@ -0,0 +1,54 @@
+package ethstore
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// The penalties of the quality score, see WithQualityScore. The penalties of the partial issues are weighted by their
+// fraction of the blocks or validators of the day.
+var (
+	withoutBlocksPenalty         = decimal.RequireFromString("0.5")
+	incompletePayloadsPenalty    = decimal.RequireFromString("0.5")
+	possiblyReorgedPenalty       = decimal.RequireFromString("0.5")
+	unfinalizedPenalty           = decimal.RequireFromString("0.1")
+	unaccountedValidatorsPenalty = decimal.RequireFromString("0.25")
+)
+
+// assessQuality returns the quality score and the warnings of the day d of all validators, see WithQualityScore.
+// unaccountedValidators are the validators that were active at the start of the day and are missing in (or not active
+// in) the state at its end, anchorID is the block id the day was calculated at and anchorSlot its slot, finalizedSlot
+// is the slot of the finalized checkpoint.
+func assessQuality(d *Day, unaccountedValidators int, anchorID string, anchorSlot, finalizedSlot uint64) (decimal.Decimal, []string) {
+	penalty := decimal.Zero
+	var warnings []string
+	if d.WithoutBlocks {
+		penalty = penalty.Add(withoutBlocksPenalty)
+		warnings = append(warnings, "calculated without blocks: execution rewards, deposits and withdrawals are not counted")
+	}
+	if d.IncompletePayloadSlots.IsPositive() && d.ProposedBlocks.IsPositive() {
+		penalty = penalty.Add(incompletePayloadsPenalty.Mul(d.IncompletePayloadSlots).Div(d.ProposedBlocks))
+		warnings = append(warnings, fmt.Sprintf("%v of %v blocks have no complete execution payload (%v blinded), their fees are not counted", d.IncompletePayloadSlots, d.ProposedBlocks, d.BlindedBlockSlots))
+	}
+	if d.PossiblyReorged {
+		penalty = penalty.Add(possiblyReorgedPenalty)
+		warnings = append(warnings, fmt.Sprintf("anchor %v is not canonical anymore, the blocks and states may be of different chains", anchorID))
+	} else if anchorSlot > finalizedSlot {
+		penalty = penalty.Add(unfinalizedPenalty)
+		warnings = append(warnings, fmt.Sprintf("anchor %v (slot %v) is after the finalized slot %v, the day can still change", anchorID, anchorSlot, finalizedSlot))
+	}
+	if unaccountedValidators > 0 {
+		n := decimal.NewFromInt(int64(unaccountedValidators))
+		penalty = penalty.Add(unaccountedValidatorsPenalty.Mul(n).Div(n.Add(d.Validators)))
+		warnings = append(warnings, fmt.Sprintf("%v validators that were active at the start of the day are missing in the state at the end and not accounted", unaccountedValidators))
+	}
+	if d.InactivityLeakEpochs.IsPositive() {
+		warnings = append(warnings, fmt.Sprintf("the chain did not finalize in %v epochs of the day, the apr is lowered by the inactivity leak", d.InactivityLeakEpochs))
+	}
+	score := decimal.NewFromInt(1).Sub(penalty)
+	if score.IsNegative() {
+		score = decimal.Zero
+	}
+	return score, warnings
+}
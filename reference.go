@@ -0,0 +1,134 @@
+package ethstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// referenceAprTolerance and referenceRelativeTolerance are the differences to a reference that are attributed to
+// rounding: the published aprs are rounded and the sums may be stored with less precision.
+var referenceAprTolerance = decimal.New(1, -8)
+var referenceRelativeTolerance = decimal.New(1, -9)
+
+// ReferenceDiff is the result of CompareToReference. The differences are day - reference.
+type ReferenceDiff struct {
+	Day                  uint64
+	Reference            *Day
+	Apr                  decimal.Decimal
+	Validators           decimal.Decimal
+	ConsensusRewardsGwei decimal.Decimal
+	TxFeesSumWei         decimal.Decimal
+	TotalRewardsWei      decimal.Decimal
+	// Material is set if a difference exceeds the rounding tolerance, Notes then explain the likely causes.
+	Material bool
+	Notes    []string
+}
+
+// referenceResponse holds both formats CompareToReference understands: the eth.store api of beaconcha.in, which wraps
+// the day in data and uses sums in Wei, and the json of a Day.
+type referenceResponse struct {
+	Data *struct {
+		Day                     decimal.Decimal `json:"day"`
+		Apr                     decimal.Decimal `json:"apr"`
+		Validators              decimal.Decimal `json:"validators"`
+		EffectiveBalancesSumWei decimal.Decimal `json:"effective_balances_sum_wei"`
+		StartBalancesSumWei     decimal.Decimal `json:"start_balances_sum_wei"`
+		EndBalancesSumWei       decimal.Decimal `json:"end_balances_sum_wei"`
+		DepositsSumWei          decimal.Decimal `json:"deposits_sum_wei"`
+		TxFeesSumWei            decimal.Decimal `json:"tx_fees_sum_wei"`
+		ConsensusRewardsSumWei  decimal.Decimal `json:"consensus_rewards_sum_wei"`
+		TotalRewardsWei         decimal.Decimal `json:"total_rewards_wei"`
+	} `json:"data"`
+	Day
+}
+
+// CompareToReference fetches the published eth.store of the same day from referenceURL and compares it to day, e.g.
+// to verify that a self-hosted setup matches the canonical methodology. A "{day}" in referenceURL is replaced with the
+// day, otherwise the day is appended as a path segment, so https://beaconcha.in/api/v1/ethstore works as reference.
+// The reference can be given in the format of that api or as the json of a Day.
+func CompareToReference(ctx context.Context, day *Day, referenceURL string) (*ReferenceDiff, error) {
+	dayNumber := uint64(day.Day.IntPart())
+	url := strings.ReplaceAll(referenceURL, "{day}", fmt.Sprintf("%d", dayNumber))
+	if url == referenceURL {
+		url = fmt.Sprintf("%s/%d", strings.TrimRight(referenceURL, "/"), dayNumber)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for reference: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reference for day %v: %w", dayNumber, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("error getting reference for day %v: %v: %s", dayNumber, resp.Status, body)
+	}
+	r := &referenceResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, fmt.Errorf("error decoding reference for day %v: %w", dayNumber, err)
+	}
+	reference := &r.Day
+	if r.Data != nil {
+		reference = &Day{
+			Day:                  r.Data.Day,
+			Apr:                  r.Data.Apr,
+			Validators:           r.Data.Validators,
+			EffectiveBalanceGwei: r.Data.EffectiveBalancesSumWei.Shift(-9),
+			StartBalanceGwei:     r.Data.StartBalancesSumWei.Shift(-9),
+			EndBalanceGwei:       r.Data.EndBalancesSumWei.Shift(-9),
+			DepositsSumGwei:      r.Data.DepositsSumWei.Shift(-9),
+			TxFeesSumWei:         r.Data.TxFeesSumWei,
+			ConsensusRewardsGwei: r.Data.ConsensusRewardsSumWei.Shift(-9),
+			TotalRewardsWei:      r.Data.TotalRewardsWei,
+		}
+	}
+	if !reference.Day.Equal(day.Day) {
+		return nil, fmt.Errorf("reference is for day %v instead of day %v", reference.Day, day.Day)
+	}
+	return compareToReference(day, reference), nil
+}
+
+func compareToReference(day, reference *Day) *ReferenceDiff {
+	diff := &ReferenceDiff{
+		Day:                  uint64(day.Day.IntPart()),
+		Reference:            reference,
+		Apr:                  day.Apr.Sub(reference.Apr),
+		Validators:           day.Validators.Sub(reference.Validators),
+		ConsensusRewardsGwei: day.ConsensusRewardsGwei.Sub(reference.ConsensusRewardsGwei),
+		TxFeesSumWei:         day.TxFeesSumWei.Sub(reference.TxFeesSumWei),
+		TotalRewardsWei:      day.TotalRewardsWei.Sub(reference.TotalRewardsWei),
+	}
+	material := func(d, ref decimal.Decimal) bool {
+		return d.Abs().GreaterThan(ref.Abs().Mul(referenceRelativeTolerance))
+	}
+	note := func(format string, args ...interface{}) {
+		diff.Material = true
+		diff.Notes = append(diff.Notes, fmt.Sprintf(format, args...))
+	}
+
+	if !diff.Validators.IsZero() {
+		note("validators differ by %v: check the day boundaries (WithEpochBoundaries, WithPeriodSlots) and that the node serves the states of the day", diff.Validators)
+	}
+	if material(diff.ConsensusRewardsGwei, reference.ConsensusRewardsGwei) {
+		note("consensus rewards differ by %v Gwei: check the accounted deposits and withdrawals, the reference may account them differently", diff.ConsensusRewardsGwei)
+	}
+	if material(diff.TxFeesSumWei, reference.TxFeesSumWei) {
+		if day.TxFeesSumWei.LessThan(reference.TxFeesSumWei) {
+			note("tx fees are lower by %v Wei: the tx fees are priority fees only, the reference may include the burnt base fee or mev payments; also check IncompletePayloadSlots", diff.TxFeesSumWei.Neg())
+		} else {
+			note("tx fees are higher by %v Wei: the reference may not account the fees of all blocks, e.g. of blocks without complete execution payload", diff.TxFeesSumWei)
+		}
+	}
+	if !diff.Material && diff.Apr.Abs().GreaterThan(referenceAprTolerance) {
+		note("apr differs by %v while the sums match: the reference may use another annualization or apr basis (WithNominalBalanceBasis)", diff.Apr)
+	}
+	return diff
+}
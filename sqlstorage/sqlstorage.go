@@ -0,0 +1,109 @@
+// Package sqlstorage implements ethstore.Storage on top of database/sql.
+//
+// The queries use $n placeholders and INSERT ... ON CONFLICT, which works with postgres and sqlite.
+// The package does not import a driver, the caller opens the *sql.DB with the driver of its choice.
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ethstore "github.com/gobitfly/eth.store"
+)
+
+const Schema = `CREATE TABLE IF NOT EXISTS ethstore_days (
+	day                    BIGINT PRIMARY KEY,
+	daytime                TIMESTAMP NOT NULL,
+	apr                    NUMERIC NOT NULL,
+	validators             NUMERIC NOT NULL,
+	effective_balance_gwei NUMERIC NOT NULL,
+	start_balance_gwei     NUMERIC NOT NULL,
+	end_balance_gwei       NUMERIC NOT NULL,
+	deposits_sum_gwei      NUMERIC NOT NULL,
+	withdrawals_sum_gwei   NUMERIC NOT NULL,
+	consensus_rewards_gwei NUMERIC NOT NULL,
+	tx_fees_sum_wei        NUMERIC NOT NULL,
+	total_rewards_wei      NUMERIC NOT NULL,
+	data                   TEXT NOT NULL
+)`
+
+// Storage stores days in the table ethstore_days. The columns make the most important values queryable,
+// the data column holds the complete day as json so that LoadDay returns all fields of ethstore.Day.
+type Storage struct {
+	db *sql.DB
+}
+
+var _ ethstore.Storage = (*Storage)(nil)
+
+func New(db *sql.DB) *Storage {
+	return &Storage{db: db}
+}
+
+// CreateTable creates the table ethstore_days if it does not exist yet.
+func (s *Storage) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, Schema)
+	if err != nil {
+		return fmt.Errorf("error creating table: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) SaveDay(ctx context.Context, d *ethstore.Day) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("error marshaling day %v: %w", d.Day, err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO ethstore_days (day, daytime, apr, validators, effective_balance_gwei, start_balance_gwei, end_balance_gwei, deposits_sum_gwei, withdrawals_sum_gwei, consensus_rewards_gwei, tx_fees_sum_wei, total_rewards_wei, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (day) DO UPDATE SET
+			daytime = excluded.daytime,
+			apr = excluded.apr,
+			validators = excluded.validators,
+			effective_balance_gwei = excluded.effective_balance_gwei,
+			start_balance_gwei = excluded.start_balance_gwei,
+			end_balance_gwei = excluded.end_balance_gwei,
+			deposits_sum_gwei = excluded.deposits_sum_gwei,
+			withdrawals_sum_gwei = excluded.withdrawals_sum_gwei,
+			consensus_rewards_gwei = excluded.consensus_rewards_gwei,
+			tx_fees_sum_wei = excluded.tx_fees_sum_wei,
+			total_rewards_wei = excluded.total_rewards_wei,
+			data = excluded.data`,
+		d.Day.IntPart(), d.DayTime.UTC(), d.Apr, d.Validators, d.EffectiveBalanceGwei, d.StartBalanceGwei, d.EndBalanceGwei, d.DepositsSumGwei, d.WithdrawalsSumGwei, d.ConsensusRewardsGwei, d.TxFeesSumWei, d.TotalRewardsWei, string(data))
+	if err != nil {
+		return fmt.Errorf("error saving day %v: %w", d.Day, err)
+	}
+	return nil
+}
+
+func (s *Storage) LoadDay(ctx context.Context, day uint64) (*ethstore.Day, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM ethstore_days WHERE day = $1`, int64(day)).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading day %v: %w", day, err)
+	}
+	d := &ethstore.Day{}
+	err = json.Unmarshal([]byte(data), d)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling day %v: %w", day, err)
+	}
+	return d, nil
+}
+
+func (s *Storage) LatestDay(ctx context.Context) (uint64, bool, error) {
+	var day sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `SELECT MAX(day) FROM ethstore_days`).Scan(&day)
+	if err != nil {
+		return 0, false, fmt.Errorf("error loading latest day: %w", err)
+	}
+	if !day.Valid {
+		return 0, false, nil
+	}
+	return uint64(day.Int64), true, nil
+}
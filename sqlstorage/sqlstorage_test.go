@@ -0,0 +1,190 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ethstore "github.com/gobitfly/eth.store"
+	"github.com/shopspring/decimal"
+)
+
+// memoryDriver is a database/sql driver that keeps the table ethstore_days in memory and only understands the queries
+// of Storage, so that the package can be tested without a database server or a cgo driver.
+type memoryDriver struct {
+	mu   sync.Mutex
+	rows map[int64][]driver.Value
+}
+
+func (d *memoryDriver) Open(name string) (driver.Conn, error) {
+	return &memoryConn{d: d}, nil
+}
+
+type memoryConn struct {
+	d *memoryDriver
+}
+
+func (c *memoryConn) Prepare(query string) (driver.Stmt, error) {
+	return &memoryStmt{d: c.d, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (c *memoryConn) Close() error {
+	return nil
+}
+
+func (c *memoryConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+type memoryStmt struct {
+	d     *memoryDriver
+	query string
+}
+
+func (s *memoryStmt) Close() error {
+	return nil
+}
+
+func (s *memoryStmt) NumInput() int {
+	return -1
+}
+
+func (s *memoryStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS ethstore_days "):
+		if s.d.rows == nil {
+			s.d.rows = map[int64][]driver.Value{}
+		}
+	case strings.HasPrefix(s.query, "INSERT INTO ethstore_days ") && strings.Contains(s.query, "ON CONFLICT (day) DO UPDATE SET"):
+		if s.d.rows == nil {
+			return nil, fmt.Errorf("no table ethstore_days")
+		}
+		if len(args) != 13 {
+			return nil, fmt.Errorf("got %v arguments for 13 columns", len(args))
+		}
+		s.d.rows[args[0].(int64)] = args
+	default:
+		return nil, fmt.Errorf("unexpected query: %v", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *memoryStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.rows == nil {
+		return nil, fmt.Errorf("no table ethstore_days")
+	}
+	switch s.query {
+	case "SELECT data FROM ethstore_days WHERE day = $1":
+		row, exists := s.d.rows[args[0].(int64)]
+		if !exists {
+			return &memoryRows{column: "data"}, nil
+		}
+		return &memoryRows{column: "data", values: []driver.Value{row[12]}}, nil
+	case "SELECT MAX(day) FROM ethstore_days":
+		var latest driver.Value
+		for day := range s.d.rows {
+			if latest == nil || day > latest.(int64) {
+				latest = day
+			}
+		}
+		return &memoryRows{column: "max", values: []driver.Value{latest}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %v", s.query)
+}
+
+// memoryRows are rows of a single column.
+type memoryRows struct {
+	column string
+	values []driver.Value
+}
+
+func (r *memoryRows) Columns() []string {
+	return []string{r.column}
+}
+
+func (r *memoryRows) Close() error {
+	return nil
+}
+
+func (r *memoryRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func TestStorage(t *testing.T) {
+	d := &memoryDriver{}
+	sql.Register("ethstore-memory", d)
+	db, err := sql.Open("ethstore-memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	s := New(db)
+	if err := s.CreateTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, err := s.LatestDay(ctx); err != nil || found {
+		t.Errorf("empty table should have no latest day: %v, %v", found, err)
+	}
+	if day, err := s.LoadDay(ctx, 10); err != nil || day != nil {
+		t.Errorf("missing day should load as nil: %v, %v", day, err)
+	}
+
+	day10 := &ethstore.Day{
+		Day:                decimal.NewFromInt(10),
+		DayTime:            time.Unix(1607688023, 0).UTC(),
+		Apr:                decimal.RequireFromString("0.0621640625"),
+		Validators:         decimal.NewFromInt(29),
+		TxFeesSumWei:       decimal.RequireFromString("72000000000000000"),
+		TotalRewardsWei:    decimal.RequireFromString("170322400000000000000000"),
+		MethodologyVersion: ethstore.MethodologyVersion,
+	}
+	day11 := &ethstore.Day{Day: decimal.NewFromInt(11), DayTime: time.Unix(1607774423, 0).UTC(), Apr: decimal.RequireFromString("0.05")}
+	for _, day := range []*ethstore.Day{day11, day10} {
+		if err := s.SaveDay(ctx, day); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loaded, err := s.LoadDay(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == nil || !loaded.Equal(day10) {
+		t.Errorf("wrong loaded day: %v, diff: %v", loaded, ethstore.Diff(loaded, day10))
+	}
+	if latest, found, err := s.LatestDay(ctx); err != nil || !found || latest != 11 {
+		t.Errorf("got latest day %v (%v, %v), want 11", latest, found, err)
+	}
+	if row := d.rows[10]; row[2] != "0.0621640625" || row[11] != "170322400000000000000000" {
+		t.Errorf("wrong queryable columns of day 10: apr %v, total rewards %v", row[2], row[11])
+	}
+
+	// saving a day again replaces it
+	recalculated := *day10
+	recalculated.Apr = decimal.RequireFromString("0.07")
+	if err := s.SaveDay(ctx, &recalculated); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = s.LoadDay(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == nil || !loaded.Apr.Equal(recalculated.Apr) || len(d.rows) != 2 {
+		t.Errorf("the day should have been replaced: %v, %v rows", loaded, len(d.rows))
+	}
+}
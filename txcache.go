@@ -0,0 +1,52 @@
+package ethstore
+
+import (
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// TxCache caches the decoded transactions and the receipts of the execution payloads of blocks, see WithTxCache. It is
+// bounded to a number of blocks, the least recently used are evicted, and safe for concurrent use, so one cache can be
+// shared by calls.
+type TxCache struct {
+	cache *lru.Cache
+}
+
+// txCacheEntry holds the transactions of an execution payload and their receipts in the same order, both must not be
+// modified.
+type txCacheEntry struct {
+	txs      []*gethTypes.Transaction
+	receipts []*TxReceipt
+}
+
+// NewTxCache returns a TxCache of the transactions of up to blocks blocks, e.g. 7200 for a mainnet day.
+func NewTxCache(blocks int) (*TxCache, error) {
+	if blocks <= 0 {
+		return nil, fmt.Errorf("invalid tx cache size %v: must be positive", blocks)
+	}
+	c, err := lru.New(blocks)
+	if err != nil {
+		return nil, err
+	}
+	return &TxCache{cache: c}, nil
+}
+
+// Len returns the number of blocks in the cache.
+func (c *TxCache) Len() int {
+	return c.cache.Len()
+}
+
+func (c *TxCache) get(blockHash phase0.Hash32) (*txCacheEntry, bool) {
+	e, found := c.cache.Get(blockHash)
+	if !found {
+		return nil, false
+	}
+	return e.(*txCacheEntry), true
+}
+
+func (c *TxCache) add(blockHash phase0.Hash32, e *txCacheEntry) {
+	c.cache.Add(blockHash, e)
+}
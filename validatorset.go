@@ -0,0 +1,437 @@
+package ethstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/shopspring/decimal"
+)
+
+// ValidatorSetMeta reports which of the validators of a validator set were accounted, see WithValidatorSets.
+type ValidatorSetMeta struct {
+	// Requested are the distinct validator indices of the set and Matched the ones that are accounted validators of
+	// the day.
+	Requested int `json:"requested"`
+	Matched   int `json:"matched"`
+	// Missing are the requested indices that are not accounted, sorted: validators that were not active the whole day
+	// (or are younger than WithMinActivationAge) and indices that are stale or mistyped.
+	Missing []uint64 `json:"missing,omitempty"`
+	// Duplicates counts the indices that were passed more than once to WithValidatorSets (they count once) and
+	// Overlapping the distinct indices of the set that are in another set as well. Neither is an error, but both
+	// usually point to a data-entry error of the sets.
+	Duplicates  int `json:"duplicates,omitempty"`
+	Overlapping int `json:"overlapping,omitempty"`
+	// OutOfRange are the missing indices that are not even a validator in the state at the end of the day, sorted. They
+	// are usually indices of a different network or of a later state.
+	OutOfRange []uint64 `json:"outOfRange,omitempty"`
+}
+
+// validatorSetDays returns the days of the validator sets by name and which of their validators are accounted in
+// validatorsByIndex, see WithValidatorSets. validatorCount is the number of validators in the state at the end of the
+// day, the indices at or above it are out of range.
+func validatorSetDays(day *Day, sets map[string]map[phase0.ValidatorIndex]bool, duplicates map[string]int, validatorsByIndex map[phase0.ValidatorIndex]*Validator, validatorCount uint64, nominalBalanceGwei phase0.Gwei, annualizationDays decimal.Decimal, trimFraction float64) (map[string]*Day, map[string]ValidatorSetMeta) {
+	// the number of sets of each index
+	memberships := make(map[phase0.ValidatorIndex]int)
+	for _, set := range sets {
+		for index, member := range set {
+			if member {
+				memberships[index]++
+			}
+		}
+	}
+	days := make(map[string]*Day, len(sets))
+	metas := make(map[string]ValidatorSetMeta, len(sets))
+	for name, set := range sets {
+		meta := ValidatorSetMeta{Duplicates: duplicates[name]}
+		vals := make([]*Validator, 0, len(set))
+		for index, member := range set {
+			if !member {
+				continue
+			}
+			meta.Requested++
+			if memberships[index] > 1 {
+				meta.Overlapping++
+			}
+			v, exists := validatorsByIndex[index]
+			if !exists {
+				meta.Missing = append(meta.Missing, uint64(index))
+				if uint64(index) >= validatorCount {
+					meta.OutOfRange = append(meta.OutOfRange, uint64(index))
+				}
+				continue
+			}
+			vals = append(vals, v)
+		}
+		meta.Matched = len(vals)
+		sort.Slice(meta.Missing, func(i, j int) bool { return meta.Missing[i] < meta.Missing[j] })
+		sort.Slice(meta.OutOfRange, func(i, j int) bool { return meta.OutOfRange[i] < meta.OutOfRange[j] })
+		days[name] = validatorSetDay(day, vals, nominalBalanceGwei, annualizationDays, trimFraction)
+		metas[name] = meta
+	}
+	return days, metas
+}
+
+// checkPartition returns an error if the sets are not a partition of the validator indices of pool: every index of
+// pool has to be in exactly one set and the sets have no other indices.
+func checkPartition(pool []uint64, sets map[string][]uint64) error {
+	inPool := make(map[uint64]bool, len(pool))
+	for _, index := range pool {
+		inPool[index] = true
+	}
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	setOf := make(map[uint64]string, len(pool))
+	for _, name := range names {
+		for _, index := range sets[name] {
+			if !inPool[index] {
+				return fmt.Errorf("validator %v of %v is not in the pool", index, name)
+			}
+			if other, exists := setOf[index]; exists && other != name {
+				return fmt.Errorf("validator %v is in %v and %v", index, other, name)
+			}
+			setOf[index] = name
+		}
+	}
+	if len(setOf) < len(inPool) {
+		for _, index := range pool {
+			if _, exists := setOf[index]; !exists {
+				return fmt.Errorf("validator %v of the pool is in no set (%v of %v are)", index, len(setOf), len(inPool))
+			}
+		}
+	}
+	return nil
+}
+
+// validatorSetDay returns the day of the accounted validators vals of a validator set. Their balances and rewards are
+// summed like for the day of all validators, the values of the whole day that the validator days share as well (like
+// ProposedBlocks) are taken from day. AprStdDev and AprTrimmedMean are over the aprs of the validators of the set,
+// which are calculated like the aprs of the validator days.
+func validatorSetDay(day *Day, vals []*Validator, nominalBalanceGwei phase0.Gwei, annualizationDays decimal.Decimal, trimFraction float64) *Day {
+	var effectiveBalanceGwei, startBalanceGwei, endBalanceGwei, depositsSumGwei, withdrawalsSumGwei, proposerConsensusRewardsGwei, withdrawalRequestsSumGwei phase0.Gwei
+	var syncCommitteeDuties, syncCommitteeParticipations, skippedDeposits, missedAttestations, txCount, payloadBlocks, inclusionDistanceSum, includedAttestations uint64
+	gasUtilizationSum := decimal.Zero
+	txFeesSumWei := new(big.Int)
+	mevRewardsWei := new(big.Int)
+	aprs := make([]weightedApr, 0, len(vals))
+	for _, v := range vals {
+		validatorAprBasisGwei, weight := v.EffectiveBalanceGwei, gweiToDecimal(v.EffectiveBalanceGwei)
+		if nominalBalanceGwei != 0 {
+			validatorAprBasisGwei, weight = nominalBalanceGwei, decimal.NewFromInt(1)
+		}
+		validatorExecutionRewardsWei := new(big.Int).Set(v.TxFeesSumWei)
+		if v.MevRewardsWei != nil {
+			validatorExecutionRewardsWei.Add(validatorExecutionRewardsWei, v.MevRewardsWei)
+		}
+		aprs = append(aprs, weightedApr{
+			apr:    ComputeApr(v.StartBalanceGwei, v.EndBalanceGwei, v.DepositsSumGwei, v.WithdrawalsSumGwei, validatorExecutionRewardsWei, validatorAprBasisGwei, annualizationDays),
+			weight: weight,
+		})
+		effectiveBalanceGwei += v.EffectiveBalanceGwei
+		startBalanceGwei += v.StartBalanceGwei
+		endBalanceGwei += v.EndBalanceGwei
+		depositsSumGwei += v.DepositsSumGwei
+		withdrawalsSumGwei += v.WithdrawalsSumGwei
+		proposerConsensusRewardsGwei += v.ProposerConsensusRewardsGwei
+		txFeesSumWei.Add(txFeesSumWei, v.TxFeesSumWei)
+		if v.MevRewardsWei != nil {
+			mevRewardsWei.Add(mevRewardsWei, v.MevRewardsWei)
+		}
+		syncCommitteeDuties += v.SyncCommitteeDuties
+		syncCommitteeParticipations += v.SyncCommitteeParticipations
+		skippedDeposits += v.SkippedDeposits
+		missedAttestations += v.MissedAttestations
+		txCount += v.ProposedTxCount
+		payloadBlocks += v.PayloadBlocks
+		gasUtilizationSum = gasUtilizationSum.Add(v.GasUtilizationSum)
+		inclusionDistanceSum += v.InclusionDistanceSum
+		includedAttestations += v.IncludedAttestations
+		withdrawalRequestsSumGwei += v.WithdrawalRequestsSumGwei
+	}
+
+	consensusRewards := consensusRewardsGwei(startBalanceGwei, endBalanceGwei, depositsSumGwei, withdrawalsSumGwei)
+	executionRewardsWei := new(big.Int).Add(txFeesSumWei, mevRewardsWei)
+	totalRewardsWei := decimal.NewFromBigInt(executionRewardsWei, 0).Add(consensusRewards.Mul(decimal.NewFromInt(1e9)))
+	aprBasisGwei := effectiveBalanceGwei
+	if nominalBalanceGwei != 0 {
+		aprBasisGwei = nominalBalanceGwei * phase0.Gwei(len(vals))
+	}
+	apr := ComputeApr(startBalanceGwei, endBalanceGwei, depositsSumGwei, withdrawalsSumGwei, executionRewardsWei, aprBasisGwei, annualizationDays)
+	consensusApr := ComputeApr(startBalanceGwei, endBalanceGwei, depositsSumGwei, withdrawalsSumGwei, nil, aprBasisGwei, annualizationDays)
+	aprExMev := ComputeApr(startBalanceGwei, endBalanceGwei, depositsSumGwei, withdrawalsSumGwei, txFeesSumWei, aprBasisGwei, annualizationDays)
+
+	return &Day{
+		Day:                    day.Day,
+		DayTime:                day.DayTime,
+		StartEpoch:             day.StartEpoch,
+		Apr:                    apr,
+		Apy:                    apy(apr),
+		Validators:             decimal.NewFromInt(int64(len(vals))),
+		EffectiveBalanceGwei:   gweiToDecimal(effectiveBalanceGwei),
+		StartBalanceGwei:       gweiToDecimal(startBalanceGwei),
+		EndBalanceGwei:         gweiToDecimal(endBalanceGwei),
+		DepositsSumGwei:        gweiToDecimal(depositsSumGwei),
+		WithdrawalsSumGwei:     gweiToDecimal(withdrawalsSumGwei),
+		ConsensusRewardsGwei:   consensusRewards,
+		TxFeesSumWei:           decimal.NewFromBigInt(txFeesSumWei, 0),
+		MevRewardsWei:          decimal.NewFromBigInt(mevRewardsWei, 0),
+		TotalRewardsWei:        totalRewardsWei,
+		SyncCommitteeDuties:    decimal.NewFromInt(int64(syncCommitteeDuties)),
+		SyncParticipationRate:  syncParticipationRate(syncCommitteeParticipations, syncCommitteeDuties),
+		PossiblyReorged:        day.PossiblyReorged,
+		ProposedBlocks:         day.ProposedBlocks,
+		MissedSlots:            day.MissedSlots,
+		SkippedDeposits:        decimal.NewFromInt(int64(skippedDeposits)),
+		IncompletePayloadSlots: day.IncompletePayloadSlots,
+		BlindedBlockSlots:      day.BlindedBlockSlots,
+		DuringNonFinality:      day.DuringNonFinality,
+		InactivityLeakEpochs:   day.InactivityLeakEpochs,
+		MissedAttestations:     decimal.NewFromInt(int64(missedAttestations)),
+		ActivatedValidators:    day.ActivatedValidators,
+		ExitedValidators:       day.ExitedValidators,
+		YoungValidators:        day.YoungValidators,
+		ComputedAt:             day.ComputedAt,
+		MethodologyVersion:     day.MethodologyVersion,
+		// the deposits of an accounted validator are top-ups
+		TopUpDepositsSumGwei: gweiToDecimal(depositsSumGwei),
+		TotalTxCount:         decimal.NewFromInt(int64(txCount)),
+		PeriodSeconds:        day.PeriodSeconds,
+		WithoutBlocks:        day.WithoutBlocks,
+		ConsensusApr:         consensusApr,
+		ExecutionApr:         apr.Sub(consensusApr),
+		AprExMev:             aprExMev,
+		AprStdDev:            stdDevApr(aprs),
+		AprTrimmedMean:       trimmedMeanApr(aprs, trimFraction),
+
+		AvgRewardsPerValidatorEth:    avgRewardsPerValidatorEth(totalRewardsWei, len(vals)),
+		ProposerConsensusRewardsGwei: gweiToDecimal(proposerConsensusRewardsGwei),
+		AvgGasUtilization:            avgGasUtilization(gasUtilizationSum, payloadBlocks),
+		PayloadBlocks:                decimal.NewFromInt(int64(payloadBlocks)),
+		AvgInclusionDistance:         avgInclusionDistance(inclusionDistanceSum, includedAttestations),
+		IncludedAttestations:         decimal.NewFromInt(int64(includedAttestations)),
+		WithdrawalRequestsSumGwei:    gweiToDecimal(withdrawalRequestsSumGwei),
+	}
+}
+
+// LoadValidatorSetFromFile returns the pubkeys of the validators listed in the file at path, as lower case hex with a
+// 0x prefix, in the order of the file and without duplicates. It understands the formats validator clients and
+// operators commonly keep their validators in:
+//
+//   - one pubkey per line, empty lines and lines starting with # are ignored
+//   - a json array of pubkeys
+//   - the response of the list keystores endpoint of the keymanager api ({"data":[{"validating_pubkey":...}]})
+//   - a validator_definitions.yml of lighthouse, the voting_public_key of every definition is used
+//   - a directory of EIP-2335 keystores, the pubkey of every json file in it is used
+//
+// Pubkeys may be given with or without the 0x prefix, an invalid pubkey is returned as an error. The validator set
+// options take indices, ResolveValidatorIndices returns the indices of the pubkeys.
+func LoadValidatorSetFromFile(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var pubkeys []string
+	if info.IsDir() {
+		pubkeys, err = readKeystorePubkeys(path)
+	} else {
+		var data []byte
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		pubkeys, err = parseValidatorSet(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading validator set from %v: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(pubkeys))
+	set := make([]string, 0, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		normalized, err := normalizePubkey(pubkey)
+		if err != nil {
+			return nil, fmt.Errorf("error reading validator set from %v: %w", path, err)
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			set = append(set, normalized)
+		}
+	}
+	return set, nil
+}
+
+// ResolveValidatorIndices returns the validator indices of pubkeys in the state stateID of the node at address, in the
+// order of pubkeys, e.g. to pass the pubkeys of LoadValidatorSetFromFile to WithValidatorSets. Pubkeys may be given
+// with or without the 0x prefix. An invalid pubkey or a pubkey that is not a validator in the state is returned as an
+// error, the error of missing pubkeys names how many are missing and the first of them.
+func ResolveValidatorIndices(ctx context.Context, address, stateID string, pubkeys []string) ([]uint64, error) {
+	normalized := make([]string, 0, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		n, err := normalizePubkey(pubkey)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, n)
+	}
+
+	client, err := newConsClient(ctx, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := GetValidators(ctx, client, stateID)
+	if err != nil {
+		return nil, err
+	}
+	indexOf := make(map[string]uint64, len(vals))
+	for index, v := range vals {
+		indexOf[fmt.Sprintf("%#x", v.Validator.PublicKey[:])] = uint64(index)
+	}
+
+	indices := make([]uint64, 0, len(normalized))
+	var missing []string
+	for _, pubkey := range normalized {
+		index, exists := indexOf[pubkey]
+		if !exists {
+			missing = append(missing, pubkey)
+			continue
+		}
+		indices = append(indices, index)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%v of %v pubkeys are not validators in state %v, the first is %v", len(missing), len(pubkeys), stateID, missing[0])
+	}
+	return indices, nil
+}
+
+// parseValidatorSet returns the pubkeys of the file formats of LoadValidatorSetFromFile, as they are given.
+func parseValidatorSet(data []byte) ([]string, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		var pubkeys []string
+		if err := json.Unmarshal(data, &pubkeys); err != nil {
+			return nil, fmt.Errorf("error decoding json array of pubkeys: %w", err)
+		}
+		return pubkeys, nil
+	case bytes.HasPrefix(data, []byte("{")):
+		var keystores struct {
+			Data []struct {
+				ValidatingPubkey string `json:"validating_pubkey"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(data, &keystores); err != nil {
+			return nil, fmt.Errorf("error decoding keymanager keystores: %w", err)
+		}
+		pubkeys := make([]string, 0, len(keystores.Data))
+		for _, k := range keystores.Data {
+			pubkeys = append(pubkeys, k.ValidatingPubkey)
+		}
+		return pubkeys, nil
+	}
+
+	var pubkeys []string
+	definitions := bytes.Contains(data, []byte("voting_public_key:"))
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if definitions {
+			// validator definitions are a yaml list, only the lines with the pubkeys are of interest
+			i := strings.Index(line, "voting_public_key:")
+			if i < 0 {
+				continue
+			}
+			line = strings.Trim(strings.TrimSpace(line[i+len("voting_public_key:"):]), `"'`)
+		} else if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pubkeys = append(pubkeys, line)
+	}
+	return pubkeys, scanner.Err()
+}
+
+// readKeystorePubkeys returns the pubkeys of the EIP-2335 keystores (json files) in dir, by file name.
+func readKeystorePubkeys(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	pubkeys := make([]string, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var keystore struct {
+			Pubkey string `json:"pubkey"`
+		}
+		if err := json.Unmarshal(data, &keystore); err != nil {
+			return nil, fmt.Errorf("error decoding keystore %v: %w", filepath.Base(file), err)
+		}
+		if keystore.Pubkey == "" {
+			return nil, fmt.Errorf("keystore %v has no pubkey", filepath.Base(file))
+		}
+		pubkeys = append(pubkeys, keystore.Pubkey)
+	}
+	return pubkeys, nil
+}
+
+// normalizePubkey returns pubkey as lower case hex with a 0x prefix, or an error if it is not a BLS pubkey.
+func normalizePubkey(pubkey string) (string, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(pubkey, "0x"), "0X")
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 48 {
+		return "", fmt.Errorf("invalid pubkey %q", pubkey)
+	}
+	return "0x" + hex.EncodeToString(b), nil
+}
+
+// HashSets returns a deterministic key of the validator sets (name to validator indices), e.g. to cache results of
+// the sets. The names and the indices of every set are sorted and duplicate indices are dropped first, so the same
+// logical sets always have the same key. The key is the hex of a sha256 of that canonical form.
+func HashSets(sets map[string][]uint64) string {
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	buf := make([]byte, 8)
+	writeUint64 := func(v uint64) {
+		binary.BigEndian.PutUint64(buf, v)
+		h.Write(buf)
+	}
+	writeUint64(uint64(len(names)))
+	for _, name := range names {
+		// the lengths keep the encoding unambiguous, e.g. of names that are prefixes of each other
+		writeUint64(uint64(len(name)))
+		h.Write([]byte(name))
+		indices := append([]uint64(nil), sets[name]...)
+		sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+		unique := indices[:0]
+		for _, index := range indices {
+			if len(unique) == 0 || index != unique[len(unique)-1] {
+				unique = append(unique, index)
+			}
+		}
+		writeUint64(uint64(len(unique)))
+		for _, index := range unique {
+			writeUint64(index)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}